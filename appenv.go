@@ -0,0 +1,55 @@
+package envy
+
+import "strings"
+
+// AppEnvKeys are the variables consulted, in order, to determine the name of
+// the running application environment.
+var AppEnvKeys = []string{"APP_ENV", "GO_ENV"}
+
+// DefaultAppEnv is the application environment reported by AppEnv when none
+// of AppEnvKeys are set.
+const DefaultAppEnv = "development"
+
+// AppEnv returns the application environment name, such as "development" or
+// "production", read from APP_ENV or GO_ENV. It returns DefaultAppEnv when
+// neither is set.
+func (e *Env) AppEnv() string {
+	return e.AppEnvOr(DefaultAppEnv)
+}
+
+// AppEnvOr returns the application environment name read from APP_ENV or
+// GO_ENV, or def when neither is set to a non-empty value.
+func (e *Env) AppEnvOr(def string) string {
+	for _, k := range AppEnvKeys {
+		if v := strings.TrimSpace(e.Getenv(k)); v != "" {
+			return v
+		}
+	}
+	return def
+}
+
+// IsDevelopment reports whether AppEnv is "development" or "dev".
+func (e *Env) IsDevelopment() bool {
+	return e.isAppEnv("development", "dev")
+}
+
+// IsTest reports whether AppEnv is "test" or "testing".
+func (e *Env) IsTest() bool {
+	return e.isAppEnv("test", "testing")
+}
+
+// IsProduction reports whether AppEnv is "production" or "prod".
+func (e *Env) IsProduction() bool {
+	return e.isAppEnv("production", "prod")
+}
+
+// isAppEnv reports whether AppEnv case-insensitively matches any of names.
+func (e *Env) isAppEnv(names ...string) bool {
+	cur := e.AppEnv()
+	for _, n := range names {
+		if strings.EqualFold(cur, n) {
+			return true
+		}
+	}
+	return false
+}
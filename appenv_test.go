@@ -0,0 +1,82 @@
+package envy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_AppEnv(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  *Env
+		exp  string
+		dev  bool
+		test bool
+		prod bool
+	}{
+		{
+			name: "nil env",
+			env:  nil,
+			exp:  DefaultAppEnv,
+			dev:  true,
+		},
+		{
+			name: "unset",
+			env:  Zero(),
+			exp:  DefaultAppEnv,
+			dev:  true,
+		},
+		{
+			name: "APP_ENV",
+			env:  FromMap(map[string]string{"APP_ENV": "production"}),
+			exp:  "production",
+			prod: true,
+		},
+		{
+			name: "GO_ENV",
+			env:  FromMap(map[string]string{"GO_ENV": "test"}),
+			exp:  "test",
+			test: true,
+		},
+		{
+			name: "APP_ENV wins over GO_ENV",
+			env:  FromMap(map[string]string{"APP_ENV": "PROD", "GO_ENV": "test"}),
+			exp:  "PROD",
+			prod: true,
+		},
+		{
+			name: "empty APP_ENV falls through",
+			env:  FromMap(map[string]string{"APP_ENV": "", "GO_ENV": "testing"}),
+			exp:  "testing",
+			test: true,
+		},
+		{
+			name: "custom name",
+			env:  FromMap(map[string]string{"APP_ENV": "staging"}),
+			exp:  "staging",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			r.Equal(tc.exp, tc.env.AppEnv())
+			r.Equal(tc.dev, tc.env.IsDevelopment())
+			r.Equal(tc.test, tc.env.IsTest())
+			r.Equal(tc.prod, tc.env.IsProduction())
+		})
+	}
+}
+
+func Test_Env_AppEnvOr(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	r.Equal("staging", Zero().AppEnvOr("staging"))
+	r.Equal("test", FromMap(map[string]string{"GO_ENV": "test"}).AppEnvOr("staging"))
+}
@@ -0,0 +1,263 @@
+// Package audit cross-references the environment variables a Go codebase
+// reads with those defined in env files, reporting variables that are
+// defined but never read and reads that have no definition.
+package audit
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/markbates/envy"
+)
+
+// ReadFuncs are the function and method names whose first argument, when a
+// string literal, is treated as a read of that environment variable. Both
+// package functions (os.Getenv) and methods (env.Getenv) match.
+var ReadFuncs = map[string]bool{
+	"Getenv":    true,
+	"LookupEnv": true,
+	"Lookupenv": true,
+	"IsSet":     true,
+	"MustGet":   true,
+
+	"GetInt":        true,
+	"GetIntOr":      true,
+	"GetInt64":      true,
+	"GetInt64Or":    true,
+	"GetBool":       true,
+	"GetBoolOr":     true,
+	"GetFloat64":    true,
+	"GetFloat64Or":  true,
+	"GetDuration":   true,
+	"GetDurationOr": true,
+}
+
+// GenericReadFuncs are the generic function names whose second argument,
+// when a string literal, is treated as a read; the first is the Env, as in
+// envy.Get[int](env, "PORT"). They only match when called with explicit
+// type arguments.
+var GenericReadFuncs = map[string]bool{
+	"Get": true,
+}
+
+// Read is a single environment variable read found in source.
+type Read struct {
+	Key string
+	Pos token.Position
+}
+
+func (r Read) String() string {
+	return fmt.Sprintf("%s: %s", r.Pos, r.Key)
+}
+
+// Report is the result of Audit.
+type Report struct {
+	// Unused holds keys that are defined but never read, sorted.
+	Unused []string
+	// Undefined holds reads of keys that are not defined, in source order.
+	Undefined []Read
+}
+
+// OK reports whether the audit found no problems.
+func (r Report) OK() bool {
+	return len(r.Unused) == 0 && len(r.Undefined) == 0
+}
+
+// Codes of the problems returned by Report.Problems.
+const (
+	CodeUnused    = "unused"
+	CodeUndefined = "undefined"
+)
+
+// Problems converts the Report into an envy.Report, for tooling that
+// handles problems from every check the same way: unused keys are
+// warnings, and undefined reads are errors at the position of the read.
+func (r Report) Problems() envy.Report {
+	rep := envy.Report{Problems: []envy.Problem{}}
+	for _, k := range r.Unused {
+		rep.Add(envy.Problem{
+			Severity: envy.Warning,
+			Code:     CodeUnused,
+			Key:      k,
+			Message:  fmt.Sprintf("%s is defined but never read", k),
+		})
+	}
+
+	for _, rd := range r.Undefined {
+		rep.Add(envy.Problem{
+			Severity: envy.Error,
+			Code:     CodeUndefined,
+			Key:      rd.Key,
+			File:     rd.Pos.Filename,
+			Line:     rd.Pos.Line,
+			Message:  fmt.Sprintf("%s is read but never defined", rd.Key),
+		})
+	}
+	return rep
+}
+
+// Scan parses the Go files matched by patterns and returns every read of an
+// environment variable named by a string literal, in file and position
+// order. A pattern is a directory, or a directory followed by "/..." to
+// include all of its subdirectories; vendor, testdata, and hidden
+// directories are skipped when recursing.
+func Scan(patterns ...string) ([]Read, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	fset := token.NewFileSet()
+	var reads []Read
+
+	for _, pat := range patterns {
+		dir, recursive := strings.CutSuffix(pat, "...")
+		dir = filepath.Clean(strings.TrimSuffix(dir, "/"))
+		if dir == "" {
+			dir = "."
+		}
+
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if d.IsDir() {
+				if path == dir {
+					return nil
+				}
+
+				if !recursive || skipDir(d.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if !strings.HasSuffix(path, ".go") {
+				return nil
+			}
+
+			found, err := scanFile(fset, path)
+			if err != nil {
+				return err
+			}
+
+			reads = append(reads, found...)
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return reads, nil
+}
+
+// skipDir reports whether a directory should be skipped when recursing.
+func skipDir(name string) bool {
+	return name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+// scanFile returns the reads in a single file.
+func scanFile(fset *token.FileSet, path string) ([]Read, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	return Inspect(fset, f), nil
+}
+
+// Inspect returns the reads in a parsed file.
+func Inspect(fset *token.FileSet, f *ast.File) []Read {
+	var reads []Read
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+
+		// generic calls, envy.Get[int](env, "PORT"), index the callee
+		fun, funcs, arg := call.Fun, ReadFuncs, 0
+		switch x := fun.(type) {
+		case *ast.IndexExpr:
+			fun, funcs, arg = x.X, GenericReadFuncs, 1
+		case *ast.IndexListExpr:
+			fun, funcs, arg = x.X, GenericReadFuncs, 1
+		}
+
+		sel, ok := fun.(*ast.SelectorExpr)
+		if !ok || !funcs[sel.Sel.Name] || len(call.Args) <= arg {
+			return true
+		}
+
+		key, ok := StringLit(call.Args[arg])
+		if !ok {
+			return true
+		}
+
+		reads = append(reads, Read{Key: key, Pos: fset.Position(call.Args[arg].Pos())})
+		return true
+	})
+
+	return reads
+}
+
+// StringLit returns the value of expr if it is a string literal.
+func StringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+	return s, true
+}
+
+// Audit compares the variables defined in env with reads. Keys for which
+// ignore returns true are excluded from both lists; ignore may be nil.
+func Audit(env *envy.Env, reads []Read, ignore func(key string) bool) Report {
+	if ignore == nil {
+		ignore = func(string) bool { return false }
+	}
+
+	used := map[string]bool{}
+	rep := Report{
+		Unused:    []string{},
+		Undefined: []Read{},
+	}
+
+	for _, rd := range reads {
+		used[rd.Key] = true
+		if !env.IsSet(rd.Key) && !ignore(rd.Key) {
+			rep.Undefined = append(rep.Undefined, rd)
+		}
+	}
+
+	for _, kv := range env.Environ() {
+		k, _, _ := strings.Cut(kv, "=")
+		if !used[k] && !ignore(k) {
+			rep.Unused = append(rep.Unused, k)
+		}
+	}
+
+	sort.Strings(rep.Unused)
+	return rep
+}
@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/markbates/envy"
+	"github.com/stretchr/testify/require"
+)
+
+func keys(reads []Read) []string {
+	ks := make([]string, len(reads))
+	for i, rd := range reads {
+		ks[i] = rd.Key
+	}
+	return ks
+}
+
+func Test_Scan(t *testing.T) {
+	t.Parallel()
+
+	root := filepath.Join("testdata", "src", "app")
+
+	tcs := []struct {
+		name     string
+		patterns []string
+		exp      []string
+		err      bool
+	}{
+		{
+			name:     "single directory",
+			patterns: []string{root},
+			exp:      []string{"PORT", "DATABASE_URL", "MISSING", "DEBUG"},
+		},
+		{
+			name:     "recursive",
+			patterns: []string{root + "/..."},
+			exp:      []string{"PORT", "DATABASE_URL", "MISSING", "DEBUG", "DEBUG"},
+		},
+		{
+			name:     "missing directory",
+			patterns: []string{filepath.Join("testdata", "nope")},
+			err:      true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			reads, err := Scan(tc.patterns...)
+			if tc.err {
+				r.Error(err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, keys(reads))
+		})
+	}
+}
+
+func Test_Audit(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	root := filepath.Join("testdata", "src", "app")
+
+	env, err := envy.FromFile(os.DirFS(root), ".env")
+	r.NoError(err)
+
+	reads, err := Scan(root + "/...")
+	r.NoError(err)
+
+	rep := Audit(env, reads, nil)
+	r.False(rep.OK())
+	r.Equal([]string{"UNUSED"}, rep.Unused)
+	r.Equal([]string{"MISSING"}, keys(rep.Undefined))
+	r.Equal(filepath.Join(root, "main.go"), rep.Undefined[0].Pos.Filename)
+	r.Equal(14, rep.Undefined[0].Pos.Line)
+
+	probs := rep.Problems()
+	r.False(probs.OK())
+	r.Len(probs.Problems, 2)
+	r.Equal(envy.Problem{
+		Severity: envy.Warning,
+		Code:     CodeUnused,
+		Key:      "UNUSED",
+		Message:  "UNUSED is defined but never read",
+	}, probs.Problems[0])
+	r.Equal(envy.Problem{
+		Severity: envy.Error,
+		Code:     CodeUndefined,
+		Key:      "MISSING",
+		File:     filepath.Join(root, "main.go"),
+		Line:     14,
+		Message:  "MISSING is read but never defined",
+	}, probs.Problems[1])
+
+	rep = Audit(env, reads, func(k string) bool { return k == "UNUSED" || k == "MISSING" })
+	r.True(rep.OK())
+	r.Empty(rep.Problems().Problems)
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"os"
+
+	"github.com/markbates/envy"
+)
+
+func main() {
+	env := envy.New()
+
+	_ = os.Getenv("PORT")
+	_, _ = os.LookupEnv("DATABASE_URL")
+	_ = env.Getenv("MISSING")
+	_, _ = envy.Get[bool](env, "DEBUG")
+
+	key := "DYNAMIC"
+	_ = os.Getenv(key)
+}
@@ -0,0 +1,7 @@
+package sub
+
+import "os"
+
+func Debug() bool {
+	return os.Getenv(`DEBUG`) != ""
+}
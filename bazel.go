@@ -0,0 +1,207 @@
+package envy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// BazelFlag is a Bazel command line flag that sets environment variables.
+type BazelFlag string
+
+// Bazel's environment flags.
+const (
+	ActionEnv     BazelFlag = "action_env"
+	HostActionEnv BazelFlag = "host_action_env"
+	TestEnv       BazelFlag = "test_env"
+	RepoEnv       BazelFlag = "repo_env"
+)
+
+// BazelEnv is the environment declared by a Bazel flag. Bazel accepts both
+// --action_env=NAME=value, which fixes a value, and --action_env=NAME, which
+// passes the variable through from the client environment and so makes the
+// build depend on it.
+type BazelEnv struct {
+	// Env holds the variables with fixed values.
+	Env *Env
+	// Inherit lists, in order, the variables taken from the client
+	// environment.
+	Inherit []string
+}
+
+// FromBazelArgs collects the variables set by flag in args, such as
+// "--action_env=CC=clang" or "--action_env", "PATH". As in Bazel, a later
+// flag for the same variable replaces an earlier one. Other arguments are
+// ignored. It returns an error if the flag has no value.
+func FromBazelArgs(args []string, flag BazelFlag) (BazelEnv, error) {
+	env := map[string]string{}
+	var inherit []string
+
+	name := "--" + string(flag)
+	for i := 0; i < len(args); i++ {
+		var spec string
+		switch {
+		case args[i] == name:
+			if i+1 >= len(args) {
+				return BazelEnv{}, fmt.Errorf("%s: missing value", name)
+			}
+			i++
+			spec = args[i]
+		case strings.HasPrefix(args[i], name+"="):
+			spec = args[i][len(name)+1:]
+		default:
+			continue
+		}
+
+		k, v, fixed := strings.Cut(spec, "=")
+		if !validKey(k) {
+			return BazelEnv{}, fmt.Errorf("%s: invalid variable %q", name, spec)
+		}
+
+		inherit = removeString(inherit, k)
+		delete(env, k)
+
+		if fixed {
+			env[k] = v
+			continue
+		}
+		inherit = append(inherit, k)
+	}
+
+	return BazelEnv{Env: FromMap(env), Inherit: inherit}, nil
+}
+
+// FromBazelrc collects the variables set by flag in a .bazelrc file. Every
+// line is considered regardless of its command or config, e.g. "build" and
+// "test:ci" alike; comments and import directives are skipped. Arguments are
+// split the way Bazel does, honoring quotes and backslash escapes.
+func FromBazelrc(r io.Reader, flag BazelFlag) (BazelEnv, error) {
+	if r == nil {
+		return BazelEnv{}, fmt.Errorf("nil reader")
+	}
+
+	var args []string
+
+	buf := bufio.NewScanner(r)
+	for n := 1; buf.Scan(); n++ {
+		line := strings.TrimSpace(buf.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		words, err := splitBazelrc(line)
+		if err != nil {
+			return BazelEnv{}, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		switch words[0] {
+		case "import", "try-import":
+			continue
+		}
+		args = append(args, words[1:]...)
+	}
+
+	if err := buf.Err(); err != nil {
+		return BazelEnv{}, err
+	}
+
+	return FromBazelArgs(args, flag)
+}
+
+// BazelArgs returns the Env as sorted flag=NAME=value arguments, suitable
+// for passing to the bazel command.
+func (e *Env) BazelArgs(flag BazelFlag) []string {
+	ents := e.entries()
+
+	args := make([]string, 0, len(ents))
+	for _, ent := range ents {
+		args = append(args, fmt.Sprintf("--%s=%s=%s", flag, ent.key, ent.value))
+	}
+	return args
+}
+
+// ToBazelrc writes the Env to w as sorted .bazelrc lines applying flag to
+// command, e.g. "build --action_env=CC=clang", quoting values as needed so
+// that FromBazelrc reads them back unchanged.
+func (e *Env) ToBazelrc(w io.Writer, command string, flag BazelFlag) error {
+	if w == nil {
+		return fmt.Errorf("nil writer")
+	}
+
+	for _, arg := range e.BazelArgs(flag) {
+		if _, err := fmt.Fprintf(w, "%s %s\n", command, bazelrcQuote(arg)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitBazelrc splits a .bazelrc line into words.
+func splitBazelrc(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	var quote rune
+	inWord := false
+
+	rs := []rune(line)
+	for i := 0; i < len(rs); i++ {
+		c := rs[i]
+		switch {
+		case c == '\\' && quote != '\'':
+			if i+1 >= len(rs) {
+				return nil, fmt.Errorf("trailing backslash")
+			}
+			i++
+			word.WriteRune(rs[i])
+			inWord = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+				continue
+			}
+			word.WriteRune(c)
+		case c == '"' || c == '\'':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// bazelrcQuote quotes s for a .bazelrc line if it contains characters that
+// splitBazelrc would otherwise interpret.
+func bazelrcQuote(s string) string {
+	if !strings.ContainsAny(s, " \t'\"\\#") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// removeString returns ss without any element equal to s.
+func removeString(ss []string, s string) []string {
+	out := ss[:0]
+	for _, x := range ss {
+		if x != s {
+			out = append(out, x)
+		}
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package envy
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromBazelArgs(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name    string
+		args    []string
+		flag    BazelFlag
+		exp     []string
+		inherit []string
+		err     string
+	}{
+		{
+			name: "empty",
+			flag: ActionEnv,
+			exp:  []string{},
+		},
+		{
+			name:    "fixed and inherited",
+			args:    []string{"build", "--action_env=CC=clang", "--action_env", "PATH", "--test_env=X=1", "//..."},
+			flag:    ActionEnv,
+			exp:     []string{"CC=clang"},
+			inherit: []string{"PATH"},
+		},
+		{
+			name:    "later flags win",
+			args:    []string{"--action_env=CC=gcc", "--action_env=CC", "--action_env=PATH", "--action_env=PATH=/bin", "--action_env=CC=clang=1"},
+			flag:    ActionEnv,
+			exp:     []string{"CC=clang=1", "PATH=/bin"},
+			inherit: []string{},
+		},
+		{
+			name: "other flag",
+			args: []string{"--action_env=CC=clang", "--test_env=X=1"},
+			flag: TestEnv,
+			exp:  []string{"X=1"},
+		},
+		{
+			name: "missing value",
+			args: []string{"--action_env"},
+			flag: ActionEnv,
+			err:  "--action_env: missing value",
+		},
+		{
+			name: "invalid variable",
+			args: []string{"--action_env="},
+			flag: ActionEnv,
+			err:  `--action_env: invalid variable ""`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			be, err := FromBazelArgs(tc.args, tc.flag)
+			if tc.err != "" {
+				r.EqualError(err, tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, be.Env.Environ())
+			r.Equal(tc.inherit, be.Inherit)
+		})
+	}
+}
+
+func Test_FromBazelrc(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name    string
+		in      string
+		exp     []string
+		inherit []string
+		err     string
+	}{
+		{
+			name: "bazelrc",
+			in: `# toolchain
+import %workspace%/tools/bazel.rc
+build --action_env=CC=clang --action_env=PATH
+build:ci --action_env="GREETING=hello world" --action_env=Q='it'\''s'
+test --test_env=ONLY_TEST=1
+`,
+			exp:     []string{"CC=clang", "GREETING=hello world", "Q=it's"},
+			inherit: []string{"PATH"},
+		},
+		{
+			name: "unterminated quote",
+			in:   "build --action_env=\"A=1\n",
+			err:  "line 1: unterminated \" quote",
+		},
+		{
+			name: "trailing backslash",
+			in:   "build --action_env=A=1\\",
+			err:  "line 1: trailing backslash",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			be, err := FromBazelrc(strings.NewReader(tc.in), ActionEnv)
+			if tc.err != "" {
+				r.EqualError(err, tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, be.Env.Environ())
+			r.Equal(tc.inherit, be.Inherit)
+		})
+	}
+
+	_, err := FromBazelrc(nil, ActionEnv)
+	require.Error(t, err)
+}
+
+func Test_Env_ToBazelrc(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := FromMap(map[string]string{
+		"CC":       "clang",
+		"GREETING": "hello world",
+		"Q":        `it's a "test" \o/`,
+	})
+
+	r.Equal([]string{"--action_env=CC=clang", "--action_env=GREETING=hello world", `--action_env=Q=it's a "test" \o/`}, env.BazelArgs(ActionEnv))
+
+	bb := &bytes.Buffer{}
+	r.NoError(env.ToBazelrc(bb, "build", ActionEnv))
+	r.Equal("build --action_env=CC=clang\nbuild '--action_env=GREETING=hello world'\nbuild '--action_env=Q=it'\\''s a \"test\" \\o/'\n", bb.String())
+
+	be, err := FromBazelrc(bb, ActionEnv)
+	r.NoError(err)
+	r.Equal(env.Environ(), be.Env.Environ())
+
+	r.Error(env.ToBazelrc(nil, "build", ActionEnv))
+}
@@ -0,0 +1,75 @@
+package envy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// EnvironBlock returns the Env as a Unix environment block: each
+// "KEY=VALUE" entry, sorted by key, followed by a NUL byte, the layout of
+// /proc/PID/environ and of the strings execve copies onto a new process's
+// stack. A nil or empty Env gives an empty block. It returns an error if a
+// key or value contains a NUL byte, which cannot be represented.
+func (e *Env) EnvironBlock() ([]byte, error) {
+	var b []byte
+	for _, ent := range e.entries() {
+		if err := blockEntry(ent); err != nil {
+			return nil, err
+		}
+
+		b = append(b, ent.key...)
+		b = append(b, '=')
+		b = append(b, ent.value...)
+		b = append(b, 0)
+	}
+	return b, nil
+}
+
+// WindowsEnvironBlock returns the Env as a Windows environment block, as
+// passed to CreateProcessW with CREATE_UNICODE_ENVIRONMENT: each
+// "KEY=VALUE" entry in UTF-16 followed by a NUL, and a final NUL ending
+// the block. Entries are sorted by key ignoring case, as Windows expects.
+// A nil or empty Env gives a block of two NULs. It returns an error if a
+// key or value contains a NUL or is not valid UTF-8.
+func (e *Env) WindowsEnvironBlock() ([]uint16, error) {
+	ents := e.entries()
+	sort.SliceStable(ents, func(i, j int) bool {
+		return strings.ToUpper(ents[i].key) < strings.ToUpper(ents[j].key)
+	})
+
+	var b []uint16
+	for _, ent := range ents {
+		if err := blockEntry(ent); err != nil {
+			return nil, err
+		}
+
+		if !utf8.ValidString(ent.key) || !utf8.ValidString(ent.value) {
+			return nil, fmt.Errorf("%s: invalid UTF-8", ent.key)
+		}
+
+		b = append(b, utf16.Encode([]rune(ent.key+"="+ent.value))...)
+		b = append(b, 0)
+	}
+
+	if len(b) == 0 {
+		// an empty block still needs its own terminator
+		b = append(b, 0)
+	}
+	return append(b, 0), nil
+}
+
+// blockEntry returns an error if ent cannot be written to a NUL-separated
+// environment block.
+func blockEntry(ent entry) error {
+	if strings.IndexByte(ent.key, 0) >= 0 {
+		return fmt.Errorf("%q: key contains a NUL byte", ent.key)
+	}
+
+	if strings.IndexByte(ent.value, 0) >= 0 {
+		return fmt.Errorf("%s: value contains a NUL byte", ent.key)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package envy
+
+import (
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_EnvironBlock(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  *Env
+		exp  string
+		err  string
+	}{
+		{name: "nil env", env: nil, exp: ""},
+		{name: "empty env", env: Zero(), exp: ""},
+		{
+			name: "sorted",
+			env:  FromMap(map[string]string{"b": "2", "A": "x=y", "EMPTY": ""}),
+			exp:  "A=x=y\x00EMPTY=\x00b=2\x00",
+		},
+		{
+			name: "NUL in value",
+			env:  FromMap(map[string]string{"A": "x\x00y"}),
+			err:  "A: value contains a NUL byte",
+		},
+		{
+			name: "NUL in key",
+			env:  FromMap(map[string]string{"A\x00B": "x"}),
+			err:  "key contains a NUL byte",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			b, err := tc.env.EnvironBlock()
+			if tc.err != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, string(b))
+		})
+	}
+}
+
+func Test_Env_WindowsEnvironBlock(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  *Env
+		exp  string
+		err  string
+	}{
+		{name: "nil env", env: nil, exp: "\x00\x00"},
+		{name: "empty env", env: Zero(), exp: "\x00\x00"},
+		{
+			name: "sorted ignoring case",
+			env:  FromMap(map[string]string{"b": "2", "A": "1", "Path": `C:\Windows`, "c": "é"}),
+			exp:  "A=1\x00b=2\x00c=é\x00Path=C:\\Windows\x00\x00",
+		},
+		{
+			name: "NUL in value",
+			env:  FromMap(map[string]string{"A": "x\x00y"}),
+			err:  "A: value contains a NUL byte",
+		},
+		{
+			name: "invalid UTF-8",
+			env:  FromMap(map[string]string{"A": "\xff"}),
+			err:  "A: invalid UTF-8",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			b, err := tc.env.WindowsEnvironBlock()
+			if tc.err != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(utf16.Encode([]rune(tc.exp)), b)
+		})
+	}
+}
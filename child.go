@@ -0,0 +1,48 @@
+package envy
+
+import "maps"
+
+// Child returns a scoped copy of the Env for temporary configuration, such
+// as per request or per job, and a cleanup func that destroys it. The child
+// is a snapshot, not an overlay reading through to the Env: it starts with
+// the Env's variables, metadata, resolvers, key provider, Policy, and the
+// layers Explain reports, and changes to either afterwards are not seen by
+// the other, so anything set on the child disappears with it. Resolvers
+// that have not run yet run on the child's reads as they would on the
+// Env's. Subscribers and history are not carried over. The cleanup wipes
+// the child's values as Destroy does, so secrets set for the scope do not
+// linger, and may be called more than once; defer it where the child is
+// created. A nil Env gives an empty child.
+func (e *Env) Child() (*Env, func()) {
+	child := Zero()
+	if e.IsNil() {
+		return child, child.Destroy
+	}
+
+	e.mu.RLock()
+	child.envs = maps.Clone(e.envs)
+	child.meta = maps.Clone(e.meta)
+	child.tombstones = maps.Clone(e.tombstones)
+	child.keys = e.keys
+	child.policy = e.policy
+	child.layers = e.layers
+	child.followRefs = e.followRefs
+	child.untrusted = e.untrusted
+
+	child.created = e.created
+	child.modified = maps.Clone(e.modified)
+
+	resolvers := maps.Clone(e.resolvers)
+	e.mu.RUnlock()
+
+	// a running chain holds its lock while it takes the Env's, so the
+	// chains are copied without the Env's lock
+	for k, res := range resolvers {
+		res.mu.Lock()
+		resolvers[k] = &resolution{done: res.done, chain: res.chain}
+		res.mu.Unlock()
+	}
+	child.resolvers = resolvers
+
+	return child, child.Destroy
+}
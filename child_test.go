@@ -0,0 +1,104 @@
+package envy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_Child(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	parent := FromMap(map[string]string{"A": "1", "B": "2"})
+	r.NoError(parent.SetMeta("A", Meta{Description: "the a"}))
+
+	child, cleanup := parent.Child()
+	defer cleanup()
+
+	r.Equal([]string{"A=1", "B=2"}, child.Environ())
+	m, ok := child.Meta("A")
+	r.True(ok)
+	r.Equal("the a", m.Description)
+
+	// changes to the child stay in the child
+	r.NoError(child.Setenv("A", "one"))
+	r.NoError(child.Setenv("C", "3"))
+	r.NoError(child.Unsetenv("B"))
+	r.Equal([]string{"A=one", "C=3"}, child.Environ())
+	r.Equal([]string{"A=1", "B=2"}, parent.Environ())
+
+	// and the parent's later changes stay in the parent
+	r.NoError(parent.Setenv("D", "4"))
+	r.False(child.IsSet("D"))
+
+	cleanup()
+	r.True(child.IsNil())
+	r.Equal("", child.Getenv("A"))
+	r.Equal([]string{"A=1", "B=2", "D=4"}, parent.Environ())
+
+	// cleanup may run again
+	cleanup()
+}
+
+func Test_Env_Child_policy(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	rules, err := ParseRules(strings.NewReader("deny DEBUG=true\n"))
+	r.NoError(err)
+
+	parent := Zero()
+	r.NoError(parent.SetPolicy(rules))
+
+	child, cleanup := parent.Child()
+	defer cleanup()
+
+	r.ErrorIs(child.Setenv("DEBUG", "true"), ErrPolicy)
+	r.NoError(child.Setenv("DEBUG", "false"))
+}
+
+func Test_Env_Child_resolvers(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	calls := 0
+	counted := func(v string) Resolver {
+		return func(string) (string, bool, error) {
+			calls++
+			return v, true, nil
+		}
+	}
+
+	parent := Zero()
+	r.NoError(parent.AddResolver("DONE", 0, counted("done")))
+	r.NoError(parent.AddResolver("PENDING", 0, counted("pending")))
+	r.Equal("done", parent.Getenv("DONE"))
+
+	child, cleanup := parent.Child()
+	defer cleanup()
+
+	// a chain that ran in the parent does not run again
+	r.Equal("done", child.Getenv("DONE"))
+	r.Equal(1, calls)
+
+	// a pending one runs in the child only
+	r.Equal("pending", child.Getenv("PENDING"))
+	r.Equal(2, calls)
+	r.False(parent.IsSet("PENDING"))
+}
+
+func Test_Env_Child_nil(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	var parent *Env
+	child, cleanup := parent.Child()
+	r.False(child.IsNil())
+	r.Equal(0, child.Len())
+
+	r.NoError(child.Setenv("A", "1"))
+	cleanup()
+	r.True(child.IsNil())
+}
@@ -0,0 +1,228 @@
+package envy
+
+import (
+	"strings"
+)
+
+// CIInfo describes the continuous integration system, if any, that the
+// environment belongs to. Fields that the provider does not expose are left
+// empty.
+type CIInfo struct {
+	// Provider is a short, stable identifier such as "github-actions" or
+	// "gitlab". It is empty when no CI system was detected.
+	Provider string
+	// Name is the human-readable name of the provider.
+	Name string
+	// Branch is the branch being built, without any "refs/heads/" prefix.
+	Branch string
+	// Commit is the SHA of the commit being built.
+	Commit string
+	// PR is the pull/merge request number, if the build is for one.
+	PR string
+	// BuildURL links to the build in the provider's UI, when available.
+	BuildURL string
+}
+
+// IsCI reports whether a CI system was detected.
+func (c CIInfo) IsCI() bool {
+	return c.Provider != ""
+}
+
+// IsPR reports whether the build is for a pull or merge request.
+func (c CIInfo) IsPR() bool {
+	return c.PR != ""
+}
+
+// ciProvider maps a CI system's variables onto CIInfo.
+type ciProvider struct {
+	id     string
+	name   string
+	detect func(e *Env) bool
+	info   func(e *Env) CIInfo
+}
+
+// ciProviders are checked in order; the first match wins. The generic "CI"
+// check lives in DetectCI so that specific providers always take precedence.
+var ciProviders = []ciProvider{
+	{
+		id:     "github-actions",
+		name:   "GitHub Actions",
+		detect: func(e *Env) bool { return e.Getenv("GITHUB_ACTIONS") == "true" },
+		info: func(e *Env) CIInfo {
+			ci := CIInfo{
+				Commit: e.Getenv("GITHUB_SHA"),
+				Branch: e.Getenv("GITHUB_HEAD_REF"),
+			}
+
+			ref := e.Getenv("GITHUB_REF")
+			if ci.Branch == "" {
+				ci.Branch = strings.TrimPrefix(ref, "refs/heads/")
+				if strings.HasPrefix(ci.Branch, "refs/") {
+					ci.Branch = ""
+				}
+			}
+
+			// refs/pull/<n>/merge
+			if rest, ok := strings.CutPrefix(ref, "refs/pull/"); ok {
+				ci.PR, _, _ = strings.Cut(rest, "/")
+			}
+
+			if s, r, id := e.Getenv("GITHUB_SERVER_URL"), e.Getenv("GITHUB_REPOSITORY"), e.Getenv("GITHUB_RUN_ID"); s != "" && r != "" && id != "" {
+				ci.BuildURL = s + "/" + r + "/actions/runs/" + id
+			}
+			return ci
+		},
+	},
+	{
+		id:     "gitlab",
+		name:   "GitLab CI",
+		detect: func(e *Env) bool { return e.IsSet("GITLAB_CI") },
+		info: func(e *Env) CIInfo {
+			return CIInfo{
+				Branch:   firstSet(e, "CI_MERGE_REQUEST_SOURCE_BRANCH_NAME", "CI_COMMIT_BRANCH", "CI_COMMIT_REF_NAME"),
+				Commit:   e.Getenv("CI_COMMIT_SHA"),
+				PR:       e.Getenv("CI_MERGE_REQUEST_IID"),
+				BuildURL: e.Getenv("CI_JOB_URL"),
+			}
+		},
+	},
+	{
+		id:     "circleci",
+		name:   "CircleCI",
+		detect: func(e *Env) bool { return e.Getenv("CIRCLECI") == "true" },
+		info: func(e *Env) CIInfo {
+			ci := CIInfo{
+				Branch:   e.Getenv("CIRCLE_BRANCH"),
+				Commit:   e.Getenv("CIRCLE_SHA1"),
+				PR:       e.Getenv("CIRCLE_PR_NUMBER"),
+				BuildURL: e.Getenv("CIRCLE_BUILD_URL"),
+			}
+
+			if ci.PR == "" {
+				ci.PR = lastPathSegment(e.Getenv("CIRCLE_PULL_REQUEST"))
+			}
+			return ci
+		},
+	},
+	{
+		id:     "jenkins",
+		name:   "Jenkins",
+		detect: func(e *Env) bool { return e.IsSet("JENKINS_URL") && e.IsSet("BUILD_ID") },
+		info: func(e *Env) CIInfo {
+			return CIInfo{
+				Branch:   strings.TrimPrefix(firstSet(e, "CHANGE_BRANCH", "BRANCH_NAME", "GIT_BRANCH"), "origin/"),
+				Commit:   e.Getenv("GIT_COMMIT"),
+				PR:       firstSet(e, "CHANGE_ID", "ghprbPullId"),
+				BuildURL: e.Getenv("BUILD_URL"),
+			}
+		},
+	},
+	{
+		id:     "buildkite",
+		name:   "Buildkite",
+		detect: func(e *Env) bool { return e.Getenv("BUILDKITE") == "true" },
+		info: func(e *Env) CIInfo {
+			ci := CIInfo{
+				Branch:   e.Getenv("BUILDKITE_BRANCH"),
+				Commit:   e.Getenv("BUILDKITE_COMMIT"),
+				BuildURL: e.Getenv("BUILDKITE_BUILD_URL"),
+			}
+
+			// buildkite sets the PR variable to "false" for branch builds
+			if pr := e.Getenv("BUILDKITE_PULL_REQUEST"); pr != "false" {
+				ci.PR = pr
+			}
+			return ci
+		},
+	},
+	{
+		id:     "travis",
+		name:   "Travis CI",
+		detect: func(e *Env) bool { return e.Getenv("TRAVIS") == "true" },
+		info: func(e *Env) CIInfo {
+			ci := CIInfo{
+				Branch:   firstSet(e, "TRAVIS_PULL_REQUEST_BRANCH", "TRAVIS_BRANCH"),
+				Commit:   e.Getenv("TRAVIS_COMMIT"),
+				BuildURL: e.Getenv("TRAVIS_BUILD_WEB_URL"),
+			}
+
+			if pr := e.Getenv("TRAVIS_PULL_REQUEST"); pr != "false" {
+				ci.PR = pr
+			}
+			return ci
+		},
+	},
+	{
+		id:     "azure-pipelines",
+		name:   "Azure Pipelines",
+		detect: func(e *Env) bool { return e.IsSet("TF_BUILD") },
+		info: func(e *Env) CIInfo {
+			return CIInfo{
+				Branch: strings.TrimPrefix(firstSet(e, "SYSTEM_PULLREQUEST_SOURCEBRANCH", "BUILD_SOURCEBRANCH"), "refs/heads/"),
+				Commit: e.Getenv("BUILD_SOURCEVERSION"),
+				PR:     firstSet(e, "SYSTEM_PULLREQUEST_PULLREQUESTNUMBER", "SYSTEM_PULLREQUEST_PULLREQUESTID"),
+			}
+		},
+	},
+	{
+		id:     "bitbucket",
+		name:   "Bitbucket Pipelines",
+		detect: func(e *Env) bool { return e.IsSet("BITBUCKET_BUILD_NUMBER") },
+		info: func(e *Env) CIInfo {
+			return CIInfo{
+				Branch: e.Getenv("BITBUCKET_BRANCH"),
+				Commit: e.Getenv("BITBUCKET_COMMIT"),
+				PR:     e.Getenv("BITBUCKET_PR_ID"),
+			}
+		},
+	},
+}
+
+// DetectCI inspects env for the variables set by well-known CI systems and
+// returns a normalized description of the build. If no specific provider is
+// recognized but CI is set to a truthy value, Provider is "generic". A nil Env
+// yields the zero CIInfo.
+func DetectCI(env *Env) CIInfo {
+	if env.IsNil() {
+		return CIInfo{}
+	}
+
+	for _, p := range ciProviders {
+		if !p.detect(env) {
+			continue
+		}
+
+		ci := p.info(env)
+		ci.Provider = p.id
+		ci.Name = p.name
+		return ci
+	}
+
+	switch strings.ToLower(env.Getenv("CI")) {
+	case "true", "1", "yes":
+		return CIInfo{
+			Provider: "generic",
+			Name:     "CI",
+		}
+	}
+
+	return CIInfo{}
+}
+
+// firstSet returns the first non-empty value among keys.
+func firstSet(e *Env, keys ...string) string {
+	for _, k := range keys {
+		if v := e.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// lastPathSegment returns the part of s after the final '/'.
+func lastPathSegment(s string) string {
+	if i := strings.LastIndexByte(s, '/'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
@@ -0,0 +1,148 @@
+package envy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DetectCI(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  *Env
+		exp  CIInfo
+	}{
+		{
+			name: "nil env",
+			env:  nil,
+			exp:  CIInfo{},
+		},
+		{
+			name: "not ci",
+			env:  FromMap(map[string]string{"HOME": "/home"}),
+			exp:  CIInfo{},
+		},
+		{
+			name: "github actions push",
+			env: FromMap(map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_REF":        "refs/heads/main",
+				"GITHUB_SHA":        "abc123",
+				"GITHUB_SERVER_URL": "https://github.com",
+				"GITHUB_REPOSITORY": "markbates/envy",
+				"GITHUB_RUN_ID":     "42",
+			}),
+			exp: CIInfo{
+				Provider: "github-actions",
+				Name:     "GitHub Actions",
+				Branch:   "main",
+				Commit:   "abc123",
+				BuildURL: "https://github.com/markbates/envy/actions/runs/42",
+			},
+		},
+		{
+			name: "github actions pull request",
+			env: FromMap(map[string]string{
+				"GITHUB_ACTIONS":  "true",
+				"GITHUB_REF":      "refs/pull/7/merge",
+				"GITHUB_HEAD_REF": "feature",
+				"GITHUB_SHA":      "abc123",
+			}),
+			exp: CIInfo{
+				Provider: "github-actions",
+				Name:     "GitHub Actions",
+				Branch:   "feature",
+				Commit:   "abc123",
+				PR:       "7",
+			},
+		},
+		{
+			name: "gitlab merge request",
+			env: FromMap(map[string]string{
+				"GITLAB_CI":                           "true",
+				"CI":                                  "true",
+				"CI_COMMIT_REF_NAME":                  "main",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "feature",
+				"CI_COMMIT_SHA":                       "def456",
+				"CI_MERGE_REQUEST_IID":                "3",
+			}),
+			exp: CIInfo{
+				Provider: "gitlab",
+				Name:     "GitLab CI",
+				Branch:   "feature",
+				Commit:   "def456",
+				PR:       "3",
+			},
+		},
+		{
+			name: "circleci pull request url",
+			env: FromMap(map[string]string{
+				"CIRCLECI":            "true",
+				"CIRCLE_BRANCH":       "feature",
+				"CIRCLE_SHA1":         "aaa",
+				"CIRCLE_PULL_REQUEST": "https://github.com/markbates/envy/pull/12",
+			}),
+			exp: CIInfo{
+				Provider: "circleci",
+				Name:     "CircleCI",
+				Branch:   "feature",
+				Commit:   "aaa",
+				PR:       "12",
+			},
+		},
+		{
+			name: "jenkins",
+			env: FromMap(map[string]string{
+				"JENKINS_URL": "https://ci.example.com",
+				"BUILD_ID":    "9",
+				"GIT_BRANCH":  "origin/main",
+				"GIT_COMMIT":  "bbb",
+				"BUILD_URL":   "https://ci.example.com/job/9",
+			}),
+			exp: CIInfo{
+				Provider: "jenkins",
+				Name:     "Jenkins",
+				Branch:   "main",
+				Commit:   "bbb",
+				BuildURL: "https://ci.example.com/job/9",
+			},
+		},
+		{
+			name: "buildkite branch build",
+			env: FromMap(map[string]string{
+				"BUILDKITE":              "true",
+				"BUILDKITE_BRANCH":       "main",
+				"BUILDKITE_COMMIT":       "ccc",
+				"BUILDKITE_PULL_REQUEST": "false",
+			}),
+			exp: CIInfo{
+				Provider: "buildkite",
+				Name:     "Buildkite",
+				Branch:   "main",
+				Commit:   "ccc",
+			},
+		},
+		{
+			name: "generic ci",
+			env:  FromMap(map[string]string{"CI": "1"}),
+			exp: CIInfo{
+				Provider: "generic",
+				Name:     "CI",
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			got := DetectCI(tc.env)
+			r.Equal(tc.exp, got)
+			r.Equal(tc.exp.Provider != "", got.IsCI())
+			r.Equal(tc.exp.PR != "", got.IsPR())
+		})
+	}
+}
@@ -0,0 +1,273 @@
+// Package cloudenv exports an envy.Env to cloud deploy targets, checking
+// their key restrictions first so that problems surface before a deploy is
+// rejected: reserved names such as Lambda's AWS_REGION, keys in the wrong
+// character set, and size limits. Offending keys can be reported or
+// renamed automatically with Mangle.
+package cloudenv
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/markbates/envy"
+)
+
+// Problem codes reported by Check.
+const (
+	CodeReserved     = "reserved-key"
+	CodeInvalidKey   = "invalid-key"
+	CodeKeyTooLong   = "key-too-long"
+	CodeValueTooLong = "value-too-long"
+	CodeTooLarge     = "too-large"
+)
+
+// Target describes the environment variable restrictions of a deploy
+// target and how to write variables for it.
+type Target struct {
+	// Name identifies the target in problem messages.
+	Name string
+	// Reserved are names the platform sets itself and rejects.
+	Reserved []string
+	// ReservedPrefixes are prefixes of names the platform rejects.
+	ReservedPrefixes []string
+	// Key, if not nil, must match every name.
+	Key *regexp.Regexp
+	// MaxKeyLen, MaxValueLen, and MaxTotal limit, in bytes, each name,
+	// each value, and all names and values together. Zero means no limit.
+	MaxKeyLen   int
+	MaxValueLen int
+	MaxTotal    int
+	// Prefix is prepended by Mangle to reserved names.
+	Prefix string
+	// Write writes the variables in the format the target's tooling
+	// reads.
+	Write func(w io.Writer, env *envy.Env) error
+}
+
+// Lambda is AWS Lambda: names start with a letter, the runtime's own
+// variables are reserved, and all variables together may not exceed 4 KB.
+// Write produces the JSON accepted by
+// "aws lambda update-function-configuration --environment".
+var Lambda = Target{
+	Name: "lambda",
+	Reserved: []string{
+		"_HANDLER", "_X_AMZN_TRACE_ID", "AWS_ACCESS_KEY", "AWS_ACCESS_KEY_ID",
+		"AWS_DEFAULT_REGION", "AWS_EXECUTION_ENV", "AWS_REGION",
+		"AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "LAMBDA_RUNTIME_DIR",
+		"LAMBDA_TASK_ROOT",
+	},
+	ReservedPrefixes: []string{"AWS_LAMBDA_"},
+	Key:              regexp.MustCompile(`\A[a-zA-Z][a-zA-Z0-9_]*\z`),
+	MaxTotal:         4 * 1024,
+	Prefix:           "APP_",
+	Write: func(w io.Writer, env *envy.Env) error {
+		return json.NewEncoder(w).Encode(map[string]map[string]string{"Variables": vars(env)})
+	},
+}
+
+// CloudRun is Google Cloud Run: the variables of the container contract
+// are reserved, as are names starting with X_GOOGLE_, and each value is
+// limited to 32 KB. Write produces the YAML read by
+// "gcloud run deploy --env-vars-file".
+var CloudRun = Target{
+	Name: "cloudrun",
+	Reserved: []string{
+		"PORT", "K_SERVICE", "K_REVISION", "K_CONFIGURATION", "CLOUD_RUN_JOB",
+		"CLOUD_RUN_EXECUTION", "CLOUD_RUN_TASK_INDEX", "CLOUD_RUN_TASK_ATTEMPT",
+		"CLOUD_RUN_TASK_COUNT",
+	},
+	ReservedPrefixes: []string{"X_GOOGLE_"},
+	Key:              regexp.MustCompile(`\A[a-zA-Z_][a-zA-Z0-9_]*\z`),
+	MaxValueLen:      32 * 1024,
+	Prefix:           "APP_",
+	Write: func(w io.Writer, env *envy.Env) error {
+		m := vars(env)
+		for _, k := range sortedKeys(m) {
+			// JSON strings are valid YAML scalars
+			v, err := json.Marshal(m[k])
+			if err != nil {
+				return err
+			}
+
+			if _, err := fmt.Fprintf(w, "%s: %s\n", k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// Check reports every variable of env the target would reject, as Error
+// problems in key order.
+func (t Target) Check(env *envy.Env) envy.Report {
+	rep := envy.Report{}
+
+	m := vars(env)
+	total := 0
+	for _, k := range sortedKeys(m) {
+		v := m[k]
+		total += len(k) + len(v)
+
+		switch {
+		case t.reserved(k):
+			rep.Add(t.problem(CodeReserved, k, "%s is reserved by %s"))
+		case t.Key != nil && !t.Key.MatchString(k):
+			rep.Add(t.problem(CodeInvalidKey, k, "%s is not a valid %s variable name"))
+		}
+
+		if t.MaxKeyLen > 0 && len(k) > t.MaxKeyLen {
+			rep.Add(t.problem(CodeKeyTooLong, k, fmt.Sprintf("%%s is longer than the %d bytes %%s allows", t.MaxKeyLen)))
+		}
+
+		if t.MaxValueLen > 0 && len(v) > t.MaxValueLen {
+			rep.Add(t.problem(CodeValueTooLong, k, fmt.Sprintf("the value of %%s is longer than the %d bytes %%s allows", t.MaxValueLen)))
+		}
+	}
+
+	if t.MaxTotal > 0 && total > t.MaxTotal {
+		rep.Add(envy.Problem{
+			Severity: envy.Error,
+			Code:     CodeTooLarge,
+			Message:  fmt.Sprintf("variables total %d bytes, more than the %d bytes %s allows", total, t.MaxTotal, t.Name),
+		})
+	}
+	return rep
+}
+
+// Mangle returns a copy of env with its names changed to ones the target
+// accepts, and the renames it made, old name to new. Characters outside
+// [A-Za-z0-9_] become underscores, names that still do not match Key get
+// an "X_" prefix, reserved names get Prefix, and names longer than
+// MaxKeyLen are cut short and end in a hash of the original name. Values
+// are never changed, so Check may still find problems. It returns an error
+// if two names would end up the same.
+func (t Target) Mangle(env *envy.Env) (*envy.Env, map[string]string, error) {
+	m := vars(env)
+	out := make(map[string]string, len(m))
+	renames := map[string]string{}
+	from := map[string]string{}
+
+	for _, k := range sortedKeys(m) {
+		nk := t.mangle(k)
+		if prev, ok := from[nk]; ok {
+			return nil, nil, fmt.Errorf("%s and %s would both be named %s", prev, k, nk)
+		}
+
+		from[nk] = k
+		out[nk] = m[k]
+		if nk != k {
+			renames[k] = nk
+		}
+	}
+	return envy.FromMap(out), renames, nil
+}
+
+// mangle returns the name Mangle gives k.
+func (t Target) mangle(k string) string {
+	nk := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, k)
+
+	if t.Key != nil && !t.Key.MatchString(nk) {
+		nk = "X_" + nk
+	}
+
+	if t.reserved(nk) {
+		nk = t.Prefix + nk
+	}
+
+	if t.MaxKeyLen > 0 && len(nk) > t.MaxKeyLen {
+		sum := sha256.Sum256([]byte(k))
+		suffix := "_" + strings.ToUpper(hex.EncodeToString(sum[:4]))
+		nk = nk[:max(t.MaxKeyLen-len(suffix), 0)] + suffix
+	}
+	return nk
+}
+
+// Export writes env to w with the target's Write func, first renaming its
+// keys with Mangle if mangle is true. Nothing is written if Check finds a
+// problem; the error then lists them all. It returns the renames made.
+func (t Target) Export(w io.Writer, env *envy.Env, mangle bool) (map[string]string, error) {
+	if w == nil {
+		return nil, fmt.Errorf("nil writer")
+	}
+
+	if t.Write == nil {
+		return nil, fmt.Errorf("%s: nil Write func", t.Name)
+	}
+
+	// the Env's Policy decides whether it may be exported at all
+	if _, err := env.Export(); err != nil {
+		return nil, err
+	}
+
+	renames := map[string]string{}
+	if mangle {
+		var err error
+		if env, renames, err = t.Mangle(env); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := t.Check(env).Err(); err != nil {
+		return nil, err
+	}
+	return renames, t.Write(w, env)
+}
+
+// reserved reports whether the target reserves k.
+func (t Target) reserved(k string) bool {
+	for _, r := range t.Reserved {
+		if k == r {
+			return true
+		}
+	}
+
+	for _, p := range t.ReservedPrefixes {
+		if strings.HasPrefix(k, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// problem returns an Error problem for key, formatting msg with the key
+// and the target's name.
+func (t Target) problem(code, key, msg string) envy.Problem {
+	return envy.Problem{
+		Severity: envy.Error,
+		Code:     code,
+		Key:      key,
+		Message:  fmt.Sprintf(msg, key, t.Name),
+	}
+}
+
+// vars returns the entries of env as a map.
+func vars(env *envy.Env) map[string]string {
+	m := map[string]string{}
+	for _, kv := range env.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		m[k] = v
+	}
+	return m
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,221 @@
+package cloudenv
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/markbates/envy"
+	"github.com/stretchr/testify/require"
+)
+
+func codes(rep envy.Report) []string {
+	var out []string
+	for _, p := range rep.Problems {
+		out = append(out, p.Key+":"+p.Code)
+	}
+	return out
+}
+
+func Test_Target_Check(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name   string
+		target Target
+		env    map[string]string
+		exp    []string
+	}{
+		{
+			name:   "lambda ok",
+			target: Lambda,
+			env:    map[string]string{"DATABASE_URL": "postgres://db", "debug": "1"},
+		},
+		{
+			name:   "lambda reserved",
+			target: Lambda,
+			env:    map[string]string{"AWS_REGION": "us-east-1", "AWS_LAMBDA_FOO": "1", "MY_AWS_REGION": "x"},
+			exp:    []string{"AWS_LAMBDA_FOO:reserved-key", "AWS_REGION:reserved-key"},
+		},
+		{
+			name:   "lambda invalid",
+			target: Lambda,
+			env:    map[string]string{"_PRIVATE": "1", "spring.port": "2", "9LIVES": "3"},
+			exp:    []string{"9LIVES:invalid-key", "_PRIVATE:invalid-key", "spring.port:invalid-key"},
+		},
+		{
+			name:   "lambda too large",
+			target: Lambda,
+			env:    map[string]string{"A": strings.Repeat("x", 4096)},
+			exp:    []string{":too-large"},
+		},
+		{
+			name:   "cloud run",
+			target: CloudRun,
+			env:    map[string]string{"PORT": "8080", "X_GOOGLE_X": "1", "_OK": "1", "BIG": strings.Repeat("x", 32*1024+1)},
+			exp:    []string{"BIG:value-too-long", "PORT:reserved-key", "X_GOOGLE_X:reserved-key"},
+		},
+		{
+			name:   "key length",
+			target: Target{Name: "short", MaxKeyLen: 4},
+			env:    map[string]string{"LONGER": "1", "OK": "2"},
+			exp:    []string{"LONGER:key-too-long"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			rep := tc.target.Check(envy.FromMap(tc.env))
+			r.Equal(tc.exp, codes(rep))
+			r.Equal(len(tc.exp) == 0, rep.OK())
+		})
+	}
+}
+
+func Test_Target_Mangle(t *testing.T) {
+	t.Parallel()
+
+	short := Target{
+		Name:      "short",
+		Key:       regexp.MustCompile(`\A[A-Z][A-Z0-9_]*\z`),
+		MaxKeyLen: 16,
+		Reserved:  []string{"PORT"},
+		Prefix:    "APP_",
+	}
+
+	tcs := []struct {
+		name    string
+		target  Target
+		env     map[string]string
+		renames map[string]string
+		err     string
+	}{
+		{
+			name:    "lambda",
+			target:  Lambda,
+			env:     map[string]string{"AWS_REGION": "us-east-1", "spring.port": "8080", "_X": "1", "OK": "2"},
+			renames: map[string]string{"AWS_REGION": "APP_AWS_REGION", "spring.port": "spring_port", "_X": "X__X"},
+		},
+		{
+			name:    "cloud run",
+			target:  CloudRun,
+			env:     map[string]string{"PORT": "1", "my-key": "2"},
+			renames: map[string]string{"PORT": "APP_PORT", "my-key": "my_key"},
+		},
+		{
+			name:    "truncated",
+			target:  short,
+			env:     map[string]string{"A_VERY_LONG_VARIABLE_NAME": "1", "PORT": "2"},
+			renames: map[string]string{"A_VERY_LONG_VARIABLE_NAME": "A_VERY__7DD93968", "PORT": "APP_PORT"},
+		},
+		{
+			name:   "collision",
+			target: Lambda,
+			env:    map[string]string{"my.key": "1", "my_key": "2"},
+			err:    "my.key and my_key would both be named my_key",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			env := envy.FromMap(tc.env)
+			out, renames, err := tc.target.Mangle(env)
+			if tc.err != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.renames, renames)
+			r.True(tc.target.Check(out).OK(), codes(tc.target.Check(out)))
+			for k, v := range tc.env {
+				nk := k
+				if rk, ok := renames[k]; ok {
+					nk = rk
+				}
+				r.Equal(v, out.Getenv(nk))
+			}
+		})
+	}
+}
+
+func Test_Target_Export(t *testing.T) {
+	t.Parallel()
+
+	env := envy.FromMap(map[string]string{"AWS_REGION": "us-east-1", "GREETING": `say "hi"`})
+
+	tcs := []struct {
+		name    string
+		target  Target
+		mangle  bool
+		exp     string
+		renames map[string]string
+		err     string
+	}{
+		{
+			name:   "lambda rejected",
+			target: Lambda,
+			err:    "AWS_REGION is reserved by lambda [reserved-key]",
+		},
+		{
+			name:    "lambda mangled",
+			target:  Lambda,
+			mangle:  true,
+			exp:     `{"Variables":{"APP_AWS_REGION":"us-east-1","GREETING":"say \"hi\""}}` + "\n",
+			renames: map[string]string{"AWS_REGION": "APP_AWS_REGION"},
+		},
+		{
+			name:    "cloud run",
+			target:  CloudRun,
+			exp:     "AWS_REGION: \"us-east-1\"\nGREETING: \"say \\\"hi\\\"\"\n",
+			renames: map[string]string{},
+		},
+		{
+			name:   "no writer func",
+			target: Target{Name: "custom"},
+			err:    "custom: nil Write func",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			bb := &bytes.Buffer{}
+			renames, err := tc.target.Export(bb, env, tc.mangle)
+			if tc.err != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.err)
+				r.Empty(bb.String())
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, bb.String())
+			r.Equal(tc.renames, renames)
+		})
+	}
+
+	_, err := Lambda.Export(nil, env, false)
+	require.Error(t, err)
+
+	denied := envy.FromMap(map[string]string{"GREETING": "hi"})
+	require.NoError(t, denied.SetPolicy(envy.PolicyFunc(func(envy.PolicyInput) error {
+		return errors.New("no exports")
+	})))
+
+	bb := &bytes.Buffer{}
+	_, err = CloudRun.Export(bb, denied, true)
+	require.ErrorIs(t, err, envy.ErrPolicy)
+	require.Empty(t, bb.String())
+}
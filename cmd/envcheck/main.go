@@ -0,0 +1,14 @@
+// Command envcheck runs the envcheck analyzer. It can be used standalone or
+// as a vet tool:
+//
+//	go vet -vettool=$(which envcheck) ./...
+package main
+
+import (
+	"github.com/markbates/envy/envcheck"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() {
+	unitchecker.Main(envcheck.Analyzer)
+}
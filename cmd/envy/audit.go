@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/markbates/envy"
+	"github.com/markbates/envy/audit"
+)
+
+func init() {
+	commands["audit"] = command{
+		summary: "report env vars defined but never read, and read but never defined",
+		run:     runAudit,
+	}
+}
+
+func runAudit(args []string, stdout, stderr io.Writer) error {
+	var files stringsFlag
+	var ignore string
+	var asJSON bool
+
+	flags := newFlagSet("audit", stderr)
+	flags.Var(&files, "env", "env file defining variables (repeatable; default .env)")
+	flags.StringVar(&ignore, "ignore", "", "comma-separated keys to ignore")
+	flags.BoolVar(&asJSON, "json", false, "print the problems as a JSON report")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: envy audit [-env file]... [-ignore KEYS] [-json] [packages]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		files = stringsFlag{".env"}
+	}
+
+	env := envy.Zero()
+	for _, f := range files {
+		loaded, err := envy.FromFile(os.DirFS(filepath.Dir(f)), filepath.Base(f))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && f == ".env" {
+				// the default file is optional
+				continue
+			}
+			return err
+		}
+
+		if env, err = env.Merge(loaded); err != nil {
+			return err
+		}
+	}
+
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	reads, err := audit.Scan(patterns...)
+	if err != nil {
+		return err
+	}
+
+	ignored := map[string]bool{}
+	for _, k := range strings.Split(ignore, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			ignored[k] = true
+		}
+	}
+
+	rep := audit.Audit(env, reads, func(k string) bool { return ignored[k] })
+
+	if asJSON {
+		if err := writeJSON(stdout, rep.Problems()); err != nil {
+			return err
+		}
+
+		if !rep.OK() {
+			return errFailed
+		}
+		return nil
+	}
+
+	for _, k := range rep.Unused {
+		fmt.Fprintf(stdout, "unused: %s\n", k)
+	}
+
+	for _, rd := range rep.Undefined {
+		fmt.Fprintf(stdout, "undefined: %s\n", rd)
+	}
+
+	if !rep.OK() {
+		return errFailed
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runAudit(t *testing.T) {
+	t.Parallel()
+
+	app := filepath.Join("..", "..", "audit", "testdata", "src", "app")
+	envFile := filepath.Join(app, ".env")
+
+	tcs := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+	}{
+		{
+			name:   "problems found",
+			args:   []string{"audit", "-env", envFile, app + "/..."},
+			code:   1,
+			stdout: "unused: UNUSED\nundefined: " + filepath.Join(app, "main.go") + ":14:17: MISSING\n",
+		},
+		{
+			name: "json",
+			args: []string{"audit", "-json", "-env", envFile, app + "/..."},
+			code: 1,
+			stdout: `{"problems":[` +
+				`{"severity":"warning","code":"unused","key":"UNUSED","message":"UNUSED is defined but never read"},` +
+				`{"severity":"error","code":"undefined","key":"MISSING","file":"` + filepath.ToSlash(filepath.Join(app, "main.go")) + `","line":14,"message":"MISSING is read but never defined"}]}` + "\n",
+		},
+		{
+			name:   "json ok",
+			args:   []string{"audit", "-json", "-env", envFile, "-ignore", "UNUSED,MISSING", app + "/..."},
+			stdout: `{"problems":[]}` + "\n",
+		},
+		{
+			name: "ignored",
+			args: []string{"audit", "-env", envFile, "-ignore", "UNUSED, MISSING", app + "/..."},
+		},
+		{
+			name: "missing env file",
+			args: []string{"audit", "-env", filepath.Join(app, "nope.env"), app},
+			code: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+			code := run(tc.args, stdout, stderr)
+			r.Equal(tc.code, code, stderr.String())
+			r.Equal(tc.stdout, stdout.String())
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+func init() {
+	commands["completion"] = command{
+		summary: "print a bash, zsh, or fish completion script",
+		run:     runCompletion,
+	}
+
+	commands["__complete"] = command{
+		run:    runComplete,
+		hidden: true,
+	}
+}
+
+// completionScripts are the completion scripts for each shell. They call
+// "envy __complete" with the words typed so far, after "envy", and offer
+// the lines it prints.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for envy; add to ~/.bashrc:
+#   source <(envy completion bash)
+_envy() {
+	local IFS=$'\n'
+	COMPREPLY=($(envy __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _envy envy
+`,
+	"zsh": `# zsh completion for envy; add to ~/.zshrc after compinit:
+#   source <(envy completion zsh)
+_envy() {
+	local -a candidates
+	candidates=("${(@f)$(envy __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	compadd -a candidates
+}
+compdef _envy envy
+`,
+	"fish": `# fish completion for envy; add to ~/.config/fish/config.fish:
+#   envy completion fish | source
+function __envy_complete
+	set -l tokens (commandline -opc) (commandline -ct)
+	envy __complete $tokens[2..-1] 2>/dev/null
+end
+complete -c envy -f -a '(__envy_complete)'
+`,
+}
+
+func runCompletion(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("completion", stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: envy completion bash|zsh|fish")
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("missing shell")
+	}
+
+	script, ok := completionScripts[flags.Arg(0)]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q", flags.Arg(0))
+	}
+
+	_, err := io.WriteString(stdout, script)
+	return err
+}
+
+// runComplete prints the completions for the last of args, the words
+// typed after "envy", one per line: command names for the first word,
+// and variable names from the process environment and the env files
+// given with -e (or .env) for the arguments of commands taking keys.
+func runComplete(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	cur := args[len(args)-1]
+
+	var candidates []string
+	switch {
+	case len(args) == 1:
+		candidates = append(candidates, "help")
+		for n, c := range commands {
+			if !c.hidden {
+				candidates = append(candidates, n)
+			}
+		}
+	case strings.HasPrefix(cur, "-"):
+		// flags are left to the shell
+	case commands[args[0]].keys:
+		env, err := layered(loaders["envy"], envFiles(args[1:len(args)-1]))
+		if err != nil {
+			return err
+		}
+
+		for _, kv := range env.Environ() {
+			k, _, _ := strings.Cut(kv, "=")
+			candidates = append(candidates, k)
+		}
+	}
+
+	sort.Strings(candidates)
+	for _, c := range candidates {
+		if strings.HasPrefix(c, cur) {
+			fmt.Fprintln(stdout, c)
+		}
+	}
+	return nil
+}
+
+// envFiles returns the values of the -e flags in args.
+func envFiles(args []string) []string {
+	var files []string
+	for i := 0; i < len(args); i++ {
+		a := strings.TrimPrefix(args[i], "-")
+		if a == args[i] {
+			continue
+		}
+		a = strings.TrimPrefix(a, "-")
+
+		switch {
+		case a == "e" && i+1 < len(args):
+			files = append(files, args[i+1])
+			i++
+		case strings.HasPrefix(a, "e="):
+			files = append(files, a[2:])
+		}
+	}
+	return files
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	commands["__keytest"] = command{
+		run:    func([]string, io.Writer, io.Writer) error { return nil },
+		keys:   true,
+		hidden: true,
+	}
+}
+
+func Test_runCompletion(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+	}{
+		{name: "bash", args: []string{"completion", "bash"}, stdout: "complete -o default -F _envy envy"},
+		{name: "zsh", args: []string{"completion", "zsh"}, stdout: "compdef _envy envy"},
+		{name: "fish", args: []string{"completion", "fish"}, stdout: "complete -c envy"},
+		{name: "unknown shell", args: []string{"completion", "tcsh"}, code: 1},
+		{name: "missing shell", args: []string{"completion"}, code: 1},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			r.Equal(tc.code, run(tc.args, stdout, stderr), stderr.String())
+			r.Contains(stdout.String(), tc.stdout)
+			if tc.stdout != "" {
+				r.Contains(stdout.String(), "envy __complete")
+			}
+		})
+	}
+}
+
+func Test_runComplete(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "test.env")
+	require.NoError(t, os.WriteFile(file, []byte("ZZ_COMPLETE_ONE=1\nZZ_COMPLETE_TWO=2\n"), 0o600))
+
+	tcs := []struct {
+		name string
+		args []string
+		exp  string
+	}{
+		{name: "commands", args: []string{"__complete", "com"}, exp: "completion\n"},
+		{name: "no words", args: []string{"__complete"}, exp: "audit\ncompletion\ngen\nget\nhelp\ninit\ninspect\nrun\nset\nunset\nwatch\n"},
+		{name: "keys from file", args: []string{"__complete", "__keytest", "-e", file, "ZZ_"}, exp: "ZZ_COMPLETE_ONE\nZZ_COMPLETE_TWO\n"},
+		{name: "keys from file with =", args: []string{"__complete", "__keytest", "--e=" + file, "ZZ_COMPLETE_T"}, exp: "ZZ_COMPLETE_TWO\n"},
+		{name: "flag", args: []string{"__complete", "__keytest", "-"}, exp: ""},
+		{name: "no keys", args: []string{"__complete", "run", "ZZ_"}, exp: ""},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			r.Equal(0, run(tc.args, stdout, stderr), stderr.String())
+			r.Equal(tc.exp, stdout.String())
+		})
+	}
+
+	stdout := &bytes.Buffer{}
+	r := require.New(t)
+	r.Equal(0, run([]string{"help"}, stdout, stdout))
+	r.NotContains(stdout.String(), "__complete")
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/markbates/envy"
+)
+
+func init() {
+	commands["set"] = command{
+		summary: "set variables in an env file, keeping its comments",
+		run:     runSet,
+		keys:    true,
+	}
+
+	commands["unset"] = command{
+		summary: "remove variables from an env file, keeping its comments",
+		run:     runUnset,
+		keys:    true,
+	}
+}
+
+func runSet(args []string, stdout, stderr io.Writer) error {
+	return editFile("set", "KEY=VALUE...", args, stdout, stderr, func(doc *envy.Document, arg string) error {
+		k, v, ok := strings.Cut(arg, "=")
+		if !ok {
+			return fmt.Errorf("%q is not KEY=VALUE", arg)
+		}
+		return doc.Set(k, v)
+	})
+}
+
+func runUnset(args []string, stdout, stderr io.Writer) error {
+	return editFile("unset", "KEY...", args, stdout, stderr, func(doc *envy.Document, key string) error {
+		doc.Unset(key)
+		return nil
+	})
+}
+
+// editFile applies edit to the env file named by the -file flag for each
+// positional argument, and writes it back atomically if they all succeed.
+// A missing file is created. With -json, the changes are printed to stdout
+// as a redacted Diff.
+func editFile(name, usage string, args []string, stdout, stderr io.Writer, edit func(*envy.Document, string) error) (err error) {
+	var path string
+	var lock time.Duration
+	var asJSON bool
+
+	flags := newFlagSet(name, stderr)
+	flags.StringVar(&path, "file", ".env", "env file to edit")
+	flags.DurationVar(&lock, "lock", 0, "hold the file's lock (path.lock), waiting up to this long for it")
+	flags.BoolVar(&asJSON, "json", false, "print the changes as a JSON diff")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: envy %s [-file .env] [-lock duration] [-json] %s\n", name, usage)
+		flags.PrintDefaults()
+	}
+
+	pos, err := parseInterspersed(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(pos) == 0 {
+		flags.Usage()
+		return fmt.Errorf("missing arguments")
+	}
+
+	if lock > 0 {
+		unlock, err := envy.LockFile(path, lock)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			if uerr := unlock(); err == nil {
+				err = uerr
+			}
+		}()
+	}
+
+	doc, err := envy.ReadDocument(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		doc, err = envy.ParseDocument(strings.NewReader(""))
+	}
+	if err != nil {
+		return err
+	}
+
+	before := doc.Env()
+	for _, arg := range pos {
+		if err := edit(doc, arg); err != nil {
+			return err
+		}
+	}
+
+	if err := doc.ToFile(path); err != nil {
+		return err
+	}
+
+	if asJSON {
+		after := doc.Env()
+		secret := func(k string) bool { return before.IsSecret(k) || after.IsSecret(k) }
+		return envy.Compare(before, after).WriteJSON(stdout, secret)
+	}
+	return nil
+}
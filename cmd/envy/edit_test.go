@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runSet_runUnset(t *testing.T) {
+	t.Parallel()
+
+	const original = "# the database\nDB_HOST=localhost\n\n# the port\nDB_PORT=5432\n"
+
+	tcs := []struct {
+		name   string
+		args   []string
+		code   int
+		exp    string
+		stdout string
+		stderr string
+	}{
+		{
+			name: "set existing and new",
+			args: []string{"set", "DB_HOST=example.com", "API_KEY=k=v", "-file", "FILE"},
+			exp:  "# the database\nDB_HOST=example.com\n\n# the port\nDB_PORT=5432\nAPI_KEY=k=v\n",
+		},
+		{
+			name: "unset",
+			args: []string{"unset", "-file", "FILE", "DB_PORT", "MISSING"},
+			exp:  "# the database\nDB_HOST=localhost\n\n# the port\n",
+		},
+		{
+			name:   "set json",
+			args:   []string{"set", "-json", "-file", "FILE", "DB_HOST=example.com", "DB_PASSWORD=hunter2", "DB_PORT=5432"},
+			exp:    "# the database\nDB_HOST=example.com\n\n# the port\nDB_PORT=5432\nDB_PASSWORD=hunter2\n",
+			stdout: `[{"key":"DB_HOST","kind":"modified","old":"localhost","new":"example.com"},{"key":"DB_PASSWORD","kind":"added","new":"[REDACTED]"}]` + "\n",
+		},
+		{
+			name:   "unset json",
+			args:   []string{"unset", "-json", "-file", "FILE", "DB_PORT", "MISSING"},
+			exp:    "# the database\nDB_HOST=localhost\n\n# the port\n",
+			stdout: `[{"key":"DB_PORT","kind":"removed","old":"5432"}]` + "\n",
+		},
+		{
+			name: "set with lock",
+			args: []string{"set", "-lock", "1s", "-file", "FILE", "DB_PORT=1"},
+			exp:  "# the database\nDB_HOST=localhost\n\n# the port\nDB_PORT=1\n",
+		},
+		{
+			name:   "not key=value",
+			args:   []string{"set", "-file", "FILE", "DB_PORT"},
+			code:   1,
+			exp:    original,
+			stderr: `"DB_PORT" is not KEY=VALUE`,
+		},
+		{
+			name:   "invalid key leaves file alone",
+			args:   []string{"set", "-file", "FILE", "A=1", "B C=2"},
+			code:   1,
+			exp:    original,
+			stderr: "invalid key",
+		},
+		{
+			name:   "no arguments",
+			args:   []string{"unset", "-file", "FILE"},
+			code:   1,
+			exp:    original,
+			stderr: "missing arguments",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			path := filepath.Join(t.TempDir(), ".env")
+			r.NoError(os.WriteFile(path, []byte(original), 0o600))
+
+			args := make([]string, len(tc.args))
+			for i, a := range tc.args {
+				if a == "FILE" {
+					a = path
+				}
+				args[i] = a
+			}
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			r.Equal(tc.code, run(args, stdout, stderr), stderr.String())
+			r.Equal(tc.stdout, stdout.String())
+			r.Contains(stderr.String(), tc.stderr)
+
+			b, err := os.ReadFile(path)
+			r.NoError(err)
+			r.Equal(tc.exp, string(b))
+		})
+	}
+}
+
+func Test_runSet_newFile(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "new.env")
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	r.Equal(0, run([]string{"set", "-file", path, "A=1"}, stdout, stderr), stderr.String())
+
+	b, err := os.ReadFile(path)
+	r.NoError(err)
+	r.Equal("A=1\n", string(b))
+}
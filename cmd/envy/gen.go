@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/markbates/envy"
+	"github.com/markbates/envy/gen"
+)
+
+func init() {
+	commands["gen"] = command{
+		summary: "generate a typed Go config struct from an example env file",
+		run:     runGen,
+	}
+}
+
+func runGen(args []string, stdout, stderr io.Writer) error {
+	var in, out string
+	var consts, asJSON bool
+	var opts gen.Options
+
+	flags := newFlagSet("gen", stderr)
+	flags.StringVar(&in, "in", ".env.example", "example env file to read")
+	flags.StringVar(&out, "o", "", "output file (default stdout)")
+	flags.StringVar(&opts.Package, "pkg", "config", "package name of the generated file")
+	flags.StringVar(&opts.Type, "type", "Config", "name of the generated struct")
+	flags.BoolVar(&consts, "const", false, "generate only key name constants")
+	flags.BoolVar(&asJSON, "json", false, "print the inferred fields as JSON instead of generating code")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: envy gen [-in file] [-o file] [-pkg name] [-type name] [-const] [-json]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	env, err := envy.FromFile(os.DirFS(filepath.Dir(in)), filepath.Base(in))
+	if err != nil {
+		return err
+	}
+	opts.Source = filepath.Base(in)
+
+	if asJSON {
+		return writeJSON(stdout, gen.Fields(env))
+	}
+
+	generate := gen.Generate
+	if consts {
+		generate = gen.Constants
+	}
+
+	bb := &bytes.Buffer{}
+	if err := generate(bb, gen.Fields(env), opts); err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = stdout.Write(bb.Bytes())
+		return err
+	}
+	return os.WriteFile(out, bb.Bytes(), 0o644)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runGen(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	example := filepath.Join(dir, ".env.example")
+	require.NoError(t, os.WriteFile(example, []byte("PORT=8080\nDEBUG=true\n"), 0o644))
+
+	tcs := []struct {
+		name     string
+		args     []string
+		code     int
+		contains string
+	}{
+		{
+			name:     "stdout",
+			args:     []string{"gen", "-in", example, "-pkg", "cfg"},
+			contains: "package cfg",
+		},
+		{
+			name:     "constants only",
+			args:     []string{"gen", "-in", example, "-const"},
+			contains: `EnvPort  = "PORT"`,
+		},
+		{
+			name:     "json",
+			args:     []string{"gen", "-in", example, "-json"},
+			contains: `[{"key":"DEBUG","name":"Debug","kind":"bool"},{"key":"PORT","name":"Port","kind":"int"}]`,
+		},
+		{
+			name: "missing input",
+			args: []string{"gen", "-in", filepath.Join(dir, "nope")},
+			code: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+			code := run(tc.args, stdout, stderr)
+			r.Equal(tc.code, code, stderr.String())
+			r.Contains(stdout.String(), tc.contains)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+func init() {
+	commands["get"] = command{
+		summary: "print the value of a variable",
+		run:     runGet,
+		keys:    true,
+	}
+}
+
+func runGet(args []string, stdout, stderr io.Writer) error {
+	var files stringsFlag
+	var asJSON bool
+
+	flags := newFlagSet("get", stderr)
+	flags.Var(&files, "e", "env files, comma-separated or repeated (default .env)")
+	flags.BoolVar(&asJSON, "json", false, `print {"key", "value", "set"} as JSON`)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: envy get [-e files] [-json] KEY")
+		flags.PrintDefaults()
+	}
+
+	keys, err := parseInterspersed(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected one key")
+	}
+
+	env, err := layered(loaders["envy"], files)
+	if err != nil {
+		return err
+	}
+
+	val, ok := env.Lookupenv(keys[0])
+	if asJSON {
+		err := writeJSON(stdout, struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+			Set   bool   `json:"set"`
+		}{keys[0], val, ok})
+		if err == nil && !ok {
+			err = errFailed
+		}
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("%s is not set", keys[0])
+	}
+
+	fmt.Fprintln(stdout, val)
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runGet(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "test.env")
+	require.NoError(t, os.WriteFile(file, []byte("GREETING=hello world\nEMPTY=\n"), 0o600))
+
+	tcs := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{name: "set", args: []string{"get", "-e", file, "GREETING"}, stdout: "hello world\n"},
+		{name: "flag after key", args: []string{"get", "GREETING", "-e", file}, stdout: "hello world\n"},
+		{name: "empty", args: []string{"get", "-e", file, "EMPTY"}, stdout: "\n"},
+		{name: "unset", args: []string{"get", "-e", file, "ENVY_GET_MISSING"}, code: 1, stderr: "ENVY_GET_MISSING is not set"},
+		{name: "json", args: []string{"get", "-json", "-e", file, "GREETING"}, stdout: `{"key":"GREETING","value":"hello world","set":true}` + "\n"},
+		{name: "json unset", args: []string{"get", "-json", "-e", file, "ENVY_GET_MISSING"}, code: 1, stdout: `{"key":"ENVY_GET_MISSING","value":"","set":false}` + "\n"},
+		{name: "no key", args: []string{"get"}, code: 1, stderr: "expected one key"},
+		{name: "missing file", args: []string{"get", "-e", file + ".nope", "A"}, code: 1},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			r.Equal(tc.code, run(tc.args, stdout, stderr), stderr.String())
+			r.Equal(tc.stdout, stdout.String())
+			r.Contains(stderr.String(), tc.stderr)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/markbates/envy"
+)
+
+func init() {
+	commands["init"] = command{
+		summary: "create an env file from a template, prompting for its placeholders",
+		run:     runInit,
+	}
+}
+
+func runInit(args []string, stdout, stderr io.Writer) error {
+	var tmpl, out, answers string
+	var force, asJSON bool
+
+	flags := newFlagSet("init", stderr)
+	flags.StringVar(&tmpl, "t", ".env.template", `template with {{prompt "NAME" "default"}} placeholders`)
+	flags.StringVar(&out, "o", ".env", "env file to create")
+	flags.StringVar(&answers, "answers", "", "env file answering the prompts, instead of asking on the terminal")
+	flags.BoolVar(&force, "force", false, "overwrite an existing output file")
+	flags.BoolVar(&asJSON, "json", false, "print the variables created as a JSON diff")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: envy init [-t template] [-o file] [-answers file] [-force] [-json]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if !force {
+		if _, err := os.Stat(out); err == nil {
+			return fmt.Errorf("%s already exists; use -force to overwrite it", out)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	// prompts go to stderr so they do not mix with piped output
+	prompt := envy.Interactive(os.Stdin, stderr)
+	if answers != "" {
+		env, err := envy.FromFile(os.DirFS(filepath.Dir(answers)), filepath.Base(answers))
+		if err != nil {
+			return err
+		}
+
+		// unanswered prompts take their default, as with envy.Answers
+		fallback := envy.Answers(nil)
+		prompt = func(name, def string) (string, error) {
+			if v, ok := env.Lookupenv(name); ok {
+				return v, nil
+			}
+			return fallback(name, def)
+		}
+	}
+
+	f, err := os.Open(tmpl)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	bb := &bytes.Buffer{}
+	if err := envy.RenderTemplate(bb, f, prompt); err != nil {
+		return err
+	}
+
+	doc, err := envy.ParseDocument(bb)
+	if err != nil {
+		return err
+	}
+	if err := doc.ToFile(out); err != nil {
+		return err
+	}
+
+	if asJSON {
+		env := doc.Env()
+		return envy.Compare(envy.Zero(), env).WriteJSON(stdout, env.IsSecret)
+	}
+	return nil
+}
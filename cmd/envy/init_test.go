@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runInit(t *testing.T) {
+	t.Parallel()
+
+	const tmpl = "# database\nDB_HOST={{prompt \"DB_HOST\" \"localhost\"}}\nDB_PASSWORD={{prompt \"DB_PASSWORD\"}}\n"
+
+	tcs := []struct {
+		name     string
+		args     []string
+		existing string
+		code     int
+		exp      string
+		stdout   string
+		stderr   string
+	}{
+		{
+			name: "answers",
+			args: []string{"init", "-t", "TMPL", "-o", "OUT", "-answers", "ANSWERS"},
+			exp:  "# database\nDB_HOST=localhost\nDB_PASSWORD=s3cr3t\n",
+		},
+		{
+			name:     "existing output",
+			args:     []string{"init", "-t", "TMPL", "-o", "OUT", "-answers", "ANSWERS"},
+			existing: "KEEP=1\n",
+			code:     1,
+			exp:      "KEEP=1\n",
+			stderr:   "already exists",
+		},
+		{
+			name:     "force",
+			args:     []string{"init", "-force", "-t", "TMPL", "-o", "OUT", "-answers", "ANSWERS"},
+			existing: "KEEP=1\n",
+			exp:      "# database\nDB_HOST=localhost\nDB_PASSWORD=s3cr3t\n",
+		},
+		{
+			name:   "json",
+			args:   []string{"init", "-json", "-t", "TMPL", "-o", "OUT", "-answers", "ANSWERS"},
+			exp:    "# database\nDB_HOST=localhost\nDB_PASSWORD=s3cr3t\n",
+			stdout: `[{"key":"DB_HOST","kind":"added","new":"localhost"},{"key":"DB_PASSWORD","kind":"added","new":"[REDACTED]"}]` + "\n",
+		},
+		{
+			name:   "missing template",
+			args:   []string{"init", "-t", "NOPE", "-o", "OUT", "-answers", "ANSWERS"},
+			code:   1,
+			stderr: "no such file",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			dir := t.TempDir()
+			paths := map[string]string{
+				"TMPL":    filepath.Join(dir, ".env.template"),
+				"OUT":     filepath.Join(dir, ".env"),
+				"ANSWERS": filepath.Join(dir, "answers.env"),
+				"NOPE":    filepath.Join(dir, "nope"),
+			}
+			r.NoError(os.WriteFile(paths["TMPL"], []byte(tmpl), 0o600))
+			r.NoError(os.WriteFile(paths["ANSWERS"], []byte("DB_PASSWORD=s3cr3t\n"), 0o600))
+			if tc.existing != "" {
+				r.NoError(os.WriteFile(paths["OUT"], []byte(tc.existing), 0o600))
+			}
+
+			args := make([]string, len(tc.args))
+			for i, a := range tc.args {
+				if p, ok := paths[a]; ok {
+					a = p
+				}
+				args[i] = a
+			}
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			r.Equal(tc.code, run(args, stdout, stderr), stderr.String())
+			r.Contains(stderr.String(), tc.stderr)
+			r.Equal(tc.stdout, stdout.String())
+
+			if tc.exp == "" {
+				return
+			}
+
+			b, err := os.ReadFile(paths["OUT"])
+			r.NoError(err)
+			r.Equal(tc.exp, string(b))
+		})
+	}
+}
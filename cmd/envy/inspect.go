@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/markbates/envy/inspect"
+)
+
+func init() {
+	commands["inspect"] = command{
+		summary: "print the redacted environment of a running process",
+		run:     runInspect,
+	}
+}
+
+func runInspect(args []string, stdout, stderr io.Writer) error {
+	var pid int
+	var socket string
+	var history, asJSON bool
+
+	flags := newFlagSet("inspect", stderr)
+	flags.IntVar(&pid, "pid", 0, "process to inspect; it must serve its environment with inspect.Serve")
+	flags.StringVar(&socket, "socket", "", "socket to connect to, in place of the one for -pid")
+	flags.BoolVar(&history, "history", false, "also print the changes the process has kept")
+	flags.BoolVar(&asJSON, "json", false, "print the snapshot as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: envy inspect (-pid N | -socket path) [-history] [-json]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if socket == "" {
+		if pid <= 0 {
+			flags.Usage()
+			return fmt.Errorf("expected -pid or -socket")
+		}
+		socket = inspect.SocketPath(pid)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	snap, err := inspect.Fetch(ctx, socket)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		return writeJSON(stdout, snap)
+	}
+
+	fmt.Fprintf(stdout, "# pid %d, revision %d, fingerprint %s, at %s\n", snap.PID, snap.Revision, snap.Fingerprint, snap.Time)
+
+	keys := make([]string, 0, len(snap.Env))
+	for k := range snap.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if src := snap.Sources[k]; src != "" {
+			fmt.Fprintf(stdout, "%s=%s # %s\n", k, snap.Env[k], src)
+			continue
+		}
+		fmt.Fprintf(stdout, "%s=%s\n", k, snap.Env[k])
+	}
+
+	if !history {
+		return nil
+	}
+
+	for _, h := range snap.History {
+		fmt.Fprintf(stdout, "\n# revision %d\n", h.Rev)
+		if err := h.Diff.WriteUnified(stdout, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
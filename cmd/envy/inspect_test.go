@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/markbates/envy"
+	"github.com/markbates/envy/inspect"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runInspect(t *testing.T) {
+	t.Parallel()
+
+	env := envy.FromMap(map[string]string{"PORT": "8080", "DB_PASSWORD": "hunter2"})
+	require.NoError(t, env.KeepHistory(5))
+	require.NoError(t, env.Setenv("PORT", "9090"))
+
+	socket := filepath.Join(t.TempDir(), "envy", "app.sock")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go inspect.Serve(ctx, env, socket)
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(socket)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	tcs := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout []string
+		stderr string
+	}{
+		{name: "text", args: []string{"inspect", "-socket", socket}, stdout: []string{
+			"# pid ", "revision 1, fingerprint " + env.Fingerprint(),
+			"DB_PASSWORD=" + envy.Redacted + "\nPORT=9090\n",
+		}},
+		{name: "history", args: []string{"inspect", "-socket", socket, "-history"}, stdout: []string{
+			"\n# revision 1\n", "-PORT=8080\n+PORT=9090\n",
+		}},
+		{name: "json", args: []string{"inspect", "-json", "-socket", socket}, stdout: []string{`"env":{"DB_PASSWORD":"[REDACTED]","PORT":"9090"}`}},
+		{name: "no target", args: []string{"inspect"}, code: 1, stderr: "expected -pid or -socket"},
+		{name: "no server", args: []string{"inspect", "-socket", socket + ".nope"}, code: 1, stderr: "no such file"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			r.Equal(tc.code, run(tc.args, stdout, stderr), stderr.String())
+			for _, s := range tc.stdout {
+				r.Contains(stdout.String(), s)
+			}
+			r.Contains(stderr.String(), tc.stderr)
+		})
+	}
+}
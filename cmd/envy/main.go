@@ -0,0 +1,146 @@
+// Command envy is a command line interface to the envy library.
+//
+// Usage:
+//
+//	envy <command> [arguments]
+//
+// Run "envy help" for the list of commands.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+)
+
+// command is a single envy subcommand.
+type command struct {
+	// summary is a one-line description shown by "envy help".
+	summary string
+	// run executes the command with the arguments following its name.
+	run func(args []string, stdout, stderr io.Writer) error
+	// keys makes shell completion offer variable names for the
+	// command's arguments.
+	keys bool
+	// hidden leaves the command out of "envy help".
+	hidden bool
+}
+
+// commands maps subcommand names to their implementations. Each
+// subcommand registers itself from an init func in its own file.
+var commands = map[string]command{}
+
+// errFailed marks a run that already reported its problems and should
+// exit non-zero without printing anything further.
+var errFailed = errors.New("failed")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command line args and returns the process exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stdout)
+		return 0
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "envy: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+
+	err := cmd.run(args[1:], stdout, stderr)
+
+	var exit *exec.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errFailed):
+		return 1
+	case errors.As(err, &exit) && exit.ExitCode() > 0:
+		// a command started by the subcommand failed; pass its code on
+		return exit.ExitCode()
+	}
+
+	fmt.Fprintf(stderr, "envy %s: %s\n", args[0], err)
+	return 1
+}
+
+// usage prints the list of commands.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: envy <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	names := make([]string, 0, len(commands))
+	for n, c := range commands {
+		if !c.hidden {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", n, commands[n].summary)
+	}
+}
+
+// newFlagSet returns a flag set for a subcommand that reports errors
+// instead of exiting.
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("envy "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// parseInterspersed parses args with flags, allowing flags after
+// positional arguments, as in "envy set KEY=VALUE -file .env", and returns
+// the positional arguments. Arguments after "--" are never flags.
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	var pos []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+
+		rest := flags.Args()
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			return append(pos, rest...), nil
+		}
+
+		if len(rest) == 0 {
+			return pos, nil
+		}
+
+		pos = append(pos, rest[0])
+		args = rest[1:]
+	}
+}
+
+// writeJSON writes v to w as a single line of JSON, the output of the
+// -json flag of every command that supports it.
+func writeJSON(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// stringsFlag is a repeatable string flag.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return fmt.Sprint(*s)
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
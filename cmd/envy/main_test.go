@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_run(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{
+			name:   "no args",
+			args:   nil,
+			stdout: "Usage: envy",
+		},
+		{
+			name:   "help",
+			args:   []string{"help"},
+			stdout: "audit",
+		},
+		{
+			name:   "unknown command",
+			args:   []string{"nope"},
+			code:   2,
+			stderr: `unknown command "nope"`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+			code := run(tc.args, stdout, stderr)
+			r.Equal(tc.code, code)
+			r.Contains(stdout.String(), tc.stdout)
+			r.Contains(stderr.String(), tc.stderr)
+		})
+	}
+}
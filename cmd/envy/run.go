@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/markbates/envy"
+)
+
+func init() {
+	commands["run"] = command{
+		summary: "run a command with env files layered over the environment",
+		run:     runRun,
+	}
+}
+
+// loaders read an env file in each supported dialect.
+var loaders = map[string]func(path string) (*envy.Env, error){
+	"envy": func(path string) (*envy.Env, error) {
+		return envy.FromFile(os.DirFS(filepath.Dir(path)), filepath.Base(path))
+	},
+	"foreman": func(path string) (*envy.Env, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		return envy.FromForeman(f)
+	},
+}
+
+func runRun(args []string, stdout, stderr io.Writer) error {
+	var files stringsFlag
+	var mode string
+
+	flags := newFlagSet("run", stderr)
+	flags.Var(&files, "e", "env files, comma-separated or repeated (default .env)")
+	flags.StringVar(&mode, "mode", "envy", "env file dialect: envy or foreman")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: envy run [-e files] [-mode envy|foreman] command [args]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	load, ok := loaders[mode]
+	if !ok {
+		return fmt.Errorf("unknown mode %q", mode)
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("missing command")
+	}
+
+	env, err := layered(load, files)
+	if err != nil {
+		return err
+	}
+
+	vars, err := env.Export()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(flags.Arg(0), flags.Args()[1:]...)
+	cmd.Env = vars
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+// layered returns the process environment with the env files, which may
+// be comma-separated, loaded over it. With no files, .env is loaded if it
+// exists.
+func layered(load func(path string) (*envy.Env, error), files []string) (*envy.Env, error) {
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, strings.Split(f, ",")...)
+	}
+
+	if len(paths) == 0 {
+		paths = []string{".env"}
+	}
+
+	// like foreman, the files override the process environment and later
+	// files override earlier ones
+	env := envy.New()
+	for _, p := range paths {
+		loaded, err := load(p)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && len(files) == 0 {
+				// the default file is optional
+				continue
+			}
+			return nil, err
+		}
+
+		if env, err = env.Merge(loaded); err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
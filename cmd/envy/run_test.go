@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	t.Setenv("ENVY_RUN_PROCESS", "process")
+	t.Setenv("ENVY_RUN_OVERRIDE", "process")
+
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "plain.env")
+	quoted := filepath.Join(dir, "quoted.env")
+	require.NoError(t, os.WriteFile(plain, []byte("ENVY_RUN_OVERRIDE=file\nENVY_RUN_QUOTED=\"a\\\"b\"\n"), 0o644))
+	require.NoError(t, os.WriteFile(quoted, []byte("ENVY_RUN_LATER=later\n"), 0o644))
+
+	const script = `echo "$ENVY_RUN_PROCESS $ENVY_RUN_OVERRIDE $ENVY_RUN_QUOTED $ENVY_RUN_LATER"`
+
+	tcs := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+	}{
+		{
+			name:   "envy mode",
+			args:   []string{"run", "-e", plain, "sh", "-c", script},
+			stdout: "process file a\"b \n",
+		},
+		{
+			name:   "foreman mode with comma-separated files",
+			args:   []string{"run", "-mode", "foreman", "-e", plain + "," + quoted, "sh", "-c", script},
+			stdout: "process file a\"b later\n",
+		},
+		{
+			name: "exit code is passed on",
+			args: []string{"run", "-e", plain, "sh", "-c", "exit 3"},
+			code: 3,
+		},
+		{
+			name: "missing command",
+			args: []string{"run", "-e", plain},
+			code: 1,
+		},
+		{
+			name: "unknown mode",
+			args: []string{"run", "-mode", "nope", "true"},
+			code: 1,
+		},
+		{
+			name: "missing file",
+			args: []string{"run", "-e", filepath.Join(dir, "nope.env"), "true"},
+			code: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+			code := run(tc.args, stdout, stderr)
+			r.Equal(tc.code, code, stderr.String())
+			r.Equal(tc.stdout, stdout.String())
+		})
+	}
+}
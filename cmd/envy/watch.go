@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/markbates/envy"
+)
+
+func init() {
+	commands["watch"] = command{
+		summary: "print a redacted diff whenever env sources change",
+		run:     runWatch,
+	}
+}
+
+// sources open the env sources named by URL in each supported scheme.
+var sources = map[string]func(u *url.URL) (*envy.Env, error){
+	"file": func(u *url.URL) (*envy.Env, error) {
+		// file://.env has the path in the host
+		p := u.Host + u.Path
+		return envy.FromFile(os.DirFS(filepath.Dir(p)), filepath.Base(p))
+	},
+	"env": func(*url.URL) (*envy.Env, error) {
+		return envy.New(), nil
+	},
+}
+
+func runWatch(args []string, stdout, stderr io.Writer) error {
+	var from stringsFlag
+	var interval time.Duration
+	var max int
+	var asJSON bool
+
+	flags := newFlagSet("watch", stderr)
+	flags.Var(&from, "from", "source URL, file://path or env://, later ones overriding earlier ones (repeatable; default file://.env)")
+	flags.DurationVar(&interval, "interval", 2*time.Second, "how often to check the sources")
+	flags.BoolVar(&asJSON, "json", false, `print each change as a line of JSON, {"time", "changes"}`)
+	flags.IntVar(&max, "max", 0, "exit after this many changes (default 0: run until interrupted)")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: envy watch [-from URL]... [-interval d] [-max n] [-json] [-- command [args]]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+
+	if len(from) == 0 {
+		from = stringsFlag{"file://.env"}
+	}
+
+	load := func() (*envy.Env, error) {
+		env := envy.Zero()
+		for _, f := range from {
+			loaded, err := openSource(f)
+			if err != nil {
+				return nil, err
+			}
+
+			if env, err = env.Merge(loaded); err != nil {
+				return nil, err
+			}
+		}
+		return env, nil
+	}
+
+	cur, err := load()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+
+	for changes := 0; max == 0 || changes < max; {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick.C:
+		}
+
+		next, err := load()
+		if err != nil {
+			// a file may briefly be missing while an editor saves it
+			fmt.Fprintf(stderr, "envy watch: %s\n", err)
+			continue
+		}
+
+		d := envy.Compare(cur, next)
+		if len(d) == 0 {
+			continue
+		}
+
+		secret := func(k string) bool { return cur.IsSecret(k) || next.IsSecret(k) }
+		if err := printChange(stdout, d, secret, asJSON); err != nil {
+			return err
+		}
+
+		if flags.NArg() > 0 {
+			if err := runChanged(ctx, flags.Args(), next, stdout, stderr); err != nil {
+				fmt.Fprintf(stderr, "envy watch: %s\n", err)
+			}
+		}
+
+		cur = next
+		changes++
+	}
+	return nil
+}
+
+// runChanged runs the command args with the variables env exports, after
+// a change.
+func runChanged(ctx context.Context, args []string, env *envy.Env, stdout, stderr io.Writer) error {
+	vars, err := env.Export()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = vars
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+// printChange prints d, with the values of keys secret reports redacted,
+// as a timestamped unified diff or, with asJSON, a line of JSON.
+func printChange(w io.Writer, d envy.Diff, secret func(key string) bool, asJSON bool) error {
+	now := time.Now()
+	if asJSON {
+		return writeJSON(w, struct {
+			Time    time.Time `json:"time"`
+			Changes envy.Diff `json:"changes"`
+		}{now, d.Redact(secret)})
+	}
+
+	fmt.Fprintf(w, "# %s\n", now.Format(time.RFC3339))
+	return d.WriteUnified(w, secret)
+}
+
+// openSource loads the source named by s, a URL with a scheme from
+// sources or a plain file path.
+func openSource(s string) (*envy.Env, error) {
+	if !strings.Contains(s, "://") {
+		s = "file://" + s
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	open, ok := sources[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported source %q: supported schemes are env and file", s)
+	}
+	return open(u)
+}
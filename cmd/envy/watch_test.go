@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markbates/envy"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runWatch(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	r := require.New(t)
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.env")
+	r.NoError(os.WriteFile(base, []byte("WATCH_TOKEN=s3cr3t\nWATCH_A=0\n"), 0o600))
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	done := make(chan int)
+	go func() {
+		done <- run([]string{
+			"watch", "-from", "file://" + base, "-interval", "10ms", "-max", "1",
+			"--", "sh", "-c", "echo got $WATCH_A",
+		}, stdout, stderr)
+	}()
+
+	var code int
+	for i := 1; ; i++ {
+		// replace the file atomically so the watcher never sees it empty
+		tmp := base + ".tmp"
+		r.NoError(os.WriteFile(tmp, []byte(fmt.Sprintf("WATCH_TOKEN=rotated-%d\nWATCH_A=%d\n", i, i)), 0o600))
+		r.NoError(os.Rename(tmp, base))
+
+		select {
+		case code = <-done:
+		case <-time.After(20 * time.Millisecond):
+			continue
+		}
+		break
+	}
+
+	r.Equal(0, code, stderr.String())
+
+	out := stdout.String()
+	r.Contains(out, "+WATCH_A=")
+	r.Contains(out, "-WATCH_TOKEN=[REDACTED]\n+WATCH_TOKEN=[REDACTED]\n")
+	r.NotContains(out, "s3cr3t")
+	r.NotContains(out, "rotated")
+	r.Contains(out, "got ")
+}
+
+func Test_printChange(t *testing.T) {
+	t.Parallel()
+
+	d := envy.Diff{
+		{Key: "WATCH_A", Kind: envy.Modified, Old: "0", New: "1"},
+		{Key: "WATCH_TOKEN", Kind: envy.Added, New: "s3cr3t"},
+	}
+
+	tcs := []struct {
+		name   string
+		secret func(string) bool
+		asJSON bool
+		exp    string
+	}{
+		{name: "unified", exp: "-WATCH_A=0\n+WATCH_A=1\n+WATCH_TOKEN=[REDACTED]\n"},
+		{name: "secret", secret: func(k string) bool { return k != "WATCH_TOKEN" }, exp: "-WATCH_A=[REDACTED]\n+WATCH_A=[REDACTED]\n+WATCH_TOKEN=s3cr3t\n"},
+		{name: "json", asJSON: true, exp: `"changes":[{"key":"WATCH_A","kind":"modified","old":"0","new":"1"},{"key":"WATCH_TOKEN","kind":"added","new":"[REDACTED]"}]}` + "\n"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			bb := &bytes.Buffer{}
+			r.NoError(printChange(bb, d, tc.secret, tc.asJSON))
+			r.True(strings.HasSuffix(bb.String(), tc.exp), bb.String())
+			if tc.secret == nil {
+				r.NotContains(bb.String(), "s3cr3t")
+			}
+
+			if tc.asJSON {
+				r.True(json.Valid(bb.Bytes()))
+				r.True(strings.HasPrefix(bb.String(), `{"time":"`))
+			}
+		})
+	}
+}
+
+func Test_runWatch_errors(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name   string
+		args   []string
+		stderr string
+	}{
+		{name: "unsupported scheme", args: []string{"watch", "-from", "ssm:///app"}, stderr: `unsupported source "ssm:///app"`},
+		{name: "missing file", args: []string{"watch", "-from", "file://does-not-exist.env"}, stderr: "does-not-exist.env"},
+		{name: "bad interval", args: []string{"watch", "-interval", "0s"}, stderr: "interval must be positive"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			r.Equal(1, run(tc.args, stdout, stderr))
+			r.Contains(stderr.String(), tc.stderr)
+		})
+	}
+}
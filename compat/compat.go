@@ -0,0 +1,154 @@
+// Package compat exposes the package-level API of gobuffalo/envy (v1) on top
+// of an envy.Env, so code written against the old package can migrate by
+// swapping its import path.
+//
+// Unlike v1, the functions in this package never modify the process
+// environment; all reads and writes go to envy.Default, which importing
+// the package, like Reload, rebuilds with envy.Load from os.Environ and a
+// ".env" file in the working directory, if present. Changes are made
+// through the Default's own methods, so they are never lost to a
+// concurrent one, and its subscribers see them.
+package compat
+
+import (
+	"errors"
+	"fmt"
+	"go/build"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/markbates/envy"
+)
+
+// GO111MODULE is the name of the variable that toggles Go module support.
+const GO111MODULE = "GO111MODULE"
+
+func init() {
+	// a missing .env file is not an error; anything else is reported
+	// the next time Load or Reload is called explicitly
+	_ = Reload()
+}
+
+// env returns the package-level default Env.
+func env() *envy.Env {
+	return envy.Default()
+}
+
+// Reload discards the current default Env, rebuilds it from os.Environ, and
+// loads ".env" on top of it, if present.
+func Reload() error {
+	return envy.Load(func() (*envy.Env, error) {
+		return readFile(".env", true)
+	})
+}
+
+// Load reads the named env files into the default Env, with later files
+// overriding earlier ones. With no arguments it loads ".env", silently
+// ignoring it if it does not exist; explicitly named files must exist.
+func Load(files ...string) error {
+	optional := len(files) == 0
+	if optional {
+		files = []string{".env"}
+	}
+
+	for _, f := range files {
+		loaded, err := readFile(f, optional)
+		if err != nil {
+			return err
+		}
+
+		if err := env().Apply(envy.Compare(envy.Zero(), loaded)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readFile reads the env file at path, or returns an empty Env if it does
+// not exist and is optional.
+func readFile(path string, optional bool) (*envy.Env, error) {
+	loaded, err := envy.FromFile(os.DirFS(filepath.Dir(path)), filepath.Base(path))
+	if optional && errors.Is(err, fs.ErrNotExist) {
+		return envy.Zero(), nil
+	}
+	return loaded, err
+}
+
+// Get returns the value of key, or value if key is not set.
+func Get(key string, value string) string {
+	if v, ok := env().Lookupenv(key); ok {
+		return v
+	}
+	return value
+}
+
+// MustGet returns the value of key, or an error if key is not set.
+func MustGet(key string) (string, error) {
+	v, ok := env().Lookupenv(key)
+	if !ok {
+		return "", fmt.Errorf("could not find ENV var with %s", key)
+	}
+	return v, nil
+}
+
+// Set sets key to value in the default Env.
+func Set(key string, value string) {
+	_ = env().Setenv(key, value)
+}
+
+// MustSet sets key to value in the default Env, returning any error.
+func MustSet(key string, value string) error {
+	return env().Setenv(key, value)
+}
+
+// Map returns a copy of the default Env as a map.
+func Map() map[string]string {
+	m := map[string]string{}
+	for _, kv := range env().Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		m[k] = v
+	}
+	return m
+}
+
+// Environ returns the default Env in "KEY=VALUE" form.
+func Environ() []string {
+	return env().Environ()
+}
+
+// Temp makes a copy of the default Env, runs f, and then restores the copy,
+// discarding any changes f made.
+func Temp(f func()) {
+	orig, err := envy.Zero().Merge(env())
+	if err != nil {
+		orig = envy.Zero()
+	}
+
+	defer func() {
+		// f may have replaced the Default with Reload
+		cur := env()
+		_ = cur.Apply(envy.Compare(cur, orig))
+	}()
+
+	f()
+}
+
+// GoPath returns GOPATH from the default Env, falling back to the Go
+// toolchain's default.
+func GoPath() string {
+	return Get("GOPATH", build.Default.GOPATH)
+}
+
+// GoBin returns GOBIN from the default Env, falling back to GOPATH/bin.
+func GoBin() string {
+	return Get("GOBIN", filepath.Join(GoPath(), "bin"))
+}
+
+// Mods reports whether Go modules are enabled. Modules have been the default
+// since Go 1.16, so only an explicit GO111MODULE=off disables them.
+func Mods() bool {
+	return Get(GO111MODULE, "on") != "off"
+}
@@ -0,0 +1,98 @@
+package compat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/markbates/envy"
+	"github.com/stretchr/testify/require"
+)
+
+// tests in this file share the package-level default Env and so do not run
+// in parallel.
+
+func Test_Get_Set(t *testing.T) {
+	r := require.New(t)
+	r.NoError(Reload())
+
+	r.Equal("default", Get("COMPAT_TEST_KEY", "default"))
+
+	_, err := MustGet("COMPAT_TEST_KEY")
+	r.Error(err)
+
+	Set("COMPAT_TEST_KEY", "value")
+	r.Equal("value", Get("COMPAT_TEST_KEY", "default"))
+	r.Equal("value", envy.Default().Getenv("COMPAT_TEST_KEY"))
+
+	v, err := MustGet("COMPAT_TEST_KEY")
+	r.NoError(err)
+	r.Equal("value", v)
+
+	r.NoError(MustSet("COMPAT_TEST_KEY", "other"))
+	r.Equal("other", Map()["COMPAT_TEST_KEY"])
+	r.Contains(Environ(), "COMPAT_TEST_KEY=other")
+
+	// the process environment is untouched
+	_, ok := os.LookupEnv("COMPAT_TEST_KEY")
+	r.False(ok)
+
+	r.NoError(Reload())
+	r.Equal("default", Get("COMPAT_TEST_KEY", "default"))
+}
+
+func Test_Temp(t *testing.T) {
+	r := require.New(t)
+	r.NoError(Reload())
+
+	Set("COMPAT_TEMP", "before")
+
+	Temp(func() {
+		Set("COMPAT_TEMP", "during")
+		Set("COMPAT_TEMP_NEW", "new")
+		r.Equal("during", Get("COMPAT_TEMP", ""))
+	})
+
+	r.Equal("before", Get("COMPAT_TEMP", ""))
+	r.Equal("", Get("COMPAT_TEMP_NEW", ""))
+}
+
+func Test_Load(t *testing.T) {
+	r := require.New(t)
+	r.NoError(Reload())
+
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.env")
+	b := filepath.Join(dir, "b.env")
+	r.NoError(os.WriteFile(a, []byte("COMPAT_A=1\nCOMPAT_B=1\n"), 0o600))
+	r.NoError(os.WriteFile(b, []byte("COMPAT_B=2\n"), 0o600))
+
+	Set("COMPAT_KEPT", "1")
+
+	r.NoError(Load(a, b))
+	r.Equal("1", Get("COMPAT_A", ""))
+	r.Equal("2", Get("COMPAT_B", ""))
+	r.Equal("1", Get("COMPAT_KEPT", ""))
+
+	r.Error(Load(filepath.Join(dir, "missing.env")))
+
+	r.NoError(Reload())
+	r.Equal("", Get("COMPAT_A", ""))
+}
+
+func Test_Go_Helpers(t *testing.T) {
+	r := require.New(t)
+	r.NoError(Reload())
+
+	Temp(func() {
+		Set("GOPATH", "/go")
+		r.Equal("/go", GoPath())
+		r.Equal(filepath.Join("/go", "bin"), GoBin())
+
+		Set(GO111MODULE, "off")
+		r.False(Mods())
+
+		Set(GO111MODULE, "on")
+		r.True(Mods())
+	})
+}
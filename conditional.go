@@ -0,0 +1,196 @@
+package envy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// conditional evaluates the #if, #else, and #endif directives in the lines
+// of an env file, returning lines of the same length in which directives
+// and the entries of sections whose condition is false are blanked, so that
+// line numbers are preserved. Blocks may nest. The supported conditions are:
+//
+//	#if KEY=value    KEY equals value
+//	#if KEY!=value   KEY does not equal value
+//	#if KEY          KEY is set and not empty
+//	#if !KEY         KEY is unset or empty
+//
+// A comment is only a directive if it has exactly one of these forms, with
+// no white space in KEY or value, so a comment such as "#if unset, the port
+// is 8080" is left alone.
+//
+// KEY is looked up among the entries kept above the directive, falling back
+// to the process environment, so a file can branch on an APP_ENV set either
+// earlier in the file or by the shell. If expand is not nil, it is called
+// with the lines kept above the directive and returns them with their
+// references expanded, so conditions see the values the file will load.
+func conditional(lines []string, expand func([]string) ([]string, error)) ([]string, error) {
+	out := make([]string, len(lines))
+
+	type block struct {
+		line   int
+		active bool
+		seenIf bool
+		inElse bool
+	}
+
+	var stack []block
+
+	// active reports whether lines in the innermost block are kept
+	active := func() bool {
+		return len(stack) == 0 || stack[len(stack)-1].active
+	}
+
+	for i, line := range lines {
+		s := strings.TrimSpace(line)
+
+		if cond, ok := ifDirective(s); ok {
+			parent := active()
+			ok := false
+			if parent {
+				lookup, err := conditionLookup(out[:i], expand)
+				if err != nil {
+					return nil, err
+				}
+				ok = cond(lookup)
+			}
+
+			stack = append(stack, block{line: i + 1, active: ok, seenIf: ok})
+			continue
+		}
+
+		switch s {
+		case "#else":
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: #else without #if", i+1)
+			}
+
+			b := &stack[len(stack)-1]
+			if b.inElse {
+				return nil, fmt.Errorf("line %d: duplicate #else", i+1)
+			}
+
+			parent := len(stack) == 1 || stack[len(stack)-2].active
+			b.inElse = true
+			b.active = parent && !b.seenIf
+			continue
+		case "#endif":
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: #endif without #if", i+1)
+			}
+
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		if !active() {
+			continue
+		}
+
+		out[i] = line
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("line %d: #if without #endif", stack[len(stack)-1].line)
+	}
+
+	return out, nil
+}
+
+// conditionLookup returns the lookup an #if directive below kept is
+// evaluated with.
+func conditionLookup(kept []string, expand func([]string) ([]string, error)) (func(string) string, error) {
+	if expand != nil {
+		var err error
+		if kept, err = expand(kept); err != nil {
+			return nil, err
+		}
+	}
+
+	defined := map[string]string{}
+	for _, line := range kept {
+		if k, v, ok := lineEntry(line); ok {
+			defined[k] = v
+		}
+	}
+
+	return func(k string) string {
+		if v, ok := defined[k]; ok {
+			return v
+		}
+		return os.Getenv(k)
+	}, nil
+}
+
+// directive returns the name of the directive s, a trimmed line, is, if
+// it is one: "#if", "#else", or "#endif".
+func directive(s string) (string, bool) {
+	if _, ok := ifDirective(s); ok {
+		return "#if", true
+	}
+
+	if s == "#else" || s == "#endif" {
+		return s, true
+	}
+	return "", false
+}
+
+// ifDirective parses s, a trimmed line, as an #if directive, returning its
+// condition.
+func ifDirective(s string) (func(lookup func(string) string) bool, bool) {
+	rest, ok := strings.CutPrefix(s, "#if")
+	if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '\t') {
+		return nil, false
+	}
+	return parseCondition(strings.TrimSpace(rest))
+}
+
+// parseCondition parses the expression of an #if directive, reporting
+// false if expr is not one of the supported conditions.
+func parseCondition(expr string) (func(lookup func(string) string) bool, bool) {
+	if strings.ContainsFunc(expr, unicode.IsSpace) {
+		return nil, false
+	}
+
+	if k, ok := strings.CutPrefix(expr, "!"); ok {
+		if !conditionKey(k) {
+			return nil, false
+		}
+
+		return func(lookup func(string) string) bool {
+			return lookup(k) == ""
+		}, true
+	}
+
+	if k, v, ok := strings.Cut(expr, "="); ok {
+		k, not := strings.CutSuffix(k, "!")
+		if !conditionKey(k) {
+			return nil, false
+		}
+
+		return func(lookup func(string) string) bool {
+			return (lookup(k) == v) != not
+		}, true
+	}
+
+	if !conditionKey(expr) {
+		return nil, false
+	}
+
+	return func(lookup func(string) string) bool {
+		return lookup(expr) != ""
+	}, true
+}
+
+// conditionKey reports whether k can be the KEY of a condition.
+func conditionKey(k string) bool {
+	if k == "" || k[0] == '!' {
+		return false
+	}
+
+	return !strings.ContainsFunc(k, func(r rune) bool {
+		return r == '=' || unicode.IsControl(r)
+	})
+}
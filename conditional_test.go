@@ -0,0 +1,132 @@
+package envy
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromFile_Conditional(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		in   string
+		opts []ParseOption
+		exp  []string
+		err  string
+	}{
+		{
+			name: "no directives",
+			in:   "A=1\nB=2",
+			exp:  []string{"A=1", "B=2"},
+		},
+		{
+			name: "if true",
+			in:   "APP_ENV=production\n#if APP_ENV=production\nDEBUG=false\n#endif\nPORT=80",
+			exp:  []string{"APP_ENV=production", "DEBUG=false", "PORT=80"},
+		},
+		{
+			name: "if false with else",
+			in:   "APP_ENV=development\n#if APP_ENV=production\nDEBUG=false\n#else\nDEBUG=true\n#endif",
+			exp:  []string{"APP_ENV=development", "DEBUG=true"},
+		},
+		{
+			name: "not equal, set, and unset",
+			in:   "A=x\n#if A!=y\nB=1\n#endif\n#if A\nC=1\n#endif\n#if !Z_ENVY_UNSET\nD=1\n#endif\n#if Z_ENVY_UNSET\nE=1\n#endif",
+			exp:  []string{"A=x", "B=1", "C=1", "D=1"},
+		},
+		{
+			name: "nested",
+			in:   "A=1\n#if A=2\n#if A=1\nB=1\n#else\nC=1\n#endif\n#else\n#if A=1\nD=1\n#else\nE=1\n#endif\n#endif",
+			exp:  []string{"A=1", "D=1"},
+		},
+		{
+			name: "skipped entries are not defined",
+			in:   "#if Z_ENVY_UNSET\nA=1\n#endif\n#if A\nB=1\n#endif",
+			exp:  []string{},
+		},
+		{
+			name: "plain comments are not directives",
+			in:   "# if you need it\nA=1",
+			exp:  []string{"A=1"},
+		},
+		{
+			name: "prose is not a directive",
+			in:   "#if unset, the port defaults to 8080\nA=1\n#if  A\nB=1\n#endif\n#if A = 1\n#ifdef A",
+			exp:  []string{"A=1", "B=1"},
+		},
+		{
+			name: "interpolated values",
+			in:   "STAGE=production\nAPP_ENV=$STAGE\n#if APP_ENV=production\nDEBUG=false\n#else\nDEBUG=true\n#endif",
+			opts: []ParseOption{Interpolate(true)},
+			exp:  []string{"APP_ENV=production", "DEBUG=false", "STAGE=production"},
+		},
+		{
+			name: "not interpolated",
+			in:   "STAGE=production\nAPP_ENV=$STAGE\n#if APP_ENV=production\nDEBUG=false\n#else\nDEBUG=true\n#endif",
+			exp:  []string{"APP_ENV=$STAGE", "DEBUG=true", "STAGE=production"},
+		},
+		{
+			name: "unterminated",
+			in:   "A=1\n#if A\nB=1",
+			err:  ".env: line 2: #if without #endif",
+		},
+		{
+			name: "stray endif",
+			in:   "#endif",
+			err:  ".env: line 1: #endif without #if",
+		},
+		{
+			name: "stray else",
+			in:   "#else",
+			err:  ".env: line 1: #else without #if",
+		},
+		{
+			name: "duplicate else",
+			in:   "#if A\n#else\n#else\n#endif",
+			err:  ".env: line 3: duplicate #else",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			cab := fstest.MapFS{".env": &fstest.MapFile{Data: []byte(tc.in)}}
+
+			env, err := FromFile(cab, ".env", tc.opts...)
+			if tc.err != "" {
+				r.EqualError(err, tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, env.Environ())
+		})
+	}
+}
+
+func Test_FromFile_Conditional_Meta(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	in := strings.Join([]string{
+		"APP_ENV=production",
+		"#if APP_ENV=production",
+		"# production port",
+		"PORT=80",
+		"#endif",
+	}, "\n")
+
+	env, err := FromFile(fstest.MapFS{".env": &fstest.MapFile{Data: []byte(in)}}, ".env")
+	r.NoError(err)
+
+	m, ok := env.Meta("PORT")
+	r.True(ok)
+	r.Equal(4, m.Line)
+	r.Equal("production port", m.Description)
+}
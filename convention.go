@@ -0,0 +1,77 @@
+package envy
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+)
+
+// ConventionFiles returns the files FromConvention reads for the
+// environment env, such as "development" or "test", lowest precedence
+// first: .env, .env.local, .env.{env}, and .env.{env}.local. Without env
+// only .env and .env.local are read.
+func ConventionFiles(env string) []string {
+	if env == "" {
+		return []string{".env", ".env.local"}
+	}
+	return []string{".env", ".env.local", ".env." + env, ".env." + env + ".local"}
+}
+
+// FromConvention loads the files named by ConventionFiles from cab, each
+// one overriding the ones before: shared defaults in .env, uncommitted
+// machine-specific overrides in .env.local, and per-environment settings
+// in .env.{env} and .env.{env}.local, which win over both. Missing files
+// are skipped, so with none present the Env is empty. opts apply to every
+// file, so WithStats sums up all the layers. With Interpolate on, a file
+// can refer to keys set by the layers before it, which win over those of
+// InterpolateFrom. Provenance is kept, so Source names the file each value
+// came from.
+func FromConvention(cab fs.FS, env string, opts ...ParseOption) (*Env, error) {
+	if cab == nil {
+		return nil, fmt.Errorf("nil fs.FS")
+	}
+
+	if env != "" && (!validProfile(env) || env == "local") {
+		return nil, fmt.Errorf("invalid environment %q", env)
+	}
+
+	var po parseOptions
+	for _, opt := range opts {
+		opt(&po)
+	}
+
+	out := Zero()
+	for _, path := range ConventionFiles(env) {
+		lopts := opts
+		if po.interpolate {
+			from := out
+			if po.interpolateFrom != nil {
+				var err error
+				if from, err = po.interpolateFrom.Merge(out); err != nil {
+					return nil, err
+				}
+			}
+			lopts = append(slices.Clip(opts), InterpolateFrom(from))
+		}
+
+		layer, err := FromFileOptional(cab, path, lopts...)
+		if err != nil {
+			return nil, err
+		}
+
+		po.stats.layered(out, layer)
+
+		if out, err = out.Merge(layer); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// ConventionSource returns a Source that loads env files from cab with
+// FromConvention, for use with Load.
+func ConventionSource(cab fs.FS, env string, opts ...ParseOption) Source {
+	return func() (*Env, error) {
+		return FromConvention(cab, env, opts...)
+	}
+}
@@ -0,0 +1,107 @@
+package envy
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromConvention(t *testing.T) {
+	t.Parallel()
+
+	cab := fstest.MapFS{
+		".env":                  {Data: []byte("A=env\nB=env\nC=env\nD=env\n")},
+		".env.production":       {Data: []byte("B=production\nC=production\nD=production\n")},
+		".env.local":            {Data: []byte("C=local\nD=local\n")},
+		".env.production.local": {Data: []byte("D=production.local\n")},
+		".env.test":             {Data: []byte("B=test\n")},
+	}
+
+	layered := fstest.MapFS{
+		".env":       {Data: []byte("HOST=db\nPORT=1\n")},
+		".env.local": {Data: []byte("URL=${USER}@${HOST}:${PORT}\n")},
+	}
+
+	tcs := []struct {
+		name string
+		cab  fstest.MapFS
+		env  string
+		opts []ParseOption
+		exp  []string
+		src  string
+		err  string
+	}{
+		{
+			name: "all layers",
+			cab:  cab,
+			env:  "production",
+			exp:  []string{"A=env", "B=production", "C=production", "D=production.local"},
+			src:  ".env.production.local line 1",
+		},
+		{
+			name: "missing layers skipped",
+			cab:  cab,
+			env:  "test",
+			exp:  []string{"A=env", "B=test", "C=local", "D=local"},
+			src:  ".env.local line 2",
+		},
+		{
+			name: "no env",
+			cab:  cab,
+			exp:  []string{"A=env", "B=env", "C=local", "D=local"},
+			src:  ".env.local line 2",
+		},
+		{
+			name: "no files",
+			cab:  fstest.MapFS{},
+			env:  "production",
+			exp:  []string{},
+		},
+		{
+			name: "malformed",
+			cab:  fstest.MapFS{".env.local": {Data: []byte("A='open\n")}},
+			err:  ".env.local: line 1",
+		},
+		{
+			name: "interpolate layers",
+			cab:  layered,
+			opts: []ParseOption{Interpolate(true)},
+			exp:  []string{"HOST=db", "PORT=1", "URL=@db:1"},
+		},
+		{
+			name: "interpolate from",
+			cab:  layered,
+			opts: []ParseOption{Interpolate(true), InterpolateFrom(FromMap(map[string]string{"HOST": "base", "USER": "app"}))},
+			exp:  []string{"HOST=db", "PORT=1", "URL=app@db:1"},
+		},
+		{
+			name: "not interpolated",
+			cab:  layered,
+			exp:  []string{"HOST=db", "PORT=1", "URL=${USER}@${HOST}:${PORT}"},
+		},
+		{name: "path in env", cab: cab, env: "../prod", err: `invalid environment "../prod"`},
+		{name: "local env", cab: cab, env: "local", err: `invalid environment "local"`},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			env, err := ConventionSource(tc.cab, tc.env, tc.opts...)()
+			if tc.err != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, env.Environ())
+			r.Equal(tc.src, env.Source("D"))
+		})
+	}
+
+	_, err := FromConvention(nil, "")
+	require.Error(t, err)
+}
@@ -0,0 +1,101 @@
+package envy
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvHeader is the optional first row of a CSV file.
+var csvHeader = []string{"key", "value"}
+
+// FromCSV reads a two-column key,value CSV file, as exported by most
+// spreadsheets, with standard CSV quoting for values containing commas,
+// quotes, or newlines. A first row of exactly "key,value" is treated as a
+// header and skipped, as are blank rows and a leading UTF-8 byte order
+// mark. Extra columns are allowed only if they are empty. It returns an
+// error naming the line for malformed rows, invalid keys, and keys given
+// more than once.
+func FromCSV(r io.Reader) (*Env, error) {
+	if r == nil {
+		return nil, fmt.Errorf("nil reader")
+	}
+
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(3); err == nil && string(b) == "\ufeff" {
+		if _, err := br.Discard(3); err != nil {
+			return nil, err
+		}
+	}
+
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+
+	em := map[string]string{}
+	lines := map[string]int{}
+	for first := true; ; first = false {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		if first && len(rec) == 2 && rec[0] == csvHeader[0] && rec[1] == csvHeader[1] {
+			continue
+		}
+
+		if len(rec) < 2 {
+			return nil, fmt.Errorf("line %d: expected key,value", line)
+		}
+
+		for _, extra := range rec[2:] {
+			if strings.TrimSpace(extra) != "" {
+				return nil, fmt.Errorf("line %d: expected key,value, got %d columns", line, len(rec))
+			}
+		}
+
+		k := strings.TrimSpace(rec[0])
+		if !validKey(k) {
+			return nil, fmt.Errorf("line %d: invalid key %q", line, rec[0])
+		}
+
+		if prev, ok := lines[k]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key %s (first set on line %d)", line, k, prev)
+		}
+
+		em[k] = rec[1]
+		lines[k] = line
+	}
+
+	return FromMap(em), nil
+}
+
+// ToCSV writes the Env to w as a two-column CSV file sorted by key, with a
+// "key,value" header row, quoting values as needed. Values are written as
+// is: spreadsheet programs may treat values starting with '=', '+', '-',
+// or '@' as formulas.
+func (e *Env) ToCSV(w io.Writer) error {
+	if w == nil {
+		return fmt.Errorf("nil writer")
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, ent := range e.entries() {
+		if err := cw.Write([]string{ent.key, ent.value}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
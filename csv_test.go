@@ -0,0 +1,89 @@
+package envy
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromCSV(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		in   string
+		exp  []string
+		err  string
+	}{
+		{
+			name: "header and quoting",
+			in:   "key,value\nA,1\nB,\"x, \"\"y\"\"\"\nC,\"multi\nline\"\n",
+			exp:  []string{"A=1", "B=x, \"y\"", "C=multi\nline"},
+		},
+		{
+			name: "no header",
+			in:   "A,1\r\nB,2\r\n",
+			exp:  []string{"A=1", "B=2"},
+		},
+		{
+			name: "bom and blank rows",
+			in:   "\ufeffkey,value\n\nA,1\n",
+			exp:  []string{"A=1"},
+		},
+		{
+			name: "empty extra columns",
+			in:   "A,1,,\n B ,2\n",
+			exp:  []string{"A=1", "B=2"},
+		},
+		{name: "empty", in: "", exp: []string{}},
+		{name: "one column", in: "A,1\nB\n", err: "line 2: expected key,value"},
+		{name: "extra column", in: "A,1,x\n", err: "line 1: expected key,value, got 3 columns"},
+		{name: "invalid key", in: "key,value\n,1\n", err: `line 2: invalid key ""`},
+		{name: "duplicate", in: "A,1\nB,2\nA,3\n", err: "line 3: duplicate key A (first set on line 1)"},
+		{name: "bad quoting", in: "A,\"1\n", err: "extraneous or missing"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			env, err := FromCSV(strings.NewReader(tc.in))
+			if tc.err != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, env.Environ())
+		})
+	}
+
+	_, err := FromCSV(nil)
+	require.Error(t, err)
+}
+
+func Test_Env_ToCSV(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := FromMap(map[string]string{"B": "x, \"y\"", "A": "1", "C": "multi\nline"})
+
+	bb := &bytes.Buffer{}
+	r.NoError(env.ToCSV(bb))
+	r.Equal("key,value\nA,1\nB,\"x, \"\"y\"\"\"\nC,\"multi\nline\"\n", bb.String())
+
+	back, err := FromCSV(bb)
+	r.NoError(err)
+	r.Equal(env.Environ(), back.Environ())
+
+	r.Error(env.ToCSV(nil))
+
+	bb.Reset()
+	var e *Env
+	r.NoError(e.ToCSV(bb))
+	r.Equal("key,value\n", bb.String())
+}
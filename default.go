@@ -0,0 +1,120 @@
+package envy
+
+import (
+	"io/fs"
+	"sync"
+	"sync/atomic"
+)
+
+// Source produces an Env to be layered on top of another, in the same shape
+// as the function accepted by With.
+type Source func() (*Env, error)
+
+// FileSource returns a Source that reads path from cab using FromFile with
+// opts.
+func FileSource(cab fs.FS, path string, opts ...ParseOption) Source {
+	return func() (*Env, error) {
+		return FromFile(cab, path, opts...)
+	}
+}
+
+var (
+	// defaultEnv holds the package-level Env returned by Default.
+	defaultEnv atomic.Pointer[Env]
+
+	// defaultMu serializes Load and Reload and guards defaultSources.
+	defaultMu      sync.Mutex
+	defaultSources []Source
+)
+
+// Default returns the package-level Env. Until Load is called it is a
+// snapshot of the process environment taken on first use. The returned Env
+// may be replaced by a later Load or Reload; callers that need a stable view
+// should hold on to the returned pointer rather than calling Default again.
+func Default() *Env {
+	if e := defaultEnv.Load(); e != nil {
+		return e
+	}
+
+	defaultEnv.CompareAndSwap(nil, New())
+	return defaultEnv.Load()
+}
+
+// Load builds a new Env from the process environment with each source layered
+// on top in order, and atomically installs it as the Default. The sources are
+// remembered for Reload. Subscribers of the previous Default are moved to
+// the new one and notified of the differences, so watchers of the Default,
+// such as webhooks, see reloads as changes. Its Policy, KeepHistory limit,
+// KeyProvider, and FollowRefs setting are carried over too. If any source
+// fails, the Default is left unchanged.
+func Load(sources ...Source) error {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if err := loadDefault(sources); err != nil {
+		return err
+	}
+
+	defaultSources = sources
+	return nil
+}
+
+// Reload rebuilds the Default from the process environment and the sources
+// passed to the most recent successful Load, notifying subscribers as Load
+// does. If any source fails, the Default is left unchanged.
+func Reload() error {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	return loadDefault(defaultSources)
+}
+
+// loadDefault builds and installs the Default. defaultMu must be held.
+func loadDefault(sources []Source) error {
+	env := New()
+	for _, src := range sources {
+		var err error
+		env, err = With(env, src)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the subscribers and settings of the Default follow it, and the
+	// subscribers are told what changed
+	old := defaultEnv.Load()
+	if old != nil {
+		old.mu.Lock()
+		env.subs, env.nextSub = old.subs, old.nextSub
+		old.subs = nil
+		old.succ = env
+
+		env.policy = old.policy
+		env.historyLimit = old.historyLimit
+		if old.keys != nil {
+			env.keys = old.keys
+		}
+		env.followRefs = old.followRefs && !env.untrusted
+		old.mu.Unlock()
+	}
+
+	defaultEnv.Store(env)
+
+	if old == nil {
+		return nil
+	}
+
+	d := Compare(old, env)
+	if len(d) == 0 {
+		return nil
+	}
+
+	env.mu.RLock()
+	subs := env.subscribers()
+	env.mu.RUnlock()
+
+	for _, s := range subs {
+		s.fn(d)
+	}
+	return nil
+}
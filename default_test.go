@@ -0,0 +1,146 @@
+package envy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// tests in this file share the package-level Default and so do not run in
+// parallel.
+
+func Test_Default(t *testing.T) {
+	r := require.New(t)
+
+	d := Default()
+	r.NotNil(d)
+	r.Same(d, Default())
+	r.Equal(os.Getenv("PATH"), d.Getenv("PATH"))
+}
+
+func Test_Load_Reload(t *testing.T) {
+	r := require.New(t)
+	t.Cleanup(func() {
+		r.NoError(Load())
+	})
+
+	calls := 0
+	counter := func() (*Env, error) {
+		calls++
+		return FromMap(map[string]string{"CALLS": fmt.Sprint(calls)}), nil
+	}
+
+	r.NoError(Load(FileSource(os.DirFS("testdata"), "valid.env"), counter))
+
+	d := Default()
+	r.Equal("VALUE1", d.Getenv("KEY1"))
+	r.Equal("1", d.Getenv("CALLS"))
+
+	r.NoError(Reload())
+	r.NotSame(d, Default())
+	r.Equal("VALUE1", Default().Getenv("KEY1"))
+	r.Equal("2", Default().Getenv("CALLS"))
+
+	// a failing load leaves the Default and remembered sources untouched
+	before := Default()
+	r.Error(Load(func() (*Env, error) {
+		return nil, fmt.Errorf("boom")
+	}))
+	r.Same(before, Default())
+
+	r.NoError(Reload())
+	r.Equal("3", Default().Getenv("CALLS"))
+}
+
+func Test_Default_Concurrent(t *testing.T) {
+	r := require.New(t)
+	t.Cleanup(func() {
+		r.NoError(Load())
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_ = Reload()
+		}()
+		go func() {
+			defer wg.Done()
+			_ = Default().Getenv("PATH")
+		}()
+	}
+	wg.Wait()
+}
+
+func Test_Reload_Subscribers(t *testing.T) {
+	r := require.New(t)
+	t.Cleanup(func() {
+		r.NoError(Load())
+	})
+
+	port := "80"
+	r.NoError(Load(func() (*Env, error) {
+		return FromMap(map[string]string{"PORT": port}), nil
+	}))
+
+	var got []Diff
+	cancel, err := Default().Subscribe(func(d Diff) {
+		got = append(got, d)
+	})
+	r.NoError(err)
+
+	// a reload that changes nothing is not reported
+	r.NoError(Reload())
+	r.Empty(got)
+
+	port = "8080"
+	r.NoError(Reload())
+	r.Equal([]Diff{{{Key: "PORT", Kind: Modified, Old: "80", New: "8080"}}}, got)
+
+	// the subscription follows the new Default
+	r.NoError(Default().Setenv("PORT", "9090"))
+	r.Len(got, 2)
+	r.Equal(Diff{{Key: "PORT", Kind: Modified, Old: "8080", New: "9090"}}, got[1])
+
+	cancel()
+	port = "1"
+	r.NoError(Reload())
+	r.NoError(Default().Setenv("PORT", "2"))
+	r.Len(got, 2)
+}
+
+func Test_Reload_Settings(t *testing.T) {
+	r := require.New(t)
+	t.Cleanup(func() {
+		r.NoError(Load())
+	})
+
+	r.NoError(Load())
+
+	rules, err := ParseRules(strings.NewReader("deny Z_ENVY_DENIED\n"))
+	r.NoError(err)
+
+	keys := StaticKey("0123456789abcdef0123456789abcdef")
+	d := Default()
+	r.NoError(d.SetPolicy(rules))
+	r.NoError(d.KeepHistory(3))
+	r.NoError(d.SetKeyProvider(keys))
+	r.NoError(d.FollowRefs(true))
+
+	r.NoError(Reload())
+	d = Default()
+
+	err = d.Setenv("Z_ENVY_DENIED", "1")
+	r.ErrorIs(err, ErrPolicy)
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	r.Equal(3, d.historyLimit)
+	r.Equal(KeyProvider(keys), d.keys)
+	r.True(d.followRefs)
+}
@@ -0,0 +1,173 @@
+package envy
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+)
+
+// ChangeKind describes how a key differs between two environments.
+type ChangeKind int
+
+const (
+	// Added means the key is new.
+	Added ChangeKind = iota + 1
+	// Removed means the key no longer exists.
+	Removed
+	// Modified means the key exists in both with different values.
+	Modified
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	}
+	return "unknown"
+}
+
+// Change is a single difference between two environments. Old is empty for
+// Added changes and New is empty for Removed ones.
+type Change struct {
+	Key  string     `json:"key"`
+	Kind ChangeKind `json:"kind"`
+	Old  string     `json:"old,omitempty"`
+	New  string     `json:"new,omitempty"`
+}
+
+// Diff is a set of changes, sorted by key.
+type Diff []Change
+
+// Keys returns the keys in the Diff.
+func (d Diff) Keys() []string {
+	keys := make([]string, len(d))
+	for i, c := range d {
+		keys[i] = c.Key
+	}
+	return keys
+}
+
+// Compare returns the changes needed to turn from into to. A nil Env is
+// treated as empty.
+func Compare(from, to *Env) Diff {
+	a := map[string]string{}
+	for _, ent := range from.entries() {
+		a[ent.key] = ent.value
+	}
+
+	b := map[string]string{}
+	for _, ent := range to.entries() {
+		b[ent.key] = ent.value
+	}
+
+	return diffMaps(a, b)
+}
+
+// diffMaps returns the changes needed to turn a into b.
+func diffMaps(a, b map[string]string) Diff {
+	d := Diff{}
+	for k, ov := range a {
+		nv, ok := b[k]
+		switch {
+		case !ok:
+			d = append(d, Change{Key: k, Kind: Removed, Old: ov})
+		case ov != nv:
+			d = append(d, Change{Key: k, Kind: Modified, Old: ov, New: nv})
+		}
+	}
+
+	for k, nv := range b {
+		if _, ok := a[k]; !ok {
+			d = append(d, Change{Key: k, Kind: Added, New: nv})
+		}
+	}
+
+	sort.Slice(d, func(i, j int) bool {
+		return d[i].Key < d[j].Key
+	})
+	return d
+}
+
+// Apply makes the changes in d to the Env as a single update, notifying
+// subscribers once with the changes that actually took effect. Added and
+// Modified changes set Key to New; Removed changes unset Key. Old values are
+// not checked. It returns an error for a nil Env, or if the Policy set with
+// SetPolicy rejects the changes, in which case none are made.
+func (e *Env) Apply(d Diff) error {
+	if e.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	set, unset, err := d.split()
+	if err != nil {
+		return err
+	}
+
+	_, err = e.update(set, unset...)
+	return err
+}
+
+// Plan returns the changes Apply(d) would make to the Env, without making
+// them: changes that would have no effect are left out, and Old values
+// reflect the Env's current contents. It is a dry run for showing a plan
+// before mutating anything. A nil Env is treated as empty.
+func (e *Env) Plan(d Diff) (Diff, error) {
+	set, unset, err := d.split()
+	if err != nil {
+		return nil, err
+	}
+
+	cur := map[string]string{}
+	for _, ent := range e.entries() {
+		cur[ent.key] = ent.value
+	}
+
+	next := maps.Clone(cur)
+	maps.Copy(next, set)
+	for _, k := range unset {
+		delete(next, k)
+	}
+
+	return diffMaps(cur, next), nil
+}
+
+// split converts d into the keys to set and unset.
+func (d Diff) split() (map[string]string, []string, error) {
+	set := map[string]string{}
+	var unset []string
+	for _, c := range d {
+		switch c.Kind {
+		case Added, Modified:
+			set[c.Key] = c.New
+		case Removed:
+			unset = append(unset, c.Key)
+		default:
+			return nil, nil, fmt.Errorf("invalid change kind %d for %q", c.Kind, c.Key)
+		}
+	}
+	return set, unset, nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (k ChangeKind) MarshalText() ([]byte, error) {
+	switch k {
+	case Added, Removed, Modified:
+		return []byte(k.String()), nil
+	}
+	return nil, fmt.Errorf("invalid change kind %d", int(k))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (k *ChangeKind) UnmarshalText(b []byte) error {
+	for _, c := range []ChangeKind{Added, Removed, Modified} {
+		if string(b) == c.String() {
+			*k = c
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid change kind %q", b)
+}
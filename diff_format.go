@@ -0,0 +1,91 @@
+package envy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PatchOp is a single RFC 6902 JSON Patch operation against a flat JSON
+// object of environment variables.
+type PatchOp struct {
+	Op    string  `json:"op"`
+	Path  string  `json:"path"`
+	Value *string `json:"value,omitempty"`
+}
+
+// WriteJSON writes d to w as a JSON array of changes. Values are redacted
+// with sensitive, or IsSensitive if it is nil; pass a func returning false to
+// disable redaction.
+func (d Diff) WriteJSON(w io.Writer, sensitive func(key string) bool) error {
+	if w == nil {
+		return fmt.Errorf("nil writer")
+	}
+
+	rd := d.Redact(sensitive)
+	if rd == nil {
+		rd = Diff{}
+	}
+	return json.NewEncoder(w).Encode(rd)
+}
+
+// WriteUnified writes d to w as a unified-diff style listing of KEY=VALUE
+// lines, with removed and old values prefixed by "-" and added and new
+// values by "+". Nothing is written for an empty Diff. Values are redacted as
+// for WriteJSON.
+func (d Diff) WriteUnified(w io.Writer, sensitive func(key string) bool) error {
+	if w == nil {
+		return fmt.Errorf("nil writer")
+	}
+
+	if len(d) == 0 {
+		return nil
+	}
+
+	bb := &strings.Builder{}
+	bb.WriteString("--- before\n+++ after\n")
+
+	for _, c := range d.Redact(sensitive) {
+		switch c.Kind {
+		case Added:
+			fmt.Fprintf(bb, "+%s=%s\n", c.Key, c.New)
+		case Removed:
+			fmt.Fprintf(bb, "-%s=%s\n", c.Key, c.Old)
+		case Modified:
+			fmt.Fprintf(bb, "-%s=%s\n+%s=%s\n", c.Key, c.Old, c.Key, c.New)
+		}
+	}
+
+	_, err := io.WriteString(w, bb.String())
+	return err
+}
+
+// JSONPatch converts d into RFC 6902 operations that transform the JSON
+// object form of the old environment into the new one. Values are redacted
+// as for WriteJSON.
+func (d Diff) JSONPatch(sensitive func(key string) bool) []PatchOp {
+	ops := []PatchOp{}
+	for _, c := range d.Redact(sensitive) {
+		path := "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(c.Key)
+		v := c.New
+
+		switch c.Kind {
+		case Added:
+			ops = append(ops, PatchOp{Op: "add", Path: path, Value: &v})
+		case Removed:
+			ops = append(ops, PatchOp{Op: "remove", Path: path})
+		case Modified:
+			ops = append(ops, PatchOp{Op: "replace", Path: path, Value: &v})
+		}
+	}
+	return ops
+}
+
+// WriteJSONPatch writes the JSONPatch form of d to w.
+func (d Diff) WriteJSONPatch(w io.Writer, sensitive func(key string) bool) error {
+	if w == nil {
+		return fmt.Errorf("nil writer")
+	}
+	return json.NewEncoder(w).Encode(d.JSONPatch(sensitive))
+}
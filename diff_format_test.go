@@ -0,0 +1,75 @@
+package envy
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var formatDiff = Diff{
+	{Key: "A/B", Kind: Added, New: "1"},
+	{Key: "DB_PASSWORD", Kind: Modified, Old: "old", New: "new"},
+	{Key: "PORT", Kind: Modified, Old: "80", New: "8080"},
+	{Key: "Z", Kind: Removed, Old: "gone"},
+}
+
+func Test_Diff_WriteJSON(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	bb := &bytes.Buffer{}
+	r.NoError(formatDiff.WriteJSON(bb, nil))
+	r.JSONEq(`[
+		{"key":"A/B","kind":"added","new":"1"},
+		{"key":"DB_PASSWORD","kind":"modified","old":"[REDACTED]","new":"[REDACTED]"},
+		{"key":"PORT","kind":"modified","old":"80","new":"8080"},
+		{"key":"Z","kind":"removed","old":"gone"}
+	]`, bb.String())
+
+	bb.Reset()
+	r.NoError(Diff(nil).WriteJSON(bb, nil))
+	r.Equal("[]\n", bb.String())
+
+	r.Error(formatDiff.WriteJSON(nil, nil))
+}
+
+func Test_Diff_WriteUnified(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	bb := &bytes.Buffer{}
+	r.NoError(formatDiff.WriteUnified(bb, func(string) bool { return false }))
+	r.Equal(`--- before
++++ after
++A/B=1
+-DB_PASSWORD=old
++DB_PASSWORD=new
+-PORT=80
++PORT=8080
+-Z=gone
+`, bb.String())
+
+	bb.Reset()
+	r.NoError(Diff{}.WriteUnified(bb, nil))
+	r.Empty(bb.String())
+
+	r.Error(formatDiff.WriteUnified(nil, nil))
+}
+
+func Test_Diff_JSONPatch(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	bb := &bytes.Buffer{}
+	r.NoError(formatDiff.WriteJSONPatch(bb, nil))
+	r.JSONEq(`[
+		{"op":"add","path":"/A~1B","value":"1"},
+		{"op":"replace","path":"/DB_PASSWORD","value":"[REDACTED]"},
+		{"op":"replace","path":"/PORT","value":"8080"},
+		{"op":"remove","path":"/Z"}
+	]`, bb.String())
+
+	r.Empty(Diff{}.JSONPatch(nil))
+	r.Error(formatDiff.WriteJSONPatch(nil, nil))
+}
@@ -0,0 +1,217 @@
+package envy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Compare(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		from *Env
+		to   *Env
+		exp  Diff
+	}{
+		{
+			name: "nil envs",
+			exp:  Diff{},
+		},
+		{
+			name: "identical",
+			from: FromMap(map[string]string{"KEY": "VALUE"}),
+			to:   FromMap(map[string]string{"KEY": "VALUE"}),
+			exp:  Diff{},
+		},
+		{
+			name: "all kinds",
+			from: FromMap(map[string]string{"A": "1", "B": "2", "C": "3"}),
+			to:   FromMap(map[string]string{"A": "1", "B": "20", "D": "4"}),
+			exp: Diff{
+				{Key: "B", Kind: Modified, Old: "2", New: "20"},
+				{Key: "C", Kind: Removed, Old: "3"},
+				{Key: "D", Kind: Added, New: "4"},
+			},
+		},
+		{
+			name: "from nil",
+			to:   FromMap(map[string]string{"A": "1"}),
+			exp:  Diff{{Key: "A", Kind: Added, New: "1"}},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			got := Compare(tc.from, tc.to)
+			r.Equal(tc.exp, got)
+		})
+	}
+}
+
+func Test_Diff_Keys(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	d := Diff{{Key: "A", Kind: Added}, {Key: "B", Kind: Removed}}
+	r.Equal([]string{"A", "B"}, d.Keys())
+	r.Equal("added", Added.String())
+	r.Equal("removed", Removed.String())
+	r.Equal("modified", Modified.String())
+	r.Equal("unknown", ChangeKind(0).String())
+}
+
+func Test_Env_Apply(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  *Env
+		diff Diff
+		exp  []string
+		err  bool
+	}{
+		{
+			name: "nil env",
+			env:  nil,
+			err:  true,
+		},
+		{
+			name: "invalid kind",
+			env:  Zero(),
+			diff: Diff{{Key: "KEY"}},
+			exp:  []string{},
+			err:  true,
+		},
+		{
+			name: "all kinds",
+			env:  FromMap(map[string]string{"A": "1", "B": "2", "C": "3"}),
+			diff: Diff{
+				{Key: "B", Kind: Modified, Old: "2", New: "20"},
+				{Key: "C", Kind: Removed, Old: "3"},
+				{Key: "D", Kind: Added, New: "4"},
+			},
+			exp: []string{"A=1", "B=20", "D=4"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			err := tc.env.Apply(tc.diff)
+			if tc.err {
+				r.Error(err)
+			} else {
+				r.NoError(err)
+			}
+
+			if tc.exp != nil {
+				r.Equal(tc.exp, tc.env.Environ())
+			}
+		})
+	}
+}
+
+func Test_Env_Plan(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  *Env
+		diff Diff
+		exp  Diff
+		err  bool
+	}{
+		{
+			name: "nil env",
+			diff: Diff{{Key: "A", Kind: Added, New: "1"}},
+			exp:  Diff{{Key: "A", Kind: Added, New: "1"}},
+		},
+		{
+			name: "invalid kind",
+			env:  Zero(),
+			diff: Diff{{Key: "KEY"}},
+			err:  true,
+		},
+		{
+			name: "only effective changes with current old values",
+			env:  FromMap(map[string]string{"A": "1", "B": "2", "C": "3"}),
+			diff: Diff{
+				{Key: "A", Kind: Modified, Old: "stale", New: "1"},
+				{Key: "B", Kind: Added, New: "20"},
+				{Key: "C", Kind: Removed},
+				{Key: "D", Kind: Removed},
+				{Key: "E", Kind: Modified, New: "5"},
+			},
+			exp: Diff{
+				{Key: "B", Kind: Modified, Old: "2", New: "20"},
+				{Key: "C", Kind: Removed, Old: "3"},
+				{Key: "E", Kind: Added, New: "5"},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			before := tc.env.Environ()
+
+			got, err := tc.env.Plan(tc.diff)
+			if tc.err {
+				r.Error(err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, got)
+			r.Equal(before, tc.env.Environ())
+		})
+	}
+}
+
+func Test_Env_Apply_Compare(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	from := FromMap(map[string]string{"A": "1", "B": "2"})
+	to := FromMap(map[string]string{"B": "3", "C": "4"})
+
+	var got []Diff
+	_, err := from.Subscribe(func(d Diff) {
+		got = append(got, d)
+	})
+	r.NoError(err)
+
+	d := Compare(from, to)
+	r.NoError(from.Apply(d))
+	r.Equal(to.Environ(), from.Environ())
+	r.Equal([]Diff{d}, got)
+}
+
+func Test_ChangeKind_Text(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	b, err := json.Marshal(Diff{{Key: "A", Kind: Modified, Old: "1", New: "2"}})
+	r.NoError(err)
+	r.Equal(`[{"key":"A","kind":"modified","old":"1","new":"2"}]`, string(b))
+
+	var d Diff
+	r.NoError(json.Unmarshal(b, &d))
+	r.Equal(Diff{{Key: "A", Kind: Modified, Old: "1", New: "2"}}, d)
+
+	_, err = ChangeKind(0).MarshalText()
+	r.Error(err)
+
+	var k ChangeKind
+	r.Error(k.UnmarshalText([]byte("bogus")))
+}
@@ -0,0 +1,130 @@
+package envy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FromDockerEnvFile reads the file at path the way "docker run --env-file"
+// does, so the same file means the same thing to both. Leading white space
+// is ignored, lines starting with # are comments, and everything after the
+// first "=" is the value, kept exactly: quotes, "export" prefixes, trailing
+// white space, and "$" have no special meaning. A line with only a name
+// takes that variable's value from the process environment, and is skipped
+// if it is not set there. The line and the comment lines directly above
+// each key are recorded as its Meta. It returns an error for a nil fs.FS
+// or a read failure; the error wraps ErrMalformed for the lines Docker
+// rejects: invalid UTF-8, an empty name, or a name containing white space.
+func FromDockerEnvFile(cab fs.FS, path string) (*Env, error) {
+	if cab == nil {
+		return nil, fmt.Errorf("nil fs.FS")
+	}
+
+	f, err := cab.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	em := map[string]string{}
+	meta := map[string]Meta{}
+	var comments []string
+
+	buf := bufio.NewScanner(f)
+	n := 0
+	for buf.Scan() {
+		n++
+		line := buf.Text()
+		if n == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+
+		if !utf8.ValidString(line) {
+			return nil, malformedError{fmt.Errorf("%s: line %d: invalid UTF-8", path, n)}
+		}
+
+		line = strings.TrimLeftFunc(line, unicode.IsSpace)
+		if line == "" {
+			comments = nil
+			continue
+		}
+
+		if c, ok := strings.CutPrefix(line, "#"); ok {
+			comments = append(comments, strings.TrimSpace(c))
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, "=")
+		if k == "" {
+			return nil, malformedError{fmt.Errorf("%s: line %d: no variable name in %q", path, n, line)}
+		}
+
+		if strings.ContainsFunc(k, unicode.IsSpace) {
+			return nil, malformedError{fmt.Errorf("%s: line %d: variable %q contains white space", path, n, k)}
+		}
+
+		if !ok {
+			if v, ok = os.LookupEnv(k); !ok {
+				comments = nil
+				continue
+			}
+		}
+
+		em[k] = v
+		meta[k] = Meta{
+			Loader:      "file",
+			File:        path,
+			Line:        n,
+			Description: strings.Join(comments, "\n"),
+		}
+		comments = nil
+	}
+
+	if err := buf.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	e := FromMap(em)
+	e.meta = meta
+	return e, nil
+}
+
+// ToDockerEnvFile writes the Env to w as sorted KEY=VALUE lines that
+// "docker run --env-file" and FromDockerEnvFile read back unchanged.
+// Nothing is quoted or escaped, as the format has no way to, so it returns
+// an error, before writing anything, for a key Docker would misread, one
+// that starts with # or contains white space, and for a value containing a
+// line break or invalid UTF-8.
+func (e *Env) ToDockerEnvFile(w io.Writer) error {
+	if w == nil {
+		return fmt.Errorf("nil writer")
+	}
+
+	ents, err := e.exportEntries()
+	if err != nil {
+		return err
+	}
+
+	for _, ent := range ents {
+		switch {
+		case strings.HasPrefix(ent.key, "#"), strings.ContainsFunc(ent.key, unicode.IsSpace), !utf8.ValidString(ent.key):
+			return fmt.Errorf("%q: cannot be written to a Docker env file", ent.key)
+		case strings.ContainsAny(ent.value, "\n\r"):
+			return fmt.Errorf("%s: value contains a line break", ent.key)
+		case !utf8.ValidString(ent.value):
+			return fmt.Errorf("%s: value is not valid UTF-8", ent.key)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, ent := range ents {
+		fmt.Fprintf(bw, "%s=%s\n", ent.key, ent.value)
+	}
+	return bw.Flush()
+}
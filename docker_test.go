@@ -0,0 +1,148 @@
+package envy
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromDockerEnvFile(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		in   string
+		exp  map[string]string
+		err  string
+	}{
+		{
+			name: "values kept exactly",
+			in:   "A=1\n  B=two words  \nC=\"quoted\"\nD='single'\nE=a=b # not a comment\nF=$HOME\nG=\n",
+			exp: map[string]string{
+				"A": "1",
+				"B": "two words  ",
+				"C": `"quoted"`,
+				"D": "'single'",
+				"E": "a=b # not a comment",
+				"F": "$HOME",
+				"G": "",
+			},
+		},
+		{
+			name: "comments and BOM",
+			in:   "\ufeff# comment\n\n  # indented\nA=1\n",
+			exp:  map[string]string{"A": "1"},
+		},
+		{
+			name: "from process",
+			in:   "PATH\nENVY_DOCKER_NOT_SET_ANYWHERE\n",
+			exp:  map[string]string{"PATH": os.Getenv("PATH")},
+		},
+		{name: "export prefix", in: "export A=1\n", err: `env.list: line 1: variable "export A" contains white space`},
+		{name: "no name", in: "A=1\n=2\n", err: `env.list: line 2: no variable name in "=2"`},
+		{name: "invalid UTF-8", in: "A=\xff\n", err: "env.list: line 1: invalid UTF-8"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			cab := fstest.MapFS{"env.list": {Data: []byte(tc.in)}}
+
+			env, err := FromDockerEnvFile(cab, "env.list")
+			if tc.err != "" {
+				r.ErrorIs(err, ErrMalformed)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, env.jsonMap())
+		})
+	}
+}
+
+func Test_FromDockerEnvFile_meta(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	cab := fstest.MapFS{"env.list": {Data: []byte("# the port\nPORT=80\n")}}
+
+	env, err := FromDockerEnvFile(cab, "env.list")
+	r.NoError(err)
+
+	m, ok := env.Meta("PORT")
+	r.True(ok)
+	r.Equal(2, m.Line)
+	r.Equal("the port", m.Description)
+
+	_, err = FromDockerEnvFile(cab, "nope.list")
+	r.ErrorIs(err, fs.ErrNotExist)
+
+	_, err = FromDockerEnvFile(nil, "env.list")
+	r.Error(err)
+}
+
+func Test_Env_ToDockerEnvFile(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  *Env
+		exp  string
+		err  string
+	}{
+		{name: "nil env", env: nil, exp: ""},
+		{
+			name: "sorted and unquoted",
+			env:  FromMap(map[string]string{"B": " padded ", "A": `"quoted"`, "C": "a=b"}),
+			exp:  "A=\"quoted\"\nB= padded \nC=a=b\n",
+		},
+		{
+			name: "line break",
+			env:  FromMap(map[string]string{"A": "1", "B": "x\ny"}),
+			err:  "B: value contains a line break",
+		},
+		{
+			name: "white space in key",
+			env:  FromMap(map[string]string{"A B": "1"}),
+			err:  `"A B": cannot be written to a Docker env file`,
+		},
+		{
+			name: "comment key",
+			env:  FromMap(map[string]string{"#A": "1"}),
+			err:  `"#A": cannot be written to a Docker env file`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			bb := &bytes.Buffer{}
+			err := tc.env.ToDockerEnvFile(bb)
+			if tc.err != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.err)
+				r.Empty(bb.String())
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, bb.String())
+
+			env, err := FromDockerEnvFile(fstest.MapFS{"env.list": {Data: bb.Bytes()}}, "env.list")
+			r.NoError(err)
+			r.Equal(tc.env.jsonMap(), env.jsonMap())
+		})
+	}
+
+	r := require.New(t)
+	r.Error(Zero().ToDockerEnvFile(nil))
+}
@@ -0,0 +1,216 @@
+package envy
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Document is an env file held line by line, so it can be edited and
+// written back without losing comments, blank lines, directives, or the
+// order of entries. Unlike FromFile, it does not evaluate #if directives:
+// Get, Set, and Unset act on the entries as written.
+type Document struct {
+	lines []string
+}
+
+// ParseDocument reads a Document from r.
+func ParseDocument(r io.Reader) (*Document, error) {
+	if r == nil {
+		return nil, fmt.Errorf("nil reader")
+	}
+
+	d := &Document{}
+	buf := bufio.NewScanner(r)
+	for buf.Scan() {
+		d.lines = append(d.lines, buf.Text())
+	}
+
+	if err := buf.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ReadDocument reads the Document at path. A missing file is an error; use
+// errors.Is(err, fs.ErrNotExist) to start from an empty Document instead.
+func ReadDocument(path string) (*Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseDocument(f)
+}
+
+// docEntry is an entry of a Document: its key, its decoded value, and the
+// lines it takes up, more than one for a quoted value spanning several.
+type docEntry struct {
+	key   string
+	value string
+	start int
+	end   int
+}
+
+// entries parses the Document once, as FromFile reads it, and returns its
+// entries in order. Comments are never entries, even when they look like
+// KEY=VALUE, and neither are the lines of a quoted value spanning several,
+// which belong to the entry they continue. Malformed entries are skipped.
+func (d *Document) entries() []docEntry {
+	out, err := dotenvLines(d.lines, false, func(int, string, error) {})
+	if err != nil {
+		return nil
+	}
+
+	var ents []docEntry
+	for i, line := range out {
+		k, v, ok := lineEntry(line)
+		if !ok {
+			continue
+		}
+		ents = append(ents, docEntry{key: k, value: v, start: i, end: entryEnd(d.lines, i)})
+	}
+	return ents
+}
+
+// entryEnd returns the index of the last line of the entry starting at
+// lines[i], which must be one dotenvLines decoded.
+func entryEnd(lines []string, i int) int {
+	s := strings.TrimLeft(lines[i], " \t")
+	if rest, ok := strings.CutPrefix(s, "export"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+		s = rest
+	}
+
+	_, v, _ := strings.Cut(s, "=")
+	v = strings.TrimLeft(v, " \t")
+	if v == "" || (v[0] != '"' && v[0] != '\'' && v[0] != '`') {
+		return i
+	}
+
+	_, _, end, err := quotedValue(lines, i, v, false)
+	if err != nil {
+		return i
+	}
+	return end
+}
+
+// Get returns the decoded value of the last entry for key, which is the
+// one FromFile keeps by default, and whether there is one.
+func (d *Document) Get(key string) (string, bool) {
+	ents := d.entries()
+	for i := len(ents) - 1; i >= 0; i-- {
+		if ents[i].key == key {
+			return ents[i].value, true
+		}
+	}
+	return "", false
+}
+
+// Keys returns the keys of the Document's entries in the order they first
+// appear.
+func (d *Document) Keys() []string {
+	seen := map[string]bool{}
+	keys := []string{}
+	for _, ent := range d.entries() {
+		if !seen[ent.key] {
+			seen[ent.key] = true
+			keys = append(keys, ent.key)
+		}
+	}
+	return keys
+}
+
+// Set replaces the value of the last entry for key in place, keeping its
+// position and the comments around it, or appends a new entry if there is
+// none. The value is written as WriteTo writes it, quoted and escaped if
+// FromFile would not otherwise read it back unchanged. It returns an error
+// for an invalid key, including one with white space, or a value
+// containing a newline, which would not keep the entry on one line.
+func (d *Document) Set(key, value string) error {
+	if !validKey(key) || strings.HasPrefix(key, "#") || strings.ContainsFunc(key, unicode.IsSpace) {
+		return fmt.Errorf("invalid key %q", key)
+	}
+
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("%s: value contains a newline", key)
+	}
+
+	line := key + "=" + dotenvValue(value)
+
+	ents := d.entries()
+	for i := len(ents) - 1; i >= 0; i-- {
+		if ent := ents[i]; ent.key == key {
+			d.lines = slices.Replace(d.lines, ent.start, ent.end+1, line)
+			return nil
+		}
+	}
+
+	d.lines = append(d.lines, line)
+	return nil
+}
+
+// Unset removes every entry for key, with all the lines of a value
+// spanning several, and reports whether there was one. Comments above the
+// entries are kept.
+func (d *Document) Unset(key string) bool {
+	found := false
+	ents := d.entries()
+	for i := len(ents) - 1; i >= 0; i-- {
+		if ent := ents[i]; ent.key == key {
+			found = true
+			d.lines = slices.Delete(d.lines, ent.start, ent.end+1)
+		}
+	}
+	return found
+}
+
+// Env returns the Document's entries as an Env, the last entry winning
+// for keys set more than once.
+func (d *Document) Env() *Env {
+	em := map[string]string{}
+	for _, ent := range d.entries() {
+		em[ent.key] = ent.value
+	}
+	return FromMap(em)
+}
+
+// WriteTo implements io.WriterTo, writing the Document as it would be
+// saved.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if w == nil {
+		return 0, fmt.Errorf("nil writer")
+	}
+
+	n, err := w.Write(d.bytes())
+	return int64(n), err
+}
+
+// ToFile writes the Document to path the way Env.ToFile writes an Env,
+// atomically and honoring the same options. With DryRun, the changes are
+// computed between the variables in the file and those of the Document.
+func (d *Document) ToFile(path string, opts ...FileOption) error {
+	read := func(path string) (*Env, error) {
+		cur, err := ReadDocument(path)
+		if err != nil {
+			return nil, err
+		}
+		return cur.Env(), nil
+	}
+	return writeFile(path, d.Env(), d.bytes, read, opts)
+}
+
+// bytes returns the Document's lines, each newline-terminated.
+func (d *Document) bytes() []byte {
+	bb := &bytes.Buffer{}
+	for _, line := range d.lines {
+		bb.WriteString(line)
+		bb.WriteByte('\n')
+	}
+	return bb.Bytes()
+}
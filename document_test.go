@@ -0,0 +1,215 @@
+package envy
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testDocument = `# database
+DB_HOST=localhost
+DB_PORT=5432
+
+#if APP_ENV=production
+DB_HOST=db.internal
+#endif
+# API_KEY=commented
+`
+
+func Test_Document(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		edit func(*Document) error
+		exp  string
+	}{
+		{
+			name: "set replaces last entry in place",
+			edit: func(d *Document) error { return d.Set("DB_HOST", "example.com") },
+			exp:  strings.Replace(testDocument, "DB_HOST=db.internal", "DB_HOST=example.com", 1),
+		},
+		{
+			name: "set appends",
+			edit: func(d *Document) error { return d.Set("API_KEY", "k") },
+			exp:  testDocument + "API_KEY=k\n",
+		},
+		{
+			name: "set empty",
+			edit: func(d *Document) error { return d.Set("DB_PORT", "") },
+			exp:  strings.Replace(testDocument, "DB_PORT=5432", "DB_PORT=", 1),
+		},
+		{
+			name: "unset removes every entry",
+			edit: func(d *Document) error {
+				if !d.Unset("DB_HOST") {
+					return os.ErrNotExist
+				}
+				return nil
+			},
+			exp: "# database\nDB_PORT=5432\n\n#if APP_ENV=production\n#endif\n# API_KEY=commented\n",
+		},
+		{
+			name: "unset missing",
+			edit: func(d *Document) error {
+				if d.Unset("API_KEY") {
+					return os.ErrExist
+				}
+				return nil
+			},
+			exp: testDocument,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			d, err := ParseDocument(strings.NewReader(testDocument))
+			r.NoError(err)
+			r.NoError(tc.edit(d))
+
+			bb := &bytes.Buffer{}
+			_, err = d.WriteTo(bb)
+			r.NoError(err)
+			r.Equal(tc.exp, bb.String())
+		})
+	}
+}
+
+func Test_Document_Get(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	d, err := ParseDocument(strings.NewReader(testDocument))
+	r.NoError(err)
+
+	v, ok := d.Get("DB_HOST")
+	r.True(ok)
+	r.Equal("db.internal", v)
+
+	_, ok = d.Get("API_KEY")
+	r.False(ok)
+
+	r.Equal([]string{"DB_HOST", "DB_PORT"}, d.Keys())
+	r.Equal([]string{"DB_HOST=db.internal", "DB_PORT=5432"}, d.Env().Environ())
+
+	r.Error(d.Set("A\nB", "x"))
+	r.Error(d.Set("#A", "x"))
+	r.Error(d.Set(" A", "x"))
+	r.Error(d.Set("A=B", "x"))
+	r.Error(d.Set("A", "multi\nline"))
+
+	_, err = ParseDocument(nil)
+	r.Error(err)
+}
+
+func Test_Document_quoting(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name  string
+		value string
+		line  string
+	}{
+		{name: "plain", value: "abc", line: "A=abc"},
+		{name: "comment", value: "abc #123", line: `A="abc #123"`},
+		{name: "reference", value: "$HOME/x", line: `A="\$HOME/x"`},
+		{name: "leading quote", value: `"x`, line: `A="\"x"`},
+		{name: "spaces", value: " x ", line: `A=" x "`},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			d, err := ParseDocument(strings.NewReader(""))
+			r.NoError(err)
+			r.NoError(d.Set("A", tc.value))
+
+			bb := &bytes.Buffer{}
+			_, err = d.WriteTo(bb)
+			r.NoError(err)
+			r.Equal(tc.line+"\n", bb.String())
+
+			v, ok := d.Get("A")
+			r.True(ok)
+			r.Equal(tc.value, v)
+
+			for _, opts := range [][]ParseOption{nil, {Interpolate(true)}} {
+				env, err := FromFile(fstest.MapFS{".env": {Data: bb.Bytes()}}, ".env", opts...)
+				r.NoError(err)
+				r.Equal(tc.value, env.Getenv("A"))
+			}
+		})
+	}
+}
+
+func Test_Document_multiline(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	const in = "A=1\nCERT=\"line1\nB=inside\n\"\nB=outside\n# tail\n"
+
+	d, err := ParseDocument(strings.NewReader(in))
+	r.NoError(err)
+
+	r.Equal([]string{"A", "CERT", "B"}, d.Keys())
+
+	v, ok := d.Get("CERT")
+	r.True(ok)
+	r.Equal("line1\nB=inside\n", v)
+
+	r.True(d.Unset("B"))
+	v, ok = d.Get("CERT")
+	r.True(ok)
+	r.Equal("line1\nB=inside\n", v)
+
+	r.NoError(d.Set("CERT", "short"))
+
+	bb := &bytes.Buffer{}
+	_, err = d.WriteTo(bb)
+	r.NoError(err)
+	r.Equal("A=1\nCERT=short\n# tail\n", bb.String())
+
+	d, err = ParseDocument(strings.NewReader(in))
+	r.NoError(err)
+	r.True(d.Unset("CERT"))
+	r.Equal([]string{"A=1", "B=outside"}, d.Env().Environ())
+}
+
+func Test_Document_ToFile(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	path := filepath.Join(t.TempDir(), ".env")
+	r.NoError(os.WriteFile(path, []byte(testDocument), 0o640))
+
+	d, err := ReadDocument(path)
+	r.NoError(err)
+	r.NoError(d.Set("DB_PORT", "6543"))
+
+	var plan Diff
+	r.NoError(d.ToFile(path, DryRun(&plan)))
+	r.Equal(Diff{{Key: "DB_PORT", Kind: Modified, Old: "5432", New: "6543"}}, plan)
+
+	r.NoError(d.ToFile(path))
+
+	b, err := os.ReadFile(path)
+	r.NoError(err)
+	r.Equal(strings.Replace(testDocument, "5432", "6543", 1), string(b))
+
+	info, err := os.Stat(path)
+	r.NoError(err)
+	r.Equal(os.FileMode(0o640), info.Mode().Perm())
+
+	_, err = ReadDocument(filepath.Join(t.TempDir(), "missing"))
+	r.ErrorIs(err, os.ErrNotExist)
+}
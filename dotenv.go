@@ -0,0 +1,194 @@
+package envy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// dotenvLines decodes the entries among the lines of an env file, returning
+// lines of the same length in which each entry is rewritten as a plain
+// KEY=VALUE line holding its decoded value, so the rest of the parsing
+// works on one form and line numbers are preserved. The syntax is that of
+// the common dotenv libraries:
+//
+//   - blank lines and lines starting with # are kept as they are, so
+//     comments and directives still apply
+//   - an entry may start with "export " and may have white space around
+//     the "="; lines without "=" or with white space or control characters
+//     in the key are blanked
+//   - a value in single quotes or backticks is taken literally
+//   - a value in double quotes decodes \n, \r, \t, \", \\, and \$, keeping
+//     any other backslash
+//   - quoted values may span lines; the lines they take up after the first
+//     are blanked
+//   - an unquoted value is trimmed, and a # preceded by white space starts
+//     a comment
+//   - "unset KEY..." lines are kept, without any comment, to be applied as
+//     tombstones
+//
+// If escape is true, the decoded lines are to be interpolated, so the "$"
+// of single-quoted and backtick values and of \$ escapes is written as "$$"
+// to keep it literal.
+//
+// It returns an error for an unterminated quoted value or text after the
+// closing quote other than a comment. If skip is not nil, it is called
+// instead with each malformed entry, including the lines blanked for not
+// being entries, and the entry is skipped: an unterminated quote only
+// blanks the line it starts on, so it cannot swallow the rest of the file.
+// A quote type can fail to close only once per file, since any later
+// opening quote of the same type would have closed it, so recovering
+// stays linear.
+func dotenvLines(lines []string, escape bool, skip func(line int, key string, err error)) ([]string, error) {
+	out := make([]string, len(lines))
+
+	for i := 0; i < len(lines); i++ {
+		s := strings.TrimLeft(lines[i], " \t")
+		if s == "" || s[0] == '#' {
+			out[i] = lines[i]
+			continue
+		}
+
+		if keys, ok := unsetKeys(s); ok {
+			out[i] = "unset " + strings.Join(keys, " ")
+			continue
+		}
+
+		if rest, ok := strings.CutPrefix(s, "export"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			s = strings.TrimLeft(rest, " \t")
+		}
+
+		k, v, ok := strings.Cut(s, "=")
+		k = strings.TrimRight(k, " \t")
+		if !ok || k == "" || strings.ContainsAny(k, " \t") || strings.ContainsFunc(k, unicode.IsControl) {
+			if skip != nil {
+				skip(i+1, "", fmt.Errorf("not a KEY=VALUE entry: %q", s))
+			}
+			continue
+		}
+
+		// the white space before a comment is part of the raw value
+		raw := v
+		v = strings.TrimLeft(v, " \t")
+		if v == "" || (v[0] != '"' && v[0] != '\'' && v[0] != '`') {
+			out[i] = k + "=" + unquotedValue(raw)
+			continue
+		}
+
+		start := i
+		val, rest, end, err := quotedValue(lines, i, v, escape)
+		if err != nil && skip != nil {
+			skip(start+1, k, err)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", start+1, k, err)
+		}
+
+		if rest = strings.TrimLeft(rest, " \t"); rest != "" && rest[0] != '#' {
+			err := fmt.Errorf("unexpected %q after quoted value", rest)
+			if skip == nil {
+				return nil, fmt.Errorf("line %d: %s: %w", end+1, k, err)
+			}
+
+			skip(end+1, k, err)
+			i = end
+			continue
+		}
+
+		out[start] = k + "=" + val
+		i = end
+	}
+
+	return out, nil
+}
+
+// unquotedValue returns v without its comment and surrounding white space.
+func unquotedValue(v string) string {
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = v[:i]
+	}
+
+	if i := strings.Index(v, "\t#"); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimSpace(v)
+}
+
+// quotedValue decodes the quoted value starting at v, the value part of
+// lines[i], continuing onto later lines until the closing quote. It
+// returns the value, the text after the closing quote, and the index of
+// the line it is on. With escape, literal dollar signs are doubled as
+// described on dotenvLines.
+func quotedValue(lines []string, i int, v string, escape bool) (string, string, int, error) {
+	q := v[0]
+	v = v[1:]
+
+	var bb strings.Builder
+	for {
+		for j := 0; j < len(v); j++ {
+			c := v[j]
+			switch {
+			case c == q:
+				return bb.String(), v[j+1:], i, nil
+			case c == '$' && escape && q != '"':
+				bb.WriteString("$$")
+			case c == '\\' && q == '"' && escape && j+1 < len(v) && v[j+1] == '$':
+				j++
+				bb.WriteString("$$")
+			case c == '\\' && q == '"' && j+1 < len(v):
+				j++
+				switch v[j] {
+				case 'n':
+					bb.WriteByte('\n')
+				case 'r':
+					bb.WriteByte('\r')
+				case 't':
+					bb.WriteByte('\t')
+				case '"', '\\', '$':
+					bb.WriteByte(v[j])
+				default:
+					bb.WriteByte('\\')
+					bb.WriteByte(v[j])
+				}
+			default:
+				bb.WriteByte(c)
+			}
+		}
+
+		if i+1 >= len(lines) {
+			return "", "", i, fmt.Errorf("unterminated quoted value")
+		}
+
+		i++
+		v = lines[i]
+		bb.WriteByte('\n')
+	}
+}
+
+// lineEntry returns the key and value of a plain KEY=VALUE line, as
+// produced by dotenvLines. Comments are never entries.
+func lineEntry(line string) (string, string, bool) {
+	s := strings.TrimSpace(line)
+	if strings.HasPrefix(s, "#") {
+		return "", "", false
+	}
+
+	k, v, ok := strings.Cut(line, "=")
+	if !ok || !validKey(k) {
+		return "", "", false
+	}
+	return k, v, true
+}
+
+// fromLines builds an Env from plain KEY=VALUE lines, as produced by
+// dotenvLines, keeping each value exactly; later lines win.
+func fromLines(lines []string) *Env {
+	em := map[string]string{}
+	for _, line := range lines {
+		if k, v, ok := lineEntry(line); ok {
+			em[k] = v
+		}
+	}
+	return FromMap(em)
+}
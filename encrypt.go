@@ -0,0 +1,173 @@
+package envy
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EncryptedPrefix marks a value encrypted with EncryptValue. The rest of
+// the value is the base64 encoded AES-256-GCM nonce and ciphertext.
+const EncryptedPrefix = "enc:v1:"
+
+// ErrDecrypt is returned when an encrypted value cannot be decrypted,
+// for example because no KeyProvider is set, the key is wrong, or the
+// value was modified.
+var ErrDecrypt = errors.New("cannot decrypt value")
+
+// KeyProvider supplies the 32-byte AES-256 key used to decrypt values
+// marked with EncryptedPrefix. It is called on every read of an encrypted
+// value, so it may fetch the key from a KMS or keychain and cache it.
+type KeyProvider interface {
+	DataKey() ([]byte, error)
+}
+
+// StaticKey is a KeyProvider that always returns itself.
+type StaticKey []byte
+
+// DataKey implements KeyProvider.
+func (k StaticKey) DataKey() ([]byte, error) {
+	return k, nil
+}
+
+// EncryptValue encrypts plain with the 32-byte key and returns it marked
+// with EncryptedPrefix, ready to be stored in an env file next to
+// unencrypted values. Each call uses a fresh nonce.
+func EncryptValue(key []byte, plain string) (string, error) {
+	aead, err := valueAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	ct := aead.Seal(nonce, nonce, []byte(plain), nil)
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(ct), nil
+}
+
+// DecryptValue decrypts a value produced by EncryptValue with the same key.
+// Values without EncryptedPrefix are returned unchanged. It returns an
+// error wrapping ErrDecrypt if the value cannot be decrypted.
+func DecryptValue(key []byte, value string) (string, error) {
+	enc, ok := strings.CutPrefix(value, EncryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+
+	aead, err := valueAEAD(key)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+
+	ct, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+
+	if len(ct) < aead.NonceSize() {
+		return "", fmt.Errorf("%w: truncated", ErrDecrypt)
+	}
+
+	plain, err := aead.Open(nil, ct[:aead.NonceSize()], ct[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+	return string(plain), nil
+}
+
+// valueAEAD returns the AES-256-GCM cipher for key.
+func valueAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SetKeyProvider registers the KeyProvider used to decrypt values marked
+// with EncryptedPrefix, so a file can mix public and secret values while
+// Getenv returns plaintext. Environ, Raw, and the writers keep the
+// encrypted form, so the Env can be saved again without exposing secrets.
+// A nil KeyProvider removes the registered one. It returns an error for a
+// nil Env.
+func (e *Env) SetKeyProvider(p KeyProvider) error {
+	if e.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.keys = p
+	return nil
+}
+
+// Decrypted returns the value of key, decrypting it with the registered
+// KeyProvider if it is marked with EncryptedPrefix. Unlike Getenv, it
+// reports why a value could not be read: an error wrapping ErrDecrypt for
+// encrypted values, or the error of a resolver added with AddResolver. A
+// missing key returns an empty string and no error.
+func (e *Env) Decrypted(key string) (string, error) {
+	val, _, err := e.lookup(key)
+	return val, err
+}
+
+// lookup implements Getenv, Lookupenv, and Decrypted: it resolves key,
+// follows a reference to another Env if FollowRefs allows, then decrypts
+// its value. An encrypted value that cannot be decrypted is returned empty
+// with the error, and still reported as set.
+func (e *Env) lookup(key string) (string, bool, error) {
+	return e.lookupHops(key, 0)
+}
+
+// lookupHops is lookup for a key reached by following hops references.
+func (e *Env) lookupHops(key string, hops int) (string, bool, error) {
+	if e.IsNil() {
+		return "", false, nil
+	}
+
+	if err := e.resolve(key); err != nil {
+		return "", false, err
+	}
+
+	e.mu.RLock()
+	val, ok := e.envs[key]
+	p := e.keys
+	follow := e.followRefs
+	e.mu.RUnlock()
+
+	if follow && strings.HasPrefix(val, RefPrefix) {
+		val, err := deref(key, val, hops)
+		return val, true, err
+	}
+
+	if !strings.HasPrefix(val, EncryptedPrefix) {
+		return val, ok, nil
+	}
+
+	if p == nil {
+		return "", true, fmt.Errorf("%w: %s: no key provider", ErrDecrypt, key)
+	}
+
+	dk, err := p.DataKey()
+	if err != nil {
+		return "", true, fmt.Errorf("%w: %s: %w", ErrDecrypt, key, err)
+	}
+
+	plain, err := DecryptValue(dk, val)
+	if err != nil {
+		return "", true, fmt.Errorf("%s: %w", key, err)
+	}
+	return plain, true, nil
+}
@@ -0,0 +1,145 @@
+package envy
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EncryptValue(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	key := bytes.Repeat([]byte{1}, 32)
+
+	enc, err := EncryptValue(key, "s3cr3t")
+	r.NoError(err)
+	r.True(len(enc) > len(EncryptedPrefix))
+	r.NotContains(enc, "s3cr3t")
+
+	again, err := EncryptValue(key, "s3cr3t")
+	r.NoError(err)
+	r.NotEqual(enc, again)
+
+	tampered := []byte(enc)
+	tampered[len(EncryptedPrefix)+20] ^= 1
+
+	tcs := []struct {
+		name  string
+		key   []byte
+		value string
+		exp   string
+		err   bool
+	}{
+		{name: "encrypted", key: key, value: enc, exp: "s3cr3t"},
+		{name: "plain", key: key, value: "public", exp: "public"},
+		{name: "wrong key", key: bytes.Repeat([]byte{2}, 32), value: enc, err: true},
+		{name: "short key", key: key[:16], value: enc, err: true},
+		{name: "bad base64", key: key, value: EncryptedPrefix + "!!", err: true},
+		{name: "truncated", key: key, value: EncryptedPrefix + "AAAA", err: true},
+		{name: "tampered", key: key, value: string(tampered), err: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			act, err := DecryptValue(tc.key, tc.value)
+			if tc.err {
+				r.ErrorIs(err, ErrDecrypt)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, act)
+		})
+	}
+
+	_, err = EncryptValue(key[:16], "x")
+	r.Error(err)
+}
+
+func Test_Env_Decrypted(t *testing.T) {
+	t.Parallel()
+
+	key := bytes.Repeat([]byte{7}, 32)
+	enc, err := EncryptValue(key, "s3cr3t")
+	require.NoError(t, err)
+
+	tcs := []struct {
+		name string
+		keys KeyProvider
+		exp  string
+		err  bool
+	}{
+		{name: "static key", keys: StaticKey(key), exp: "s3cr3t"},
+		{name: "no provider", err: true},
+		{name: "wrong key", keys: StaticKey(bytes.Repeat([]byte{8}, 32)), err: true},
+		{name: "provider error", keys: failingKey{}, err: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			env := FromMap(map[string]string{"TOKEN": enc, "HOST": "example.com"})
+			r.NoError(env.SetKeyProvider(tc.keys))
+
+			r.Equal("example.com", env.Getenv("HOST"))
+			r.Equal(enc, env.Raw("TOKEN"))
+			r.Contains(env.Environ(), "TOKEN="+enc)
+
+			act, err := env.Decrypted("TOKEN")
+			if tc.err {
+				r.ErrorIs(err, ErrDecrypt)
+				r.Contains(err.Error(), "TOKEN")
+				r.Equal("", env.Getenv("TOKEN"))
+
+				val, ok := env.Lookupenv("TOKEN")
+				r.Equal("", val)
+				r.True(ok)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, act)
+			r.Equal(tc.exp, env.Getenv("TOKEN"))
+		})
+	}
+}
+
+func Test_Env_SetKeyProvider(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	var env *Env
+	r.Error(env.SetKeyProvider(StaticKey(nil)))
+
+	v, err := env.Decrypted("TOKEN")
+	r.NoError(err)
+	r.Equal("", v)
+
+	key := bytes.Repeat([]byte{3}, 32)
+	enc, err := EncryptValue(key, "s3cr3t")
+	r.NoError(err)
+
+	a := FromMap(map[string]string{"A": "a"})
+	r.NoError(a.SetKeyProvider(StaticKey(key)))
+
+	merged, err := FromMap(map[string]string{"TOKEN": enc}).Merge(a)
+	r.NoError(err)
+	r.Equal("s3cr3t", merged.Getenv("TOKEN"))
+
+	merged.Destroy()
+	r.Equal("", merged.Getenv("TOKEN"))
+}
+
+type failingKey struct{}
+
+func (failingKey) DataKey() ([]byte, error) {
+	return nil, errors.New("keychain locked")
+}
@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
 // Env stores environment variables in memory with thread-safe access. A nil
@@ -16,48 +17,104 @@ type Env struct {
 	// envs is a map that holds environment variables.
 	envs map[string]string
 	mu   sync.RWMutex
+
+	// rev counts the changes made since the Env was created.
+	rev uint64
+
+	// history holds the most recent changes, oldest first, for
+	// Snapshot and Rollback. It is only kept when historyLimit > 0.
+	history      []revision
+	historyLimit int
+
+	// subs are the change callbacks registered with Subscribe.
+	subs    map[uint64]func(Diff)
+	nextSub uint64
+
+	// succ is the Env the subscribers moved to when Load or Reload
+	// replaced this Env as the Default.
+	succ *Env
+
+	// process is the process environment as of New or the last Refresh.
+	// It is nil for Envs not created by New.
+	process map[string]string
+
+	// meta holds the optional metadata of entries. See Meta.
+	meta map[string]Meta
+
+	// keys decrypts values marked with EncryptedPrefix. See SetKeyProvider.
+	keys KeyProvider
+
+	// created is when the Env was created, the ModTime of every entry
+	// not in modified, which holds the keys changed since.
+	created  time.Time
+	modified map[string]time.Time
+
+	// resolvers are the fallback chains added with AddResolver.
+	resolvers map[string]*resolution
+
+	// tombstones are the keys marked deleted with Tombstone, which Merge
+	// removes from the layers below.
+	tombstones map[string]bool
+
+	// policy approves changes and exports. See SetPolicy.
+	policy Policy
+
+	// layers are the layers merged by Layered, lowest priority first,
+	// for Explain.
+	layers []layer
+
+	// followRefs makes reads follow RefPrefix values. See FollowRefs.
+	followRefs bool
+
+	// untrusted marks an Env holding input read with Sandboxed, which
+	// must never follow references.
+	untrusted bool
 }
 
 // Getenv returns the value of the environment variable named by key. It returns
 // an empty string when the key is not present or the Env is nil, mirroring
-// os.Getenv semantics.
+// os.Getenv semantics. A key with resolvers is resolved on its first read,
+// see AddResolver. Encrypted values are decrypted with the registered
+// KeyProvider; one that cannot be resolved or decrypted reads as empty. Use
+// Decrypted to see the error.
 func (e *Env) Getenv(key string) string {
-	if e.IsNil() {
-		return ""
-	}
-
-	e.mu.RLock()
-	defer e.mu.RUnlock()
+	val, _, _ := e.lookup(key)
+	return val
+}
 
-	return e.envs[key]
+// Lookupenv returns the value of the environment variable named by key and
+// whether it is set, mirroring os.LookupEnv, so that a key set to the empty
+// string can be told apart from a missing one. Values are resolved and
+// decrypted as by Getenv. It reports false for a nil Env.
+func (e *Env) Lookupenv(key string) (string, bool) {
+	val, ok, _ := e.lookup(key)
+	return val, ok
 }
 
-// Setenv sets the value of the environment variable named by key. It returns an
-// error if the Env or its backing map is nil.
+// Setenv sets the value of the environment variable named by key, notifying
+// subscribers if the value changed. It returns an error if the Env or its
+// backing map is nil, or if the Policy set with SetPolicy rejects the
+// change.
 func (e *Env) Setenv(key, value string) error {
 	if e.IsNil() {
 		return fmt.Errorf("nil env")
 	}
 
-	e.mu.Lock()
-	defer e.mu.Unlock()
-
-	e.envs[key] = value
-	return nil
+	_, err := e.update(map[string]string{key: value})
+	return err
 }
 
-// Unsetenv deletes the environment variable named by key. Removing a missing
-// key is a no-op. An error is returned if the Env or its backing map is nil.
+// Unsetenv deletes the environment variable named by key, notifying
+// subscribers if it was set. Removing a missing key is a no-op. An error is
+// returned if the Env or its backing map is nil, or if the Policy set with
+// SetPolicy rejects the change.
 func (e *Env) Unsetenv(key string) error {
 	if e.IsNil() {
 		return fmt.Errorf("nil env")
 	}
 
-	e.mu.Lock()
-	defer e.mu.Unlock()
-
-	delete(e.envs, key)
-	return nil
+	_, err := e.update(nil, key)
+	return err
 }
 
 // IsNil reports whether the receiver or its backing map is nil. This allows
@@ -73,55 +130,275 @@ func (e *Env) IsNil() bool {
 	return e.envs == nil
 }
 
+// EnvironOption configures Environ.
+type EnvironOption func(*environOptions)
+
+type environOptions struct {
+	natural bool
+}
+
+// NaturalOrder sorts the output of Environ by key with NaturalLess, so
+// numbered keys read in order (WORKER_2 before WORKER_10), instead of byte
+// by byte.
+func NaturalOrder(on bool) EnvironOption {
+	return func(o *environOptions) {
+		o.natural = on
+	}
+}
+
 // Environ returns a sorted slice of strings in the form "key=value" for every
 // variable stored in the Env. The slice is deterministic to make comparisons in
-// tests predictable.
-func (e *Env) Environ() []string {
+// tests predictable. Options such as NaturalOrder change the order. Pending
+// resolvers run first, see AddResolver. If the Policy rejects exporting the
+// Env, the slice is empty; Export reports why.
+func (e *Env) Environ(opts ...EnvironOption) []string {
 	if e.IsNil() {
 		return []string{}
 	}
 
-	e.mu.RLock()
-	defer e.mu.RUnlock()
+	var o environOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
 
-	envs := []string{}
-	for k, v := range e.envs {
-		envs = append(envs, k+"="+v)
+	// a chain that fails leaves its key as it is, as a read does
+	_ = e.ResolveAll()
+
+	ents, err := e.allowedEntries()
+	if err != nil {
+		return []string{}
+	}
+
+	if o.natural {
+		sort.SliceStable(ents, func(i, j int) bool {
+			return NaturalLess(ents[i].key, ents[j].key)
+		})
+	}
+
+	envs := make([]string, len(ents))
+	for i, ent := range ents {
+		envs[i] = ent.key + "=" + ent.value
 	}
 
-	sort.Strings(envs)
+	if !o.natural {
+		sort.Strings(envs)
+	}
 	return envs
 }
 
+// ExpandOption configures Expandenv.
+type ExpandOption func(*expandOptions)
+
+type expandOptions struct {
+	percent bool
+}
+
+// ExpandPercent enables (or disables) expansion of Windows-style %VAR%
+// references in addition to ${var} and $var. Unlike the shell syntax, a
+// %VAR% reference to an unknown key is left untouched, as cmd.exe does, and
+// keys are matched case-insensitively if there is no exact match. Pass
+// runtime.GOOS == "windows" to only enable it on Windows.
+func ExpandPercent(on bool) ExpandOption {
+	return func(o *expandOptions) {
+		o.percent = on
+	}
+}
+
 // Expandenv replaces ${var} or $var in the input string according to the
 // stored environment variables. Unknown keys are replaced with the empty
-// string. If the Env is nil, the input string is returned unchanged.
-func (e *Env) Expandenv(s string) string {
+// string. As in the shell, ${var-word} uses word if var is not set and
+// ${var:-word} also uses it if var is set to the empty string; word is not
+// expanded. If the Env is nil, the input string is returned unchanged.
+// Options such as ExpandPercent enable additional syntaxes.
+func (e *Env) Expandenv(s string, opts ...ExpandOption) string {
 	if e.IsNil() {
 		return s
 	}
 
+	var o expandOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	expand := func(s string) string {
+		return expandShell(s, func(key string) (string, bool) {
+			val, ok := e.envs[key]
+			return val, ok
+		})
+	}
+
+	if !o.percent {
+		return expand(s)
+	}
+
+	// split the input on %VAR% references so that values substituted
+	// for them are not themselves expanded as $var
+	var bb strings.Builder
+	for {
+		start := strings.IndexByte(s, '%')
+		if start < 0 {
+			break
+		}
+
+		end := strings.IndexByte(s[start+1:], '%')
+		if end < 0 {
+			break
+		}
+		end += start + 1
+
+		val, ok := e.lookupFold(s[start+1 : end])
+		if !ok {
+			// not a reference; keep the '%' and look for
+			// the next one, which may open a reference
+			bb.WriteString(expand(s[:start+1]))
+			s = s[start+1:]
+			continue
+		}
+
+		bb.WriteString(expand(s[:start]))
+		bb.WriteString(val)
+		s = s[end+1:]
+	}
+
+	bb.WriteString(expand(s))
+	return bb.String()
+}
+
+// ExpandWith replaces ${var} or $var in s as Expandenv does, looking each
+// key up in envs in order and using the first Env in which it is set, so
+// that application config can be layered over machine-level variables
+// without merging them first. A key set to the empty string in an earlier
+// Env hides later ones, as it would after a Merge. Nil Envs are skipped.
+func ExpandWith(s string, envs ...*Env) string {
+	return expandShell(s, func(key string) (string, bool) {
+		for _, e := range envs {
+			if val, ok := e.raw(key); ok {
+				return val, true
+			}
+		}
+		return "", false
+	})
+}
+
+// raw returns the stored value of key, without resolving or decrypting it.
+// It reports false for a nil Env.
+func (e *Env) raw(key string) (string, bool) {
+	if e.IsNil() {
+		return "", false
+	}
+
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
+	val, ok := e.envs[key]
+	return val, ok
+}
+
+// expandShell replaces ${var} and $var references in s with the values
+// returned by lookup, applying ${var-word} and ${var:-word} defaults.
+func expandShell(s string, lookup func(key string) (string, bool)) string {
 	return os.Expand(s, func(key string) string {
-		if val, ok := e.envs[key]; ok {
+		if val, ok := lookup(key); ok {
 			return val
 		}
-		return ""
+
+		name, word, op := splitDefault(key)
+		val, ok := lookup(name)
+		switch {
+		case op == "-" && !ok, op == ":-" && val == "":
+			return word
+		}
+		return val
 	})
 }
 
+// splitDefault splits a ${name-word} or ${name:-word} reference into its
+// parts. For other references op is empty and name is ref.
+func splitDefault(ref string) (name, word, op string) {
+	i := strings.IndexByte(ref, '-')
+	if i <= 0 {
+		return ref, "", ""
+	}
+
+	name, op = ref[:i], "-"
+	if n, ok := strings.CutSuffix(name, ":"); ok {
+		name, op = n, ":-"
+	}
+
+	if !isShellName(name) {
+		return ref, "", ""
+	}
+	return name, ref[i+1:], op
+}
+
+// isShellName reports whether s is a valid shell variable name.
+func isShellName(s string) bool {
+	if s == "" || ('0' <= s[0] && s[0] <= '9') {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '_' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupFold returns the value for key, falling back to a case-insensitive
+// match. The read lock must be held.
+func (e *Env) lookupFold(key string) (string, bool) {
+	if key == "" || strings.ContainsAny(key, "=") {
+		return "", false
+	}
+
+	if val, ok := e.envs[key]; ok {
+		return val, true
+	}
+
+	for k, v := range e.envs {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
 // Merge returns a new Env containing the receiver's variables
-// overridden by the variables from other. It returns an error
-// if either Env is nil.
+// overridden by the variables from other, including those other sets to
+// the empty string. The Meta of each entry comes from the Env its value
+// came from, so Source keeps reporting where a value was defined, and so
+// does its ModTime. The merged Env uses the receiver's KeyProvider, or
+// other's if the receiver has none. Keys other marked with Tombstone are
+// removed, and the merged Env keeps the tombstones of both, so they hide
+// keys in every layer below. It returns an error if either Env is nil.
 func (e *Env) Merge(other *Env) (*Env, error) {
+	merged, _, err := e.merge(other)
+	return merged, err
+}
+
+// MergeReport is Merge that also returns what the merge changed compared
+// to the receiver: Added for keys only in other, and Modified for keys
+// other overwrote with a different value. Keys other sets to the value
+// they already had are left out, so an empty Diff means the update changed
+// nothing. Only keys tombstoned by other are Removed.
+func (e *Env) MergeReport(other *Env) (*Env, Diff, error) {
+	return e.merge(other)
+}
+
+// merge implements Merge and MergeReport, reading both Envs once so the
+// Diff matches the merged Env.
+func (e *Env) merge(other *Env) (*Env, Diff, error) {
 	if e.IsNil() {
-		return nil, fmt.Errorf("cannot merge into nil env")
+		return nil, nil, fmt.Errorf("cannot merge into nil env")
 	}
 
 	if other.IsNil() {
-		return nil, fmt.Errorf("cannot merge from nil env")
+		return nil, nil, fmt.Errorf("cannot merge from nil env")
 	}
 
 	e.mu.RLock()
@@ -131,15 +408,52 @@ func (e *Env) Merge(other *Env) (*Env, error) {
 	defer other.mu.RUnlock()
 
 	em := map[string]string{}
+	meta := map[string]Meta{}
+	mod := map[string]time.Time{}
 	for k, v := range e.envs {
 		em[k] = v
+		mod[k] = e.modTime(k)
+		if m, ok := e.meta[k]; ok {
+			meta[k] = m
+		}
 	}
 
+	d := diffMaps(e.envs, other.envs)
 	for k, v := range other.envs {
 		em[k] = v
+		mod[k] = other.modTime(k)
+		delete(meta, k)
+		if m, ok := other.meta[k]; ok {
+			meta[k] = m
+		}
 	}
 
-	return FromMap(em), nil
+	// keys the other Env tombstoned are hidden, not just overridden
+	for k := range other.tombstones {
+		delete(em, k)
+		delete(meta, k)
+		delete(mod, k)
+	}
+
+	merged := FromMap(em)
+	merged.meta = meta
+	merged.modified = mod
+	merged.tombstones = mergeTombstones(e, other)
+	merged.keys = e.keys
+	if merged.keys == nil {
+		merged.keys = other.keys
+	}
+	merged.untrusted = e.untrusted || other.untrusted
+	merged.followRefs = e.followRefs && !merged.untrusted
+
+	// keys only in the receiver are kept, not removed, unless tombstoned
+	changes := Diff{}
+	for _, c := range d {
+		if c.Kind != Removed || other.tombstones[c.Key] {
+			changes = append(changes, c)
+		}
+	}
+	return merged, changes, nil
 }
 
 // IsSet reports whether key is present in the Env. It returns false for a nil Env.
@@ -158,3 +472,30 @@ func (e *Env) IsSet(key string) bool {
 func (e *Env) String() string {
 	return strings.Join(e.Environ(), ";")
 }
+
+// entry is a single key/value pair.
+type entry struct {
+	key   string
+	value string
+}
+
+// entries returns a snapshot of the Env's variables sorted by key. It returns
+// nil for a nil Env.
+func (e *Env) entries() []entry {
+	if e.IsNil() {
+		return nil
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	ents := make([]entry, 0, len(e.envs))
+	for k, v := range e.envs {
+		ents = append(ents, entry{key: k, value: v})
+	}
+
+	sort.Slice(ents, func(i, j int) bool {
+		return ents[i].key < ents[j].key
+	})
+	return ents
+}
@@ -43,6 +43,34 @@ func Test_Env_Gentenv(t *testing.T) {
 
 }
 
+func Test_Env_Lookupenv(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  *Env
+		key  string
+		exp  string
+		ok   bool
+	}{
+		{name: "set", env: FromMap(map[string]string{"KEY": "VALUE"}), key: "KEY", exp: "VALUE", ok: true},
+		{name: "set to empty", env: FromMap(map[string]string{"KEY": ""}), key: "KEY", ok: true},
+		{name: "unset", env: Zero(), key: "KEY"},
+		{name: "nil env", key: "KEY"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			val, ok := tc.env.Lookupenv(tc.key)
+			r.Equal(tc.exp, val)
+			r.Equal(tc.ok, ok)
+		})
+	}
+}
+
 func Test_Env_Setenv(t *testing.T) {
 	t.Parallel()
 
@@ -262,6 +290,30 @@ func Test_Env_Expandenv(t *testing.T) {
 			input: "Value is $KEY",
 			exp:   "Value is ",
 		},
+		{
+			name:  "default for unset",
+			env:   FromMap(map[string]string{"EMPTY": ""}),
+			input: "${MISSING-dflt} ${EMPTY-dflt}|",
+			exp:   "dflt |",
+		},
+		{
+			name:  "default for unset or empty",
+			env:   FromMap(map[string]string{"EMPTY": "", "KEY": "VALUE"}),
+			input: "${MISSING:-a} ${EMPTY:-b} ${KEY:-c}",
+			exp:   "a b VALUE",
+		},
+		{
+			name:  "default with dashes",
+			env:   Zero(),
+			input: "${PORT:-80-90}",
+			exp:   "80-90",
+		},
+		{
+			name:  "dashed key",
+			env:   FromMap(map[string]string{"A-B": "dash"}),
+			input: "${A-B} ${C-D}",
+			exp:   "dash D",
+		},
 	}
 
 	for _, tc := range tcs {
@@ -275,6 +327,112 @@ func Test_Env_Expandenv(t *testing.T) {
 	}
 }
 
+func Test_ExpandWith(t *testing.T) {
+	t.Parallel()
+
+	app := FromMap(map[string]string{"NAME": "app", "EMPTY": ""})
+	machine := FromMap(map[string]string{"NAME": "host", "HOME": "/home/app", "EMPTY": "full"})
+
+	tcs := []struct {
+		name  string
+		envs  []*Env
+		input string
+		exp   string
+	}{
+		{name: "no envs", input: "$NAME|", exp: "|"},
+		{name: "first wins", envs: []*Env{app, machine}, input: "$NAME", exp: "app"},
+		{name: "order", envs: []*Env{machine, app}, input: "$NAME", exp: "host"},
+		{name: "falls through", envs: []*Env{app, machine}, input: "${NAME}:$HOME", exp: "app:/home/app"},
+		{name: "empty hides later", envs: []*Env{app, machine}, input: "[$EMPTY]", exp: "[]"},
+		{name: "defaults", envs: []*Env{app, machine}, input: "${MISSING-a} ${EMPTY:-b} ${HOME:-c}", exp: "a b /home/app"},
+		{name: "nil skipped", envs: []*Env{nil, machine}, input: "$NAME", exp: "host"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			r.Equal(tc.exp, ExpandWith(tc.input, tc.envs...))
+		})
+	}
+}
+
+func Test_Env_Expandenv_Percent(t *testing.T) {
+	t.Parallel()
+
+	env := FromMap(map[string]string{
+		"USERPROFILE": `C:\Users\me`,
+		"KEY":         "VALUE",
+		"DOLLAR":      "$KEY",
+	})
+
+	tcs := []struct {
+		name  string
+		input string
+		opts  []ExpandOption
+		exp   string
+	}{
+		{
+			name:  "disabled by default",
+			input: `%USERPROFILE%\bin`,
+			exp:   `%USERPROFILE%\bin`,
+		},
+		{
+			name:  "disabled explicitly",
+			input: `%USERPROFILE%\bin`,
+			opts:  []ExpandOption{ExpandPercent(false)},
+			exp:   `%USERPROFILE%\bin`,
+		},
+		{
+			name:  "percent reference",
+			input: `%USERPROFILE%\bin`,
+			opts:  []ExpandOption{ExpandPercent(true)},
+			exp:   `C:\Users\me\bin`,
+		},
+		{
+			name:  "case insensitive",
+			input: `%UserProfile%`,
+			opts:  []ExpandOption{ExpandPercent(true)},
+			exp:   `C:\Users\me`,
+		},
+		{
+			name:  "mixed with dollar syntax",
+			input: "%KEY% and $KEY",
+			opts:  []ExpandOption{ExpandPercent(true)},
+			exp:   "VALUE and VALUE",
+		},
+		{
+			name:  "unknown reference left untouched",
+			input: "%MISSING% 100%",
+			opts:  []ExpandOption{ExpandPercent(true)},
+			exp:   "%MISSING% 100%",
+		},
+		{
+			name:  "stray percent before reference",
+			input: "100% of %KEY%",
+			opts:  []ExpandOption{ExpandPercent(true)},
+			exp:   "100% of VALUE",
+		},
+		{
+			name:  "substituted values are not re-expanded",
+			input: "%DOLLAR%",
+			opts:  []ExpandOption{ExpandPercent(true)},
+			exp:   "$KEY",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			got := env.Expandenv(tc.input, tc.opts...)
+			r.Equal(tc.exp, got)
+		})
+	}
+}
+
 func Test_Env_Merge(t *testing.T) {
 	t.Parallel()
 
@@ -291,6 +449,12 @@ func Test_Env_Merge(t *testing.T) {
 			env2: FromMap(map[string]string{"KEY2": "NEWVALUE2", "KEY3": "VALUE3"}),
 			exp:  []string{"KEY1=VALUE1", "KEY2=NEWVALUE2", "KEY3=VALUE3"},
 		},
+		{
+			name: "empty values override",
+			env1: FromMap(map[string]string{"KEY": "VALUE"}),
+			env2: FromMap(map[string]string{"KEY": ""}),
+			exp:  []string{"KEY="},
+		},
 		{
 			name: "merge into nil env",
 			env1: nil,
@@ -323,6 +487,64 @@ func Test_Env_Merge(t *testing.T) {
 	}
 }
 
+func Test_Env_MergeReport(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env1 *Env
+		env2 *Env
+		exp  []string
+		diff Diff
+		err  bool
+	}{
+		{
+			name: "added, overwritten, and untouched",
+			env1: FromMap(map[string]string{"KEEP": "1", "SAME": "2", "OLD": "3"}),
+			env2: FromMap(map[string]string{"SAME": "2", "OLD": "new", "ADD": "4"}),
+			exp:  []string{"ADD=4", "KEEP=1", "OLD=new", "SAME=2"},
+			diff: Diff{
+				{Key: "ADD", Kind: Added, New: "4"},
+				{Key: "OLD", Kind: Modified, Old: "3", New: "new"},
+			},
+		},
+		{
+			name: "no changes",
+			env1: FromMap(map[string]string{"A": "1"}),
+			env2: FromMap(map[string]string{"A": "1"}),
+			exp:  []string{"A=1"},
+			diff: Diff{},
+		},
+		{
+			name: "merge into nil env",
+			env2: FromMap(map[string]string{"KEY": "VALUE"}),
+			err:  true,
+		},
+		{
+			name: "merge from nil env",
+			env1: FromMap(map[string]string{"KEY": "VALUE"}),
+			err:  true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			merged, d, err := tc.env1.MergeReport(tc.env2)
+			if tc.err {
+				r.Error(err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, merged.Environ())
+			r.Equal(tc.diff, d)
+		})
+	}
+}
+
 func Test_Env_IsSet(t *testing.T) {
 	t.Parallel()
 
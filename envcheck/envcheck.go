@@ -0,0 +1,172 @@
+// Package envcheck defines an analyzer that reports reads of environment
+// variables that are not declared in the project's .env.example file,
+// catching misspelled variable names at build time.
+//
+// Reads are calls to os.Getenv, os.LookupEnv, the Getenv, Lookupenv,
+// IsSet, and typed getter (GetInt, GetBoolOr, ...) methods of *envy.Env,
+// and envy.Get[T], whose key is a constant string. The declarations file
+// is found by searching upward from each package's directory for a file
+// named .env.example, or given with the -declared flag.
+package envcheck
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/markbates/envy"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// DeclaredFile is the file name searched for when -declared is not set.
+const DeclaredFile = ".env.example"
+
+// Analyzer reports undeclared environment variable reads.
+var Analyzer = &analysis.Analyzer{
+	Name:     "envcheck",
+	Doc:      "report reads of environment variables not declared in .env.example",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var declared string
+
+func init() {
+	Analyzer.Flags.StringVar(&declared, "declared", "", "path to the file declaring environment variables (default: nearest "+DeclaredFile+")")
+}
+
+// readFuncs maps package paths to the functions, or methods of that
+// package's types, that read a variable named by their first argument.
+var readFuncs = map[string]map[string]bool{
+	"os": {"Getenv": true, "LookupEnv": true},
+	"github.com/markbates/envy": {
+		"Getenv": true, "Lookupenv": true, "IsSet": true,
+		"GetInt": true, "GetIntOr": true, "GetInt64": true, "GetInt64Or": true,
+		"GetBool": true, "GetBoolOr": true, "GetFloat64": true, "GetFloat64Or": true,
+		"GetDuration": true, "GetDurationOr": true,
+	},
+}
+
+// genericReadFuncs are the generic functions in readFuncs' form whose
+// second argument names the variable, the first being the Env.
+var genericReadFuncs = map[string]map[string]bool{
+	"github.com/markbates/envy": {"Get": true},
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	if len(pass.Files) == 0 {
+		return nil, nil
+	}
+
+	path := declared
+	if path == "" {
+		dir := filepath.Dir(pass.Fset.File(pass.Files[0].Pos()).Name())
+		path = findUp(dir, DeclaredFile)
+	}
+
+	// without a declarations file there is nothing to check against
+	if path == "" {
+		return nil, nil
+	}
+
+	env, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	ins.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		arg, ok := keyArg(pass, n.(*ast.CallExpr))
+		if !ok {
+			return
+		}
+
+		tv, ok := pass.TypesInfo.Types[arg]
+		if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+			return
+		}
+
+		key := constant.StringVal(tv.Value)
+		if env.IsSet(key) {
+			return
+		}
+
+		pass.Reportf(arg.Pos(), "environment variable %q is not declared in %s", key, filepath.Base(path))
+	})
+
+	return nil, nil
+}
+
+// keyArg returns the argument naming the variable read by call, if call is
+// one of readFuncs or genericReadFuncs.
+func keyArg(pass *analysis.Pass, call *ast.CallExpr) (ast.Expr, bool) {
+	// generic calls, envy.Get[int](env, "PORT"), index the callee
+	fun, funcs, arg := call.Fun, readFuncs, 0
+	switch x := fun.(type) {
+	case *ast.IndexExpr:
+		fun, funcs, arg = x.X, genericReadFuncs, 1
+	case *ast.IndexListExpr:
+		fun, funcs, arg = x.X, genericReadFuncs, 1
+	}
+
+	sel, ok := fun.(*ast.SelectorExpr)
+	if !ok || len(call.Args) <= arg {
+		return nil, false
+	}
+
+	fn, ok := pass.TypesInfo.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || !funcs[fn.Pkg().Path()][fn.Name()] {
+		return nil, false
+	}
+	return call.Args[arg], true
+}
+
+// findUp returns the path of name in dir or its nearest ancestor, or "".
+func findUp(dir, name string) string {
+	for {
+		p := filepath.Join(dir, name)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = map[string]*envy.Env{}
+)
+
+// load reads and caches the declarations file at path.
+func load(path string) (*envy.Env, error) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	if env, ok := cache[path]; ok {
+		return env, nil
+	}
+
+	env, err := envy.FromFile(os.DirFS(filepath.Dir(path)), filepath.Base(path))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("declarations file %s: %w", path, err)
+		}
+		return nil, err
+	}
+
+	cache[path] = env
+	return env, nil
+}
@@ -0,0 +1,25 @@
+package a
+
+import (
+	"os"
+
+	"github.com/markbates/envy"
+)
+
+const debugKey = "DEBUG"
+
+func f(env *envy.Env, key string) {
+	_ = os.Getenv("PORT")
+	_, _ = os.LookupEnv("DATABSE_URL") // want `environment variable "DATABSE_URL" is not declared in .env.example`
+	_ = os.Getenv(debugKey)            // want `environment variable "DEBUG" is not declared in .env.example`
+	_ = os.Getenv(key)
+
+	_ = env.Getenv("DATABASE_URL")
+	_ = env.IsSet("MISSING") // want `environment variable "MISSING" is not declared in .env.example`
+	_ = env.GetIntOr("PORT", 3000)
+	_, _ = env.GetDuration("TIMEOUT") // want `environment variable "TIMEOUT" is not declared in .env.example`
+	_ = env.Setenv("UNDECLARED_WRITE", "ok")
+
+	_, _ = envy.Get[int](env, "PORT")
+	_, _ = envy.Get[[]string](env, "HOSTS") // want `environment variable "HOSTS" is not declared in .env.example`
+}
@@ -0,0 +1,16 @@
+// Package envy is a stub of the real package for analyzer tests.
+package envy
+
+type Env struct{}
+
+func (e *Env) Getenv(key string) string              { return "" }
+func (e *Env) IsSet(key string) bool                 { return false }
+func (e *Env) Lookupenv(key string) (string, bool)   { return "", false }
+func (e *Env) GetIntOr(key string, def int) int      { return def }
+func (e *Env) GetDuration(key string) (int64, error) { return 0, nil }
+func (e *Env) Setenv(key, value string) error        { return nil }
+
+func Get[T any](e *Env, key string) (T, error) {
+	var zero T
+	return zero, nil
+}
@@ -1,6 +1,48 @@
 // Package envy provides a concurrency-safe, in-memory view of environment
 // variables that mirrors the behavior of the standard library's environment
 // helpers without mutating the process state.
+//
+// Building with the envy_minimal tag leaves out the parts of the package
+// that need more than the standard library or that run programs, FromYAML,
+// FromKubernetesManifest, and Supervise, so the package can be embedded in
+// libraries and seccomp-confined processes without pulling in YAML,
+// os/exec, or the network. Remote providers and stores live in their own
+// packages, under providers and store, and are never compiled or linked
+// unless imported. They are part of this module, not modules of their own,
+// so its go.mod still requires their dependencies, such as bbolt, and the
+// go command may download them to resolve the module graph.
+//
+// # Env file syntax
+//
+// FromFile reads a file line by line; a quoted value is the only thing
+// that may span lines. In EBNF, with ws being spaces and tabs:
+//
+//	line      = blank | comment | directive | unset | entry | other .
+//	blank     = [ ws ] .
+//	comment   = [ ws ] "#" { char } .
+//	directive = [ ws ] ( if | "#else" | "#endif" ) [ ws ] .
+//	if        = "#if" ws ( "!" key | key [ ( "=" | "!=" ) word ] ) .
+//	word      = { char-not-ws } .
+//	unset     = [ ws ] "unset" ws key { ws key } [ ws comment ] .
+//	entry     = [ ws ] [ "export" ws ] key [ ws ] "=" [ ws ] value .
+//	key       = char-not-ws-eq-ctl { char-not-ws-eq-ctl } .
+//	value     = unquoted | quoted [ ws ] [ comment ] .
+//	unquoted  = { char } .                 (trimmed; ends at ws "#")
+//	quoted    = "'" { any-but-' } "'"      (literal)
+//	          | "`" { any-but-` } "`"      (literal)
+//	          | '"' { any-but-" | escape } '"' .
+//	escape    = "\" ( "n" | "r" | "t" | '"' | "\" | "$" ) .
+//
+// A backslash before any other character in double quotes is kept. A
+// comment is a directive only if it matches directive exactly, so prose
+// such as "#if unset, the port is 8080" stays a comment. Every line
+// matching "other", such as text without "=" or a key containing white
+// space, is ignored. An entry is malformed if its quoted value is never
+// closed or is followed by anything but a comment; FromFile fails on the
+// first one unless SkipMalformed is given, in which case it skips and
+// reports each of them, so no input can make one entry swallow the next.
+// With the Interpolate option, unquoted and double-quoted values are then
+// expanded as described there.
 package envy
 
 import (
@@ -10,8 +52,11 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"maps"
 	"os"
+	"runtime"
 	"strings"
+	"time"
 )
 
 // Zero returns a new Env with no environment variables set. It is useful when
@@ -23,28 +68,51 @@ func Zero() *Env {
 
 // New returns an Env populated with the current process's environment
 // variables. Future calls to Setenv/Unsetenv modify the Env only and do not
-// change the process environment.
+// change the process environment. Use Refresh to pick up later changes to
+// the process environment.
 func New() *Env {
-	return FromSlice(os.Environ())
+	e := FromSlice(os.Environ())
+	e.process = maps.Clone(e.envs)
+	e.meta = processMeta(e.envs)
+	return e
 }
 
 // FromSlice builds an Env from a slice of strings in the form "KEY=VALUE".
-// Malformed entries are ignored. Later entries with the same key overwrite
-// earlier ones, matching the standard environment semantics.
-func FromSlice(envs []string) *Env {
+// Each entry is split at its first "=", so values keep any further "="
+// and their white space exactly, and may be empty; white space around the
+// key is ignored. Blank entries and comments starting with # or // are
+// skipped. Malformed entries, those without "=" or with an empty key, are
+// ignored; pass WithDiagnostics or ReportTo, the only ParseOptions that
+// apply, to be told about them with CodeMalformed, the entry's 1-based
+// index as the Line. Later entries with the same key overwrite earlier
+// ones, matching the standard environment semantics.
+func FromSlice(envs []string, opts ...ParseOption) *Env {
+	var po parseOptions
+	for _, opt := range opts {
+		opt(&po)
+	}
+
 	em := map[string]string{}
-	for _, env := range envs {
-		// trim spaces
-		env = strings.TrimSpace(env)
+	for i, env := range envs {
+		s := strings.TrimSpace(env)
+		if s == "" || strings.HasPrefix(s, "#") || strings.HasPrefix(s, "//") {
+			continue
+		}
 
-		// split into key and value
-		parts := strings.SplitN(env, "=", 2)
-		if len(parts) != 2 {
+		k, v, ok := strings.Cut(env, "=")
+		k = strings.TrimSpace(k)
+		if !ok || !validKey(k) {
+			if po.diagnose != nil {
+				po.diagnose(Diagnostic{
+					Line:    i + 1,
+					Code:    CodeMalformed,
+					Message: fmt.Sprintf("malformed entry %q", s),
+				})
+			}
 			continue
 		}
 
-		// set key/value
-		em[parts[0]] = parts[1]
+		em[k] = v
 	}
 
 	return FromMap(em)
@@ -59,29 +127,54 @@ func FromMap(envs map[string]string) *Env {
 	}
 
 	for k := range envs {
-		// trim spaces
-		s := strings.TrimSpace(k)
-
-		// ignore empty keys, comments, and keys with '='
-		if s == "" || strings.Contains(s, "=") || strings.HasPrefix(s, "//") {
+		if !validKey(k) {
 			delete(envs, k)
 			continue
 		}
 	}
 
 	return &Env{
-		envs: envs,
+		envs:    envs,
+		created: time.Now(),
 	}
 }
 
-// FromReader reads environment entries from r, splitting on sep and trimming
-// surrounding whitespace. It returns an error for a nil reader or scanner
-// failures (including invalid UTF-8).
-func FromReader(r io.Reader, sep byte) (*Env, error) {
+// FromReader reads environment entries from r, splitting on sep and
+// trimming surrounding whitespace. With Interpolate, references to other
+// keys are expanded, and entries are parsed as by FromSlice. Pass Systemd
+// to read r as a systemd EnvironmentFile= instead. Of the other
+// ParseOptions, only Interpolate, InterpolateFrom, WithDiagnostics or
+// ReportTo, and, with Systemd, OnDuplicate apply. It returns an error for a
+// nil reader, scanner failures (including invalid UTF-8), or a reference
+// cycle.
+func FromReader(r io.Reader, sep byte, opts ...ParseOption) (*Env, error) {
 	if r == nil {
 		return nil, fmt.Errorf("nil reader")
 	}
 
+	var po parseOptions
+	for _, opt := range opts {
+		opt(&po)
+	}
+
+	if po.systemd {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+
+		lines, err := systemdLines("", string(b), po)
+		if err != nil {
+			return nil, malformedError{err}
+		}
+
+		lines, err = dedupe("", lines, po)
+		if err != nil {
+			return nil, malformedError{err}
+		}
+		return fromLines(lines), nil
+	}
+
 	buf := bufio.NewScanner(r)
 
 	buf.Split(func(data []byte, eof bool) (int, []byte, error) {
@@ -117,12 +210,43 @@ func FromReader(r io.Reader, sep byte) (*Env, error) {
 		return nil, err
 	}
 
-	return FromSlice(envs), nil
+	if !po.interpolate {
+		return FromSlice(envs, opts...), nil
+	}
+
+	expanded, err := interpolate(envs, po.interpolateFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	e := FromSlice(expanded, opts...)
+	e.keepRaw(envs, expanded)
+	return e, nil
 }
 
 // FromFile reads newline-separated environment entries from the provided
-// filesystem path. It returns an error for a nil fs.FS or any read failure.
-func FromFile(cab fs.FS, path string) (e *Env, err error) {
+// filesystem path, in the syntax of the common dotenv libraries: # comments,
+// optional "export " prefixes, white space around "=", single, double, and
+// backtick quoting, escapes in double quotes, and quoted values spanning
+// lines. Unquoted values are trimmed and end at a # preceded by white
+// space. With Interpolate, references to other keys are expanded. An "unset
+// KEY" line marks KEY with a Tombstone, so the file hides it when merged
+// over other layers. Sections may be guarded by "#if KEY=value" ... "#else"
+// ... "#endif" directives, evaluated against the final values of the
+// entries above them and the process environment. The file, line, and
+// preceding comment of each entry are recorded as its Meta. Keys set more
+// than once are resolved by the DuplicatePolicy given with OnDuplicate,
+// LastWins by default. A key with a GOOS suffix, such as PATH.windows or
+// PATH.darwin, is set as the plain key on the matching operating system,
+// taking precedence over the plain entry, and ignored elsewhere. Values can
+// be post-processed per key with Transform. Pass Sandboxed for files from
+// untrusted sources, and Systemd to read the file as systemd reads an
+// EnvironmentFile=. The syntax is described in full in the package
+// documentation. It returns an error for a nil fs.FS or any read failure;
+// the error wraps fs.ErrNotExist if the file does not exist, and
+// ErrMalformed if it cannot be parsed, for example because of unbalanced
+// directives or, without SkipMalformed, an unterminated quote.
+func FromFile(cab fs.FS, path string, opts ...ParseOption) (e *Env, err error) {
 	if cab == nil {
 		return nil, fmt.Errorf("nil fs.FS")
 	}
@@ -145,8 +269,89 @@ func FromFile(cab fs.FS, path string) (e *Env, err error) {
 		err = errors.Join(err, cerr)
 	}()
 
+	return parseFile(path, f, opts)
+}
+
+// FromFileOptional is FromFile for files that may or may not exist, such
+// as .env.local: if path does not exist in cab, it returns an empty Env.
+// Any other failure, including a malformed file, is returned.
+func FromFileOptional(cab fs.FS, path string, opts ...ParseOption) (*Env, error) {
+	e, err := FromFile(cab, path, opts...)
+	if errors.Is(err, fs.ErrNotExist) && !errors.Is(err, ErrMalformed) {
+		var po parseOptions
+		for _, opt := range opts {
+			opt(&po)
+		}
+
+		if po.stats != nil {
+			po.stats.Missing = append(po.stats.Missing, path)
+		}
+		return Zero(), nil
+	}
+	return e, err
+}
+
+// parseFile parses the env file read from r, named path.
+func parseFile(path string, r io.Reader, opts []ParseOption) (*Env, error) {
+	var po parseOptions
+	for _, opt := range opts {
+		opt(&po)
+	}
+
+	start := time.Now()
+
+	if po.sandbox != nil {
+		lines, err := readSandboxed(r, *po.sandbox)
+		if errors.Is(err, ErrRejected) {
+			return nil, malformedError{fmt.Errorf("%s: %w", path, err)}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		if po.systemd {
+			lines, err = systemdLines(path, strings.Join(lines, "\n"), po)
+		} else {
+			lines, err = dotenvLines(lines, false, po.skip(path))
+		}
+		if err != nil {
+			return nil, malformedError{fmt.Errorf("%s: %w", path, err)}
+		}
+
+		// escapes such as \r and quoted values spanning lines decode to
+		// characters the raw lines could not hold
+		for i, line := range lines {
+			k, v, ok := lineEntry(line)
+			if !ok {
+				continue
+			}
+
+			if err := checkUntrusted(v); err != nil {
+				return nil, malformedError{fmt.Errorf("%s: %w: line %d: %s: %w", path, ErrRejected, i+1, k, err)}
+			}
+		}
+
+		n := len(lines)
+		parsed := lines
+
+		lines, err = dedupe(path, lines, po)
+		if err != nil {
+			return nil, malformedError{fmt.Errorf("%s: %w", path, err)}
+		}
+
+		e := fromLines(lines)
+		e.meta = fileMeta(path, lines)
+		e.untrusted = true
+		if err := e.transform(path, po.transforms); err != nil {
+			return nil, err
+		}
+
+		po.stats.record(path, n, parsed, start)
+		return e, nil
+	}
+
 	lines := []string{}
-	buf := bufio.NewScanner(f)
+	buf := bufio.NewScanner(r)
 	for buf.Scan() {
 		lines = append(lines, buf.Text())
 	}
@@ -155,7 +360,75 @@ func FromFile(cab fs.FS, path string) (e *Env, err error) {
 		return nil, err
 	}
 
-	return FromSlice(lines), nil
+	n := len(lines)
+
+	if po.systemd {
+		lines, err := systemdLines(path, strings.Join(lines, "\n"), po)
+		if err != nil {
+			return nil, malformedError{fmt.Errorf("%s: %w", path, err)}
+		}
+
+		parsed := lines
+
+		lines, err = dedupe(path, lines, po)
+		if err != nil {
+			return nil, malformedError{fmt.Errorf("%s: %w", path, err)}
+		}
+
+		e := fromLines(lines)
+		e.meta = fileMeta(path, lines)
+		if err := e.transform(path, po.transforms); err != nil {
+			return nil, err
+		}
+
+		po.stats.record(path, n, parsed, start)
+		return e, nil
+	}
+
+	lines, err := dotenvLines(lines, po.interpolate, po.skip(path))
+	if err != nil {
+		return nil, malformedError{fmt.Errorf("%s: %w", path, err)}
+	}
+
+	var expand func([]string) ([]string, error)
+	if po.interpolate {
+		expand = func(kept []string) ([]string, error) {
+			return interpolate(kept, po.interpolateFrom)
+		}
+	}
+
+	lines, err = conditional(lines, expand)
+	if err != nil {
+		return nil, malformedError{fmt.Errorf("%s: %w", path, err)}
+	}
+
+	parsed := lines
+
+	lines, err = dedupe(path, lines, po)
+	if err != nil {
+		return nil, malformedError{fmt.Errorf("%s: %w", path, err)}
+	}
+
+	written := lines
+	if po.interpolate {
+		if lines, err = interpolate(lines, po.interpolateFrom); err != nil {
+			return nil, malformedError{fmt.Errorf("%s: %w", path, err)}
+		}
+	}
+
+	e := fromLines(lines)
+	e.meta = fileMeta(path, lines)
+	if po.interpolate {
+		e.keepRaw(written, lines)
+	}
+	e.applyUnset(lines)
+	e.resolveGOOS(runtime.GOOS)
+	if err := e.transform(path, po.transforms); err != nil {
+		return nil, err
+	}
+
+	po.stats.record(path, n, parsed, start)
+	return e, nil
 }
 
 // With calls fn to produce an Env and merges the result into env. It returns
@@ -172,3 +445,13 @@ func With(env *Env, fn func() (*Env, error)) (*Env, error) {
 
 	return env.Merge(n)
 }
+
+// validKey reports whether k can be stored in an Env. Empty keys, comments,
+// and keys containing '=' are not allowed.
+func validKey(k string) bool {
+	// trim spaces
+	s := strings.TrimSpace(k)
+
+	// ignore empty keys, comments, and keys with '='
+	return s != "" && !strings.Contains(s, "=") && !strings.HasPrefix(s, "//")
+}
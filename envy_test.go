@@ -2,12 +2,15 @@ package envy
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
+	"os/exec"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/require"
 )
@@ -32,6 +35,7 @@ func Test_FromSlice(t *testing.T) {
 		name  string
 		input []string
 		exp   []string
+		diags []int
 	}{
 		{
 			name:  "well formed entries",
@@ -42,6 +46,17 @@ func Test_FromSlice(t *testing.T) {
 			name:  "malformed entries ignored",
 			input: bad,
 			exp:   []string{"KEY1=VALUE1", "KEY2=VALUE2", "KEY3=valueWith=equals", "KEY4=KEY5-=baz"},
+			diags: []int{1, 4},
+		},
+		{
+			name:  "values kept whole",
+			input: []string{"GREETING=hello big world", "PAD=  x  ", "EMPTY=", "  KEY =v", "URL=a=b&c=d"},
+			exp:   []string{"EMPTY=", "GREETING=hello big world", "KEY=v", "PAD=  x  ", "URL=a=b&c=d"},
+		},
+		{
+			name:  "comments skipped",
+			input: []string{"# KEY=v", "  #KEY=v", "A=#not a comment"},
+			exp:   []string{"A=#not a comment"},
 		},
 	}
 
@@ -51,6 +66,14 @@ func Test_FromSlice(t *testing.T) {
 			r := require.New(t)
 			got := FromSlice(tc.input)
 			r.Equal(tc.exp, got.Environ())
+
+			var diags []int
+			FromSlice(tc.input, WithDiagnostics(func(d Diagnostic) {
+				r.Equal(CodeMalformed, d.Code)
+				r.Contains(d.Message, tc.input[d.Line-1])
+				diags = append(diags, d.Line)
+			}))
+			r.Equal(tc.diags, diags)
 		})
 	}
 
@@ -257,6 +280,62 @@ func Test_FromFile(t *testing.T) {
 	}
 }
 
+func Test_FromFile_errors(t *testing.T) {
+	t.Parallel()
+
+	cab := fstest.MapFS{
+		"bad.env":      {Data: []byte("A='open\n")},
+		"unbal.env":    {Data: []byte("#if A=1\nB=2\n")},
+		"rejected.env": {Data: []byte("#if A=1\n#endif\n")},
+	}
+
+	tcs := []struct {
+		name      string
+		path      string
+		opts      []ParseOption
+		missing   bool
+		malformed bool
+	}{
+		{name: "missing", path: "nope.env", missing: true},
+		{name: "unterminated quote", path: "bad.env", malformed: true},
+		{name: "unbalanced directive", path: "unbal.env", malformed: true},
+		{name: "rejected", path: "rejected.env", opts: []ParseOption{Sandboxed(Limits{})}, malformed: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			_, err := FromFile(cab, tc.path, tc.opts...)
+			r.Error(err)
+			r.Equal(tc.missing, errors.Is(err, fs.ErrNotExist))
+			r.Equal(tc.malformed, errors.Is(err, ErrMalformed))
+			r.Contains(err.Error(), tc.path)
+
+			env, err := FromFileOptional(cab, tc.path, tc.opts...)
+			if tc.missing {
+				r.NoError(err)
+				r.Equal(0, env.Len())
+				return
+			}
+			r.ErrorIs(err, ErrMalformed)
+		})
+	}
+}
+
+func Test_FromFileOptional(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env, err := FromFileOptional(os.DirFS("testdata"), "valid.env")
+	r.NoError(err)
+	r.Equal([]string{"KEY1=VALUE1", "KEY2=VALUE2"}, env.Environ())
+
+	_, err = FromFileOptional(nil, "valid.env")
+	r.Error(err)
+}
+
 func Test_With(t *testing.T) {
 	t.Parallel()
 
@@ -361,3 +440,55 @@ func ExampleWith() {
 	// KEY1: VALUE1
 
 }
+
+func Test_minimal(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	if testing.Short() {
+		t.Skip("skipping go list in short mode")
+	}
+
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	out, err := exec.Command(gobin, "list", "-deps", "-tags", "envy_minimal", ".").CombinedOutput()
+	r.NoError(err, string(out))
+
+	for _, pkg := range strings.Fields(string(out)) {
+		r.NotEqual("os/exec", pkg)
+		r.NotEqual("net", pkg)
+		r.NotEqual("net/http", pkg)
+
+		first, _, _ := strings.Cut(pkg, "/")
+		if strings.Contains(first, ".") {
+			r.Equal("github.com/markbates/envy", pkg)
+		}
+	}
+}
+
+func FuzzFromFile(f *testing.F) {
+	for _, s := range dotenvCorpus {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, in string) {
+		r := require.New(t)
+
+		cab := fstest.MapFS{".env": &fstest.MapFile{Data: []byte(in)}}
+		env, err := FromFile(cab, ".env", SkipMalformed(true), Interpolate(false))
+		if strings.Contains(in, "#") {
+			// unbalanced directives are still errors
+			return
+		}
+		r.NoError(err)
+
+		for _, kv := range env.Environ() {
+			k, _, _ := strings.Cut(kv, "=")
+			r.NotEmpty(k)
+			r.False(strings.ContainsAny(k, " \t\n"), kv)
+		}
+	})
+}
@@ -0,0 +1,210 @@
+// Package envyhttp serves an envy.Env over an authenticated HTTP API, so a
+// single process that resolves configuration can share it with co-located
+// processes that cannot use the providers themselves.
+//
+// The API is:
+//
+//	GET /env         all variables as a JSON object
+//	GET /env/{key}   a single value as text/plain; 404 if unset
+//	GET /watch       a text/event-stream of changes
+//
+// Every endpoint serves the same view of the Env: the keys it exports, with
+// the values Lookupenv reads, so encrypted values are decrypted and
+// references followed. If the Env's Policy rejects the export, requests
+// fail with 403 Forbidden.
+//
+// The watch stream starts with an "env" event holding the full environment
+// as JSON, followed by a "change" event holding an envy.Diff as JSON for
+// every change to the view. If a client falls behind, the changes it
+// missed are sent as one.
+//
+// Every request must carry an "Authorization: Bearer <token>" header.
+package envyhttp
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/markbates/envy"
+)
+
+// Handler serves an Env. It implements http.Handler.
+type Handler struct {
+	env   *envy.Env
+	token string
+	mux   *http.ServeMux
+}
+
+// New returns a Handler serving env to clients presenting token. It returns
+// an error for a nil env or an empty token.
+func New(env *envy.Env, token string) (*Handler, error) {
+	if env.IsNil() {
+		return nil, fmt.Errorf("nil env")
+	}
+
+	if token == "" {
+		return nil, fmt.Errorf("empty token")
+	}
+
+	h := &Handler{
+		env:   env,
+		token: token,
+		mux:   http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET /env", h.getEnv)
+	h.mux.HandleFunc("GET /env/{key}", h.getKey)
+	h.mux.HandleFunc("GET /watch", h.watch)
+
+	return h, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="envy"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) authorized(r *http.Request) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(h.token)) == 1
+}
+
+func (h *Handler) getEnv(w http.ResponseWriter, r *http.Request) {
+	m, err := view(h.env)
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(m)
+}
+
+func (h *Handler) getKey(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.env.Export(); err != nil {
+		serveError(w, err)
+		return
+	}
+
+	val, ok := h.env.Lookupenv(r.PathValue("key"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.WriteString(w, val)
+}
+
+func (h *Handler) watch(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// changes are read back through the view, so a client that falls
+	// behind gets the changes it missed in one event
+	changed := make(chan struct{}, 1)
+
+	cancel, err := h.env.Subscribe(func(envy.Diff) {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cancel()
+
+	cur, err := view(h.env)
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if err := writeEvent(w, "env", cur); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+		}
+
+		next, err := view(h.env)
+		if err != nil {
+			return
+		}
+
+		d := envy.Compare(envy.FromMap(cur), envy.FromMap(next))
+		if len(d) == 0 {
+			continue
+		}
+
+		if err := writeEvent(w, "change", d); err != nil {
+			return
+		}
+		flusher.Flush()
+		cur = next
+	}
+}
+
+// writeEvent writes a single server-sent event with a JSON payload.
+func writeEvent(w io.Writer, name string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, b)
+	return err
+}
+
+// view returns the variables env exports, with their values as Lookupenv
+// reads them.
+func view(env *envy.Env) (map[string]string, error) {
+	vars, err := env.Export()
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]string{}
+	for _, kv := range vars {
+		k, _, _ := strings.Cut(kv, "=")
+		m[k], _ = env.Lookupenv(k)
+	}
+	return m, nil
+}
+
+// serveError replies with err, as 403 Forbidden if it is a rejection by
+// the Env's Policy.
+func serveError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	if errors.Is(err, envy.ErrPolicy) {
+		code = http.StatusForbidden
+	}
+	http.Error(w, err.Error(), code)
+}
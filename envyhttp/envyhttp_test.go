@@ -0,0 +1,175 @@
+package envyhttp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/markbates/envy"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_New(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	_, err := New(nil, "token")
+	r.Error(err)
+
+	_, err = New(envy.Zero(), "")
+	r.Error(err)
+}
+
+func Test_Handler(t *testing.T) {
+	t.Parallel()
+
+	env := envy.FromMap(map[string]string{"KEY": "VALUE"})
+	h, err := New(env, "secret")
+	require.NoError(t, err)
+
+	tcs := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+		body   string
+	}{
+		{
+			name:   "missing token",
+			path:   "/env",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "wrong token",
+			path:   "/env",
+			token:  "nope",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "all",
+			path:   "/env",
+			token:  "secret",
+			status: http.StatusOK,
+			body:   `{"KEY":"VALUE"}` + "\n",
+		},
+		{
+			name:   "key",
+			path:   "/env/KEY",
+			token:  "secret",
+			status: http.StatusOK,
+			body:   "VALUE",
+		},
+		{
+			name:   "missing key",
+			path:   "/env/MISSING",
+			token:  "secret",
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+
+			res := httptest.NewRecorder()
+			h.ServeHTTP(res, req)
+
+			r.Equal(tc.status, res.Code)
+			if tc.body != "" {
+				r.Equal(tc.body, res.Body.String())
+			}
+		})
+	}
+}
+
+func Test_Handler_view(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	keys := envy.StaticKey("0123456789abcdef0123456789abcdef")
+	enc, err := envy.EncryptValue(keys, "s3cr3t")
+	r.NoError(err)
+
+	env := envy.FromMap(map[string]string{"KEY": enc})
+	r.NoError(env.SetKeyProvider(keys))
+
+	h, err := New(env, "secret")
+	r.NoError(err)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+
+		res := httptest.NewRecorder()
+		h.ServeHTTP(res, req)
+		return res
+	}
+
+	// both endpoints serve the decrypted value
+	r.Equal(`{"KEY":"s3cr3t"}`+"\n", get("/env").Body.String())
+	r.Equal("s3cr3t", get("/env/KEY").Body.String())
+
+	r.NoError(env.SetPolicy(envy.PolicyFunc(func(envy.PolicyInput) error {
+		return errors.New("no")
+	})))
+	r.Equal(http.StatusForbidden, get("/env").Code)
+	r.Equal(http.StatusForbidden, get("/env/KEY").Code)
+	r.Equal(http.StatusForbidden, get("/watch").Code)
+}
+
+func Test_Handler_Watch(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := envy.FromMap(map[string]string{"KEY": "VALUE"})
+	h, err := New(env, "secret")
+	r.NoError(err)
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/watch", nil)
+	r.NoError(err)
+	req.Header.Set("Authorization", "Bearer secret")
+
+	res, err := srv.Client().Do(req)
+	r.NoError(err)
+	defer res.Body.Close()
+
+	r.Equal("text/event-stream", res.Header.Get("Content-Type"))
+
+	br := bufio.NewReader(res.Body)
+	readEvent := func() string {
+		var lines []string
+		for {
+			line, err := br.ReadString('\n')
+			r.NoError(err)
+			if line == "\n" {
+				return strings.Join(lines, "")
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	r.Equal("event: env\ndata: {\"KEY\":\"VALUE\"}\n", readEvent())
+
+	r.NoError(env.Setenv("KEY", "NEW"))
+	r.Equal("event: change\ndata: [{\"key\":\"KEY\",\"kind\":\"modified\",\"old\":\"VALUE\",\"new\":\"NEW\"}]\n", readEvent())
+
+	cancel()
+	_, _ = io.Copy(io.Discard, br)
+}
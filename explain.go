@@ -0,0 +1,276 @@
+package envy
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+)
+
+// The priorities of the usual layers of a configuration, for Layered:
+// command line flags override the environment, which overrides files.
+const (
+	PriorityFile  = 10
+	PriorityEnv   = 20
+	PriorityFlags = 30
+)
+
+// Layer is one source of values merged by Layered, such as command line
+// flags, the process environment, or a file.
+type Layer struct {
+	// Name identifies the layer in explanations, such as "flags" or
+	// ".env.production".
+	Name string
+	// Priority ranks the layer: layers with a higher priority win, and of
+	// layers with the same priority, the later one wins.
+	Priority int
+	// Env holds the layer's values, and its tombstones, which hide the
+	// key in the layers below.
+	Env *Env
+}
+
+// layer is the snapshot of a Layer that an Env built by Layered keeps to
+// explain its values. Its maps are never changed once made.
+type layer struct {
+	name       string
+	priority   int
+	envs       map[string]string
+	meta       map[string]Meta
+	tombstones map[string]bool
+}
+
+// Layered merges layers by priority, as Merge would merge them from the
+// lowest up, and returns the result, which remembers every layer's values
+// so that Explain can tell which layer each key came from and which it
+// overrode. Later changes to the layers do not affect it. It returns an
+// error for a layer without a Name or with a nil Env.
+//
+//	env, err := envy.Layered(
+//		envy.Layer{Name: ".env", Priority: envy.PriorityFile, Env: file},
+//		envy.Layer{Name: "environment", Priority: envy.PriorityEnv, Env: envy.New()},
+//		envy.Layer{Name: "flags", Priority: envy.PriorityFlags, Env: flags},
+//	)
+func Layered(layers ...Layer) (*Env, error) {
+	for i, l := range layers {
+		if l.Name == "" {
+			return nil, fmt.Errorf("layer %d: empty name", i)
+		}
+
+		if l.Env.IsNil() {
+			return nil, fmt.Errorf("%s: nil env", l.Name)
+		}
+	}
+
+	sorted := append([]Layer(nil), layers...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+
+	env := Zero()
+	snaps := make([]layer, 0, len(sorted))
+	for _, l := range sorted {
+		var err error
+		if env, err = env.Merge(l.Env); err != nil {
+			return nil, fmt.Errorf("%s: %w", l.Name, err)
+		}
+
+		l.Env.mu.RLock()
+		snaps = append(snaps, layer{
+			name:       l.Name,
+			priority:   l.Priority,
+			envs:       maps.Clone(l.Env.envs),
+			meta:       maps.Clone(l.Env.meta),
+			tombstones: maps.Clone(l.Env.tombstones),
+		})
+		l.Env.mu.RUnlock()
+	}
+
+	env.layers = snaps
+	return env, nil
+}
+
+// dropLayerKeys returns layers without the values of keys. The layers are
+// copied, not changed, as they may be shared.
+func dropLayerKeys(layers []layer, keys []string) []layer {
+	out := make([]layer, len(layers))
+	for i, l := range layers {
+		l.envs = maps.Clone(l.envs)
+		l.meta = maps.Clone(l.meta)
+		for _, k := range keys {
+			delete(l.envs, k)
+			delete(l.meta, k)
+		}
+		out[i] = l
+	}
+	return out
+}
+
+// Candidate is a layer that sets or unsets a key, in an Explanation.
+type Candidate struct {
+	Layer    string `json:"layer"`
+	Priority int    `json:"priority"`
+	// Value is the value the layer sets.
+	Value string `json:"value,omitempty"`
+	// Unset is true if the layer hides the key with a Tombstone.
+	Unset bool `json:"unset,omitempty"`
+	// Source is where the layer got the value, as reported by Source,
+	// such as ".env line 3" or "flag -port".
+	Source string `json:"source,omitempty"`
+}
+
+// Explanation tells why a key of an Env has its value. See Explain.
+type Explanation struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+	Set   bool   `json:"set"`
+	// Winner is the layer the value came from, or "" if it did not come
+	// from a layer of Layered.
+	Winner string `json:"winner,omitempty"`
+	// Reason says why, such as "set by flags (priority 30), overriding
+	// environment (priority 20)".
+	Reason string `json:"reason"`
+	// Candidates lists the layers that set or unset the key, the winner
+	// first and then in the order they lost.
+	Candidates []Candidate `json:"candidates,omitempty"`
+}
+
+func (x Explanation) String() string {
+	var bb strings.Builder
+	if x.Set {
+		fmt.Fprintf(&bb, "%s=%s: %s", x.Key, x.Value, x.Reason)
+	} else {
+		fmt.Fprintf(&bb, "%s: %s", x.Key, x.Reason)
+	}
+
+	for _, c := range x.Candidates {
+		fmt.Fprintf(&bb, "\n  %s (priority %d): ", c.Layer, c.Priority)
+		if c.Unset {
+			bb.WriteString("unset")
+		} else {
+			fmt.Fprintf(&bb, "%s=%s", x.Key, c.Value)
+		}
+
+		if c.Source != "" {
+			fmt.Fprintf(&bb, " from %s", c.Source)
+		}
+	}
+	return bb.String()
+}
+
+// Redact returns a copy of x in which the values are replaced with
+// Redacted if sensitive returns true for the key. Empty values are left
+// empty. If sensitive is nil, IsSensitive is used.
+func (x Explanation) Redact(sensitive func(key string) bool) Explanation {
+	if sensitive == nil {
+		sensitive = IsSensitive
+	}
+
+	if !sensitive(x.Key) {
+		return x
+	}
+
+	x.Value = redact(x.Value)
+	x.Candidates = append([]Candidate(nil), x.Candidates...)
+	for i := range x.Candidates {
+		x.Candidates[i].Value = redact(x.Candidates[i].Value)
+	}
+	return x
+}
+
+// Explain tells why key has its value, or is not set, answering questions
+// such as "why is PORT 8080 in prod?" in one call. For an Env built by
+// Layered, it names the layer that won, the layers it overrode, and where
+// each got its value; if the value was changed after the layers were
+// merged, it says so. For any other Env it reports the key's Source.
+// Values are as stored; use Explanation.Redact before logging it.
+func (e *Env) Explain(key string) Explanation {
+	x := Explanation{Key: key}
+	if e.IsNil() {
+		x.Reason = "not set"
+		return x
+	}
+
+	e.mu.RLock()
+	x.Value, x.Set = e.envs[key]
+	layers := e.layers
+	e.mu.RUnlock()
+
+	for i := len(layers) - 1; i >= 0; i-- {
+		l := layers[i]
+		c := Candidate{Layer: l.name, Priority: l.priority}
+
+		v, ok := l.envs[key]
+		switch {
+		case ok:
+			c.Value = v
+			c.Source = metaSource(l.meta[key])
+		case l.tombstones[key]:
+			c.Unset = true
+		default:
+			continue
+		}
+		x.Candidates = append(x.Candidates, c)
+	}
+
+	source := e.Source(key)
+	switch {
+	case len(x.Candidates) > 0 && x.Candidates[0].Unset == !x.Set && x.Candidates[0].Value == x.Value:
+		win := x.Candidates[0]
+		x.Winner = win.Layer
+
+		verb := "set"
+		if win.Unset {
+			verb = "unset"
+		}
+		x.Reason = fmt.Sprintf("%s by %s (priority %d)", verb, win.Layer, win.Priority)
+
+		var lost []string
+		for _, c := range x.Candidates[1:] {
+			lost = append(lost, fmt.Sprintf("%s (priority %d)", c.Layer, c.Priority))
+		}
+		if len(lost) > 0 {
+			x.Reason += ", overriding " + strings.Join(lost, ", ")
+		}
+	case len(layers) > 0 && x.Set:
+		x.Reason = "changed after the layers were merged"
+		if source != "" {
+			x.Reason += ", by " + source
+		}
+	case len(layers) > 0 && len(x.Candidates) > 0:
+		x.Reason = "unset after the layers were merged"
+	case len(layers) > 0:
+		x.Reason = "not set by any layer"
+	case x.Set && source != "":
+		x.Reason = "set by " + source
+	case x.Set:
+		x.Reason = "set, origin unknown"
+	default:
+		x.Reason = "not set"
+	}
+	return x
+}
+
+// FromFlags returns an Env holding the flags of fs that were set on the
+// command line, for the top layer of Layered. Each flag is keyed by its
+// name upper-cased, with '-' and '.' turned into '_', so -db.port sets
+// DB_PORT, and its Meta names the flag, such as "flag -db.port". Flags
+// left at their defaults are not included, so they do not override lower
+// layers. It returns an error for a nil FlagSet.
+func FromFlags(fs *flag.FlagSet) (*Env, error) {
+	if fs == nil {
+		return nil, fmt.Errorf("nil flag.FlagSet")
+	}
+
+	em := map[string]string{}
+	meta := map[string]Meta{}
+	fs.Visit(func(f *flag.Flag) {
+		k := flatKey("", f.Name, "_")
+		em[k] = f.Value.String()
+		meta[k] = Meta{Loader: "flag -" + f.Name}
+	})
+
+	e := FromMap(em)
+	e.meta = meta
+	return e, nil
+}
@@ -0,0 +1,207 @@
+package envy
+
+import (
+	"flag"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Layered_Explain(t *testing.T) {
+	t.Parallel()
+
+	file, err := FromFile(fstest.MapFS{".env": &fstest.MapFile{Data: []byte("PORT=80\nHOST=localhost\nDEBUG=true\nunset LEGACY\n")}}, ".env")
+	require.NoError(t, err)
+
+	process := FromMap(map[string]string{"PORT": "3000", "LEGACY": "1", "API_TOKEN": "t0k"})
+
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Int("port", 0, "")
+	fs.String("db.host", "db", "")
+	require.NoError(t, fs.Parse([]string{"-port", "8080"}))
+
+	flags, err := FromFlags(fs)
+	require.NoError(t, err)
+
+	// given out of order, to be sorted by priority
+	env, err := Layered(
+		Layer{Name: "flags", Priority: PriorityFlags, Env: flags},
+		Layer{Name: ".env", Priority: PriorityFile, Env: file},
+		Layer{Name: "environment", Priority: PriorityEnv, Env: process},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{"API_TOKEN=t0k", "DEBUG=true", "HOST=localhost", "LEGACY=1", "PORT=8080"}, env.Environ())
+
+	// later changes to a layer are not seen
+	require.NoError(t, file.Setenv("HOST", "changed"))
+	require.NoError(t, env.Setenv("DEBUG", "false"))
+	require.NoError(t, env.Unsetenv("API_TOKEN"))
+
+	tcs := []struct {
+		key    string
+		winner string
+		exp    string
+	}{
+		{
+			key:    "PORT",
+			winner: "flags",
+			exp: "PORT=8080: set by flags (priority 30), overriding environment (priority 20), .env (priority 10)" +
+				"\n  flags (priority 30): PORT=8080 from flag -port" +
+				"\n  environment (priority 20): PORT=3000" +
+				"\n  .env (priority 10): PORT=80 from .env line 1",
+		},
+		{
+			key:    "HOST",
+			winner: ".env",
+			exp:    "HOST=localhost: set by .env (priority 10)\n  .env (priority 10): HOST=localhost from .env line 2",
+		},
+		{
+			key:    "LEGACY",
+			winner: "environment",
+			exp:    "LEGACY=1: set by environment (priority 20), overriding .env (priority 10)\n  environment (priority 20): LEGACY=1\n  .env (priority 10): unset",
+		},
+		{
+			key: "API_TOKEN",
+			exp: "API_TOKEN: unset after the layers were merged\n  environment (priority 20): API_TOKEN=t0k",
+		},
+		{
+			key: "DEBUG",
+			exp: "DEBUG=false: changed after the layers were merged\n  .env (priority 10): DEBUG=true from .env line 3",
+		},
+		{
+			key: "DB_HOST",
+			exp: "DB_HOST: not set by any layer",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			x := env.Explain(tc.key)
+			r.Equal(tc.winner, x.Winner)
+			r.Equal(tc.exp, x.String())
+		})
+	}
+}
+
+func Test_Explain_unset(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	lower := FromMap(map[string]string{"A": "1"})
+	upper := Zero()
+	r.NoError(upper.Tombstone("A"))
+
+	env, err := Layered(Layer{Name: "base", Env: lower}, Layer{Name: "override", Env: upper})
+	r.NoError(err)
+
+	x := env.Explain("A")
+	r.False(x.Set)
+	r.Equal("override", x.Winner)
+	r.Equal("A: unset by override (priority 0), overriding base (priority 0)\n  override (priority 0): unset\n  base (priority 0): A=1", x.String())
+}
+
+func Test_Explain_plain(t *testing.T) {
+	t.Parallel()
+
+	env, err := FromFile(fstest.MapFS{".env": &fstest.MapFile{Data: []byte("A=1\n")}}, ".env")
+	require.NoError(t, err)
+	require.NoError(t, env.Setenv("B", "2"))
+
+	tcs := []struct {
+		name string
+		env  *Env
+		key  string
+		exp  string
+	}{
+		{name: "file", env: env, key: "A", exp: "A=1: set by .env line 1"},
+		{name: "unknown", env: env, key: "B", exp: "B=2: set, origin unknown"},
+		{name: "missing", env: env, key: "C", exp: "C: not set"},
+		{name: "nil", env: nil, key: "A", exp: "A: not set"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			x := tc.env.Explain(tc.key)
+			r.Empty(x.Winner)
+			r.Empty(x.Candidates)
+			r.Equal(tc.exp, x.String())
+		})
+	}
+}
+
+func Test_Explanation_Redact(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env, err := Layered(
+		Layer{Name: "file", Env: FromMap(map[string]string{"API_TOKEN": "old", "PORT": "1"})},
+		Layer{Name: "env", Env: FromMap(map[string]string{"API_TOKEN": "new"})},
+	)
+	r.NoError(err)
+
+	x := env.Explain("API_TOKEN")
+	red := x.Redact(nil)
+	r.Equal(Redacted, red.Value)
+	r.Equal(Redacted, red.Candidates[1].Value)
+	r.Equal("old", x.Candidates[1].Value)
+
+	r.Equal(env.Explain("PORT"), env.Explain("PORT").Redact(nil))
+}
+
+func Test_Layered_errors(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	_, err := Layered(Layer{Env: Zero()})
+	r.Error(err)
+
+	_, err = Layered(Layer{Name: "nil"})
+	r.Error(err)
+
+	_, err = FromFlags(nil)
+	r.Error(err)
+}
+
+func Test_Explain_Wipe(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env, err := Layered(Layer{Name: "file", Env: FromMap(map[string]string{"SECRET": "s", "A": "1"})})
+	r.NoError(err)
+
+	child, cleanup := env.Child()
+	defer cleanup()
+
+	r.NoError(env.Wipe("SECRET"))
+	r.Empty(env.Explain("SECRET").Candidates)
+	r.Equal("file", env.Explain("A").Winner)
+
+	// the child's layers are its own
+	r.Equal("file", child.Explain("SECRET").Winner)
+}
+
+func Test_Explain_Wipe_hidden(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	upper := Zero()
+	r.NoError(upper.Tombstone("SECRET"))
+
+	env, err := Layered(
+		Layer{Name: "file", Priority: 1, Env: FromMap(map[string]string{"SECRET": "hunter2"})},
+		Layer{Name: "override", Priority: 2, Env: upper},
+	)
+	r.NoError(err)
+	r.False(env.IsSet("SECRET"))
+	r.Contains(env.Explain("SECRET").String(), "hunter2")
+
+	r.NoError(env.Wipe("SECRET"))
+	r.NotContains(env.Explain("SECRET").String(), "hunter2")
+}
@@ -0,0 +1,175 @@
+package envy
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// FileOption configures ToFile.
+type FileOption func(*fileOptions)
+
+type fileOptions struct {
+	lock    bool
+	timeout time.Duration
+	backups int
+	dryRun  *Diff
+}
+
+// WithLock makes ToFile hold the advisory lock for the file (see LockFile)
+// while writing, retrying for up to timeout if another process holds it.
+func WithLock(timeout time.Duration) FileOption {
+	return func(o *fileOptions) {
+		o.lock = true
+		o.timeout = timeout
+	}
+}
+
+// WithBackups makes ToFile keep up to n previous versions of the file,
+// named path.bak.1 (the most recent) through path.bak.n.
+func WithBackups(n int) FileOption {
+	return func(o *fileOptions) {
+		o.backups = n
+	}
+}
+
+// DryRun makes ToFile store in *d the changes it would make to the
+// variables in path, compared with the file's current contents, and return
+// without writing anything. A missing file counts as empty.
+func DryRun(d *Diff) FileOption {
+	return func(o *fileOptions) {
+		o.dryRun = d
+	}
+}
+
+// ToFile writes the Env to path in the same format as WriteTo, which
+// FromFile reads back unchanged. The data is written to a temporary file in
+// the same directory, synced, and renamed over path, so a crash mid-write
+// never leaves a truncated file behind. An existing file keeps its
+// permissions; a new one is created with mode 0600. It returns an error,
+// before touching path, for a key WriteTo cannot write.
+func (e *Env) ToFile(path string, opts ...FileOption) error {
+	b, err := e.dotenv()
+	if err != nil {
+		return err
+	}
+
+	read := func(path string) (*Env, error) {
+		return FromFile(os.DirFS(filepath.Dir(path)), filepath.Base(path))
+	}
+	return writeFile(path, e, func() []byte { return b }, read, opts)
+}
+
+// WriteFile is ToFile, named for symmetry with os.WriteFile and WriteTo.
+func (e *Env) WriteFile(path string, opts ...FileOption) error {
+	return e.ToFile(path, opts...)
+}
+
+// writeFile atomically writes the output of data to path, applying opts.
+// For DryRun, read loads the existing file to compare with next, the Env
+// the file holds once written.
+func writeFile(path string, next *Env, data func() []byte, read func(path string) (*Env, error), opts []FileOption) (err error) {
+	if path == "" {
+		return fmt.Errorf("empty path")
+	}
+
+	var o fileOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.backups < 0 {
+		return fmt.Errorf("negative backup count %d", o.backups)
+	}
+
+	if o.lock {
+		unlock, err := LockFile(path, o.timeout)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			if uerr := unlock(); err == nil {
+				err = uerr
+			}
+		}()
+	}
+
+	mode := fs.FileMode(0o600)
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		mode = info.Mode().Perm()
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	if o.dryRun != nil {
+		var cur *Env
+		if info != nil {
+			if cur, err = read(path); err != nil {
+				return err
+			}
+		}
+
+		*o.dryRun = Compare(cur, next)
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+
+	// on any failure below, remove the temp file; after a successful
+	// rename this is a harmless no-op
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data())
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err != nil {
+		return err
+	}
+
+	if info != nil && o.backups > 0 {
+		if err := rotateBackups(path, o.backups); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// rotateBackups shifts path.bak.1..n-1 up by one, dropping path.bak.n, and
+// copies path to path.bak.1. path itself is left in place.
+func rotateBackups(path string, n int) error {
+	bak := func(i int) string {
+		return path + ".bak." + strconv.Itoa(i)
+	}
+
+	for i := n - 1; i >= 1; i-- {
+		err := os.Rename(bak(i), bak(i+1))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(bak(1), b, 0o600)
+}
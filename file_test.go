@@ -0,0 +1,144 @@
+package envy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_ToFile(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	dir := t.TempDir()
+	env := FromMap(map[string]string{"KEY2": "VALUE2", "KEY1": "VALUE1"})
+
+	path := filepath.Join(dir, ".env")
+	r.NoError(env.ToFile(path))
+
+	b, err := os.ReadFile(path)
+	r.NoError(err)
+	r.Equal("KEY1=VALUE1\nKEY2=VALUE2\n", string(b))
+
+	got, err := FromFile(os.DirFS(dir), ".env")
+	r.NoError(err)
+	r.Equal(env.Environ(), got.Environ())
+
+	// locked writes wait for, or give up on, other writers
+	release, err := LockFile(path, 0)
+	r.NoError(err)
+	r.True(errors.Is(env.ToFile(path, WithLock(0)), ErrLocked))
+	r.NoError(release())
+	r.NoError(env.ToFile(path, WithLock(time.Second)))
+
+	r.Error(env.ToFile(""))
+}
+
+func Test_Env_WriteFile(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	dir := t.TempDir()
+	env := FromMap(map[string]string{"CERT": "-----BEGIN-----\nabc\n-----END-----", "PASS": "p#ss $ecret", "PLAIN": "x"})
+
+	path := filepath.Join(dir, ".env")
+	r.NoError(env.WriteFile(path))
+
+	b, err := os.ReadFile(path)
+	r.NoError(err)
+	r.Equal("CERT=\"-----BEGIN-----\\nabc\\n-----END-----\"\nPASS=\"p#ss \\$ecret\"\nPLAIN=x\n", string(b))
+
+	got, err := FromFile(os.DirFS(dir), ".env")
+	r.NoError(err)
+	r.Equal(env.Environ(), got.Environ())
+
+	// nothing is written for a key that cannot be
+	bad := FromMap(map[string]string{"A B": "1"})
+	r.Error(bad.WriteFile(path))
+
+	b2, err := os.ReadFile(path)
+	r.NoError(err)
+	r.Equal(b, b2)
+}
+
+func Test_Env_ToFile_DryRun(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	env := FromMap(map[string]string{"A": "1", "B": "2"})
+
+	var d Diff
+	r.NoError(env.ToFile(path, DryRun(&d)))
+	r.Equal(Diff{{Key: "A", Kind: Added, New: "1"}, {Key: "B", Kind: Added, New: "2"}}, d)
+
+	_, err := os.Stat(path)
+	r.True(errors.Is(err, os.ErrNotExist))
+
+	r.NoError(os.WriteFile(path, []byte("A=1\nB=3\nC=4\n"), 0o600))
+	r.NoError(env.ToFile(path, DryRun(&d)))
+	r.Equal(Diff{{Key: "B", Kind: Modified, Old: "3", New: "2"}, {Key: "C", Kind: Removed, Old: "4"}}, d)
+
+	b, err := os.ReadFile(path)
+	r.NoError(err)
+	r.Equal("A=1\nB=3\nC=4\n", string(b))
+}
+
+func Test_Env_ToFile_Backups(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+
+	for i := 1; i <= 4; i++ {
+		env := FromMap(map[string]string{"N": strconv.Itoa(i)})
+		r.NoError(env.ToFile(path, WithBackups(2)))
+	}
+
+	read := func(name string) string {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		r.NoError(err)
+		return string(b)
+	}
+
+	r.Equal("N=4\n", read(".env"))
+	r.Equal("N=3\n", read(".env.bak.1"))
+	r.Equal("N=2\n", read(".env.bak.2"))
+
+	_, err := os.Stat(filepath.Join(dir, ".env.bak.3"))
+	r.True(errors.Is(err, os.ErrNotExist))
+
+	// no temp files are left behind
+	ents, err := os.ReadDir(dir)
+	r.NoError(err)
+	r.Len(ents, 3)
+
+	r.Error(Zero().ToFile(path, WithBackups(-1)))
+}
+
+func Test_Env_ToFile_Mode(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	path := filepath.Join(t.TempDir(), ".env")
+
+	r.NoError(Zero().ToFile(path))
+	info, err := os.Stat(path)
+	r.NoError(err)
+	r.Equal(os.FileMode(0o600), info.Mode().Perm())
+
+	r.NoError(os.Chmod(path, 0o640))
+	r.NoError(Zero().ToFile(path))
+	info, err = os.Stat(path)
+	r.NoError(err)
+	r.Equal(os.FileMode(0o640), info.Mode().Perm())
+
+	r.Error(Zero().ToFile(filepath.Join(path, "missing", ".env")))
+}
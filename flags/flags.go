@@ -0,0 +1,126 @@
+// Package flags interprets FEATURE_* environment variables as feature
+// flags, giving small services basic feature flagging without an external
+// system.
+//
+// A flag named "new_ui" is read from FEATURE_NEW_UI. Its value is either a
+// boolean (true/false, on/off, yes/no, 1/0) or a rollout percentage such as
+// "25%". A boolean true is a 100% rollout and false is 0%.
+package flags
+
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/markbates/envy"
+)
+
+// DefaultPrefix is prepended to flag names to form their keys.
+const DefaultPrefix = "FEATURE_"
+
+// Flags reads feature flags from an Env.
+type Flags struct {
+	// Env holds the flag variables.
+	Env *envy.Env
+
+	// Prefix is prepended to flag names. Defaults to DefaultPrefix.
+	Prefix string
+
+	// Defaults maps flag names to the value used when the variable is
+	// unset or cannot be parsed, in the same format as the variable.
+	// Flags without a default are off.
+	Defaults map[string]string
+}
+
+// Key returns the variable that holds the flag name: the prefix followed by
+// name upper-cased, with '-', '.', and ' ' replaced by '_'.
+func (f Flags) Key(name string) string {
+	prefix := f.Prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+
+	r := strings.NewReplacer("-", "_", ".", "_", " ", "_")
+	return prefix + r.Replace(strings.ToUpper(name))
+}
+
+// Enabled reports whether the flag is fully on, i.e. a boolean true or a
+// 100% rollout.
+func (f Flags) Enabled(name string) bool {
+	return f.Percent(name) >= 100
+}
+
+// Percent returns the flag's rollout percentage, between 0 and 100.
+func (f Flags) Percent(name string) float64 {
+	if p, ok := parse(f.Env.Getenv(f.Key(name))); ok {
+		return p
+	}
+
+	for k, v := range f.Defaults {
+		if f.Key(k) != f.Key(name) {
+			continue
+		}
+
+		if p, ok := parse(v); ok {
+			return p
+		}
+	}
+	return 0
+}
+
+// EnabledFor reports whether the flag is on for id, such as a user or
+// account ID. The decision is a stable hash of the flag name and id compared
+// against the rollout percentage, so the same id gets the same answer until
+// the percentage changes, and raising it only adds ids.
+func (f Flags) EnabledFor(name, id string) bool {
+	p := f.Percent(name)
+	switch {
+	case p <= 0:
+		return false
+	case p >= 100:
+		return true
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(f.Key(name)))
+	h.Write([]byte{0})
+	h.Write([]byte(id))
+
+	return float64(h.Sum32()%10000) < p*100
+}
+
+// OnChange calls fn with the name of a flag, as it appears in its key
+// without the prefix, whenever its variable changes in the Env. The
+// returned func stops the notifications.
+func (f Flags) OnChange(fn func(name string)) (func(), error) {
+	prefix := f.Key("")
+
+	return f.Env.Subscribe(func(d envy.Diff) {
+		for _, k := range d.Keys() {
+			if name, ok := strings.CutPrefix(k, prefix); ok && name != "" {
+				fn(name)
+			}
+		}
+	})
+}
+
+// parse interprets a flag value as a percentage.
+func parse(v string) (float64, bool) {
+	v = strings.TrimSpace(v)
+
+	if s, ok := strings.CutSuffix(v, "%"); ok {
+		p, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil || p < 0 || p > 100 {
+			return 0, false
+		}
+		return p, true
+	}
+
+	switch strings.ToLower(v) {
+	case "1", "t", "true", "on", "yes", "y":
+		return 100, true
+	case "0", "f", "false", "off", "no", "n":
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,139 @@
+package flags
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/markbates/envy"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Flags_Key(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name   string
+		prefix string
+		exp    string
+	}{
+		{name: "new_ui", exp: "FEATURE_NEW_UI"},
+		{name: "beta-search.v2", exp: "FEATURE_BETA_SEARCH_V2"},
+		{name: "dark mode", prefix: "FF_", exp: "FF_DARK_MODE"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			r.Equal(tc.exp, Flags{Prefix: tc.prefix}.Key(tc.name))
+		})
+	}
+}
+
+func Test_Flags_Percent(t *testing.T) {
+	t.Parallel()
+
+	f := Flags{
+		Env: envy.FromMap(map[string]string{
+			"FEATURE_ON":      "true",
+			"FEATURE_OFF":     "off",
+			"FEATURE_HALF":    "50%",
+			"FEATURE_BAD":     "maybe",
+			"FEATURE_TOO_BIG": "150%",
+		}),
+		Defaults: map[string]string{
+			"bad":     "yes",
+			"missing": "10%",
+			"off":     "on",
+		},
+	}
+
+	tcs := []struct {
+		name    string
+		exp     float64
+		enabled bool
+	}{
+		{name: "on", exp: 100, enabled: true},
+		{name: "off", exp: 0},
+		{name: "half", exp: 50},
+		{name: "bad", exp: 100, enabled: true},
+		{name: "missing", exp: 10},
+		{name: "too_big", exp: 0},
+		{name: "unknown", exp: 0},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			r.Equal(tc.exp, f.Percent(tc.name))
+			r.Equal(tc.enabled, f.Enabled(tc.name))
+		})
+	}
+}
+
+func Test_Flags_EnabledFor(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name  string
+		value string
+		min   int
+		max   int
+	}{
+		{name: "off", value: "false", min: 0, max: 0},
+		{name: "on", value: "true", min: 1000, max: 1000},
+		{name: "quarter", value: "25%", min: 200, max: 300},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			f := Flags{Env: envy.FromMap(map[string]string{"FEATURE_X": tc.value})}
+
+			n := 0
+			for i := 0; i < 1000; i++ {
+				id := fmt.Sprintf("user-%d", i)
+				on := f.EnabledFor("x", id)
+				r.Equal(on, f.EnabledFor("x", id), "stable for %s", id)
+				if on {
+					n++
+				}
+			}
+
+			r.GreaterOrEqual(n, tc.min)
+			r.LessOrEqual(n, tc.max)
+		})
+	}
+}
+
+func Test_Flags_OnChange(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := envy.Zero()
+	f := Flags{Env: env}
+
+	var got []string
+	stop, err := f.OnChange(func(name string) {
+		got = append(got, name)
+	})
+	r.NoError(err)
+
+	r.NoError(env.Setenv("FEATURE_NEW_UI", "true"))
+	r.NoError(env.Setenv("PORT", "8080"))
+	r.NoError(env.Setenv("FEATURE_NEW_UI", "true"))
+	r.NoError(env.Unsetenv("FEATURE_NEW_UI"))
+
+	stop()
+	r.NoError(env.Setenv("FEATURE_OTHER", "true"))
+
+	r.Equal([]string{"NEW_UI", "NEW_UI"}, got)
+
+	_, err = Flags{}.OnChange(func(string) {})
+	r.Error(err)
+}
@@ -0,0 +1,63 @@
+package envy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// foremanLine matches the lines foreman accepts; anything else is ignored.
+var foremanLine = regexp.MustCompile(`\A([A-Za-z_0-9]+)=(.*)\z`)
+
+// foremanEscape matches a backslash escape in a double-quoted value.
+var foremanEscape = regexp.MustCompile(`\\(.)`)
+
+// FromForeman reads r using foreman's exact .env rules, for tools that
+// must be drop-in replacements for "foreman run":
+//
+//   - only lines of the form KEY=value, with KEY made of letters, digits,
+//     and underscores, are entries; everything else, including lines with
+//     leading whitespace or "export", is ignored
+//   - a value wrapped in single quotes is taken literally
+//   - a value wrapped in double quotes has the two characters `\n` turned
+//     into a newline, then any other backslash escape replaced by the
+//     escaped character
+//   - other values are used verbatim, including surrounding spaces and
+//     any "#" comment
+//   - nothing is expanded, and later lines win
+//
+// As with foreman, the values are meant to be layered over the process
+// environment rather than under it.
+func FromForeman(r io.Reader) (*Env, error) {
+	if r == nil {
+		return nil, fmt.Errorf("nil reader")
+	}
+
+	em := map[string]string{}
+
+	buf := bufio.NewScanner(r)
+	for buf.Scan() {
+		m := foremanLine.FindStringSubmatch(strings.TrimSuffix(buf.Text(), "\r"))
+		if m == nil {
+			continue
+		}
+
+		k, v := m[1], m[2]
+		switch {
+		case len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'':
+			v = v[1 : len(v)-1]
+		case len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"':
+			v = strings.ReplaceAll(v[1:len(v)-1], `\n`, "\n")
+			v = foremanEscape.ReplaceAllString(v, "$1")
+		}
+		em[k] = v
+	}
+
+	if err := buf.Err(); err != nil {
+		return nil, err
+	}
+
+	return FromMap(em), nil
+}
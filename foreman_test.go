@@ -0,0 +1,63 @@
+package envy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromForeman(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		in   string
+		exp  map[string]string
+	}{
+		{
+			name: "empty",
+			exp:  map[string]string{},
+		},
+		{
+			name: "plain values are verbatim",
+			in:   "PORT=5000\nGREETING= hello # not a comment\nEMPTY=\nURL=http://x?a=b\n",
+			exp:  map[string]string{"PORT": "5000", "GREETING": " hello # not a comment", "EMPTY": "", "URL": "http://x?a=b"},
+		},
+		{
+			name: "quotes",
+			in:   "SINGLE='a\\nb $HOME'\nDOUBLE=\"a\\nb \\\"q\\\" \\$HOME \\\\\"\nHALF=\"open\n",
+			exp:  map[string]string{"SINGLE": "a\\nb $HOME", "DOUBLE": "a\nb \"q\" $HOME \\", "HALF": "\"open"},
+		},
+		{
+			name: "ignored lines",
+			in:   "# comment\n  INDENTED=1\nexport EXPORTED=1\nBAD-KEY=1\nKEY = 1\n",
+			exp:  map[string]string{},
+		},
+		{
+			name: "crlf and later lines win",
+			in:   "A=1\r\nA=2\r\n",
+			exp:  map[string]string{"A": "2"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			env, err := FromForeman(strings.NewReader(tc.in))
+			r.NoError(err)
+
+			got := map[string]string{}
+			for _, kv := range env.Environ() {
+				k, v, _ := strings.Cut(kv, "=")
+				got[k] = v
+			}
+			r.Equal(tc.exp, got)
+		})
+	}
+
+	_, err := FromForeman(nil)
+	require.Error(t, err)
+}
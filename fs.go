@@ -0,0 +1,153 @@
+package envy
+
+import (
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+var (
+	_ fs.FS         = (*Env)(nil)
+	_ fs.ReadDirFS  = (*Env)(nil)
+	_ fs.ReadFileFS = (*Env)(nil)
+	_ fs.StatFS     = (*Env)(nil)
+)
+
+// Open implements fs.FS, presenting the Env as a flat directory in which
+// every key is a read-only file whose contents are its value. This lets code
+// written against file-based secret mounts, such as /run/secrets, be tested
+// against an in-memory Env. Keys that are not valid file names (for example,
+// those containing a '/') are not visible. The returned file is a snapshot;
+// later changes to the Env are not reflected in it.
+func (e *Env) Open(name string) (fs.File, error) {
+	if name == "." {
+		return &envDir{info: envFileInfo{name: ".", mode: fs.ModeDir | 0o555}, ents: e.dirEntries()}, nil
+	}
+
+	info, value, err := e.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &envFile{info: info, Reader: strings.NewReader(value)}, nil
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (e *Env) ReadFile(name string) ([]byte, error) {
+	_, value, err := e.stat("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(value), nil
+}
+
+// ReadDir implements fs.ReadDirFS. Only "." is a directory.
+func (e *Env) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name != "." {
+		if _, _, err := e.stat("readdir", name); err != nil {
+			return nil, err
+		}
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	return e.dirEntries(), nil
+}
+
+// Stat implements fs.StatFS.
+func (e *Env) Stat(name string) (fs.FileInfo, error) {
+	if name == "." {
+		return envFileInfo{name: ".", mode: fs.ModeDir | 0o555}, nil
+	}
+
+	info, _, err := e.stat("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// stat looks up the file name for op.
+func (e *Env) stat(op string, name string) (envFileInfo, string, error) {
+	if !fs.ValidPath(name) {
+		return envFileInfo{}, "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	if strings.Contains(name, "/") || !e.IsSet(name) {
+		return envFileInfo{}, "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	value := e.Getenv(name)
+	return envFileInfo{name: name, size: int64(len(value)), mode: 0o444}, value, nil
+}
+
+// dirEntries lists the visible keys as sorted directory entries.
+func (e *Env) dirEntries() []fs.DirEntry {
+	ents := []fs.DirEntry{}
+	for _, ent := range e.entries() {
+		if ent.key == "." || ent.key == ".." || strings.Contains(ent.key, "/") || !fs.ValidPath(ent.key) {
+			continue
+		}
+		ents = append(ents, fs.FileInfoToDirEntry(envFileInfo{
+			name: ent.key,
+			size: int64(len(ent.value)),
+			mode: 0o444,
+		}))
+	}
+	return ents
+}
+
+// envFileInfo implements fs.FileInfo for keys and the root directory.
+type envFileInfo struct {
+	name string
+	size int64
+	mode fs.FileMode
+}
+
+func (fi envFileInfo) Name() string       { return fi.name }
+func (fi envFileInfo) Size() int64        { return fi.size }
+func (fi envFileInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi envFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi envFileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi envFileInfo) Sys() any           { return nil }
+
+// envFile is an open key.
+type envFile struct {
+	*strings.Reader
+	info envFileInfo
+}
+
+func (f *envFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *envFile) Close() error               { return nil }
+
+// envDir is the open root directory.
+type envDir struct {
+	info envFileInfo
+	ents []fs.DirEntry
+	off  int
+}
+
+func (d *envDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *envDir) Close() error               { return nil }
+
+func (d *envDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: ".", Err: fs.ErrInvalid}
+}
+
+func (d *envDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.ents[d.off:]
+	if n <= 0 {
+		d.off = len(d.ents)
+		return rest, nil
+	}
+
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(rest) {
+		n = len(rest)
+	}
+
+	d.off += n
+	return rest[:n], nil
+}
@@ -0,0 +1,72 @@
+package envy
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_FS(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := FromMap(map[string]string{
+		"DB_PASSWORD": "secret",
+		"EMPTY":       "",
+		"a/b":         "hidden",
+	})
+
+	r.NoError(fstest.TestFS(env, "DB_PASSWORD", "EMPTY"))
+
+	b, err := fs.ReadFile(env, "DB_PASSWORD")
+	r.NoError(err)
+	r.Equal("secret", string(b))
+
+	_, err = fs.ReadFile(env, "a/b")
+	r.True(errors.Is(err, fs.ErrNotExist))
+
+	_, err = env.Open("MISSING")
+	r.True(errors.Is(err, fs.ErrNotExist))
+
+	_, err = env.Open("/abs")
+	r.True(errors.Is(err, fs.ErrInvalid))
+
+	ents, err := fs.ReadDir(env, ".")
+	r.NoError(err)
+	r.Len(ents, 2)
+	r.Equal("DB_PASSWORD", ents[0].Name())
+
+	info, err := fs.Stat(env, "DB_PASSWORD")
+	r.NoError(err)
+	r.Equal(int64(len("secret")), info.Size())
+	r.False(info.IsDir())
+}
+
+func Test_Env_FS_Nil(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	var env *Env
+
+	ents, err := fs.ReadDir(env, ".")
+	r.NoError(err)
+	r.Empty(ents)
+
+	_, err = env.Open("KEY")
+	r.True(errors.Is(err, fs.ErrNotExist))
+}
+
+func Test_FromFile_EnvFS(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	// an Env can serve as the fs.FS for another Env's file
+	src := FromMap(map[string]string{"app.env": "KEY1=VALUE1\nKEY2=VALUE2"})
+
+	env, err := FromFile(src, "app.env")
+	r.NoError(err)
+	r.Equal([]string{"KEY1=VALUE1", "KEY2=VALUE2"}, env.Environ())
+}
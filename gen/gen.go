@@ -0,0 +1,313 @@
+// Package gen generates Go code from env files, replacing stringly-typed
+// access to environment variables with constants and typed struct fields.
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/markbates/envy"
+)
+
+// Kind is the Go type generated for a variable.
+type Kind string
+
+// Supported kinds.
+const (
+	String   Kind = "string"
+	Int      Kind = "int"
+	Float    Kind = "float64"
+	Bool     Kind = "bool"
+	Duration Kind = "time.Duration"
+)
+
+// Field describes a single generated struct field.
+type Field struct {
+	// Key is the environment variable name, e.g. DATABASE_URL.
+	Key string `json:"key"`
+	// Name is the Go identifier derived from Key, e.g. DatabaseURL.
+	Name string `json:"name"`
+	// Kind is the field's type.
+	Kind Kind `json:"kind"`
+}
+
+// Options configures Generate.
+type Options struct {
+	// Package is the name of the generated package. Defaults to "config".
+	Package string
+	// Type is the name of the generated struct. Defaults to "Config".
+	Type string
+	// Source names the input in the generated header comment.
+	Source string
+}
+
+// Fields derives a field for every key in env, sorted by key. Each field's
+// kind is inferred from its example value: "true"/"false" is Bool, an
+// integer is Int, a decimal number is Float, a duration such as "30s" is
+// Duration, and anything else, including an empty value, is String.
+// Commented-out entries ("# KEY=value") are skipped.
+func Fields(env *envy.Env) []Field {
+	var fields []Field
+	for _, kv := range env.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(k, "#") {
+			continue
+		}
+
+		fields = append(fields, Field{
+			Key:  k,
+			Name: GoName(k),
+			Kind: infer(v),
+		})
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Key < fields[j].Key
+	})
+	return fields
+}
+
+// infer guesses the Kind of an example value.
+func infer(v string) Kind {
+	v = strings.TrimSpace(v)
+	switch {
+	case v == "":
+		return String
+	case v == "true" || v == "false":
+		return Bool
+	}
+
+	if _, err := strconv.Atoi(v); err == nil {
+		return Int
+	}
+
+	if _, err := strconv.ParseFloat(v, 64); err == nil && strings.ContainsAny(v, ".eE") {
+		return Float
+	}
+
+	if _, err := time.ParseDuration(v); err == nil {
+		return Duration
+	}
+
+	return String
+}
+
+// initialisms are upper-cased as a whole, following Go naming conventions.
+var initialisms = map[string]bool{
+	"API": true, "DB": true, "DNS": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "SQL": true, "SSH": true,
+	"TCP": true, "TLS": true, "TTL": true, "UDP": true, "URI": true,
+	"URL": true, "UUID": true, "XML": true, "AWS": true, "CPU": true,
+}
+
+// GoName converts an environment key such as DATABASE_URL into an exported
+// Go identifier such as DatabaseURL. Different keys, such as DB_URL, DB__URL,
+// and db.url, can map to the same name; Generate and Constants reject them.
+func GoName(key string) string {
+	parts := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	bb := &strings.Builder{}
+	for _, p := range parts {
+		up := strings.ToUpper(p)
+		if initialisms[up] {
+			bb.WriteString(up)
+			continue
+		}
+
+		bb.WriteString(up[:1])
+		bb.WriteString(strings.ToLower(up[1:]))
+	}
+
+	name := bb.String()
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		name = "X" + name
+	}
+	return name
+}
+
+// Generate writes a Go source file declaring a constant for every variable
+// name, a struct with a typed field per variable, and a Load function that
+// populates the struct from an *envy.Env. It returns an error if two
+// fields have the same Name, as keys such as DB_URL and DB__URL do.
+func Generate(w io.Writer, fields []Field, opts Options) error {
+	if w == nil {
+		return fmt.Errorf("nil writer")
+	}
+
+	if err := checkNames(fields); err != nil {
+		return err
+	}
+
+	pkg := opts.Package
+	if pkg == "" {
+		pkg = "config"
+	}
+
+	typ := opts.Type
+	if typ == "" {
+		typ = "Config"
+	}
+
+	needs := map[string]bool{}
+	for _, f := range fields {
+		switch f.Kind {
+		case Int, Float, Bool:
+			needs["strconv"] = true
+		case Duration:
+			needs["time"] = true
+		}
+	}
+
+	bb := &bytes.Buffer{}
+	writeHeader(bb, opts.Source)
+	fmt.Fprintf(bb, "package %s\n\n", pkg)
+
+	bb.WriteString("import (\n")
+	if len(fields) > 0 {
+		bb.WriteString("\"errors\"\n")
+	}
+	if needs["strconv"] || needs["time"] {
+		bb.WriteString("\"fmt\"\n")
+	}
+	for _, p := range []string{"strconv", "time"} {
+		if needs[p] {
+			fmt.Fprintf(bb, "%q\n", p)
+		}
+	}
+	bb.WriteString("\n\"github.com/markbates/envy\"\n)\n\n")
+
+	writeConstants(bb, fields)
+
+	fmt.Fprintf(bb, "// %s holds the typed values of the environment variables.\n", typ)
+	fmt.Fprintf(bb, "type %s struct {\n", typ)
+	for _, f := range fields {
+		fmt.Fprintf(bb, "%s %s // %s\n", f.Name, f.Kind, f.Key)
+	}
+	bb.WriteString("}\n\n")
+
+	fmt.Fprintf(bb, "// Load reads a %s from env. Unset or empty variables leave their\n", typ)
+	bb.WriteString("// field at its zero value; values that fail to parse are reported together.\n")
+	fmt.Fprintf(bb, "func Load(env *envy.Env) (%s, error) {\n", typ)
+	fmt.Fprintf(bb, "var c %s\n", typ)
+
+	if len(fields) > 0 {
+		bb.WriteString("var errs []error\n\n")
+	}
+
+	for _, f := range fields {
+		c := "Env" + f.Name
+		fmt.Fprintf(bb, "if v := env.Getenv(%s); v != \"\" {\n", c)
+		switch f.Kind {
+		case String:
+			fmt.Fprintf(bb, "c.%s = v\n", f.Name)
+		case Int:
+			writeParse(bb, f, c, "strconv.Atoi(v)")
+		case Float:
+			writeParse(bb, f, c, "strconv.ParseFloat(v, 64)")
+		case Bool:
+			writeParse(bb, f, c, "strconv.ParseBool(v)")
+		case Duration:
+			writeParse(bb, f, c, "time.ParseDuration(v)")
+		}
+		bb.WriteString("}\n\n")
+	}
+
+	if len(fields) > 0 {
+		bb.WriteString("return c, errors.Join(errs...)\n}\n")
+	} else {
+		bb.WriteString("return c, nil\n}\n")
+	}
+
+	return writeFormatted(w, bb.Bytes())
+}
+
+// Constants writes a Go source file declaring only a constant per variable
+// name, e.g. EnvDatabaseURL = "DATABASE_URL", for code that wants greppable,
+// typo-proof key names without a generated struct. Options.Type is ignored.
+// Fields with the same Name are an error, as for Generate.
+func Constants(w io.Writer, fields []Field, opts Options) error {
+	if w == nil {
+		return fmt.Errorf("nil writer")
+	}
+
+	if err := checkNames(fields); err != nil {
+		return err
+	}
+
+	pkg := opts.Package
+	if pkg == "" {
+		pkg = "config"
+	}
+
+	bb := &bytes.Buffer{}
+	writeHeader(bb, opts.Source)
+	fmt.Fprintf(bb, "package %s\n\n", pkg)
+	writeConstants(bb, fields)
+
+	return writeFormatted(w, bb.Bytes())
+}
+
+// checkNames returns an error naming the keys of fields that share a Go
+// name, which would not compile.
+func checkNames(fields []Field) error {
+	keys := map[string]string{}
+	for _, f := range fields {
+		if k, ok := keys[f.Name]; ok {
+			return fmt.Errorf("%s and %s both map to %s", k, f.Key, f.Name)
+		}
+		keys[f.Name] = f.Key
+	}
+	return nil
+}
+
+// writeHeader writes the generated-code marker.
+func writeHeader(bb *bytes.Buffer, source string) {
+	bb.WriteString("// Code generated by envy gen")
+	if source != "" {
+		fmt.Fprintf(bb, " from %s", source)
+	}
+	bb.WriteString("; DO NOT EDIT.\n\n")
+}
+
+// writeConstants writes a const block naming every key.
+func writeConstants(bb *bytes.Buffer, fields []Field) {
+	if len(fields) == 0 {
+		return
+	}
+
+	bb.WriteString("// Environment variable names.\nconst (\n")
+	for _, f := range fields {
+		fmt.Fprintf(bb, "Env%s = %q\n", f.Name, f.Key)
+	}
+	bb.WriteString(")\n\n")
+}
+
+// writeParse writes the body that parses v into field f.
+func writeParse(bb *bytes.Buffer, f Field, c string, call string) {
+	fmt.Fprintf(bb, "x, err := %s\n", call)
+	bb.WriteString("if err != nil {\n")
+	fmt.Fprintf(bb, "errs = append(errs, fmt.Errorf(\"%%s: %%w\", %s, err))\n", c)
+	bb.WriteString("} else {\n")
+	fmt.Fprintf(bb, "c.%s = x\n", f.Name)
+	bb.WriteString("}\n")
+}
+
+// writeFormatted gofmts src and writes it to w.
+func writeFormatted(w io.Writer, src []byte) error {
+	out, err := format.Source(src)
+	if err != nil {
+		return fmt.Errorf("formatting generated code: %w", err)
+	}
+
+	_, err = w.Write(out)
+	return err
+}
@@ -0,0 +1,199 @@
+package gen
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/markbates/envy"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GoName(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		key string
+		exp string
+	}{
+		{key: "PORT", exp: "Port"},
+		{key: "DATABASE_URL", exp: "DatabaseURL"},
+		{key: "aws_access_key_id", exp: "AWSAccessKeyID"},
+		{key: "HTTP-TIMEOUT", exp: "HTTPTimeout"},
+		{key: "2FA_SECRET", exp: "X2faSecret"},
+		{key: "", exp: "X"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			r.Equal(tc.exp, GoName(tc.key))
+		})
+	}
+}
+
+func Test_Fields(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  *envy.Env
+		exp  []Field
+	}{
+		{
+			name: "nil env",
+		},
+		{
+			name: "inferred kinds",
+			env: envy.FromMap(map[string]string{
+				"NAME":     "app",
+				"PORT":     "8080",
+				"RATIO":    "0.5",
+				"DEBUG":    "false",
+				"TIMEOUT":  "30s",
+				"EMPTY":    "",
+				"# IGNORE": "me",
+			}),
+			exp: []Field{
+				{Key: "DEBUG", Name: "Debug", Kind: Bool},
+				{Key: "EMPTY", Name: "Empty", Kind: String},
+				{Key: "NAME", Name: "Name", Kind: String},
+				{Key: "PORT", Name: "Port", Kind: Int},
+				{Key: "RATIO", Name: "Ratio", Kind: Float},
+				{Key: "TIMEOUT", Name: "Timeout", Kind: Duration},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			r.Equal(tc.exp, Fields(tc.env))
+		})
+	}
+}
+
+func Test_Generate(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name     string
+		fields   []Field
+		opts     Options
+		contains []string
+		absent   []string
+	}{
+		{
+			name: "no fields",
+			contains: []string{
+				"package config",
+				"type Config struct {\n}",
+				"return c, nil",
+			},
+			absent: []string{"strconv", "errors"},
+		},
+		{
+			name: "typed fields",
+			fields: []Field{
+				{Key: "DATABASE_URL", Name: "DatabaseURL", Kind: String},
+				{Key: "PORT", Name: "Port", Kind: Int},
+				{Key: "TIMEOUT", Name: "Timeout", Kind: Duration},
+			},
+			opts: Options{Package: "settings", Type: "Settings", Source: ".env.example"},
+			contains: []string{
+				"// Code generated by envy gen from .env.example; DO NOT EDIT.",
+				"package settings",
+				`EnvDatabaseURL = "DATABASE_URL"`,
+				"Port        int           // PORT",
+				"Timeout     time.Duration // TIMEOUT",
+				"func Load(env *envy.Env) (Settings, error) {",
+				"strconv.Atoi(v)",
+				"time.ParseDuration(v)",
+				"return c, errors.Join(errs...)",
+			},
+			absent: []string{"ParseBool"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			bb := &bytes.Buffer{}
+			r.NoError(Generate(bb, tc.fields, tc.opts))
+
+			_, err := parser.ParseFile(token.NewFileSet(), "gen.go", bb.Bytes(), 0)
+			r.NoError(err)
+
+			for _, s := range tc.contains {
+				r.Contains(bb.String(), s)
+			}
+			for _, s := range tc.absent {
+				r.NotContains(bb.String(), s)
+			}
+		})
+	}
+}
+
+func Test_Constants(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name   string
+		fields []Field
+		exp    string
+	}{
+		{
+			name: "no fields",
+			exp:  "// Code generated by envy gen; DO NOT EDIT.\n\npackage config\n",
+		},
+		{
+			name: "constants",
+			fields: []Field{
+				{Key: "DATABASE_URL", Name: "DatabaseURL", Kind: String},
+				{Key: "PORT", Name: "Port", Kind: Int},
+			},
+			exp: `// Code generated by envy gen; DO NOT EDIT.
+
+package config
+
+// Environment variable names.
+const (
+	EnvDatabaseURL = "DATABASE_URL"
+	EnvPort        = "PORT"
+)
+`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			bb := &bytes.Buffer{}
+			r.NoError(Constants(bb, tc.fields, Options{}))
+			r.Equal(tc.exp, bb.String())
+		})
+	}
+}
+
+func Test_Generate_collision(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := envy.FromMap(map[string]string{"DB_URL": "a", "DB__URL": "b", "PORT": "1"})
+	fields := Fields(env)
+
+	err := Generate(&bytes.Buffer{}, fields, Options{})
+	r.ErrorContains(err, "DB_URL and DB__URL both map to DBURL")
+
+	err = Constants(&bytes.Buffer{}, fields, Options{})
+	r.ErrorContains(err, "DB_URL and DB__URL both map to DBURL")
+}
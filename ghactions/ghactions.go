@@ -0,0 +1,134 @@
+// Package ghactions publishes the contents of an envy.Env to GitHub
+// Actions: as step outputs, as environment variables for later steps, and
+// as masks that keep secret values out of the logs. It lets Go programs run
+// as composite or container actions use one API for values and secrets.
+package ghactions
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/markbates/envy"
+)
+
+// The variables GitHub Actions sets to the files steps append to.
+const (
+	OutputKey = "GITHUB_OUTPUT"
+	EnvKey    = "GITHUB_ENV"
+)
+
+// WriteOutputs appends every entry of vars to the step output file named
+// by GITHUB_OUTPUT in runner, typically envy.New().
+func WriteOutputs(runner, vars *envy.Env) error {
+	return appendFile(runner, OutputKey, vars)
+}
+
+// WriteEnv appends every entry of vars to the file named by GITHUB_ENV in
+// runner, making them environment variables of the following steps.
+func WriteEnv(runner, vars *envy.Env) error {
+	return appendFile(runner, EnvKey, vars)
+}
+
+// appendFile appends vars to the file named by key in runner.
+func appendFile(runner *envy.Env, key string, vars *envy.Env) (err error) {
+	path := runner.Getenv(key)
+	if path == "" {
+		return fmt.Errorf("%s is not set", key)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	return Write(f, vars)
+}
+
+// Write writes vars to w in the format of the GITHUB_OUTPUT and GITHUB_ENV
+// files: name=value, or a name<<DELIMITER block for values containing
+// newlines. Entries are written in key order.
+func Write(w io.Writer, vars *envy.Env) error {
+	if w == nil {
+		return fmt.Errorf("nil writer")
+	}
+
+	for _, kv := range vars.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if !strings.ContainsAny(v, "\r\n") {
+			if _, err := fmt.Fprintf(w, "%s=%s\n", k, v); err != nil {
+				return err
+			}
+			continue
+		}
+
+		delim, err := delimiter(v)
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(w, "%s<<%s\n%s\n%s\n", k, delim, v, delim); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// delimiter returns a random heredoc delimiter that does not occur in v.
+func delimiter(v string) (string, error) {
+	for {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+
+		d := "ghadelimiter_" + hex.EncodeToString(b)
+		if !strings.Contains(v, d) {
+			return d, nil
+		}
+	}
+}
+
+// Mask writes an ::add-mask:: workflow command to w, normally os.Stdout, for
+// the value of every key in vars for which sensitive returns true, so the
+// runner redacts it from the logs. Each line of a multi-line value is
+// masked separately. If sensitive is nil, vars.IsSecret is used, so values
+// marked envy.Secret in their Meta and those whose names look sensitive are
+// masked.
+func Mask(w io.Writer, vars *envy.Env, sensitive func(key string) bool) error {
+	if w == nil {
+		return fmt.Errorf("nil writer")
+	}
+
+	if sensitive == nil {
+		sensitive = vars.IsSecret
+	}
+
+	for _, kv := range vars.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if !sensitive(k) {
+			continue
+		}
+
+		for _, line := range strings.FieldsFunc(v, func(r rune) bool { return r == '\n' || r == '\r' }) {
+			if _, err := fmt.Fprintf(w, "::add-mask::%s\n", escape(line)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// escape encodes s as workflow command data.
+func escape(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
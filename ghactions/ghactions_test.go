@@ -0,0 +1,115 @@
+package ghactions
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/markbates/envy"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Write(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		vars *envy.Env
+		exp  string
+	}{
+		{
+			name: "nil env",
+		},
+		{
+			name: "single line",
+			vars: envy.FromMap(map[string]string{"B": "2", "A": "1"}),
+			exp:  "A=1\nB=2\n",
+		},
+		{
+			name: "multi line",
+			vars: envy.FromMap(map[string]string{"CERT": "line1\nline2"}),
+			exp:  "CERT<<D\nline1\nline2\nD\n",
+		},
+	}
+
+	delim := regexp.MustCompile(`ghadelimiter_[0-9a-f]{16}`)
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			bb := &bytes.Buffer{}
+			r.NoError(Write(bb, tc.vars))
+			r.Equal(tc.exp, delim.ReplaceAllString(bb.String(), "D"))
+		})
+	}
+
+	require.Error(t, Write(nil, envy.Zero()))
+}
+
+func Test_WriteOutputs(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "output")
+	env := filepath.Join(dir, "env")
+	r.NoError(os.WriteFile(out, []byte("EXISTING=1\n"), 0o644))
+
+	runner := envy.FromMap(map[string]string{OutputKey: out, EnvKey: env})
+
+	r.NoError(WriteOutputs(runner, envy.FromMap(map[string]string{"VERSION": "1.2.3"})))
+	r.NoError(WriteEnv(runner, envy.FromMap(map[string]string{"MODE": "release"})))
+
+	b, err := os.ReadFile(out)
+	r.NoError(err)
+	r.Equal("EXISTING=1\nVERSION=1.2.3\n", string(b))
+
+	b, err = os.ReadFile(env)
+	r.NoError(err)
+	r.Equal("MODE=release\n", string(b))
+
+	r.EqualError(WriteOutputs(envy.Zero(), envy.Zero()), "GITHUB_OUTPUT is not set")
+}
+
+func Test_Mask(t *testing.T) {
+	t.Parallel()
+
+	vars := envy.FromMap(map[string]string{
+		"API_TOKEN": "abc%123",
+		"PORT":      "8080",
+		"KEY":       "-----BEGIN-----\r\nsecret\n",
+		"SHARED":    "visible",
+	})
+	require.NoError(t, vars.SetMeta("KEY", envy.Meta{Sensitivity: envy.Secret}))
+
+	tcs := []struct {
+		name      string
+		sensitive func(string) bool
+		exp       string
+	}{
+		{
+			name: "default",
+			exp:  "::add-mask::abc%25123\n::add-mask::-----BEGIN-----\n::add-mask::secret\n",
+		},
+		{
+			name:      "custom",
+			sensitive: func(k string) bool { return k == "SHARED" },
+			exp:       "::add-mask::visible\n",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			bb := &bytes.Buffer{}
+			r.NoError(Mask(bb, vars, tc.sensitive))
+			r.Equal(tc.exp, bb.String())
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package envy
+
+import "strings"
+
+// knownGOOS are the values of runtime.GOOS recognized as key suffixes.
+var knownGOOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true,
+	"freebsd": true, "hurd": true, "illumos": true, "ios": true,
+	"js": true, "linux": true, "netbsd": true, "openbsd": true,
+	"plan9": true, "solaris": true, "wasip1": true, "windows": true,
+	"zos": true,
+}
+
+// splitGOOS splits a key such as "PATH.windows" into "PATH" and "windows".
+// It reports false if the key has no recognized GOOS suffix.
+func splitGOOS(key string) (string, string, bool) {
+	i := strings.LastIndexByte(key, '.')
+	if i <= 0 || !knownGOOS[key[i+1:]] {
+		return "", "", false
+	}
+	return key[:i], key[i+1:], true
+}
+
+// resolveGOOS replaces keys with a GOOS suffix, such as "PATH.windows", by
+// the plain key when the suffix matches goos, overriding any plain value,
+// and drops them otherwise. The Env must not be shared yet.
+func (e *Env) resolveGOOS(goos string) {
+	for k, v := range e.envs {
+		base, sys, ok := splitGOOS(k)
+		if !ok {
+			continue
+		}
+
+		delete(e.envs, k)
+		m, hasMeta := e.meta[k]
+		delete(e.meta, k)
+
+		if sys != goos {
+			continue
+		}
+
+		e.envs[base] = v
+		if hasMeta {
+			e.meta[base] = m
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package envy
+
+import (
+	"runtime"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_resolveGOOS(t *testing.T) {
+	t.Parallel()
+
+	in := map[string]string{
+		"PATH":            "/usr/bin",
+		"PATH.windows":    `C:\bin`,
+		"PATH.darwin":     "/opt/homebrew/bin",
+		"SHELL.linux":     "/bin/bash",
+		"spring.profiles": "dev",
+		".windows":        "odd",
+	}
+
+	tcs := []struct {
+		goos string
+		exp  []string
+	}{
+		{
+			goos: "windows",
+			exp:  []string{".windows=odd", `PATH=C:\bin`, "spring.profiles=dev"},
+		},
+		{
+			goos: "darwin",
+			exp:  []string{".windows=odd", "PATH=/opt/homebrew/bin", "spring.profiles=dev"},
+		},
+		{
+			goos: "linux",
+			exp:  []string{".windows=odd", "PATH=/usr/bin", "SHELL=/bin/bash", "spring.profiles=dev"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.goos, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			em := map[string]string{}
+			for k, v := range in {
+				em[k] = v
+			}
+
+			env := FromMap(em)
+			env.resolveGOOS(tc.goos)
+			r.Equal(tc.exp, env.Environ())
+		})
+	}
+}
+
+func Test_FromFile_GOOS(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	data := "EDITOR=vi\nEDITOR." + runtime.GOOS + "=nano\nOTHER.plan9=x\n"
+	if runtime.GOOS == "plan9" {
+		data = "EDITOR=vi\nEDITOR.plan9=nano\nOTHER.windows=x\n"
+	}
+
+	env, err := FromFile(fstest.MapFS{".env": &fstest.MapFile{Data: []byte(data)}}, ".env")
+	r.NoError(err)
+	r.Equal([]string{"EDITOR=nano"}, env.Environ())
+	r.Equal(".env line 2", env.Source("EDITOR"))
+}
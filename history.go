@@ -0,0 +1,149 @@
+package envy
+
+import "fmt"
+
+// revision is the change that produced a revision.
+type revision struct {
+	rev  uint64
+	diff Diff
+}
+
+// KeepHistory makes the Env remember the changes behind its last n
+// revisions, so that Snapshot and Rollback can return to any of them. History
+// is off by default because it keeps old values, which may be secrets, in
+// memory. Passing 0 turns it off and discards what was kept; lowering n
+// discards the oldest entries.
+func (e *Env) KeepHistory(n int) error {
+	if e.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	if n < 0 {
+		return fmt.Errorf("negative history limit %d", n)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.historyLimit = n
+	e.trimHistory()
+	return nil
+}
+
+// Snapshot returns a new Env holding the contents of the Env as of revision
+// rev. rev must be the current revision or one retained by KeepHistory.
+func (e *Env) Snapshot(rev uint64) (*Env, error) {
+	if e.IsNil() {
+		return nil, fmt.Errorf("nil env")
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	em, err := e.at(rev)
+	if err != nil {
+		return nil, err
+	}
+	return FromMap(em), nil
+}
+
+// Rollback restores the Env to its contents as of revision rev, which must
+// be retained by KeepHistory. The restore is applied as a single change, so
+// subscribers are notified once and the Env moves to a new revision rather
+// than back to rev.
+func (e *Env) Rollback(rev uint64) error {
+	if e.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	_, err := e.mutate(func() (map[string]string, []string, error) {
+		target, err := e.at(rev)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var unset []string
+		for k := range e.envs {
+			if _, ok := target[k]; !ok {
+				unset = append(unset, k)
+			}
+		}
+		return target, unset, nil
+	})
+	return err
+}
+
+// at reconstructs the contents of the Env at rev by undoing recorded changes.
+// The lock must be held.
+func (e *Env) at(rev uint64) (map[string]string, error) {
+	oldest := e.rev
+	if len(e.history) > 0 {
+		oldest = e.history[0].rev - 1
+	}
+
+	if rev > e.rev || rev < oldest {
+		return nil, fmt.Errorf("revision %d is not available (have %d through %d)", rev, oldest, e.rev)
+	}
+
+	em := make(map[string]string, len(e.envs))
+	for k, v := range e.envs {
+		em[k] = v
+	}
+
+	for i := len(e.history) - 1; i >= 0 && e.history[i].rev > rev; i-- {
+		for _, c := range e.history[i].diff {
+			switch c.Kind {
+			case Added:
+				delete(em, c.Key)
+			case Removed, Modified:
+				em[c.Key] = c.Old
+			}
+		}
+	}
+
+	return em, nil
+}
+
+// record adds d, the change that produced the current revision, to the
+// history. The lock must be held.
+func (e *Env) record(d Diff) {
+	if e.historyLimit == 0 {
+		return
+	}
+
+	e.history = append(e.history, revision{rev: e.rev, diff: d})
+	e.trimHistory()
+}
+
+// trimHistory drops the oldest entries beyond the limit. The lock must be
+// held.
+func (e *Env) trimHistory() {
+	if over := len(e.history) - e.historyLimit; over > 0 {
+		e.history = append([]revision(nil), e.history[over:]...)
+	}
+}
+
+// HistoryEntry is a change retained by KeepHistory: the Diff that produced
+// revision Rev.
+type HistoryEntry struct {
+	Rev  uint64 `json:"rev"`
+	Diff Diff   `json:"diff"`
+}
+
+// History returns the changes retained by KeepHistory, oldest first. The
+// diffs hold old and new values as they are, so redact them before showing
+// them to anyone.
+func (e *Env) History() []HistoryEntry {
+	if e.IsNil() {
+		return nil
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	out := make([]HistoryEntry, len(e.history))
+	for i, h := range e.history {
+		out[i] = HistoryEntry{Rev: h.rev, Diff: append(Diff(nil), h.diff...)}
+	}
+	return out
+}
@@ -0,0 +1,112 @@
+package envy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_Rollback(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := FromMap(map[string]string{"A": "1"})
+	r.NoError(env.KeepHistory(10))
+
+	r.NoError(env.Setenv("A", "2")) // rev 1
+	r.NoError(env.Setenv("B", "x")) // rev 2
+	r.NoError(env.Unsetenv("A"))    // rev 3
+	r.Equal(uint64(3), env.Revision())
+
+	snap, err := env.Snapshot(1)
+	r.NoError(err)
+	r.Equal([]string{"A=2"}, snap.Environ())
+
+	snap, err = env.Snapshot(0)
+	r.NoError(err)
+	r.Equal([]string{"A=1"}, snap.Environ())
+
+	var got []Diff
+	_, err = env.Subscribe(func(d Diff) {
+		got = append(got, d)
+	})
+	r.NoError(err)
+
+	r.NoError(env.Rollback(1))
+	r.Equal([]string{"A=2"}, env.Environ())
+	r.Equal(uint64(4), env.Revision())
+	r.Equal([]Diff{{
+		{Key: "A", Kind: Added, New: "2"},
+		{Key: "B", Kind: Removed, Old: "x"},
+	}}, got)
+
+	// the rollback itself can be rolled back
+	r.NoError(env.Rollback(3))
+	r.Equal([]string{"B=x"}, env.Environ())
+
+	r.Error(env.Rollback(99))
+}
+
+func Test_Env_KeepHistory(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	var nilEnv *Env
+	r.Error(nilEnv.KeepHistory(1))
+	r.Error(Zero().KeepHistory(-1))
+
+	env := Zero()
+
+	// without history only the current revision is available
+	r.NoError(env.Setenv("A", "1"))
+	_, err := env.Snapshot(0)
+	r.Error(err)
+
+	snap, err := env.Snapshot(1)
+	r.NoError(err)
+	r.Equal([]string{"A=1"}, snap.Environ())
+
+	r.NoError(env.KeepHistory(2))
+	r.NoError(env.Setenv("A", "2")) // rev 2
+	r.NoError(env.Setenv("A", "3")) // rev 3
+	r.NoError(env.Setenv("A", "4")) // rev 4
+
+	// only the changes behind revisions 3 and 4 are kept, so the
+	// oldest reachable state is revision 2
+	_, err = env.Snapshot(1)
+	r.Error(err)
+
+	snap, err = env.Snapshot(2)
+	r.NoError(err)
+	r.Equal([]string{"A=2"}, snap.Environ())
+
+	r.NoError(env.KeepHistory(0))
+	_, err = env.Snapshot(3)
+	r.Error(err)
+}
+
+func Test_Env_History(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := FromMap(map[string]string{"A": "1"})
+	r.Empty(env.History())
+
+	r.NoError(env.KeepHistory(2))
+	r.NoError(env.Setenv("A", "2"))
+	r.NoError(env.Setenv("B", "x"))
+	r.NoError(env.Unsetenv("A"))
+
+	h := env.History()
+	r.Equal([]HistoryEntry{
+		{Rev: 2, Diff: Diff{{Key: "B", Kind: Added, New: "x"}}},
+		{Rev: 3, Diff: Diff{{Key: "A", Kind: Removed, Old: "2"}}},
+	}, h)
+
+	// the entries are copies
+	h[0].Diff[0].New = "y"
+	r.Equal("x", env.History()[0].Diff[0].New)
+
+	var nilEnv *Env
+	r.Nil(nilEnv.History())
+}
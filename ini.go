@@ -0,0 +1,132 @@
+package envy
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// INIOption configures FromINI.
+type INIOption func(*iniOptions)
+
+type iniOptions struct {
+	sep string
+}
+
+// INISeparator sets the separator FromINI puts between a section and its
+// keys. The default is "_".
+func INISeparator(sep string) INIOption {
+	return func(o *iniOptions) {
+		o.sep = sep
+	}
+}
+
+// FromINI reads the INI file at path into an Env. The name of each
+// [section] prefixes its keys, which are flattened as by FromYAML: each
+// part is upper cased, with '-' and spaces turned into '_', and the parts
+// are joined with the separator, so "host" in a [db] section sets DB_HOST.
+// Keys before the first section have no prefix. Keys are separated from
+// their values by "=" or ":"; lines starting with ";" or "#" are comments,
+// as is the rest of an unquoted value after " ;" or " #". Values are
+// trimmed, and single or double quotes around a value are removed. The
+// line and the comment lines directly above each key are recorded as its
+// Meta. It returns an error for a nil fs.FS or a read failure; the error
+// wraps ErrMalformed if a line cannot be parsed or two keys flatten to the
+// same name.
+func FromINI(cab fs.FS, path string, opts ...INIOption) (*Env, error) {
+	if cab == nil {
+		return nil, fmt.Errorf("nil fs.FS")
+	}
+
+	o := iniOptions{sep: "_"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	f, err := cab.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	em := map[string]string{}
+	meta := map[string]Meta{}
+
+	var section string
+	var comments []string
+
+	buf := bufio.NewScanner(f)
+	n := 0
+	for buf.Scan() {
+		n++
+		line := strings.TrimSpace(buf.Text())
+		if n == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+
+		switch {
+		case line == "":
+			comments = nil
+			continue
+		case line[0] == ';' || line[0] == '#':
+			comments = append(comments, strings.TrimSpace(line[1:]))
+			continue
+		case line[0] == '[':
+			name, ok := strings.CutSuffix(iniValue(line), "]")
+			name = strings.TrimSpace(name[1:])
+			if !ok || name == "" {
+				return nil, malformedError{fmt.Errorf("%s: line %d: malformed section %q", path, n, line)}
+			}
+
+			section = flatKey("", name, o.sep)
+			comments = nil
+			continue
+		}
+
+		i := strings.IndexAny(line, "=:")
+		if i <= 0 {
+			return nil, malformedError{fmt.Errorf("%s: line %d: expected key = value, found %q", path, n, line)}
+		}
+
+		key := flatKey(section, strings.TrimSpace(line[:i]), o.sep)
+		if _, ok := em[key]; ok {
+			return nil, malformedError{fmt.Errorf("%s: line %d: %s is set more than once (first on line %d)", path, n, key, meta[key].Line)}
+		}
+
+		if !validKey(key) {
+			return nil, malformedError{fmt.Errorf("%s: line %d: invalid key %q", path, n, key)}
+		}
+
+		em[key] = iniValue(strings.TrimSpace(line[i+1:]))
+		meta[key] = Meta{
+			Loader:      "file",
+			File:        path,
+			Line:        n,
+			Description: strings.Join(comments, "\n"),
+		}
+		comments = nil
+	}
+
+	if err := buf.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	e := FromMap(em)
+	e.meta = meta
+	return e, nil
+}
+
+// iniValue removes the quotes around s, or an inline comment after it.
+func iniValue(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+
+	for i := 1; i < len(s); i++ {
+		if (s[i] == ';' || s[i] == '#') && (s[i-1] == ' ' || s[i-1] == '\t') {
+			return strings.TrimSpace(s[:i])
+		}
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package envy
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromINI(t *testing.T) {
+	t.Parallel()
+
+	const doc = `; global settings
+name = my-app
+debug: true
+
+[db]
+# primary database
+host = localhost
+max-conns = 10 ; per instance
+password = "p;ss # word"
+path = C:\data
+
+[db.replica] ; the read replica
+host = 'replica'
+empty =
+`
+
+	tcs := []struct {
+		name string
+		in   string
+		opts []INIOption
+		exp  map[string]string
+		err  string
+	}{
+		{
+			name: "document",
+			in:   doc,
+			exp: map[string]string{
+				"NAME":             "my-app",
+				"DEBUG":            "true",
+				"DB_HOST":          "localhost",
+				"DB_MAX_CONNS":     "10",
+				"DB_PASSWORD":      "p;ss # word",
+				"DB_PATH":          `C:\data`,
+				"DB_REPLICA_HOST":  "replica",
+				"DB_REPLICA_EMPTY": "",
+			},
+		},
+		{
+			name: "separator",
+			in:   "[db]\nhost = x\n",
+			opts: []INIOption{INISeparator("__")},
+			exp:  map[string]string{"DB__HOST": "x"},
+		},
+		{name: "empty", in: "", exp: map[string]string{}},
+		{name: "collision", in: "[db]\nhost = a\n[DB]\nhost = b\n", err: "app.ini: line 4: DB_HOST is set more than once (first on line 2)"},
+		{name: "bad section", in: "[db\nhost = a\n", err: `app.ini: line 1: malformed section "[db"`},
+		{name: "empty section", in: "[ ]\n", err: "line 1: malformed section"},
+		{name: "no value", in: "host\n", err: `app.ini: line 1: expected key = value, found "host"`},
+		{name: "no key", in: "= a\n", err: "line 1: expected key = value"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			cab := fstest.MapFS{"app.ini": {Data: []byte(tc.in)}}
+
+			env, err := FromINI(cab, "app.ini", tc.opts...)
+			if tc.err != "" {
+				r.ErrorIs(err, ErrMalformed)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(len(tc.exp), env.Len())
+			for k, v := range tc.exp {
+				r.Equal(v, env.Getenv(k), k)
+			}
+		})
+	}
+}
+
+func Test_FromINI_meta(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	cab := fstest.MapFS{"app.ini": {Data: []byte("[db]\n; primary database\nhost = localhost\n")}}
+
+	env, err := FromINI(cab, "app.ini")
+	r.NoError(err)
+
+	m, ok := env.Meta("DB_HOST")
+	r.True(ok)
+	r.Equal("app.ini", m.File)
+	r.Equal(3, m.Line)
+	r.Equal("primary database", m.Description)
+
+	_, err = FromINI(cab, "nope.ini")
+	r.ErrorIs(err, fs.ErrNotExist)
+
+	_, err = FromINI(nil, "app.ini")
+	r.Error(err)
+}
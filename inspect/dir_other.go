@@ -0,0 +1,23 @@
+//go:build !unix
+
+package inspect
+
+import (
+	"fmt"
+	"os"
+)
+
+// privateDir returns an error unless dir is a directory and not a
+// symlink. Ownership and Unix modes cannot be checked here, so the
+// directory's ACL is left to the system.
+func privateDir(dir string) error {
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+
+	if !fi.IsDir() {
+		return fmt.Errorf("%s: not a directory", dir)
+	}
+	return nil
+}
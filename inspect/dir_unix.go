@@ -0,0 +1,32 @@
+//go:build unix
+
+package inspect
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// privateDir returns an error unless dir is a directory, not a symlink,
+// owned by the current user with mode 0700, so no one else can replace or
+// connect to the socket inside it.
+func privateDir(dir string) error {
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+
+	if !fi.IsDir() {
+		return fmt.Errorf("%s: not a directory", dir)
+	}
+
+	if st, ok := fi.Sys().(*syscall.Stat_t); !ok || int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("%s: not owned by the current user", dir)
+	}
+
+	if perm := fi.Mode().Perm(); perm != 0o700 {
+		return fmt.Errorf("%s: mode %#o, want 0700", dir, perm)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+// Package inspect serves a read-only, redacted view of a running process's
+// envy.Env over a local unix domain socket, so operators can see a
+// service's effective configuration with "envy inspect -pid N" instead of
+// sending signals or reading core dumps.
+//
+// A client connects to the socket and reads a single Snapshot encoded as
+// JSON; the server ignores anything the client sends. The socket is
+// created with mode 0600 in a directory only its owner can enter, so only
+// the user running the service, or root, can connect. Values of secret
+// keys, as judged by envy.Env.IsSecret, are replaced with envy.Redacted,
+// both in the environment and in its history.
+package inspect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/markbates/envy"
+)
+
+// timeout bounds how long a client may take to read a snapshot.
+const timeout = 5 * time.Second
+
+// Snapshot is what the server sends to each client.
+type Snapshot struct {
+	PID         int    `json:"pid"`
+	Time        string `json:"time"`
+	Revision    uint64 `json:"revision"`
+	Fingerprint string `json:"fingerprint"`
+
+	// Env holds the redacted variables.
+	Env map[string]string `json:"env"`
+
+	// Sources maps each variable whose origin is known to where its value
+	// came from, as reported by envy.Env.Source.
+	Sources map[string]string `json:"sources,omitempty"`
+
+	// History holds the redacted changes retained by envy.Env.KeepHistory,
+	// oldest first.
+	History []envy.HistoryEntry `json:"history,omitempty"`
+}
+
+// SocketPath returns the conventional socket path for the process pid, in
+// a directory of the temporary directory private to the current user.
+func SocketPath(pid int) string {
+	dir := "envy-" + strconv.Itoa(os.Getuid())
+	return filepath.Join(os.TempDir(), dir, strconv.Itoa(pid)+".sock")
+}
+
+// Take returns a Snapshot of env.
+func Take(env *envy.Env) (Snapshot, error) {
+	if env.IsNil() {
+		return Snapshot{}, fmt.Errorf("nil env")
+	}
+
+	snap := Snapshot{
+		PID:         os.Getpid(),
+		Time:        time.Now().UTC().Format(time.RFC3339),
+		Revision:    env.Revision(),
+		Fingerprint: env.Fingerprint(),
+		Env:         map[string]string{},
+		Sources:     map[string]string{},
+	}
+
+	for _, kv := range env.Redact(nil).Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		snap.Env[k] = v
+		if src := env.Source(k); src != "" {
+			snap.Sources[k] = src
+		}
+	}
+
+	for _, h := range env.History() {
+		h.Diff = h.Diff.Redact(env.IsSecret)
+		snap.History = append(snap.History, h)
+	}
+	return snap, nil
+}
+
+// Serve listens on the unix socket at path, SocketPath(os.Getpid()) if
+// path is empty, and sends a Snapshot of env to each client until ctx is
+// done, when it removes the socket and returns nil. A socket left behind
+// by an earlier process is replaced, but it returns an error if another
+// server is still listening at path. The directory holding the socket is
+// created if needed; it returns an error if the directory is not private
+// to the current user: on Unix, owned by it with mode 0700 and not a
+// symlink.
+func Serve(ctx context.Context, env *envy.Env, path string) (err error) {
+	if env.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	if path == "" {
+		path = SocketPath(os.Getpid())
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	// the directory may have been made by someone else, before us
+	if err := privateDir(dir); err != nil {
+		return err
+	}
+
+	if c, err := net.Dial("unix", path); err == nil {
+		return errors.Join(fmt.Errorf("%s: already in use", path), c.Close())
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// once ctx is done, the listener is already closed
+		if cerr := l.Close(); !errors.Is(cerr, net.ErrClosed) {
+			err = errors.Join(err, cerr)
+		}
+	}()
+
+	if err := os.Chmod(path, 0o600); err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		// Accept fails and returns once the listener is closed
+		_ = l.Close()
+	}()
+
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		go func() {
+			// a client that fails only fails itself
+			_ = send(c, env)
+		}()
+	}
+}
+
+// send writes a Snapshot of env to the client c and closes c.
+func send(c net.Conn, env *envy.Env) error {
+	err := c.SetDeadline(time.Now().Add(timeout))
+	if err == nil {
+		var snap Snapshot
+		if snap, err = Take(env); err == nil {
+			err = json.NewEncoder(c).Encode(snap)
+		}
+	}
+	return errors.Join(err, c.Close())
+}
+
+// Fetch reads a Snapshot from the server listening at path.
+func Fetch(ctx context.Context, path string) (_ Snapshot, err error) {
+	var d net.Dialer
+	c, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	defer func() {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(timeout)
+	}
+
+	if err := c.SetDeadline(deadline); err != nil {
+		return Snapshot{}, err
+	}
+
+	var snap Snapshot
+	if err := json.NewDecoder(c).Decode(&snap); err != nil {
+		return Snapshot{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return snap, nil
+}
@@ -0,0 +1,169 @@
+package inspect
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/markbates/envy"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Serve(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := envy.FromMap(map[string]string{"PORT": "8080", "API_TOKEN": "hunter2"})
+	r.NoError(env.KeepHistory(5))
+	r.NoError(env.Setenv("API_TOKEN", "swordfish"))
+	r.NoError(env.Setenv("PORT", "9090"))
+
+	path := filepath.Join(t.TempDir(), "envy", "app.sock")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, env, path) }()
+
+	var snap Snapshot
+	r.Eventually(func() bool {
+		var err error
+		snap, err = Fetch(context.Background(), path)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	r.Equal(os.Getpid(), snap.PID)
+	r.Equal(uint64(2), snap.Revision)
+	r.Equal(env.Fingerprint(), snap.Fingerprint)
+	r.Equal(map[string]string{"PORT": "9090", "API_TOKEN": envy.Redacted}, snap.Env)
+	r.Equal([]envy.HistoryEntry{
+		{Rev: 1, Diff: envy.Diff{{Key: "API_TOKEN", Kind: envy.Modified, Old: envy.Redacted, New: envy.Redacted}}},
+		{Rev: 2, Diff: envy.Diff{{Key: "PORT", Kind: envy.Modified, Old: "8080", New: "9090"}}},
+	}, snap.History)
+
+	fi, err := os.Stat(path)
+	r.NoError(err)
+	r.Equal(os.FileMode(0o600), fi.Mode().Perm())
+
+	// a second server on the same socket is refused
+	r.ErrorContains(Serve(context.Background(), env, path), "already in use")
+
+	cancel()
+	r.NoError(<-done)
+
+	_, err = os.Stat(path)
+	r.ErrorIs(err, os.ErrNotExist)
+}
+
+func Test_Serve_stale(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "envy", "app.sock")
+	r.NoError(os.Mkdir(filepath.Dir(path), 0o700))
+	r.NoError(os.WriteFile(path, nil, 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, envy.FromMap(map[string]string{"A": "1"}), path) }()
+
+	r.Eventually(func() bool {
+		snap, err := Fetch(context.Background(), path)
+		return err == nil && snap.Env["A"] == "1"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	r.NoError(<-done)
+}
+
+func Test_Take(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	_, err := Take(nil)
+	r.Error(err)
+
+	r.Error(Serve(context.Background(), nil, ""))
+
+	snap, err := Take(envy.FromMap(map[string]string{"A": "1"}))
+	r.NoError(err)
+	r.Empty(snap.History)
+	r.Equal(map[string]string{"A": "1"}, snap.Env)
+
+	// values marked Secret are redacted whatever their names
+	env := envy.FromMap(map[string]string{"A": "1", "B": "2"})
+	r.NoError(env.KeepHistory(5))
+	r.NoError(env.Setenv("A", "3"))
+	r.NoError(env.SetMeta("A", envy.Meta{Sensitivity: envy.Secret}))
+
+	snap, err = Take(env)
+	r.NoError(err)
+	r.Equal(map[string]string{"A": envy.Redacted, "B": "2"}, snap.Env)
+	r.Equal(envy.Diff{{Key: "A", Kind: envy.Modified, Old: envy.Redacted, New: envy.Redacted}}, snap.History[0].Diff)
+}
+
+func Test_SocketPath(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	p := SocketPath(42)
+	r.Equal("42.sock", filepath.Base(p))
+	r.Equal(os.TempDir(), filepath.Dir(filepath.Dir(p)))
+}
+
+func Test_Serve_dir(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("uses unix modes")
+	}
+
+	tcs := []struct {
+		name string
+		dir  func(r *require.Assertions, root string) string
+		err  string
+	}{
+		{
+			name: "too open",
+			dir: func(r *require.Assertions, root string) string {
+				dir := filepath.Join(root, "open")
+				r.NoError(os.Mkdir(dir, 0o700))
+				r.NoError(os.Chmod(dir, 0o777))
+				return dir
+			},
+			err: "want 0700",
+		},
+		{
+			name: "symlink",
+			dir: func(r *require.Assertions, root string) string {
+				dir := filepath.Join(root, "real")
+				r.NoError(os.Mkdir(dir, 0o700))
+				r.NoError(os.Symlink(dir, filepath.Join(root, "link")))
+				return filepath.Join(root, "link")
+			},
+			err: "not a directory",
+		},
+		{
+			name: "file",
+			dir: func(r *require.Assertions, root string) string {
+				dir := filepath.Join(root, "file")
+				r.NoError(os.WriteFile(dir, nil, 0o600))
+				return dir
+			},
+			err: "not a directory",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			dir := tc.dir(r, t.TempDir())
+			err := Serve(context.Background(), envy.FromMap(map[string]string{"A": "1"}), filepath.Join(dir, "app.sock"))
+			r.ErrorContains(err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package envy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Interpolate enables or disables the expansion of references in the
+// values read by FromFile and FromReader. It is off by default, so files
+// holding values such as bcrypt hashes or templates like $HOME/bin load as
+// written.
+//
+// A value may refer to any key set in the same file, wherever it is set,
+// as ${KEY} or $KEY, with the ${KEY-word} and ${KEY:-word} defaults of
+// Expandenv; referenced values are expanded first. Keys the file does not
+// set, and a key's references to itself, as in PATH=$PATH:/opt/bin, are
+// looked up in the Env given to InterpolateFrom, and are otherwise empty.
+// "$$" is a literal "$", as are dollar signs in single-quoted and backtick
+// values and the \$ escape in double-quoted ones. Keys that refer to each
+// other in a cycle are an error. The text as written is kept, and is what
+// Raw and Value.Raw return.
+func Interpolate(on bool) ParseOption {
+	return func(o *parseOptions) {
+		o.interpolate = on
+	}
+}
+
+// InterpolateFrom makes references to keys a file does not set resolve
+// against env, such as the process environment or a layer loaded earlier,
+// when Interpolate is on. env is read, never changed.
+func InterpolateFrom(env *Env) ParseOption {
+	return func(o *parseOptions) {
+		o.interpolateFrom = env
+	}
+}
+
+// interpolate expands the references in the values of the plain KEY=VALUE
+// lines as described on Interpolate. When a key is set more than once, the
+// last line is the one expanded and seen by references.
+func interpolate(lines []string, from *Env) ([]string, error) {
+	in := &interpolator{
+		raw:  map[string]string{},
+		done: map[string]string{},
+		from: from,
+	}
+
+	last := map[string]int{}
+	for i, line := range lines {
+		if k, v, ok := lineEntry(line); ok {
+			in.raw[k] = v
+			last[k] = i
+		}
+	}
+
+	out := slices.Clone(lines)
+	for i, line := range lines {
+		k, _, ok := lineEntry(line)
+		if !ok || last[k] != i {
+			continue
+		}
+
+		v, err := in.value(k)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", i+1, k, err)
+		}
+		out[i] = k + "=" + v
+	}
+	return out, nil
+}
+
+// interpolator expands the values of a file, remembering the keys being
+// expanded to detect cycles.
+type interpolator struct {
+	raw   map[string]string
+	done  map[string]string
+	from  *Env
+	stack []string
+}
+
+// value returns the expanded value of key, which must be set in the file.
+func (in *interpolator) value(key string) (string, error) {
+	if v, ok := in.done[key]; ok {
+		return v, nil
+	}
+
+	if i := slices.Index(in.stack, key); i >= 0 {
+		cycle := append(slices.Clone(in.stack[i:]), key)
+		return "", fmt.Errorf("reference cycle %s", strings.Join(cycle, " -> "))
+	}
+
+	in.stack = append(in.stack, key)
+	defer func() { in.stack = in.stack[:len(in.stack)-1] }()
+
+	var err error
+	v := expandShell(in.raw[key], func(ref string) (string, bool) {
+		if ref == "$" {
+			return "$", true
+		}
+
+		if _, ok := in.raw[ref]; !ok || ref == key || err != nil {
+			return in.from.raw(ref)
+		}
+
+		val, verr := in.value(ref)
+		if verr != nil {
+			err = verr
+		}
+		return val, true
+	})
+	if err != nil {
+		return "", err
+	}
+
+	in.done[key] = v
+	return v, nil
+}
+
+// keepRaw records, as the Meta.Raw of each key, the value written in the
+// last line setting it among lines, before interpolation rewrote it into
+// expanded.
+func (e *Env) keepRaw(lines, expanded []string) {
+	for i, line := range lines {
+		if line == expanded[i] {
+			continue
+		}
+
+		k, v, ok := lineEntry(line)
+		if !ok {
+			continue
+		}
+
+		if e.meta == nil {
+			e.meta = map[string]Meta{}
+		}
+
+		// interpolate only rewrites the last line setting a key
+		m := e.meta[k]
+		m.Raw = v
+		e.meta[k] = m
+	}
+}
@@ -0,0 +1,170 @@
+package envy
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromFile_interpolate(t *testing.T) {
+	t.Parallel()
+
+	from := FromMap(map[string]string{"PATH": "/bin", "REGION": "eu", "HOST": "outer"})
+
+	tcs := []struct {
+		name string
+		in   string
+		opts []ParseOption
+		exp  map[string]string
+		err  string
+	}{
+		{
+			name: "earlier keys",
+			in:   "HOST=localhost\nPORT=8080\nURL=http://$HOST:${PORT}/\n",
+			exp:  map[string]string{"HOST": "localhost", "PORT": "8080", "URL": "http://localhost:8080/"},
+		},
+		{
+			name: "later keys",
+			in:   "URL=http://$HOST/\nHOST=localhost\n",
+			exp:  map[string]string{"HOST": "localhost", "URL": "http://localhost/"},
+		},
+		{
+			name: "chained",
+			in:   "A=$B-a\nB=$C-b\nC=c\n",
+			exp:  map[string]string{"A": "c-b-a", "B": "c-b", "C": "c"},
+		},
+		{
+			name: "undefined is empty",
+			in:   "A=[$REGION]\n",
+			exp:  map[string]string{"A": "[]"},
+		},
+		{
+			name: "defaults",
+			in:   "EMPTY=\nA=${MISSING-x} ${EMPTY:-y} ${EMPTY-z}|\n",
+			exp:  map[string]string{"EMPTY": "", "A": "x y |"},
+		},
+		{
+			name: "from env",
+			in:   "A=$REGION\nHOST=inner\nB=$HOST\n",
+			opts: []ParseOption{InterpolateFrom(from)},
+			exp:  map[string]string{"A": "eu", "HOST": "inner", "B": "inner"},
+		},
+		{
+			name: "self reference",
+			in:   "PATH=$PATH:/opt/bin\n",
+			opts: []ParseOption{InterpolateFrom(from)},
+			exp:  map[string]string{"PATH": "/bin:/opt/bin"},
+		},
+		{
+			name: "last value wins",
+			in:   "A=1\nB=$A\nA=2\n",
+			exp:  map[string]string{"A": "2", "B": "2"},
+		},
+		{
+			name: "literals",
+			in:   "A=x\nB='$A'\nC=`$A`\nD=\"\\$A $A\"\nE=$$A\n",
+			exp:  map[string]string{"A": "x", "B": "$A", "C": "$A", "D": "$A x", "E": "$A"},
+		},
+		{
+			name: "disabled",
+			in:   "A=x\nB=$A\nC=\"\\$A\"\n",
+			opts: []ParseOption{Interpolate(false)},
+			exp:  map[string]string{"A": "x", "B": "$A", "C": "$A"},
+		},
+		{
+			name: "from env disabled",
+			in:   "A=$REGION\n",
+			opts: []ParseOption{Interpolate(false), InterpolateFrom(from)},
+			exp:  map[string]string{"A": "$REGION"},
+		},
+		{
+			name: "cycle",
+			in:   "A=$B\nB=${C}\nC=$A\n",
+			err:  ".env: line 1: A: reference cycle A -> B -> C -> A",
+		},
+		{
+			name: "cycle with self",
+			in:   "A=$A$B\nB=$A\n",
+			err:  "reference cycle A -> B -> A",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			opts := append([]ParseOption{Interpolate(true)}, tc.opts...)
+			env, err := FromFile(fstest.MapFS{".env": {Data: []byte(tc.in)}}, ".env", opts...)
+			if tc.err != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(len(tc.exp), env.Len())
+			for k, v := range tc.exp {
+				r.Equal(v, env.Getenv(k), k)
+			}
+		})
+	}
+}
+
+func Test_FromReader_interpolate(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env, err := FromReader(strings.NewReader("URL=http://$HOST;HOST=db;RAW=$$HOST"), ';', Interpolate(true))
+	r.NoError(err)
+	r.Equal([]string{"HOST=db", "RAW=$HOST", "URL=http://db"}, env.Environ())
+	r.Equal("http://$HOST", env.Raw("URL"))
+
+	env, err = FromReader(strings.NewReader("URL=http://$HOST;HOST=db"), ';')
+	r.NoError(err)
+	r.Equal("http://$HOST", env.Getenv("URL"))
+
+	_, err = FromReader(strings.NewReader("A=$B;B=$A"), ';', Interpolate(true))
+	r.ErrorContains(err, "reference cycle")
+
+	// sandboxed files are never interpolated
+	env, err = FromFile(fstest.MapFS{".env": {Data: []byte("A=x\nB=$A\n")}}, ".env", Sandboxed(Limits{}), Interpolate(true))
+	r.NoError(err)
+	r.Equal("$A", env.Getenv("B"))
+}
+
+func Test_FromFile_interpolate_raw(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	cab := fstest.MapFS{".env": {Data: []byte("HOST=db\nBIN=$HOME/bin\nHASH=$2a$10$abcdefghij\nURL=http://$HOST/\n")}}
+
+	// off by default, so values load as written
+	env, err := FromFile(cab, ".env")
+	r.NoError(err)
+	r.Equal("$HOME/bin", env.Getenv("BIN"))
+	r.Equal("$2a$10$abcdefghij", env.Getenv("HASH"))
+	r.Equal("http://$HOST/", env.Raw("URL"))
+
+	env, err = FromFile(cab, ".env", Interpolate(true))
+	r.NoError(err)
+	r.Equal("http://db/", env.Getenv("URL"))
+
+	// the text as written is kept
+	v := env.Value("URL")
+	r.Equal("http://$HOST/", v.Raw())
+	r.Equal("http://db/", v.Expanded())
+	r.Equal("db", env.Raw("HOST"))
+
+	m, ok := env.Meta("URL")
+	r.True(ok)
+	r.Equal("http://$HOST/", m.Raw)
+	m, _ = env.Meta("HOST")
+	r.Empty(m.Raw)
+
+	// changing the value drops the template
+	r.NoError(env.Setenv("URL", "http://other/"))
+	r.Equal("http://other/", env.Raw("URL"))
+}
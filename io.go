@@ -0,0 +1,161 @@
+package envy
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+var (
+	_ io.WriterTo   = (*Env)(nil)
+	_ io.ReaderFrom = (*Env)(nil)
+)
+
+// WriteTo implements io.WriterTo, writing the Env to w as sorted,
+// newline-terminated KEY=VALUE lines that ReadFrom reads back unchanged.
+// FromFile does too, except for keys with a GOOS suffix, such as
+// PATH.windows: they are written as they are, there being no way to escape
+// the suffix, and FromFile resolves them, reading PATH.windows as PATH on
+// Windows and dropping it elsewhere. Values are written as they are unless
+// the parser would change them, those with leading or trailing white
+// space, line breaks, "#", "$", or a leading quote, which are double-quoted
+// with \n, \r, \", \\, and \$ escaped. It returns an error, before writing
+// anything, for a key that cannot be written: one that is empty, starts
+// with # or //, or contains "=", white space, or control characters.
+func (e *Env) WriteTo(w io.Writer) (int64, error) {
+	if w == nil {
+		return 0, fmt.Errorf("nil writer")
+	}
+
+	b, err := e.dotenv()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// Reader returns an io.Reader that streams a snapshot of the Env in the same
+// format as WriteTo, suitable for pipes and HTTP request bodies. Changes made
+// to the Env after Reader returns are not reflected. If WriteTo would fail,
+// reading returns its error.
+func (e *Env) Reader() io.Reader {
+	b, err := e.dotenv()
+	if err != nil {
+		return errReader{err}
+	}
+	return bytes.NewReader(b)
+}
+
+// ReadFrom implements io.ReaderFrom, reading entries from r in the syntax
+// of FromFile, as written by WriteTo, but without interpolation, directives,
+// or tombstones, and setting them in the Env, overriding existing keys. It
+// returns the number of bytes read. On error, including an error wrapping
+// ErrMalformed for an entry that cannot be parsed, the Env is left
+// unchanged.
+func (e *Env) ReadFrom(r io.Reader) (int64, error) {
+	if e.IsNil() {
+		return 0, fmt.Errorf("nil env")
+	}
+
+	if r == nil {
+		return 0, fmt.Errorf("nil reader")
+	}
+
+	cr := &countingReader{r: r}
+	lines := []string{}
+	buf := bufio.NewScanner(cr)
+	for buf.Scan() {
+		lines = append(lines, buf.Text())
+	}
+
+	if err := buf.Err(); err != nil {
+		return cr.n, err
+	}
+
+	lines, err := dotenvLines(lines, false, nil)
+	if err != nil {
+		return cr.n, malformedError{err}
+	}
+
+	_, err = e.update(fromLines(lines).envs)
+	return cr.n, err
+}
+
+// dotenv serializes the Env as sorted KEY=VALUE lines, quoting values as
+// described on WriteTo.
+func (e *Env) dotenv() ([]byte, error) {
+	ents, err := e.exportEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ent := range ents {
+		if !validKey(ent.key) || strings.HasPrefix(ent.key, "#") || strings.ContainsFunc(ent.key, func(r rune) bool {
+			return unicode.IsSpace(r) || unicode.IsControl(r)
+		}) {
+			return nil, fmt.Errorf("%q: cannot be written to an env file", ent.key)
+		}
+	}
+
+	bb := &bytes.Buffer{}
+	for _, ent := range ents {
+		bb.WriteString(ent.key)
+		bb.WriteByte('=')
+		bb.WriteString(dotenvValue(ent.value))
+		bb.WriteByte('\n')
+	}
+	return bb.Bytes(), nil
+}
+
+// dotenvValue returns v as it is written to an env file: unchanged if
+// dotenvLines and interpolation would read it back as it is, and double
+// quoted otherwise.
+func dotenvValue(v string) string {
+	if v == "" || (v == strings.TrimSpace(v) && !strings.ContainsAny(v, "\n\r#$") && !strings.ContainsAny(v[:1], "\"'`")) {
+		return v
+	}
+
+	var bb strings.Builder
+	bb.WriteByte('"')
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; c {
+		case '\n':
+			bb.WriteString(`\n`)
+		case '\r':
+			bb.WriteString(`\r`)
+		case '"', '\\', '$':
+			bb.WriteByte('\\')
+			bb.WriteByte(c)
+		default:
+			bb.WriteByte(c)
+		}
+	}
+	bb.WriteByte('"')
+	return bb.String()
+}
+
+// errReader is an io.Reader that fails with err.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,207 @@
+package envy
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_WriteTo(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  *Env
+		exp  string
+	}{
+		{
+			name: "nil env",
+			env:  nil,
+			exp:  "",
+		},
+		{
+			name: "populated env",
+			env:  FromMap(map[string]string{"KEY2": "VALUE2", "KEY1": "VALUE1"}),
+			exp:  "KEY1=VALUE1\nKEY2=VALUE2\n",
+		},
+		{
+			name: "quoted",
+			env: FromMap(map[string]string{
+				"A": "",
+				"B": "a b\\c",
+				"C": " padded\t",
+				"D": "x #y",
+				"E": "line\nbreak\r",
+				"F": `$HOME "q" \`,
+				"G": "'single'",
+				"H": "it's",
+			}),
+			exp: "A=\nB=a b\\c\nC=\" padded\t\"\nD=\"x #y\"\nE=\"line\\nbreak\\r\"\nF=\"\\$HOME \\\"q\\\" \\\\\"\nG=\"'single'\"\nH=it's\n",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			bb := &bytes.Buffer{}
+			n, err := tc.env.WriteTo(bb)
+			r.NoError(err)
+			r.Equal(int64(len(tc.exp)), n)
+			r.Equal(tc.exp, bb.String())
+
+			b, err := io.ReadAll(tc.env.Reader())
+			r.NoError(err)
+			r.Equal(tc.exp, string(b))
+		})
+	}
+}
+
+func Test_Env_ReadFrom(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name  string
+		env   *Env
+		input io.Reader
+		exp   []string
+		err   bool
+	}{
+		{
+			name:  "nil env",
+			env:   nil,
+			input: strings.NewReader("KEY=VALUE"),
+			err:   true,
+		},
+		{
+			name:  "nil reader",
+			env:   Zero(),
+			input: nil,
+			err:   true,
+		},
+		{
+			name:  "broken reader",
+			env:   FromMap(map[string]string{"KEY1": "VALUE1"}),
+			input: &brokenReader{},
+			exp:   []string{"KEY1=VALUE1"},
+			err:   true,
+		},
+		{
+			name:  "merge",
+			env:   FromMap(map[string]string{"KEY1": "VALUE1", "KEY2": "VALUE2"}),
+			input: strings.NewReader("KEY2=NEW2\nKEY3=VALUE3\n"),
+			exp:   []string{"KEY1=VALUE1", "KEY2=NEW2", "KEY3=VALUE3"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			_, err := tc.env.ReadFrom(tc.input)
+			if tc.err {
+				r.Error(err)
+			} else {
+				r.NoError(err)
+			}
+
+			if tc.exp != nil {
+				r.Equal(tc.exp, tc.env.Environ())
+			}
+		})
+	}
+}
+
+func Test_Env_Copy(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	src := FromMap(map[string]string{"KEY1": "VALUE1", "KEY2": "VALUE2"})
+	dst := Zero()
+
+	n, err := dst.ReadFrom(src.Reader())
+	r.NoError(err)
+	r.Equal(int64(len("KEY1=VALUE1\nKEY2=VALUE2\n")), n)
+	r.Equal(src.Environ(), dst.Environ())
+}
+
+func Test_Env_WriteTo_GOOS(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	other := "windows"
+	if runtime.GOOS == other {
+		other = "linux"
+	}
+
+	src := FromMap(map[string]string{"A": "1", "PATH." + other: "x"})
+
+	// ReadFrom reads the suffixed key back as it is
+	dst := Zero()
+	_, err := dst.ReadFrom(src.Reader())
+	r.NoError(err)
+	r.Equal(src.Environ(), dst.Environ())
+
+	// FromFile resolves it, dropping it on other systems
+	b, err := io.ReadAll(src.Reader())
+	r.NoError(err)
+
+	env, err := FromFile(fstest.MapFS{".env": {Data: b}}, ".env")
+	r.NoError(err)
+	r.Equal([]string{"A=1"}, env.Environ())
+}
+
+func Test_Env_WriteTo_invalid(t *testing.T) {
+	t.Parallel()
+
+	tcs := []string{"#A", "A B", "A\tB", "A\x00"}
+
+	for _, key := range tcs {
+		t.Run(key, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			env := FromMap(map[string]string{"OK": "1", key: "v"})
+
+			bb := &bytes.Buffer{}
+			_, err := env.WriteTo(bb)
+			r.Error(err)
+			r.Empty(bb.String())
+
+			_, err = io.ReadAll(env.Reader())
+			r.Error(err)
+		})
+	}
+}
+
+func FuzzWriteTo(f *testing.F) {
+	for _, s := range dotenvCorpus {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, value string) {
+		r := require.New(t)
+
+		env := FromMap(map[string]string{"A": value, "B": "after"})
+
+		bb := &bytes.Buffer{}
+		_, err := env.WriteTo(bb)
+		r.NoError(err)
+
+		back, err := FromFile(fstest.MapFS{".env": &fstest.MapFile{Data: bb.Bytes()}}, ".env")
+		r.NoError(err, bb.String())
+		r.Equal(env.Environ(), back.Environ(), bb.String())
+
+		read := Zero()
+		_, err = read.ReadFrom(bytes.NewReader(bb.Bytes()))
+		r.NoError(err)
+		r.Equal(env.Environ(), read.Environ())
+	})
+}
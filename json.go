@@ -0,0 +1,159 @@
+package envy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// FromJSON reads a flat JSON object of variables from r, as emitted by most
+// CI and deployment tooling, such as {"PORT": "8080"}. Number and boolean
+// members are set to their JSON text and null members are skipped; nested
+// objects and arrays are rejected because an Env has no structure. It
+// returns an error for a nil reader, invalid JSON, invalid keys, or data
+// after the object.
+func FromJSON(r io.Reader) (*Env, error) {
+	if r == nil {
+		return nil, fmt.Errorf("nil reader")
+	}
+
+	dec := json.NewDecoder(r)
+
+	var raw json.RawMessage
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON object")
+	}
+
+	em, err := jsonObject(raw)
+	if err != nil {
+		return nil, err
+	}
+	return FromMap(em), nil
+}
+
+// ToJSON writes the Env to w as a flat JSON object sorted by key, followed
+// by a newline. Values are written as is; use Redact first to hide secrets.
+func (e *Env) ToJSON(w io.Writer) error {
+	if w == nil {
+		return fmt.Errorf("nil writer")
+	}
+
+	m, err := e.exportMap()
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(m)
+}
+
+// MarshalJSON implements json.Marshaler, encoding the Env as a flat JSON
+// object. A nil Env is encoded as an empty object.
+func (e *Env) MarshalJSON() ([]byte, error) {
+	m, err := e.exportMap()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, reading a flat JSON object as
+// FromJSON does. The zero Env is initialized; an Env in use has its
+// contents replaced as a single change, notifying subscribers.
+func (e *Env) UnmarshalJSON(b []byte) error {
+	if e == nil {
+		return fmt.Errorf("nil env")
+	}
+
+	em, err := jsonObject(b)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	if e.envs == nil {
+		e.envs = em
+		e.created = time.Now()
+		e.mu.Unlock()
+		return nil
+	}
+	e.mu.Unlock()
+
+	_, err = e.mutate(func() (map[string]string, []string, error) {
+		var unset []string
+		for k := range e.envs {
+			if _, ok := em[k]; !ok {
+				unset = append(unset, k)
+			}
+		}
+		return em, unset, nil
+	})
+	return err
+}
+
+// jsonMap returns the contents of the Env as a map for encoding.
+func (e *Env) jsonMap() map[string]string {
+	m := map[string]string{}
+	for _, ent := range e.entries() {
+		m[ent.key] = ent.value
+	}
+	return m
+}
+
+// exportMap is jsonMap once the Policy allows the Env to be exported.
+func (e *Env) exportMap() (map[string]string, error) {
+	ents, err := e.exportEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]string{}
+	for _, ent := range ents {
+		m[ent.key] = ent.value
+	}
+	return m, nil
+}
+
+// jsonObject decodes a flat JSON object as described on FromJSON.
+func jsonObject(b []byte) (map[string]string, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, err
+	}
+
+	if obj == nil {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+
+	em := make(map[string]string, len(obj))
+	for k, raw := range obj {
+		if !validKey(k) {
+			return nil, fmt.Errorf("invalid key %q", k)
+		}
+
+		raw = bytes.TrimSpace(raw)
+		switch raw[0] {
+		case 'n':
+			continue
+		case '"':
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			em[k] = s
+		case '{', '[':
+			return nil, fmt.Errorf("%s: nested values are not supported", k)
+		default:
+			// numbers and booleans
+			em[k] = string(raw)
+		}
+	}
+	return em, nil
+}
@@ -0,0 +1,113 @@
+package envy
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromJSON(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		in   string
+		exp  []string
+		err  string
+	}{
+		{name: "strings", in: `{"PORT": "8080", "URL": "http://a?b=1&c=2"}`, exp: []string{"PORT=8080", "URL=http://a?b=1&c=2"}},
+		{name: "numbers and booleans", in: `{"PORT": 8080, "RATIO": 0.5, "DEBUG": true}`, exp: []string{"DEBUG=true", "PORT=8080", "RATIO=0.5"}},
+		{name: "null skipped", in: `{"A": null, "B": ""}`, exp: []string{"B="}},
+		{name: "escapes", in: `{"A": "line\nbreak é"}`, exp: []string{"A=line\nbreak é"}},
+		{name: "empty", in: ` {} `, exp: []string{}},
+		{name: "nested", in: `{"A": {"B": "c"}}`, err: "A: nested values are not supported"},
+		{name: "array", in: `{"A": ["b"]}`, err: "A: nested values are not supported"},
+		{name: "invalid key", in: `{"A=B": "c"}`, err: `invalid key "A=B"`},
+		{name: "not an object", in: `"A"`, err: "cannot unmarshal"},
+		{name: "null document", in: `null`, err: "expected a JSON object"},
+		{name: "trailing data", in: `{} {}`, err: "unexpected data after JSON object"},
+		{name: "invalid", in: `{"A": }`, err: "invalid character"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			env, err := FromJSON(strings.NewReader(tc.in))
+			if tc.err != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, env.Environ())
+		})
+	}
+
+	_, err := FromJSON(nil)
+	require.Error(t, err)
+}
+
+func Test_Env_ToJSON(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := FromMap(map[string]string{"URL": "http://a?b=1&c=<2>", "A": "1", "EMPTY": ""})
+
+	bb := &bytes.Buffer{}
+	r.NoError(env.ToJSON(bb))
+	r.Equal(`{"A":"1","EMPTY":"","URL":"http://a?b=1&c=<2>"}`+"\n", bb.String())
+
+	back, err := FromJSON(bb)
+	r.NoError(err)
+	r.Equal(env.Environ(), back.Environ())
+
+	var nilEnv *Env
+	bb.Reset()
+	r.NoError(nilEnv.ToJSON(bb))
+	r.Equal("{}\n", bb.String())
+
+	r.Error(env.ToJSON(nil))
+}
+
+func Test_Env_MarshalJSON(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	type config struct {
+		Name string `json:"name"`
+		Env  *Env   `json:"env"`
+	}
+
+	in := config{Name: "app", Env: FromMap(map[string]string{"B": "2", "A": "1"})}
+
+	b, err := json.Marshal(in)
+	r.NoError(err)
+	r.Equal(`{"name":"app","env":{"A":"1","B":"2"}}`, string(b))
+
+	var out config
+	r.NoError(json.Unmarshal(b, &out))
+	r.Equal("app", out.Name)
+	r.Equal([]string{"A=1", "B=2"}, out.Env.Environ())
+	r.NoError(out.Env.Setenv("C", "3"))
+
+	// an Env in use is replaced as one change
+	var got []Diff
+	_, err = out.Env.Subscribe(func(d Diff) { got = append(got, d) })
+	r.NoError(err)
+
+	r.NoError(json.Unmarshal([]byte(`{"A": "1", "B": "two"}`), out.Env))
+	r.Equal([]string{"A=1", "B=two"}, out.Env.Environ())
+	r.Equal([]Diff{{
+		{Key: "B", Kind: Modified, Old: "2", New: "two"},
+		{Key: "C", Kind: Removed, Old: "3"},
+	}}, got)
+
+	r.Error(json.Unmarshal([]byte(`{"A": [1]}`), out.Env))
+	r.Equal([]string{"A=1", "B=two"}, out.Env.Environ())
+}
@@ -0,0 +1,212 @@
+// Package k8senv converges a Kubernetes Secret or ConfigMap to the contents
+// of an envy.Env, changing only the keys that differ.
+//
+// The package does not depend on client-go. Instead it uses the small
+// Client interface, which is easily satisfied by wrapping a typed
+// SecretInterface or ConfigMapInterface:
+//
+//	type secrets struct{ c corev1.SecretInterface }
+//
+//	func (s secrets) Get(ctx context.Context, name string) (map[string]string, error) {
+//		sec, err := s.c.Get(ctx, name, metav1.GetOptions{})
+//		if apierrors.IsNotFound(err) {
+//			return nil, k8senv.ErrNotFound
+//		}
+//		if err != nil {
+//			return nil, err
+//		}
+//		data := map[string]string{}
+//		for k, v := range sec.Data {
+//			data[k] = string(v)
+//		}
+//		return data, nil
+//	}
+//
+//	func (s secrets) Create(ctx context.Context, name string, data map[string]string) error {
+//		_, err := s.c.Create(ctx, &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name}, StringData: data}, metav1.CreateOptions{})
+//		return err
+//	}
+//
+//	func (s secrets) Patch(ctx context.Context, name string, patch []byte) error {
+//		_, err := s.c.Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+//		return err
+//	}
+package k8senv
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/markbates/envy"
+)
+
+// ErrNotFound must be returned by Client.Get when the object does not
+// exist, so that Reconcile creates it.
+var ErrNotFound = errors.New("object not found")
+
+// Kind is the kind of object being reconciled.
+type Kind string
+
+// The kinds of object Reconciler supports.
+const (
+	Secret    Kind = "Secret"
+	ConfigMap Kind = "ConfigMap"
+)
+
+// Client is the subset of a typed Secret or ConfigMap client needed to
+// reconcile one object.
+type Client interface {
+	// Get returns the data of the named object, decoded to strings, or
+	// an error wrapping ErrNotFound.
+	Get(ctx context.Context, name string) (map[string]string, error)
+	// Create creates the named object with data.
+	Create(ctx context.Context, name string, data map[string]string) error
+	// Patch applies a JSON merge patch (types.MergePatchType) to the
+	// named object.
+	Patch(ctx context.Context, name string, patch []byte) error
+}
+
+// Reconciler converges one object to an Env.
+type Reconciler struct {
+	// Client reads and writes the object. It is required.
+	Client Client
+
+	// Kind is the kind of the object, which decides how values are
+	// encoded in patches. It defaults to Secret.
+	Kind Kind
+
+	// Name is the name of the object. It is required.
+	Name string
+
+	// Prune removes keys of the object that are not in the Env. By
+	// default they are left alone, so other writers can share the
+	// object.
+	Prune bool
+}
+
+// Plan returns the changes Reconcile would make to the object, without
+// making them. Added changes for a missing object mean it would be
+// created. The Diff holds the values in the clear; pass a sensitive func
+// to Diff.WriteUnified or Diff.WriteJSON before showing it.
+func (r *Reconciler) Plan(ctx context.Context, env *envy.Env) (envy.Diff, error) {
+	d, _, err := r.plan(ctx, env)
+	return d, err
+}
+
+// Reconcile makes the object match the Env with a single create or merge
+// patch that only touches the keys that differ, and returns the changes
+// made. Nothing is written when the object already matches.
+func (r *Reconciler) Reconcile(ctx context.Context, env *envy.Env) (envy.Diff, error) {
+	d, exists, err := r.plan(ctx, env)
+	if err != nil || len(d) == 0 {
+		return d, err
+	}
+
+	if !exists {
+		data := map[string]string{}
+		for _, c := range d {
+			data[c.Key] = c.New
+		}
+
+		if err := r.Client.Create(ctx, r.Name, data); err != nil {
+			return nil, fmt.Errorf("create %s %s: %w", r.kind(), r.Name, err)
+		}
+		return d, nil
+	}
+
+	patch, err := r.patch(d)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.Client.Patch(ctx, r.Name, patch); err != nil {
+		return nil, fmt.Errorf("patch %s %s: %w", r.kind(), r.Name, err)
+	}
+	return d, nil
+}
+
+// plan returns the changes to make and whether the object exists.
+func (r *Reconciler) plan(ctx context.Context, env *envy.Env) (envy.Diff, bool, error) {
+	if r.Client == nil {
+		return nil, false, fmt.Errorf("nil client")
+	}
+
+	if r.Name == "" {
+		return nil, false, fmt.Errorf("missing object name")
+	}
+
+	if k := r.kind(); k != Secret && k != ConfigMap {
+		return nil, false, fmt.Errorf("unsupported kind %q", k)
+	}
+
+	for _, kv := range env.Environ() {
+		k, _, _ := strings.Cut(kv, "=")
+		if !validKey(k) {
+			return nil, false, fmt.Errorf("%s: not a valid %s key", k, r.kind())
+		}
+	}
+
+	exists := true
+	cur, err := r.Client.Get(ctx, r.Name)
+	if errors.Is(err, ErrNotFound) {
+		exists, cur = false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("get %s %s: %w", r.kind(), r.Name, err)
+	}
+
+	all := envy.Compare(envy.FromMap(cur), env)
+
+	d := envy.Diff{}
+	for _, c := range all {
+		if c.Kind == envy.Removed && !r.Prune {
+			continue
+		}
+		d = append(d, c)
+	}
+	return d, exists, nil
+}
+
+// patch returns the JSON merge patch making the changes in d.
+func (r *Reconciler) patch(d envy.Diff) ([]byte, error) {
+	data := map[string]any{}
+	for _, c := range d {
+		switch {
+		case c.Kind == envy.Removed:
+			data[c.Key] = nil
+		case r.kind() == Secret:
+			data[c.Key] = base64.StdEncoding.EncodeToString([]byte(c.New))
+		default:
+			data[c.Key] = c.New
+		}
+	}
+	return json.Marshal(map[string]any{"data": data})
+}
+
+func (r *Reconciler) kind() Kind {
+	if r.Kind == "" {
+		return Secret
+	}
+	return r.Kind
+}
+
+// validKey reports whether k is a valid Secret or ConfigMap data key.
+func validKey(k string) bool {
+	if k == "" || len(k) > 253 || k == "." || k == ".." {
+		return false
+	}
+
+	for i := 0; i < len(k); i++ {
+		c := k[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
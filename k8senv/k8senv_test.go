@@ -0,0 +1,186 @@
+package k8senv
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/markbates/envy"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient is an in-memory object that applies merge patches to its data.
+type fakeClient struct {
+	kind    Kind
+	data    map[string]string
+	patches []string
+	creates int
+	fail    error
+}
+
+func (f *fakeClient) Get(ctx context.Context, name string) (map[string]string, error) {
+	if f.fail != nil {
+		return nil, f.fail
+	}
+	if f.data == nil {
+		return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+
+	data := map[string]string{}
+	for k, v := range f.data {
+		data[k] = v
+	}
+	return data, nil
+}
+
+func (f *fakeClient) Create(ctx context.Context, name string, data map[string]string) error {
+	f.creates++
+	f.data = data
+	return nil
+}
+
+func (f *fakeClient) Patch(ctx context.Context, name string, patch []byte) error {
+	f.patches = append(f.patches, string(patch))
+
+	var p struct {
+		Data map[string]*string `json:"data"`
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return err
+	}
+
+	for k, v := range p.Data {
+		switch {
+		case v == nil:
+			delete(f.data, k)
+		case f.kind == ConfigMap:
+			f.data[k] = *v
+		default:
+			b, err := base64.StdEncoding.DecodeString(*v)
+			if err != nil {
+				return err
+			}
+			f.data[k] = string(b)
+		}
+	}
+	return nil
+}
+
+func Test_Reconciler(t *testing.T) {
+	t.Parallel()
+
+	desired := map[string]string{"HOST": "db", "PORT": "5432", "USER": "app"}
+
+	tcs := []struct {
+		name    string
+		kind    Kind
+		prune   bool
+		data    map[string]string
+		exp     envy.Diff
+		patch   string
+		creates int
+		after   map[string]string
+	}{
+		{
+			name: "secret update",
+			data: map[string]string{"HOST": "db", "PORT": "1", "OTHER": "x"},
+			exp: envy.Diff{
+				{Key: "PORT", Kind: envy.Modified, Old: "1", New: "5432"},
+				{Key: "USER", Kind: envy.Added, New: "app"},
+			},
+			patch: `{"data":{"PORT":"NTQzMg==","USER":"YXBw"}}`,
+			after: map[string]string{"HOST": "db", "PORT": "5432", "USER": "app", "OTHER": "x"},
+		},
+		{
+			name:  "configmap prune",
+			kind:  ConfigMap,
+			prune: true,
+			data:  map[string]string{"HOST": "db", "PORT": "5432", "USER": "root", "OTHER": "x"},
+			exp: envy.Diff{
+				{Key: "OTHER", Kind: envy.Removed, Old: "x"},
+				{Key: "USER", Kind: envy.Modified, Old: "root", New: "app"},
+			},
+			patch: `{"data":{"OTHER":null,"USER":"app"}}`,
+			after: desired,
+		},
+		{
+			name:  "in sync",
+			data:  map[string]string{"HOST": "db", "PORT": "5432", "USER": "app"},
+			exp:   envy.Diff{},
+			after: desired,
+		},
+		{
+			name: "missing",
+			exp: envy.Diff{
+				{Key: "HOST", Kind: envy.Added, New: "db"},
+				{Key: "PORT", Kind: envy.Added, New: "5432"},
+				{Key: "USER", Kind: envy.Added, New: "app"},
+			},
+			creates: 1,
+			after:   desired,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			fc := &fakeClient{kind: tc.kind, data: tc.data}
+			rec := &Reconciler{Client: fc, Kind: tc.kind, Name: "app", Prune: tc.prune}
+			env := envy.FromMap(map[string]string{"HOST": "db", "PORT": "5432", "USER": "app"})
+
+			plan, err := rec.Plan(context.Background(), env)
+			r.NoError(err)
+			r.Equal(tc.exp, plan)
+			r.Empty(fc.patches)
+			r.Zero(fc.creates)
+
+			d, err := rec.Reconcile(context.Background(), env)
+			r.NoError(err)
+			r.Equal(tc.exp, d)
+			r.Equal(tc.creates, fc.creates)
+			r.Equal(tc.after, fc.data)
+
+			if tc.patch == "" {
+				r.Empty(fc.patches)
+			} else {
+				r.Equal([]string{tc.patch}, fc.patches)
+			}
+
+			d, err = rec.Reconcile(context.Background(), env)
+			r.NoError(err)
+			r.Empty(d)
+		})
+	}
+}
+
+func Test_Reconciler_Errors(t *testing.T) {
+	t.Parallel()
+
+	env := envy.FromMap(map[string]string{"A": "1"})
+
+	tcs := []struct {
+		name string
+		rec  *Reconciler
+		env  *envy.Env
+	}{
+		{name: "no client", rec: &Reconciler{Name: "app"}, env: env},
+		{name: "no name", rec: &Reconciler{Client: &fakeClient{}}, env: env},
+		{name: "bad kind", rec: &Reconciler{Client: &fakeClient{}, Name: "app", Kind: "Pod"}, env: env},
+		{name: "bad key", rec: &Reconciler{Client: &fakeClient{}, Name: "app"}, env: envy.FromMap(map[string]string{"A B": "1"})},
+		{name: "get fails", rec: &Reconciler{Client: &fakeClient{fail: fmt.Errorf("forbidden")}, Name: "app"}, env: env},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			_, err := tc.rec.Reconcile(context.Background(), tc.env)
+			r.Error(err)
+		})
+	}
+}
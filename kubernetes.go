@@ -0,0 +1,237 @@
+//go:build !envy_minimal
+
+package envy
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FromKubernetesManifest reads the ConfigMaps and Secrets of a Kubernetes
+// manifest from r, in YAML or JSON, into an Env, so local development can
+// use the values deployed to a cluster. The manifest may hold several
+// documents separated by "---", and List objects, such as the output of
+// "kubectl get -o yaml"; objects of other kinds are skipped. A ConfigMap
+// contributes its data and its base64-decoded binaryData; a Secret its
+// base64-decoded data and its stringData, which wins over data as it does
+// in the API server. When objects set the same key, the last one wins, as
+// with envFrom. Each value's Meta names the object it came from, and
+// values from Secrets are marked Secret. It returns an error for a nil
+// reader, invalid YAML or base64, or a manifest with no ConfigMap or
+// Secret.
+func FromKubernetesManifest(r io.Reader) (*Env, error) {
+	if r == nil {
+		return nil, fmt.Errorf("nil reader")
+	}
+
+	em := map[string]string{}
+	meta := map[string]Meta{}
+	found := false
+
+	var add func(obj k8sObject) error
+	add = func(obj k8sObject) error {
+		for _, item := range obj.Items {
+			if err := add(item); err != nil {
+				return err
+			}
+		}
+
+		data, err := obj.values()
+		if err != nil || data == nil {
+			return err
+		}
+		found = true
+
+		m := Meta{Loader: "kubernetes " + obj.Kind + "/" + obj.Metadata.Name}
+		if obj.Kind == "Secret" {
+			m.Sensitivity = Secret
+		}
+
+		for k, v := range data {
+			em[k] = v
+			meta[k] = m
+		}
+		return nil
+	}
+
+	dec := yaml.NewDecoder(r)
+	for {
+		var obj k8sObject
+		err := dec.Decode(&obj)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := add(obj); err != nil {
+			return nil, err
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no ConfigMap or Secret in manifest")
+	}
+
+	e := FromMap(em)
+	for k := range meta {
+		if _, ok := em[k]; !ok {
+			delete(meta, k)
+		}
+	}
+	e.meta = meta
+	return e, nil
+}
+
+// k8sObject holds the fields FromKubernetesManifest reads from a
+// ConfigMap, a Secret, or a List of them.
+type k8sObject struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+
+	Data       map[string]string `yaml:"data"`
+	StringData map[string]string `yaml:"stringData"`
+	BinaryData map[string]string `yaml:"binaryData"`
+
+	Items []k8sObject `yaml:"items"`
+}
+
+// values returns the decoded data of a ConfigMap or Secret, or nil for
+// other kinds.
+func (obj k8sObject) values() (map[string]string, error) {
+	out := map[string]string{}
+
+	decode := func(src map[string]string) error {
+		keys := make([]string, 0, len(src))
+		for k := range src {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			b, err := base64.StdEncoding.DecodeString(src[k])
+			if err != nil {
+				return fmt.Errorf("%s/%s: %s: %w", obj.Kind, obj.Metadata.Name, k, err)
+			}
+			out[k] = string(b)
+		}
+		return nil
+	}
+
+	switch obj.Kind {
+	case "ConfigMap":
+		if err := decode(obj.BinaryData); err != nil {
+			return nil, err
+		}
+		for k, v := range obj.Data {
+			out[k] = v
+		}
+	case "Secret":
+		if err := decode(obj.Data); err != nil {
+			return nil, err
+		}
+		for k, v := range obj.StringData {
+			out[k] = v
+		}
+	default:
+		return nil, nil
+	}
+	return out, nil
+}
+
+// ToKubernetesEnv writes the Env to w as the YAML "env:" list of a
+// Kubernetes container spec, a name/value pair per variable, sorted by
+// name, for generating deployment manifests from the Env an app uses.
+// Values are written as is; use Redact first to hide secrets.
+func (e *Env) ToKubernetesEnv(w io.Writer) error {
+	if w == nil {
+		return fmt.Errorf("nil writer")
+	}
+
+	type envVar struct {
+		Name  string `yaml:"name"`
+		Value string `yaml:"value"`
+	}
+
+	ents, err := e.exportEntries()
+	if err != nil {
+		return err
+	}
+
+	vars := []envVar{}
+	for _, ent := range ents {
+		vars = append(vars, envVar{Name: ent.key, Value: ent.value})
+	}
+
+	return k8sEncode(w, map[string]any{"env": vars})
+}
+
+// ToKubernetesConfigMap writes the Env to w as a YAML ConfigMap manifest
+// named name, in namespace unless it is empty, holding every variable in
+// its data, which FromKubernetesManifest reads back. Values are written as
+// is; use Redact first to hide secrets. It returns an error for an empty
+// name, or a key that is not a valid ConfigMap key: letters, digits, '-',
+// '_', and '.'.
+func (e *Env) ToKubernetesConfigMap(w io.Writer, name, namespace string) error {
+	if w == nil {
+		return fmt.Errorf("nil writer")
+	}
+
+	if name == "" {
+		return fmt.Errorf("empty ConfigMap name")
+	}
+
+	type metadata struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace,omitempty"`
+	}
+
+	type configMap struct {
+		APIVersion string            `yaml:"apiVersion"`
+		Kind       string            `yaml:"kind"`
+		Metadata   metadata          `yaml:"metadata"`
+		Data       map[string]string `yaml:"data"`
+	}
+
+	cm := configMap{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   metadata{Name: name, Namespace: namespace},
+		Data:       map[string]string{},
+	}
+
+	ents, err := e.exportEntries()
+	if err != nil {
+		return err
+	}
+
+	for _, ent := range ents {
+		if strings.IndexFunc(ent.key, func(r rune) bool {
+			return r != '-' && r != '_' && r != '.' && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9')
+		}) >= 0 {
+			return fmt.Errorf("%q: invalid ConfigMap key", ent.key)
+		}
+		cm.Data[ent.key] = ent.value
+	}
+
+	return k8sEncode(w, cm)
+}
+
+// k8sEncode writes v to w as YAML indented the way kubectl does.
+func k8sEncode(w io.Writer, v any) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,231 @@
+//go:build !envy_minimal
+
+package envy
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromKubernetesManifest(t *testing.T) {
+	t.Parallel()
+
+	const manifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  PORT: "8080"
+  MODE: dev
+binaryData:
+  BLOB: aGVsbG8=
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: app-secrets
+data:
+  DB_PASSWORD: czNjcjN0
+  MODE: cHJvZA==
+stringData:
+  DB_PASSWORD: override
+`
+
+	const list = `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {"kind": "ConfigMap", "metadata": {"name": "a"}, "data": {"A": "1", "B": "1"}},
+    {"kind": "ConfigMap", "metadata": {"name": "b"}, "data": {"B": "2"}}
+  ]
+}`
+
+	tcs := []struct {
+		name string
+		in   string
+		exp  map[string]string
+		err  string
+	}{
+		{
+			name: "documents",
+			in:   manifest,
+			exp:  map[string]string{"PORT": "8080", "MODE": "prod", "BLOB": "hello", "DB_PASSWORD": "override"},
+		},
+		{
+			name: "JSON list",
+			in:   list,
+			exp:  map[string]string{"A": "1", "B": "2"},
+		},
+		{
+			name: "empty data",
+			in:   "kind: ConfigMap\nmetadata:\n  name: empty\n",
+			exp:  map[string]string{},
+		},
+		{name: "bad base64", in: "kind: Secret\nmetadata:\n  name: s\ndata:\n  A: '%%%'\n", err: "Secret/s: A: illegal base64 data"},
+		{name: "no config", in: "kind: Deployment\n", err: "no ConfigMap or Secret in manifest"},
+		{name: "empty", in: "", err: "no ConfigMap or Secret in manifest"},
+		{name: "invalid YAML", in: "kind: [\n", err: "yaml:"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			env, err := FromKubernetesManifest(strings.NewReader(tc.in))
+			if tc.err != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, env.jsonMap())
+		})
+	}
+
+	r := require.New(t)
+	_, err := FromKubernetesManifest(nil)
+	r.Error(err)
+}
+
+func Test_FromKubernetesManifest_meta(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	const manifest = `kind: ConfigMap
+metadata: {name: app}
+data: {PORT: "8080"}
+---
+kind: Secret
+metadata: {name: creds}
+stringData: {TOKEN: abc}
+`
+
+	env, err := FromKubernetesManifest(strings.NewReader(manifest))
+	r.NoError(err)
+
+	r.Equal("kubernetes ConfigMap/app", env.Source("PORT"))
+	r.False(env.IsSecret("PORT"))
+
+	r.Equal("kubernetes Secret/creds", env.Source("TOKEN"))
+	r.True(env.IsSecret("TOKEN"))
+}
+
+func Test_Env_ToKubernetesEnv(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  *Env
+		exp  string
+	}{
+		{name: "nil env", env: nil, exp: "env: []\n"},
+		{
+			name: "sorted and quoted",
+			env:  FromMap(map[string]string{"PORT": "8080", "DEBUG": "true", "EMPTY": "", "TEXT": "a: b"}),
+			exp: `env:
+  - name: DEBUG
+    value: "true"
+  - name: EMPTY
+    value: ""
+  - name: PORT
+    value: "8080"
+  - name: TEXT
+    value: 'a: b'
+`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			bb := &bytes.Buffer{}
+			r.NoError(tc.env.ToKubernetesEnv(bb))
+			r.Equal(tc.exp, bb.String())
+		})
+	}
+
+	r := require.New(t)
+	r.Error(Zero().ToKubernetesEnv(nil))
+
+	denied := FromMap(map[string]string{"PORT": "8080"})
+	r.NoError(denied.SetPolicy(PolicyFunc(func(PolicyInput) error {
+		return errors.New("no exports")
+	})))
+	r.ErrorIs(denied.ToKubernetesEnv(io.Discard), ErrPolicy)
+	r.ErrorIs(denied.ToKubernetesConfigMap(io.Discard, "app", ""), ErrPolicy)
+}
+
+func Test_Env_ToKubernetesConfigMap(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name      string
+		env       *Env
+		cm        string
+		namespace string
+		exp       string
+		err       string
+	}{
+		{
+			name:      "manifest",
+			env:       FromMap(map[string]string{"PORT": "8080", "app.mode": "dev"}),
+			cm:        "app",
+			namespace: "web",
+			exp: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  namespace: web
+data:
+  PORT: "8080"
+  app.mode: dev
+`,
+		},
+		{
+			name: "no namespace",
+			env:  Zero(),
+			cm:   "app",
+			exp:  "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata: {}\n",
+		},
+		{name: "no name", env: Zero(), err: "empty ConfigMap name"},
+		{name: "bad key", env: FromMap(map[string]string{"A B": "1"}), cm: "app", err: `"A B": invalid ConfigMap key`},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			bb := &bytes.Buffer{}
+			err := tc.env.ToKubernetesConfigMap(bb, tc.cm, tc.namespace)
+			if tc.err != "" {
+				r.Error(err)
+				r.Equal(tc.err, err.Error())
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, bb.String())
+
+			env, err := FromKubernetesManifest(bb)
+			r.NoError(err)
+			r.Equal(tc.env.jsonMap(), env.jsonMap())
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package envy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LoadStats summarizes what one or more loads read, for logging at startup
+// to confirm which configuration a service actually got. Pass it to
+// WithStats; it accumulates across every file parsed with the option, such
+// as all the layers read by FromConvention.
+type LoadStats struct {
+	// Files are the files read, in order.
+	Files []string `json:"files"`
+	// Missing are the optional files that did not exist, such as those
+	// skipped by FromFileOptional and FromConvention.
+	Missing []string `json:"missing,omitempty"`
+	// Lines counts the lines read.
+	Lines int `json:"lines"`
+	// Entries counts the KEY=VALUE entries parsed.
+	Entries int `json:"entries"`
+	// Added counts the keys set for the first time.
+	Added int `json:"added"`
+	// Overwritten counts the entries that set a key already set, earlier
+	// in the same file or by a lower layer.
+	Overwritten int `json:"overwritten"`
+	// Duration is the time spent reading and parsing.
+	Duration time.Duration `json:"duration"`
+}
+
+func (s LoadStats) String() string {
+	return fmt.Sprintf("loaded %d files, %d lines, %d entries (%d added, %d overwritten) in %s",
+		len(s.Files), s.Lines, s.Entries, s.Added, s.Overwritten, s.Duration)
+}
+
+// WithStats makes loading add what it read to s. Only files that load
+// successfully are counted.
+func WithStats(s *LoadStats) ParseOption {
+	return func(o *parseOptions) {
+		o.stats = s
+	}
+}
+
+// record adds a file that read n lines and parsed to the entries among
+// lines, before duplicates were resolved, starting at start.
+func (s *LoadStats) record(path string, n int, lines []string, start time.Time) {
+	if s == nil {
+		return
+	}
+
+	keys := map[string]bool{}
+	entries := 0
+	for _, line := range lines {
+		if k, _, ok := lineEntry(line); ok {
+			keys[k] = true
+			entries++
+		}
+	}
+
+	s.Files = append(s.Files, path)
+	s.Lines += n
+	s.Entries += entries
+	s.Added += len(keys)
+	s.Overwritten += entries - len(keys)
+	s.Duration += time.Since(start)
+}
+
+// layered moves the keys of layer already set in base from Added to
+// Overwritten, for loaders that merge one file over another.
+func (s *LoadStats) layered(base, layer *Env) {
+	if s == nil {
+		return
+	}
+
+	for _, kv := range layer.Environ() {
+		k, _, _ := strings.Cut(kv, "=")
+		if _, ok := base.raw(k); ok {
+			s.Added--
+			s.Overwritten++
+		}
+	}
+}
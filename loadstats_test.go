@@ -0,0 +1,91 @@
+package envy
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithStats(t *testing.T) {
+	t.Parallel()
+
+	cab := fstest.MapFS{
+		".env":            {Data: []byte("# base\nA=1\nB=2\nA=3\n\nC=4\n")},
+		".env.production": {Data: []byte("B=5\nD=6\n")},
+		"bad.env":         {Data: []byte("A='open\n")},
+	}
+
+	tcs := []struct {
+		name string
+		load func(s *LoadStats) error
+		exp  LoadStats
+	}{
+		{
+			name: "file",
+			load: func(s *LoadStats) error {
+				_, err := FromFile(cab, ".env", WithStats(s))
+				return err
+			},
+			exp: LoadStats{Files: []string{".env"}, Lines: 6, Entries: 4, Added: 3, Overwritten: 1},
+		},
+		{
+			name: "sandboxed",
+			load: func(s *LoadStats) error {
+				_, err := FromFile(cab, ".env", Sandboxed(Limits{}), WithStats(s))
+				return err
+			},
+			exp: LoadStats{Files: []string{".env"}, Lines: 6, Entries: 4, Added: 3, Overwritten: 1},
+		},
+		{
+			name: "convention",
+			load: func(s *LoadStats) error {
+				_, err := FromConvention(cab, "production", WithStats(s))
+				return err
+			},
+			exp: LoadStats{
+				Files:       []string{".env", ".env.production"},
+				Missing:     []string{".env.local", ".env.production.local"},
+				Lines:       8,
+				Entries:     6,
+				Added:       4,
+				Overwritten: 2,
+			},
+		},
+		{
+			name: "failure not counted",
+			load: func(s *LoadStats) error {
+				_, err := FromFile(cab, "bad.env", WithStats(s))
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return nil
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			var s LoadStats
+			r.NoError(tc.load(&s))
+
+			if len(s.Files) > 0 {
+				r.Positive(s.Duration)
+			}
+			s.Duration = 0
+			r.Equal(tc.exp, s)
+		})
+	}
+}
+
+func Test_LoadStats_String(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	s := LoadStats{Files: []string{".env", ".env.local"}, Lines: 10, Entries: 8, Added: 6, Overwritten: 2, Duration: 1500 * time.Microsecond}
+	r.Equal("loaded 2 files, 10 lines, 8 entries (6 added, 2 overwritten) in 1.5ms", s.String())
+}
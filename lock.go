@@ -0,0 +1,60 @@
+package envy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrLocked is returned when a file lock could not be acquired before the
+// timeout.
+var ErrLocked = errors.New("file is locked")
+
+// lockRetry is how long LockFile waits between attempts.
+const lockRetry = 25 * time.Millisecond
+
+// LockFile takes an exclusive advisory lock for path, so that cooperating
+// tools editing the same env file do not overwrite each other's changes. The
+// lock is held on a sidecar file, path + ".lock", rather than on path itself,
+// so that it survives path being replaced by a rename. If another process
+// holds the lock, LockFile retries until timeout has elapsed and then returns
+// an error wrapping ErrLocked; a zero timeout tries exactly once. The
+// returned func releases the lock.
+//
+// Locks are advisory: they only exclude other callers of LockFile (or
+// tools using flock(2) on the same lock file), not arbitrary writers.
+func LockFile(path string, timeout time.Duration) (func() error, error) {
+	name := path + ".lock"
+
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+
+		if ok {
+			break
+		}
+
+		if !time.Now().Before(deadline) {
+			_ = f.Close()
+			return nil, fmt.Errorf("%s: %w", path, ErrLocked)
+		}
+
+		time.Sleep(lockRetry)
+	}
+
+	return func() error {
+		uerr := unlock(f)
+		cerr := f.Close()
+		return errors.Join(uerr, cerr)
+	}, nil
+}
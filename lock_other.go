@@ -0,0 +1,19 @@
+//go:build !unix && !windows
+
+package envy
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// tryLock reports that file locking is unsupported on this platform.
+func tryLock(f *os.File) (bool, error) {
+	return false, fmt.Errorf("file locking is not supported on %s", runtime.GOOS)
+}
+
+// unlock is a no-op on this platform.
+func unlock(f *os.File) error {
+	return nil
+}
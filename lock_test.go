@@ -0,0 +1,42 @@
+package envy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LockFile(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	path := filepath.Join(t.TempDir(), ".env")
+
+	release, err := LockFile(path, 0)
+	r.NoError(err)
+
+	// a second lock, even from the same process, is refused
+	_, err = LockFile(path, 2*lockRetry)
+	r.True(errors.Is(err, ErrLocked))
+
+	// and is granted once the first is released
+	done := make(chan error, 1)
+	go func() {
+		unlock, err := LockFile(path, time.Second)
+		if err == nil {
+			err = unlock()
+		}
+		done <- err
+	}()
+
+	time.Sleep(2 * lockRetry)
+	r.NoError(release())
+	r.NoError(<-done)
+
+	_, err = os.Stat(path + ".lock")
+	r.NoError(err)
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package envy
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLock attempts to take an exclusive flock on f without blocking.
+func tryLock(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unlock releases the lock on f.
+func unlock(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,162 @@
+package envy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FromMakefile reads Makefile-style variable assignments, such as those kept
+// in a shared config.mk, from r. The assignment operators are:
+//
+//	KEY = value     recursively expanded
+//	KEY := value    simply expanded (also ::=)
+//	KEY ?= value    set only if KEY is not already set
+//	KEY += value    append to KEY, separated by a space
+//
+// "?=" defaults defer to values already in base, which is typically the
+// process environment as with make itself, and to earlier assignments in
+// the file; base may be nil. The result contains only the variables the file
+// assigns or appends to. $(VAR) and ${VAR} references are expanded against
+// the file's variables and then base when a line is read, for both "=" and
+// ":=". An optional "export" or "override" prefix is accepted. Comments,
+// blank lines, and lines that are not assignments, such as rules and
+// directives, are ignored; a trailing backslash continues a line.
+func FromMakefile(r io.Reader, base *Env) (*Env, error) {
+	if r == nil {
+		return nil, fmt.Errorf("nil reader")
+	}
+
+	em := map[string]string{}
+	lookup := func(k string) (string, bool) {
+		if v, ok := em[k]; ok {
+			return v, true
+		}
+		return base.Lookupenv(k)
+	}
+
+	var cont strings.Builder
+
+	buf := bufio.NewScanner(r)
+	for buf.Scan() {
+		line := buf.Text()
+		if cont.Len() > 0 {
+			line = strings.TrimLeft(line, " \t")
+		}
+
+		// like make, join continued lines with a single space
+		if s, ok := strings.CutSuffix(line, "\\"); ok {
+			cont.WriteString(strings.TrimRight(s, " \t"))
+			cont.WriteByte(' ')
+			continue
+		}
+
+		cont.WriteString(line)
+		line = cont.String()
+		cont.Reset()
+
+		k, op, v, ok := parseMakeLine(line)
+		if !ok {
+			continue
+		}
+
+		v = expandMake(v, lookup)
+
+		switch op {
+		case "?=":
+			if _, set := lookup(k); set {
+				continue
+			}
+		case "+=":
+			if old, set := lookup(k); set && old != "" {
+				v = old + " " + v
+			}
+		}
+		em[k] = v
+	}
+
+	if err := buf.Err(); err != nil {
+		return nil, err
+	}
+
+	return FromMap(em), nil
+}
+
+// makeOps are the assignment operators, longest first.
+var makeOps = []string{"::=", ":=", "?=", "+=", "="}
+
+// parseMakeLine splits an assignment into key, operator, and value.
+func parseMakeLine(line string) (string, string, string, bool) {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+
+	// recipe lines start with a tab
+	if strings.HasPrefix(line, "\t") {
+		return "", "", "", false
+	}
+
+	line = strings.TrimSpace(line)
+	for _, p := range []string{"export ", "override "} {
+		line = strings.TrimSpace(strings.TrimPrefix(line, p))
+	}
+
+	i := strings.IndexAny(line, ":?+=")
+	if i <= 0 {
+		return "", "", "", false
+	}
+
+	for _, op := range makeOps {
+		if !strings.HasPrefix(line[i:], op) {
+			continue
+		}
+
+		k := strings.TrimSpace(line[:i])
+		if strings.ContainsAny(k, " \t$") {
+			return "", "", "", false
+		}
+
+		v := strings.TrimSpace(line[i+len(op):])
+		if op == "::=" {
+			op = ":="
+		}
+		return k, op, v, true
+	}
+
+	// a rule such as "target: deps"
+	return "", "", "", false
+}
+
+// expandMake replaces $(VAR) and ${VAR} references in s, and "$$" with "$".
+func expandMake(s string, lookup func(string) (string, bool)) string {
+	var bb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 >= len(s) {
+			bb.WriteByte(s[i])
+			continue
+		}
+
+		switch open := s[i+1]; open {
+		case '$':
+			bb.WriteByte('$')
+			i++
+		case '(', '{':
+			end := strings.IndexByte(s[i+2:], map[byte]byte{'(': ')', '{': '}'}[open])
+			if end < 0 {
+				bb.WriteString(s[i:])
+				return bb.String()
+			}
+
+			v, _ := lookup(s[i+2 : i+2+end])
+			bb.WriteString(v)
+			i += 2 + end
+		default:
+			// single-letter variables such as $X
+			v, _ := lookup(s[i+1 : i+2])
+			bb.WriteString(v)
+			i++
+		}
+	}
+	return bb.String()
+}
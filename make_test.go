@@ -0,0 +1,79 @@
+package envy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromMakefile(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		in   string
+		base *Env
+		exp  []string
+	}{
+		{
+			name: "empty",
+			exp:  []string{},
+		},
+		{
+			name: "operators",
+			in: `# shared configuration
+APP := envy
+VERSION = 1.0
+PORT ?= 3000
+PORT ?= 4000
+FLAGS = -v
+FLAGS += -race
+SIMPLE ::= yes
+`,
+			exp: []string{"APP=envy", "FLAGS=-v -race", "PORT=3000", "SIMPLE=yes", "VERSION=1.0"},
+		},
+		{
+			name: "defaults defer to base",
+			in:   "PORT ?= 3000\nHOST ?= localhost\n",
+			base: FromMap(map[string]string{"PORT": "8080"}),
+			exp:  []string{"HOST=localhost"},
+		},
+		{
+			name: "expansion",
+			in:   "ROOT := /srv\nBIN = $(ROOT)/bin\nLIB = ${ROOT}/lib/$(NAME)\nPRICE = $$5\nX = $Y\n",
+			base: FromMap(map[string]string{"NAME": "app", "Y": "why"}),
+			exp:  []string{"BIN=/srv/bin", "LIB=/srv/lib/app", "PRICE=$5", "ROOT=/srv", "X=why"},
+		},
+		{
+			name: "append to base",
+			in:   "CFLAGS += -O2\n",
+			base: FromMap(map[string]string{"CFLAGS": "-g"}),
+			exp:  []string{"CFLAGS=-g -O2"},
+		},
+		{
+			name: "ignores rules, recipes, and directives",
+			in:   "all: build\n\tgo build ./... # X = 1\ninclude other.mk\nexport GOFLAGS = -mod=mod\noverride CGO_ENABLED := 0\nifeq ($(A),1)\nendif\n",
+			exp:  []string{"CGO_ENABLED=0", "GOFLAGS=-mod=mod"},
+		},
+		{
+			name: "continuation lines and comments",
+			in:   "PKGS = a \\\n  b \\\n  c # the packages\n",
+			exp:  []string{"PKGS=a b c"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			env, err := FromMakefile(strings.NewReader(tc.in), tc.base)
+			r.NoError(err)
+			r.Equal(tc.exp, env.Environ())
+		})
+	}
+
+	_, err := FromMakefile(nil, nil)
+	require.Error(t, err)
+}
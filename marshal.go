@@ -0,0 +1,175 @@
+package envy
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Marshal returns an Env holding the fields of the struct v, or a pointer
+// to one, that have an `env:"KEY"` tag, for building the environment of a
+// child process from a typed config. A tag of `env:"KEY,omitempty"` leaves
+// out zero values, and nil pointers are always left out. Fields of embedded
+// structs are included as if they were the outer struct's, and a struct
+// field tagged `envPrefix:"DB_"` has its own fields included with their
+// keys prefixed.
+//
+// Values are written in the forms Get reads: time.Duration by its String
+// method, *url.URL as a URL, types implementing encoding.TextMarshaler by
+// it, including time.Time as RFC 3339, basic types by strconv, and slices
+// as comma-separated lists. It returns an error naming the field for any
+// other type, or for a slice item containing a comma.
+func Marshal(v any) (*Env, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("nil value")
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot marshal %T: not a struct", v)
+	}
+
+	em := map[string]string{}
+	if err := marshalStruct(em, rv, ""); err != nil {
+		return nil, err
+	}
+	return FromMap(em), nil
+}
+
+// marshalStruct adds the tagged fields of the struct rv to em, with prefix
+// before their keys.
+func marshalStruct(em map[string]string, rv reflect.Value, prefix string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		fv := rv.Field(i)
+
+		tag, tagged := sf.Tag.Lookup("env")
+		nested, isNested := sf.Tag.Lookup("envPrefix")
+
+		if !sf.IsExported() || tag == "-" {
+			continue
+		}
+
+		if (sf.Anonymous && !tagged) || isNested {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+
+			if fv.Kind() != reflect.Struct {
+				if isNested {
+					return fmt.Errorf("%s: envPrefix on a %s", sf.Name, fv.Type())
+				}
+				continue
+			}
+
+			if err := marshalStruct(em, fv, prefix+nested); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !tagged {
+			continue
+		}
+
+		key, opts, _ := strings.Cut(tag, ",")
+		if key == "" {
+			return fmt.Errorf("%s: empty env tag", sf.Name)
+		}
+		key = prefix + key
+
+		if !validKey(key) {
+			return fmt.Errorf("%s: invalid key %q", sf.Name, key)
+		}
+
+		if opts == "omitempty" && fv.IsZero() {
+			continue
+		}
+
+		if fv.Kind() == reflect.Pointer && fv.IsNil() {
+			continue
+		}
+
+		s, err := marshalValue(fv, true)
+		if err != nil {
+			return fmt.Errorf("%s: %w", sf.Name, err)
+		}
+		em[key] = s
+	}
+	return nil
+}
+
+// marshalValue returns the text of v, following the order documented on
+// Marshal. Slices are only allowed if slices is true, so that their items
+// cannot be lists themselves.
+func marshalValue(v reflect.Value, slices bool) (string, error) {
+	switch x := v.Interface().(type) {
+	case time.Duration:
+		return x.String(), nil
+	case *url.URL:
+		return x.String(), nil
+	case []byte:
+		return string(x), nil
+	case encoding.TextMarshaler:
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return "", nil
+		}
+		b, err := x.MarshalText()
+		return string(b), err
+	}
+
+	if v.CanAddr() {
+		if m, ok := v.Addr().Interface().(encoding.TextMarshaler); ok {
+			b, err := m.MarshalText()
+			return string(b), err
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	case reflect.Pointer:
+		if v.IsNil() {
+			return "", nil
+		}
+		return marshalValue(v.Elem(), slices)
+	case reflect.Slice, reflect.Array:
+		if !slices {
+			break
+		}
+
+		items := make([]string, v.Len())
+		for i := range items {
+			s, err := marshalValue(v.Index(i), false)
+			if err != nil {
+				return "", fmt.Errorf("item %d: %w", i, err)
+			}
+
+			if strings.Contains(s, ",") {
+				return "", fmt.Errorf("item %d: %q contains a comma", i, s)
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	}
+	return "", fmt.Errorf("cannot marshal %s", v.Type())
+}
@@ -0,0 +1,160 @@
+package envy
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type marshalDB struct {
+	Host string `env:"HOST"`
+	Port int    `env:"PORT"`
+}
+
+type Marshaled struct {
+	Name string `env:"NAME"`
+}
+
+type marshalConfig struct {
+	Marshaled
+
+	Debug    bool          `env:"DEBUG"`
+	Workers  uint8         `env:"WORKERS"`
+	Ratio    float32       `env:"RATIO"`
+	Timeout  time.Duration `env:"TIMEOUT"`
+	Start    time.Time     `env:"START"`
+	Endpoint *url.URL      `env:"ENDPOINT"`
+	Hosts    []string      `env:"HOSTS"`
+	Ports    []int         `env:"PORTS"`
+	Raw      []byte        `env:"RAW"`
+	Level    level         `env:"LEVEL"`
+	Mode     upper         `env:"MODE"`
+	Optional *int          `env:"OPTIONAL"`
+	Empty    string        `env:"EMPTY,omitempty"`
+	Skipped  string        `env:"-"`
+	Untagged string
+	DB       marshalDB         `envPrefix:"DB_"`
+	Replica  *marshalDB        `envPrefix:"REPLICA_"`
+	Labels   map[string]string `env:"-"`
+
+	secret string `env:"SECRET"`
+}
+
+func Test_Marshal(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	u, err := url.Parse("https://example.com/api")
+	r.NoError(err)
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	cfg := marshalConfig{
+		Marshaled: Marshaled{Name: "app"},
+		Debug:     true,
+		Workers:   8,
+		Ratio:     0.1,
+		Timeout:   90 * time.Second,
+		Start:     start,
+		Endpoint:  u,
+		Hosts:     []string{"a", "b"},
+		Ports:     []int{80, 443},
+		Raw:       []byte("raw,bytes"),
+		Level:     3,
+		Mode:      "prod",
+		Skipped:   "x",
+		Untagged:  "x",
+		DB:        marshalDB{Host: "db", Port: 5432},
+		secret:    "x",
+	}
+
+	env, err := Marshal(&cfg)
+	r.NoError(err)
+
+	r.Equal([]string{
+		"DB_HOST=db",
+		"DB_PORT=5432",
+		"DEBUG=true",
+		"ENDPOINT=https://example.com/api",
+		"HOSTS=a,b",
+		"LEVEL=3",
+		"MODE=prod",
+		"NAME=app",
+		"PORTS=80,443",
+		"RATIO=0.1",
+		"RAW=raw,bytes",
+		"START=2024-05-01T10:00:00Z",
+		"TIMEOUT=1m30s",
+		"WORKERS=8",
+	}, env.Environ())
+
+	// what Marshal writes, Get reads back
+	timeout, err := Get[time.Duration](env, "TIMEOUT")
+	r.NoError(err)
+	r.Equal(cfg.Timeout, timeout)
+
+	at, err := Get[time.Time](env, "START")
+	r.NoError(err)
+	r.Equal(start, at)
+
+	ports, err := Get[[]int](env, "PORTS")
+	r.NoError(err)
+	r.Equal(cfg.Ports, ports)
+
+	ratio, err := Get[float32](env, "RATIO")
+	r.NoError(err)
+	r.Equal(cfg.Ratio, ratio)
+
+	n := 0
+	cfg.Optional = &n
+	cfg.Replica = &marshalDB{Host: "replica"}
+
+	env, err = Marshal(cfg)
+	r.NoError(err)
+	r.Equal("0", env.Getenv("OPTIONAL"))
+	r.Equal("replica", env.Getenv("REPLICA_HOST"))
+	r.Equal("0", env.Getenv("REPLICA_PORT"))
+}
+
+func Test_Marshal_errors(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		v    any
+		err  string
+	}{
+		{name: "nil", v: (*marshalConfig)(nil), err: "nil value"},
+		{name: "not a struct", v: 1, err: "not a struct"},
+		{name: "unsupported", v: struct {
+			M map[string]string `env:"M"`
+		}{}, err: "M: cannot marshal map[string]string"},
+		{name: "comma", v: struct {
+			L []string `env:"L"`
+		}{L: []string{"a,b"}}, err: `L: item 0: "a,b" contains a comma`},
+		{name: "nested slice", v: struct {
+			L [][]int `env:"L"`
+		}{L: [][]int{{1}}}, err: "L: item 0: cannot marshal []int"},
+		{name: "empty tag", v: struct {
+			A string `env:",omitempty"`
+		}{}, err: "A: empty env tag"},
+		{name: "invalid key", v: struct {
+			A string `env:"A=B"`
+		}{}, err: `A: invalid key "A=B"`},
+		{name: "prefix on non struct", v: struct {
+			A string `envPrefix:"A_"`
+		}{}, err: "A: envPrefix on a string"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			_, err := Marshal(tc.v)
+			r.Error(err)
+			r.Contains(err.Error(), tc.err)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package envy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ApplyJSONMergePatch applies an RFC 7386 JSON Merge Patch to the Env. The
+// patch must be a flat JSON object: a null member removes the key, a string
+// member sets it, and number or boolean members are set to their JSON text.
+// Nested objects and arrays are rejected because an Env has no structure. The
+// patch is validated in full and then applied as a single change.
+func (e *Env) ApplyJSONMergePatch(b []byte) error {
+	if e.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(b, &patch); err != nil {
+		return fmt.Errorf("invalid merge patch: %w", err)
+	}
+
+	if patch == nil {
+		return fmt.Errorf("invalid merge patch: not an object")
+	}
+
+	keys := make([]string, 0, len(patch))
+	for k := range patch {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	d := Diff{}
+	for _, k := range keys {
+		raw := bytes.TrimSpace(patch[k])
+
+		if !validKey(k) {
+			return fmt.Errorf("invalid merge patch: invalid key %q", k)
+		}
+
+		switch raw[0] {
+		case 'n':
+			d = append(d, Change{Key: k, Kind: Removed})
+		case '"':
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return fmt.Errorf("invalid merge patch: %s: %w", k, err)
+			}
+			d = append(d, Change{Key: k, Kind: Modified, New: s})
+		case '{', '[':
+			return fmt.Errorf("invalid merge patch: %s: nested values are not supported", k)
+		default:
+			// numbers and booleans
+			d = append(d, Change{Key: k, Kind: Modified, New: string(raw)})
+		}
+	}
+
+	return e.Apply(d)
+}
@@ -0,0 +1,93 @@
+package envy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_ApplyJSONMergePatch(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name  string
+		env   *Env
+		patch string
+		exp   []string
+		err   bool
+	}{
+		{
+			name:  "nil env",
+			env:   nil,
+			patch: `{}`,
+			err:   true,
+		},
+		{
+			name:  "set, replace, and delete",
+			env:   FromMap(map[string]string{"A": "1", "B": "2", "C": "3"}),
+			patch: `{"A": "10", "C": null, "D": "4", "MISSING": null}`,
+			exp:   []string{"A=10", "B=2", "D=4"},
+		},
+		{
+			name:  "numbers and booleans",
+			env:   Zero(),
+			patch: `{"PORT": 8080, "DEBUG": true, "RATIO": 0.5}`,
+			exp:   []string{"DEBUG=true", "PORT=8080", "RATIO=0.5"},
+		},
+		{
+			name:  "empty patch",
+			env:   FromMap(map[string]string{"A": "1"}),
+			patch: `{}`,
+			exp:   []string{"A=1"},
+		},
+		{
+			name:  "nested object rejected atomically",
+			env:   FromMap(map[string]string{"A": "1"}),
+			patch: `{"A": "2", "B": {"C": "D"}}`,
+			exp:   []string{"A=1"},
+			err:   true,
+		},
+		{
+			name:  "array rejected",
+			env:   Zero(),
+			patch: `{"A": [1]}`,
+			err:   true,
+		},
+		{
+			name:  "invalid key",
+			env:   Zero(),
+			patch: `{"A=B": "1"}`,
+			err:   true,
+		},
+		{
+			name:  "not an object",
+			env:   Zero(),
+			patch: `null`,
+			err:   true,
+		},
+		{
+			name:  "invalid json",
+			env:   Zero(),
+			patch: `{`,
+			err:   true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			err := tc.env.ApplyJSONMergePatch([]byte(tc.patch))
+			if tc.err {
+				r.Error(err)
+			} else {
+				r.NoError(err)
+			}
+
+			if tc.exp != nil {
+				r.Equal(tc.exp, tc.env.Environ())
+			}
+		})
+	}
+}
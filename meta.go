@@ -0,0 +1,208 @@
+package envy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sensitivity classifies how carefully a value must be handled.
+type Sensitivity int
+
+const (
+	// Unclassified values have no declared sensitivity.
+	Unclassified Sensitivity = iota
+	// Public values may be logged and displayed.
+	Public
+	// Secret values must be redacted from output.
+	Secret
+)
+
+func (s Sensitivity) String() string {
+	switch s {
+	case Unclassified:
+		return "unclassified"
+	case Public:
+		return "public"
+	case Secret:
+		return "secret"
+	}
+	return fmt.Sprintf("Sensitivity(%d)", int(s))
+}
+
+// Meta is optional information about an entry, answering questions such as
+// "where did this value come from?".
+type Meta struct {
+	// Loader names what produced the value, such as "file".
+	Loader string
+	// File is the file the value was read from, if any.
+	File string
+	// Line is the 1-based line in File that set the value.
+	Line int
+	// Description documents the variable. Loaders take it from the
+	// comment lines directly above the entry.
+	Description string
+	// Sensitivity classifies the value.
+	Sensitivity Sensitivity
+	// Raw is the value as written, before FromFile or FromReader expanded
+	// its references with Interpolate, in that syntax, where "$$" is a
+	// literal "$". It is empty if nothing was expanded.
+	Raw string
+}
+
+// Meta returns the metadata recorded for key and whether there is any.
+// FromFile records the file, line, and description of every entry it reads,
+// and New attributes its entries to the process environment. Changing a
+// value clears its Loader, File, Line, and Raw, since they no longer
+// describe it, and unsetting a key drops its metadata entirely.
+func (e *Env) Meta(key string) (Meta, bool) {
+	if e.IsNil() {
+		return Meta{}, false
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	m, ok := e.meta[key]
+	return m, ok
+}
+
+// SetMeta replaces the metadata for key. It returns an error for a nil Env
+// or a key that is not set.
+func (e *Env) SetMeta(key string, m Meta) error {
+	if e.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.envs[key]; !ok {
+		return fmt.Errorf("%s is not set", key)
+	}
+
+	if e.meta == nil {
+		e.meta = map[string]Meta{}
+	}
+	e.meta[key] = m
+	return nil
+}
+
+// IsSecret reports whether the value of key must be protected: its Meta
+// marks it Secret, or it is Unclassified and IsSensitive matches its name.
+// Values marked Public never are.
+func (e *Env) IsSecret(key string) bool {
+	m, _ := e.Meta(key)
+	switch m.Sensitivity {
+	case Secret:
+		return true
+	case Public:
+		return false
+	}
+	return IsSensitive(key)
+}
+
+// processLoader is the Loader of values read from the process environment.
+const processLoader = "process environment"
+
+// Source describes where the value of key came from, such as
+// ".env.local line 12" for a value read by FromFile, "process environment"
+// for one read by New, or the Loader given to Attribute, such as
+// "provider ssm:///app/prod". Provenance survives Merge, so in a stack of
+// layered Envs it names the layer that won. It returns "" if the origin is
+// unknown, for example because the value was changed with Setenv.
+func (e *Env) Source(key string) string {
+	m, _ := e.Meta(key)
+	return metaSource(m)
+}
+
+// metaSource describes the origin recorded in m, as Source does.
+func metaSource(m Meta) string {
+	switch {
+	case m.File != "" && m.Line > 0:
+		return fmt.Sprintf("%s line %d", m.File, m.Line)
+	case m.File != "":
+		return m.File
+	}
+	return m.Loader
+}
+
+// Attribute sets the Loader of every entry whose origin is unknown, so that
+// providers and other loaders can record where their values came from, e.g.
+// "provider ssm:///app/prod". It returns an error for a nil Env.
+func (e *Env) Attribute(loader string) error {
+	if e.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for k := range e.envs {
+		if m := e.meta[k]; m.Loader == "" && m.File == "" {
+			e.attribute(k, loader)
+		}
+	}
+	return nil
+}
+
+// attribute sets the origin of key to loader. The lock must be held.
+func (e *Env) attribute(key, loader string) {
+	if e.meta == nil {
+		e.meta = map[string]Meta{}
+	}
+
+	m := e.meta[key]
+	m.Loader, m.File, m.Line = loader, "", 0
+	e.meta[key] = m
+}
+
+// processMeta attributes every key in envs to the process environment.
+func processMeta(envs map[string]string) map[string]Meta {
+	meta := make(map[string]Meta, len(envs))
+	for k := range envs {
+		meta[k] = Meta{Loader: processLoader}
+	}
+	return meta
+}
+
+// clearOrigin forgets where the value of key came from, keeping its
+// description and sensitivity. The lock must be held.
+func (e *Env) clearOrigin(key string) {
+	m, ok := e.meta[key]
+	if !ok {
+		return
+	}
+
+	m.Loader, m.File, m.Line, m.Raw = "", "", 0, ""
+	e.meta[key] = m
+}
+
+// fileMeta returns the metadata for the entries in lines, read from file
+// and decoded by dotenvLines, so that a key repeated later in the file is
+// attributed to its last occurrence. Comment lines directly
+// above an entry become its Description.
+func fileMeta(file string, lines []string) map[string]Meta {
+	meta := map[string]Meta{}
+
+	var comments []string
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+
+		if c, ok := strings.CutPrefix(line, "#"); ok && !strings.Contains(c, "=") {
+			comments = append(comments, strings.TrimSpace(c))
+			continue
+		}
+
+		if k, _, ok := lineEntry(line); ok {
+			meta[k] = Meta{
+				Loader:      "file",
+				File:        file,
+				Line:        i + 1,
+				Description: strings.Join(comments, "\n"),
+			}
+		}
+		comments = nil
+	}
+
+	return meta
+}
@@ -0,0 +1,212 @@
+package envy
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Sensitivity_String(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		s   Sensitivity
+		exp string
+	}{
+		{s: Unclassified, exp: "unclassified"},
+		{s: Public, exp: "public"},
+		{s: Secret, exp: "secret"},
+		{s: 9, exp: "Sensitivity(9)"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.exp, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			r.Equal(tc.exp, tc.s.String())
+		})
+	}
+}
+
+func Test_Env_Meta_FromFile(t *testing.T) {
+	t.Parallel()
+
+	cab := fstest.MapFS{
+		".env": &fstest.MapFile{Data: []byte(`# The port to listen on.
+# Defaults to 3000.
+PORT=8080
+
+HOST=localhost
+# repeated keys are attributed to the last line
+HOST=example.com
+`)},
+	}
+
+	env, err := FromFile(cab, ".env")
+	require.NoError(t, err)
+
+	tcs := []struct {
+		key string
+		exp Meta
+		ok  bool
+	}{
+		{
+			key: "PORT",
+			exp: Meta{Loader: "file", File: ".env", Line: 3, Description: "The port to listen on.\nDefaults to 3000."},
+			ok:  true,
+		},
+		{
+			key: "HOST",
+			exp: Meta{Loader: "file", File: ".env", Line: 7, Description: "repeated keys are attributed to the last line"},
+			ok:  true,
+		},
+		{
+			key: "MISSING",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			m, ok := env.Meta(tc.key)
+			r.Equal(tc.ok, ok)
+			r.Equal(tc.exp, m)
+		})
+	}
+}
+
+func Test_Env_SetMeta(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	var nilEnv *Env
+	r.Error(nilEnv.SetMeta("A", Meta{}))
+
+	_, ok := nilEnv.Meta("A")
+	r.False(ok)
+
+	env := FromMap(map[string]string{"TOKEN": "t"})
+	r.Error(env.SetMeta("MISSING", Meta{}))
+
+	m := Meta{Loader: "vault", Description: "api token", Sensitivity: Secret}
+	r.NoError(env.SetMeta("TOKEN", m))
+
+	got, ok := env.Meta("TOKEN")
+	r.True(ok)
+	r.Equal(m, got)
+
+	// setting the same value keeps the origin
+	r.NoError(env.Setenv("TOKEN", "t"))
+	got, _ = env.Meta("TOKEN")
+	r.Equal(m, got)
+
+	// changing the value clears the origin only
+	r.NoError(env.Setenv("TOKEN", "t2"))
+	got, ok = env.Meta("TOKEN")
+	r.True(ok)
+	r.Equal(Meta{Description: "api token", Sensitivity: Secret}, got)
+
+	// unsetting drops the metadata
+	r.NoError(env.Unsetenv("TOKEN"))
+	_, ok = env.Meta("TOKEN")
+	r.False(ok)
+}
+
+func Test_Env_Source(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	cab := fstest.MapFS{
+		".env":       &fstest.MapFile{Data: []byte("PORT=3000\nHOST=localhost\n")},
+		".env.local": &fstest.MapFile{Data: []byte("# local override\n\nPORT=8080\n")},
+	}
+
+	base, err := FromFile(cab, ".env")
+	r.NoError(err)
+
+	local, err := FromFile(cab, ".env.local")
+	r.NoError(err)
+
+	remote := FromMap(map[string]string{"TOKEN": "t", "HOST": "db"})
+	r.NoError(remote.SetMeta("HOST", Meta{Loader: "consul"}))
+	r.NoError(remote.Attribute("provider ssm:///app/prod"))
+
+	env, err := base.Merge(local)
+	r.NoError(err)
+
+	env, err = env.Merge(remote)
+	r.NoError(err)
+
+	r.NoError(env.Setenv("EXTRA", "x"))
+
+	tcs := []struct {
+		key string
+		exp string
+	}{
+		{key: "PORT", exp: ".env.local line 3"},
+		{key: "HOST", exp: "consul"},
+		{key: "TOKEN", exp: "provider ssm:///app/prod"},
+		{key: "EXTRA", exp: ""},
+		{key: "MISSING", exp: ""},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			r.Equal(tc.exp, env.Source(tc.key))
+		})
+	}
+}
+
+func Test_Env_Source_Process(t *testing.T) {
+	t.Setenv("ENVY_SOURCE_PROCESS", "1")
+	r := require.New(t)
+
+	env := New()
+	r.Equal("process environment", env.Source("ENVY_SOURCE_PROCESS"))
+
+	r.NoError(env.Setenv("ENVY_SOURCE_PROCESS", "2"))
+	r.Equal("", env.Source("ENVY_SOURCE_PROCESS"))
+
+	t.Setenv("ENVY_SOURCE_PROCESS", "3")
+	_, err := env.Refresh()
+	r.NoError(err)
+	r.Equal("process environment", env.Source("ENVY_SOURCE_PROCESS"))
+
+	var nilEnv *Env
+	r.Error(nilEnv.Attribute("x"))
+	r.Equal("", nilEnv.Source("x"))
+}
+
+func Test_Env_IsSecret(t *testing.T) {
+	t.Parallel()
+
+	env := FromMap(map[string]string{"DB_PASSWORD": "p", "PUBLIC_TOKEN": "t", "PORT": "1", "SIGNING": "s"})
+	require.NoError(t, env.SetMeta("PUBLIC_TOKEN", Meta{Sensitivity: Public}))
+	require.NoError(t, env.SetMeta("SIGNING", Meta{Sensitivity: Secret}))
+
+	tcs := []struct {
+		key string
+		exp bool
+	}{
+		{key: "DB_PASSWORD", exp: true},
+		{key: "PUBLIC_TOKEN", exp: false},
+		{key: "SIGNING", exp: true},
+		{key: "PORT", exp: false},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			r.Equal(tc.exp, env.IsSecret(tc.key))
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Package metrics exposes the state of an envy.Env in the Prometheus text
+// exposition format, so dashboards can alert when a service is running on
+// stale or failed configuration.
+//
+// The exported series are:
+//
+//	envy_revision                       the Env's revision
+//	envy_entries                        the number of variables
+//	envy_fingerprint_info{fingerprint}  always 1, labeled with the fingerprint
+//	envy_reloads_total                  reloads observed
+//	envy_reload_errors_total            reloads that failed
+//	envy_last_reload_timestamp_seconds  time of the last successful reload
+//
+// Every series carries an "env" label with the Collector's Name. The package
+// has no dependency on the Prometheus client library; a Collector is an
+// http.Handler that can be mounted at /metrics or combined with other output.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/markbates/envy"
+)
+
+// Collector reports metrics about an Env.
+type Collector struct {
+	// Name labels every series. Defaults to "default".
+	Name string
+
+	// Env returns the Env to report on. It is called on every scrape, so
+	// it may follow an Env that is swapped out, such as envy.Default.
+	Env func() *envy.Env
+
+	mu         sync.Mutex
+	reloads    uint64
+	errors     uint64
+	lastReload time.Time
+}
+
+// ObserveReload records the outcome of a reload. A nil err marks a
+// successful reload and updates the last-reload timestamp.
+func (c *Collector) ObserveReload(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reloads++
+	if err != nil {
+		c.errors++
+		return
+	}
+	c.lastReload = time.Now()
+}
+
+// Reload calls fn, such as envy.Reload, records its outcome with
+// ObserveReload, and returns its error.
+func (c *Collector) Reload(fn func() error) error {
+	err := fn()
+	c.ObserveReload(err)
+	return err
+}
+
+// ServeHTTP implements http.Handler.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = c.WriteTo(w)
+}
+
+// WriteTo writes the metrics to w in the Prometheus text format.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	var env *envy.Env
+	if c.Env != nil {
+		env = c.Env()
+	}
+
+	c.mu.Lock()
+	reloads, errs, last := c.reloads, c.errors, c.lastReload
+	c.mu.Unlock()
+
+	name := c.Name
+	if name == "" {
+		name = "default"
+	}
+	lbl := `env="` + escape(name) + `"`
+
+	var lastTS float64
+	if !last.IsZero() {
+		lastTS = float64(last.UnixNano()) / 1e9
+	}
+
+	bb := &strings.Builder{}
+	series := []struct {
+		name, help, kind, labels, value string
+	}{
+		{"envy_revision", "Revision of the environment.", "gauge", lbl, strconv.FormatUint(env.Revision(), 10)},
+		{"envy_entries", "Number of variables in the environment.", "gauge", lbl, strconv.Itoa(env.Len())},
+		{"envy_fingerprint_info", "Fingerprint of the environment contents.", "gauge", lbl + `,fingerprint="` + env.Fingerprint() + `"`, "1"},
+		{"envy_reloads_total", "Reloads observed.", "counter", lbl, strconv.FormatUint(reloads, 10)},
+		{"envy_reload_errors_total", "Reloads that failed.", "counter", lbl, strconv.FormatUint(errs, 10)},
+		{"envy_last_reload_timestamp_seconds", "Unix time of the last successful reload.", "gauge", lbl, strconv.FormatFloat(lastTS, 'f', -1, 64)},
+	}
+
+	for _, s := range series {
+		fmt.Fprintf(bb, "# HELP %s %s\n# TYPE %s %s\n%s{%s} %s\n", s.name, s.help, s.name, s.kind, s.name, s.labels, s.value)
+	}
+
+	n, err := io.WriteString(w, bb.String())
+	return int64(n), err
+}
+
+// escape escapes a Prometheus label value.
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
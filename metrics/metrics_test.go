@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/markbates/envy"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Collector(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := envy.FromMap(map[string]string{"KEY": "VALUE"})
+	r.NoError(env.Setenv("KEY", "NEW"))
+
+	c := &Collector{Name: `app"1`, Env: func() *envy.Env { return env }}
+
+	r.NoError(c.Reload(func() error { return nil }))
+	r.Error(c.Reload(func() error { return fmt.Errorf("boom") }))
+
+	res := httptest.NewRecorder()
+	c.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	r.Equal(http.StatusOK, res.Code)
+	r.Contains(res.Header().Get("Content-Type"), "text/plain")
+
+	body := res.Body.String()
+	r.Contains(body, "# TYPE envy_revision gauge\n")
+	r.Contains(body, `envy_revision{env="app\"1"} 1`+"\n")
+	r.Contains(body, `envy_entries{env="app\"1"} 1`+"\n")
+	r.Contains(body, `envy_fingerprint_info{env="app\"1",fingerprint="`+env.Fingerprint()+`"} 1`+"\n")
+	r.Contains(body, `envy_reloads_total{env="app\"1"} 2`+"\n")
+	r.Contains(body, `envy_reload_errors_total{env="app\"1"} 1`+"\n")
+	r.NotContains(body, `envy_last_reload_timestamp_seconds{env="app\"1"} 0`+"\n")
+}
+
+func Test_Collector_Zero(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	c := &Collector{}
+
+	bb := &strings.Builder{}
+	n, err := c.WriteTo(bb)
+	r.NoError(err)
+	r.Equal(int64(bb.Len()), n)
+
+	body := bb.String()
+	r.Contains(body, `envy_entries{env="default"} 0`+"\n")
+	r.Contains(body, `envy_last_reload_timestamp_seconds{env="default"} 0`+"\n")
+}
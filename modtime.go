@@ -0,0 +1,61 @@
+package envy
+
+import (
+	"sort"
+	"time"
+)
+
+// ModTime returns when the entry for key was last set: when the Env was
+// created or loaded, or when a mutation last changed its value. Setting a
+// key to the value it already has does not update it. It reports false if
+// the key is not set.
+func (e *Env) ModTime(key string) (time.Time, bool) {
+	if e.IsNil() {
+		return time.Time{}, false
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if _, ok := e.envs[key]; !ok {
+		return time.Time{}, false
+	}
+	return e.modTime(key), true
+}
+
+// ChangedSince returns the keys, sorted, whose ModTime is after t, for
+// showing recently changed variables.
+func (e *Env) ChangedSince(t time.Time) []string {
+	if e.IsNil() {
+		return nil
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var keys []string
+	for k := range e.envs {
+		if e.modTime(k).After(t) {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// modTime returns the ModTime of key. The lock must be held.
+func (e *Env) modTime(key string) time.Time {
+	if t, ok := e.modified[key]; ok {
+		return t
+	}
+	return e.created
+}
+
+// touch records that key was changed now. The lock must be held.
+func (e *Env) touch(key string) {
+	if e.modified == nil {
+		e.modified = map[string]time.Time{}
+	}
+	e.modified[key] = time.Now()
+}
@@ -0,0 +1,79 @@
+package envy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_ModTime(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	before := time.Now()
+	env := FromMap(map[string]string{"A": "1", "B": "2"})
+
+	created, ok := env.ModTime("A")
+	r.True(ok)
+	r.False(created.Before(before))
+
+	b, ok := env.ModTime("B")
+	r.True(ok)
+	r.Equal(created, b)
+
+	_, ok = env.ModTime("MISSING")
+	r.False(ok)
+
+	mark := time.Now()
+	time.Sleep(time.Millisecond)
+
+	r.NoError(env.Setenv("A", "1"))
+	r.Empty(env.ChangedSince(mark))
+
+	r.NoError(env.Setenv("A", "changed"))
+	r.NoError(env.Setenv("C", "3"))
+
+	a, ok := env.ModTime("A")
+	r.True(ok)
+	r.True(a.After(mark))
+	r.Equal([]string{"A", "C"}, env.ChangedSince(mark))
+
+	b, _ = env.ModTime("B")
+	r.Equal(created, b)
+
+	r.NoError(env.Unsetenv("C"))
+	_, ok = env.ModTime("C")
+	r.False(ok)
+	r.Equal([]string{"A"}, env.ChangedSince(mark))
+
+	var e *Env
+	_, ok = e.ModTime("A")
+	r.False(ok)
+	r.Nil(e.ChangedSince(mark))
+}
+
+func Test_Env_ModTime_Merge(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	base := FromMap(map[string]string{"A": "1", "B": "2"})
+	time.Sleep(time.Millisecond)
+
+	r.NoError(base.Setenv("B", "changed"))
+	b, _ := base.ModTime("B")
+	a, _ := base.ModTime("A")
+
+	time.Sleep(time.Millisecond)
+	other := FromMap(map[string]string{"C": "3"})
+	c, _ := other.ModTime("C")
+
+	merged, err := base.Merge(other)
+	r.NoError(err)
+
+	for k, exp := range map[string]time.Time{"A": a, "B": b, "C": c} {
+		act, ok := merged.ModTime(k)
+		r.True(ok)
+		r.Equal(exp, act, k)
+	}
+}
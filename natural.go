@@ -0,0 +1,56 @@
+package envy
+
+import "strings"
+
+// NaturalLess reports whether a sorts before b in natural order: runs of
+// digits compare by numeric value and everything else byte by byte, so
+// WORKER_2 sorts before WORKER_10. The order does not depend on the locale.
+// Numbers that are equal but for leading zeros sort with the shorter one
+// first, and strings that compare equal fall back to byte order, so the
+// order is total. Use it with sort.Slice to order Diffs the same way.
+func NaturalLess(a, b string) bool {
+	x, y := a, b
+	for x != "" && y != "" {
+		if isDigit(x[0]) && isDigit(y[0]) {
+			xn, xr := digits(x)
+			yn, yr := digits(y)
+
+			xv, yv := strings.TrimLeft(xn, "0"), strings.TrimLeft(yn, "0")
+			if len(xv) != len(yv) {
+				return len(xv) < len(yv)
+			}
+			if xv != yv {
+				return xv < yv
+			}
+			if len(xn) != len(yn) {
+				return len(xn) < len(yn)
+			}
+
+			x, y = xr, yr
+			continue
+		}
+
+		if x[0] != y[0] {
+			return x[0] < y[0]
+		}
+		x, y = x[1:], y[1:]
+	}
+
+	if len(x) != len(y) {
+		return len(x) < len(y)
+	}
+	return a < b
+}
+
+// digits splits s after its leading run of digits.
+func digits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
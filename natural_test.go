@@ -0,0 +1,60 @@
+package envy
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NaturalLess(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		a, b string
+		exp  bool
+	}{
+		{a: "WORKER_2", b: "WORKER_10", exp: true},
+		{a: "WORKER_10", b: "WORKER_2", exp: false},
+		{a: "A", b: "B", exp: true},
+		{a: "A", b: "A_1", exp: true},
+		{a: "A1B2", b: "A1B10", exp: true},
+		{a: "X_2", b: "X_02", exp: true},
+		{a: "X_02", b: "X_2", exp: false},
+		{a: "X_9", b: "X_A", exp: true},
+		{a: "SAME", b: "SAME", exp: false},
+		{a: "V_18446744073709551616", b: "V_18446744073709551617", exp: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.a+"<"+tc.b, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			r.Equal(tc.exp, NaturalLess(tc.a, tc.b))
+		})
+	}
+}
+
+func Test_Env_Environ_NaturalOrder(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := FromMap(map[string]string{
+		"WORKER_10": "c",
+		"WORKER_2":  "b",
+		"WORKER_1":  "a",
+		"DB":        "x",
+	})
+
+	r.Equal([]string{"DB=x", "WORKER_10=c", "WORKER_1=a", "WORKER_2=b"}, env.Environ())
+	r.Equal([]string{"DB=x", "WORKER_1=a", "WORKER_2=b", "WORKER_10=c"}, env.Environ(NaturalOrder(true)))
+	r.Equal(env.Environ(), env.Environ(NaturalOrder(false)))
+
+	var e *Env
+	r.Equal([]string{}, e.Environ(NaturalOrder(true)))
+
+	keys := []string{"K10", "K9", "K1"}
+	sort.Slice(keys, func(i, j int) bool { return NaturalLess(keys[i], keys[j]) })
+	r.Equal([]string{"K1", "K9", "K10"}, keys)
+}
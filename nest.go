@@ -0,0 +1,154 @@
+package envy
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Nest converts the Env's flat keys into nested maps by splitting them on
+// sep, so DB_HOST=x becomes {"DB": {"HOST": "x"}} with sep "_". Leaves are
+// strings and inner nodes are map[string]any. A key that is both a value and
+// a prefix of other keys, like DB next to DB_HOST, keeps its value under the
+// empty key of its map. Keys with empty segments, such as _X or A__B, are not
+// split. An empty sep, or a nil Env, gives a flat (or empty) map. Flatten is
+// the inverse.
+func (e *Env) Nest(sep string) map[string]any {
+	root := map[string]any{}
+	for _, ent := range e.entries() {
+		path := []string{ent.key}
+		if sep != "" && !hasEmptySegment(ent.key, sep) {
+			path = strings.Split(ent.key, sep)
+		}
+
+		m := root
+		for _, seg := range path[:len(path)-1] {
+			switch cur := m[seg].(type) {
+			case map[string]any:
+				m = cur
+			case string:
+				// a shorter key already holds a value here
+				next := map[string]any{"": cur}
+				m[seg] = next
+				m = next
+			default:
+				next := map[string]any{}
+				m[seg] = next
+				m = next
+			}
+		}
+
+		last := path[len(path)-1]
+		if sub, ok := m[last].(map[string]any); ok {
+			sub[""] = ent.value
+			continue
+		}
+		m[last] = ent.value
+	}
+	return root
+}
+
+// hasEmptySegment reports whether splitting key on sep gives an empty part.
+func hasEmptySegment(key, sep string) bool {
+	return strings.HasPrefix(key, sep) || strings.HasSuffix(key, sep) || strings.Contains(key, sep+sep)
+}
+
+// flatKey appends the key part p of a structured document, such as a
+// YAML or TOML file, to the env key prefix: p is upper cased, with '-' and
+// spaces turned into '_', and its dot-separated parts, like nested keys,
+// are joined with sep.
+func flatKey(prefix, p, sep string) string {
+	parts := strings.Split(p, ".")
+	for i, part := range parts {
+		parts[i] = strings.ToUpper(strings.NewReplacer("-", "_", " ", "_").Replace(part))
+	}
+
+	p = strings.Join(parts, sep)
+	if prefix == "" {
+		return p
+	}
+	return prefix + sep + p
+}
+
+// Flatten converts nested maps into an Env by joining keys with sep, the
+// inverse of Nest. Values may be strings, booleans, numbers, nil (an empty
+// string), map[string]any, map[string]string, or []any, whose elements are
+// keyed by index (HOSTS_0, HOSTS_1, ...). A map's empty key holds the value
+// of the map's own key. It returns an error for other value types and when
+// two paths flatten to the same key.
+func Flatten(m map[string]any, sep string) (*Env, error) {
+	em := map[string]string{}
+	if err := flatten(em, "", m, sep); err != nil {
+		return nil, err
+	}
+	return FromMap(em), nil
+}
+
+// flatten adds the entries of v to em, prefixing keys with prefix.
+func flatten(em map[string]string, prefix string, v any, sep string) error {
+	join := func(k string) string {
+		switch {
+		case prefix == "":
+			return k
+		case k == "":
+			return prefix
+		}
+		return prefix + sep + k
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for _, k := range sortedKeys(v) {
+			if err := flatten(em, join(k), v[k], sep); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]string:
+		for _, k := range sortedKeys(v) {
+			if err := flatten(em, join(k), v[k], sep); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []any:
+		for i, el := range v {
+			if err := flatten(em, join(strconv.Itoa(i)), el, sep); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var s string
+	switch v := v.(type) {
+	case nil:
+	case string:
+		s = v
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		s = fmt.Sprint(v)
+	default:
+		return fmt.Errorf("%s: unsupported value type %T", prefix, v)
+	}
+
+	if prefix == "" {
+		return fmt.Errorf("empty key")
+	}
+
+	if _, ok := em[prefix]; ok {
+		return fmt.Errorf("%s: defined more than once", prefix)
+	}
+	em[prefix] = s
+	return nil
+}
+
+// sortedKeys returns the keys of m in order, so errors are deterministic.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,144 @@
+package envy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_Nest(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  *Env
+		sep  string
+		exp  map[string]any
+	}{
+		{
+			name: "nested",
+			env:  FromMap(map[string]string{"DB_HOST": "localhost", "DB_PORT": "5432", "PORT": "80"}),
+			sep:  "_",
+			exp: map[string]any{
+				"DB":   map[string]any{"HOST": "localhost", "PORT": "5432"},
+				"PORT": "80",
+			},
+		},
+		{
+			name: "value and prefix",
+			env:  FromMap(map[string]string{"DB": "postgres", "DB_HOST": "localhost"}),
+			sep:  "_",
+			exp: map[string]any{
+				"DB": map[string]any{"": "postgres", "HOST": "localhost"},
+			},
+		},
+		{
+			name: "deep",
+			env:  FromMap(map[string]string{"A__B__C": "1", "A__D": "2"}),
+			sep:  "__",
+			exp: map[string]any{
+				"A": map[string]any{"B": map[string]any{"C": "1"}, "D": "2"},
+			},
+		},
+		{
+			name: "empty segments",
+			env:  FromMap(map[string]string{"_X": "1", "A__B": "2", "C_": "3"}),
+			sep:  "_",
+			exp:  map[string]any{"_X": "1", "A__B": "2", "C_": "3"},
+		},
+		{
+			name: "empty sep",
+			env:  FromMap(map[string]string{"DB_HOST": "localhost"}),
+			exp:  map[string]any{"DB_HOST": "localhost"},
+		},
+		{
+			name: "nil env",
+			sep:  "_",
+			exp:  map[string]any{},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			act := tc.env.Nest(tc.sep)
+			r.Equal(tc.exp, act)
+
+			flat, err := Flatten(act, tc.sep)
+			r.NoError(err)
+			r.Equal(tc.env.Environ(), flat.Environ())
+		})
+	}
+}
+
+func Test_Flatten(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		in   map[string]any
+		exp  []string
+		err  bool
+	}{
+		{
+			name: "mixed types",
+			in: map[string]any{
+				"APP": map[string]any{
+					"DEBUG": true,
+					"PORT":  8080,
+					"RATIO": 0.5,
+					"NAME":  "envy",
+					"NONE":  nil,
+				},
+				"HOSTS": []any{"a", "b"},
+				"DB":    map[string]string{"HOST": "localhost"},
+			},
+			exp: []string{
+				"APP_DEBUG=true",
+				"APP_NAME=envy",
+				"APP_NONE=",
+				"APP_PORT=8080",
+				"APP_RATIO=0.5",
+				"DB_HOST=localhost",
+				"HOSTS_0=a",
+				"HOSTS_1=b",
+			},
+		},
+		{
+			name: "duplicate",
+			in: map[string]any{
+				"A_B": "1",
+				"A":   map[string]any{"B": "2"},
+			},
+			err: true,
+		},
+		{
+			name: "unsupported",
+			in:   map[string]any{"A": struct{}{}},
+			err:  true,
+		},
+		{
+			name: "empty key",
+			in:   map[string]any{"": "x"},
+			err:  true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			env, err := Flatten(tc.in, "_")
+			if tc.err {
+				r.Error(err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, env.Environ())
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package envy
+
+import (
+	"fmt"
+	"sort"
+)
+
+// subscriber is a registered change callback.
+type subscriber struct {
+	id uint64
+	fn func(Diff)
+}
+
+// Subscribe registers fn to be called with the resulting Diff whenever the
+// Env changes, for example through Setenv or Unsetenv. Calls that do not
+// change anything are not reported. fn is called synchronously, after the
+// change has been applied, on the goroutine that made it; it may read the Env
+// but must not block for long. Subscribers of the Default are carried over
+// to the Env that Load or Reload replaces it with, and told what the
+// replacement changed. The returned func unregisters fn. It returns an
+// error for a nil Env or nil fn.
+func (e *Env) Subscribe(fn func(Diff)) (func(), error) {
+	if e.IsNil() {
+		return nil, fmt.Errorf("nil env")
+	}
+
+	if fn == nil {
+		return nil, fmt.Errorf("nil func")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.subs == nil {
+		e.subs = map[uint64]func(Diff){}
+	}
+
+	e.nextSub++
+	id := e.nextSub
+	e.subs[id] = fn
+
+	return func() {
+		// the subscription moves along when a Reload replaces the Default
+		for cur := e; cur != nil; {
+			cur.mu.Lock()
+			delete(cur.subs, id)
+			next := cur.succ
+			cur.mu.Unlock()
+			cur = next
+		}
+	}, nil
+}
+
+// update sets and deletes keys as a single change and notifies subscribers
+// of the resulting Diff. Keys in unset are removed after set is applied.
+// The Env must not be nil and the lock must not be held.
+func (e *Env) update(set map[string]string, unset ...string) (Diff, error) {
+	return e.mutate(func() (map[string]string, []string, error) {
+		return set, unset, nil
+	})
+}
+
+// mutate calls fn with the lock held to decide which keys to set and
+// delete, applies them as a single change, and notifies subscribers after
+// the lock is released. Nothing changes if fn returns an error or the
+// Policy rejects the change. The Env must
+// not be nil and the lock must not be held.
+func (e *Env) mutate(fn func() (map[string]string, []string, error)) (Diff, error) {
+	d, notify, err := e.change(fn, true)
+	if err != nil {
+		return nil, err
+	}
+
+	notify()
+	return d, nil
+}
+
+// restore is mutate without asking the Policy, for putting back values
+// the Env held before, which must never be refused.
+func (e *Env) restore(fn func() (map[string]string, []string, error)) (Diff, error) {
+	d, notify, err := e.change(fn, false)
+	if err != nil {
+		return nil, err
+	}
+
+	notify()
+	return d, nil
+}
+
+// change is mutate without the notification, which is left to the
+// returned func so callers can release their own locks first. The Policy
+// is only asked if check is true.
+func (e *Env) change(fn func() (map[string]string, []string, error), check bool) (Diff, func(), error) {
+	e.mu.Lock()
+
+	// the Env may have been destroyed since the caller checked
+	if e.envs == nil {
+		e.mu.Unlock()
+		return nil, nil, fmt.Errorf("nil env")
+	}
+
+	set, unset, err := fn()
+	if err == nil && check {
+		err = e.checkPolicy(set, unset)
+	}
+	if err != nil {
+		e.mu.Unlock()
+		return nil, nil, err
+	}
+
+	d := Diff{}
+	for k, v := range set {
+		delete(e.tombstones, k)
+
+		old, ok := e.envs[k]
+		e.envs[k] = v
+
+		switch {
+		case !ok:
+			d = append(d, Change{Key: k, Kind: Added, New: v})
+		case old != v:
+			d = append(d, Change{Key: k, Kind: Modified, Old: old, New: v})
+		default:
+			continue
+		}
+		e.clearOrigin(k)
+		e.touch(k)
+	}
+
+	for _, k := range unset {
+		old, ok := e.envs[k]
+		if !ok {
+			continue
+		}
+
+		delete(e.envs, k)
+		delete(e.meta, k)
+		delete(e.modified, k)
+		d = append(d, Change{Key: k, Kind: Removed, Old: old})
+	}
+
+	if len(d) == 0 {
+		e.mu.Unlock()
+		return d, func() {}, nil
+	}
+
+	sort.Slice(d, func(i, j int) bool {
+		return d[i].Key < d[j].Key
+	})
+
+	e.rev++
+	e.record(d)
+
+	subs := e.subscribers()
+	e.mu.Unlock()
+
+	return d, func() {
+		for _, s := range subs {
+			s.fn(d)
+		}
+	}, nil
+}
+
+// subscribers returns the registered callbacks in registration order. The
+// lock must be held.
+func (e *Env) subscribers() []subscriber {
+	subs := make([]subscriber, 0, len(e.subs))
+	for id, fn := range e.subs {
+		subs = append(subs, subscriber{id: id, fn: fn})
+	}
+
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].id < subs[j].id
+	})
+	return subs
+}
@@ -0,0 +1,55 @@
+package envy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_Subscribe(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := FromMap(map[string]string{"KEY1": "VALUE1"})
+
+	var got []Diff
+	cancel, err := env.Subscribe(func(d Diff) {
+		// the change is visible to subscribers
+		r.Equal(d[0].New, env.Getenv(d[0].Key))
+		got = append(got, d)
+	})
+	r.NoError(err)
+
+	r.NoError(env.Setenv("KEY1", "NEW1"))
+	r.NoError(env.Setenv("KEY1", "NEW1")) // no change
+	r.NoError(env.Setenv("KEY2", "VALUE2"))
+	r.NoError(env.Unsetenv("KEY1"))
+	r.NoError(env.Unsetenv("MISSING")) // no change
+
+	_, err = env.ReadFrom(strings.NewReader("KEY2=VALUE2\nKEY3=VALUE3\n"))
+	r.NoError(err)
+
+	r.Equal([]Diff{
+		{{Key: "KEY1", Kind: Modified, Old: "VALUE1", New: "NEW1"}},
+		{{Key: "KEY2", Kind: Added, New: "VALUE2"}},
+		{{Key: "KEY1", Kind: Removed, Old: "NEW1"}},
+		{{Key: "KEY3", Kind: Added, New: "VALUE3"}},
+	}, got)
+
+	cancel()
+	r.NoError(env.Setenv("KEY4", "VALUE4"))
+	r.Len(got, 4)
+}
+
+func Test_Env_Subscribe_Errors(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	var env *Env
+	_, err := env.Subscribe(func(Diff) {})
+	r.Error(err)
+
+	_, err = Zero().Subscribe(nil)
+	r.Error(err)
+}
@@ -0,0 +1,202 @@
+package envy
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMalformed is wrapped by the errors FromFile returns for files it
+// could read but not parse, such as an unterminated quoted value, so that
+// they can be told apart from a missing file, which wraps fs.ErrNotExist.
+var ErrMalformed = errors.New("malformed env file")
+
+// malformedError marks err as ErrMalformed without changing its message.
+type malformedError struct {
+	err error
+}
+
+func (e malformedError) Error() string {
+	return e.err.Error()
+}
+
+func (e malformedError) Unwrap() []error {
+	return []error{ErrMalformed, e.err}
+}
+
+// DuplicatePolicy decides what happens when a file sets the same key more
+// than once.
+type DuplicatePolicy int
+
+const (
+	// LastWins keeps the last value, matching the standard environment
+	// semantics. It is the default.
+	LastWins DuplicatePolicy = iota
+	// FirstWins keeps the first value and ignores later ones.
+	FirstWins
+	// DuplicateError makes parsing fail.
+	DuplicateError
+)
+
+func (p DuplicatePolicy) String() string {
+	switch p {
+	case LastWins:
+		return "last-wins"
+	case FirstWins:
+		return "first-wins"
+	case DuplicateError:
+		return "error"
+	}
+	return fmt.Sprintf("DuplicatePolicy(%d)", int(p))
+}
+
+// Diagnostic is a problem noticed while parsing a file that did not stop
+// it from loading.
+type Diagnostic struct {
+	// File is the file being parsed.
+	File string
+	// Line is the 1-based line the diagnostic refers to.
+	Line int
+	// Key is the key involved, if any.
+	Key string
+	// Code identifies the kind of diagnostic, such as CodeDuplicateKey.
+	Code string
+	// Message describes the problem.
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+}
+
+// ParseOption configures how FromFile parses a file.
+type ParseOption func(*parseOptions)
+
+// parseOptions holds the settings applied by ParseOption funcs.
+type parseOptions struct {
+	duplicates DuplicatePolicy
+	diagnose   func(Diagnostic)
+
+	// sandbox holds the limits of a Sandboxed parse; nil otherwise.
+	sandbox *Limits
+
+	// transforms are the pipelines added with Transform.
+	transforms []transform
+
+	// interpolate turns on expansion of references; interpolateFrom
+	// resolves references to keys the file does not set.
+	interpolate     bool
+	interpolateFrom *Env
+
+	// stats is the LoadStats given to WithStats, if any.
+	stats *LoadStats
+
+	// systemd selects the EnvironmentFile= syntax described on Systemd.
+	systemd bool
+
+	// skipMalformed skips and reports malformed entries. See SkipMalformed.
+	skipMalformed bool
+}
+
+// OnDuplicate sets the policy for keys set more than once in the same
+// file. The default is LastWins.
+func OnDuplicate(p DuplicatePolicy) ParseOption {
+	return func(o *parseOptions) {
+		o.duplicates = p
+	}
+}
+
+// SkipMalformed makes FromFile skip the entries it cannot parse, such as
+// a value with an unterminated quote or text after its closing quote,
+// reporting each with CodeMalformed to WithDiagnostics or ReportTo, along
+// with the lines it ignores for not being entries, instead of failing on
+// the first. An unterminated quote then only costs the line it is on, not
+// the rest of the file. Unbalanced directives still fail.
+func SkipMalformed(on bool) ParseOption {
+	return func(o *parseOptions) {
+		o.skipMalformed = on
+	}
+}
+
+// skip returns the func dotenvLines reports the malformed entries of file
+// to, or nil to have it fail on them.
+func (o parseOptions) skip(file string) func(int, string, error) {
+	if !o.skipMalformed {
+		return nil
+	}
+
+	return func(line int, key string, err error) {
+		o.diagnostic(Diagnostic{
+			File:    file,
+			Line:    line,
+			Key:     key,
+			Code:    CodeMalformed,
+			Message: err.Error(),
+		})
+	}
+}
+
+// WithDiagnostics makes parsing call fn for every Diagnostic, such as a
+// duplicate key resolved by the DuplicatePolicy.
+func WithDiagnostics(fn func(Diagnostic)) ParseOption {
+	return func(o *parseOptions) {
+		o.diagnose = fn
+	}
+}
+
+// diagnostic reports d if a diagnostics func was given.
+func (o parseOptions) diagnostic(d Diagnostic) {
+	if o.diagnose != nil {
+		o.diagnose(d)
+	}
+}
+
+// dedupe applies the duplicate-key policy to the lines of file, blanking
+// the entries it discards so that line numbers are preserved. The lines
+// are those produced by dotenvLines.
+func dedupe(file string, lines []string, o parseOptions) ([]string, error) {
+	first := map[string]int{}
+	last := map[string]int{}
+
+	out := make([]string, len(lines))
+	copy(out, lines)
+
+	for i, line := range lines {
+		k, _, ok := lineEntry(line)
+		if !ok {
+			continue
+		}
+
+		prev, dup := first[k]
+		if !dup {
+			first[k] = i
+			last[k] = i
+			continue
+		}
+
+		switch o.duplicates {
+		case DuplicateError:
+			return nil, fmt.Errorf("line %d: duplicate key %s (first set on line %d)", i+1, k, prev+1)
+		case FirstWins:
+			out[i] = ""
+			o.diagnostic(Diagnostic{
+				File:    file,
+				Line:    i + 1,
+				Key:     k,
+				Code:    CodeDuplicateKey,
+				Message: fmt.Sprintf("duplicate key %s ignored; %s keeps line %d", k, o.duplicates, prev+1),
+			})
+		default:
+			out[last[k]] = ""
+			o.diagnostic(Diagnostic{
+				File:    file,
+				Line:    i + 1,
+				Key:     k,
+				Code:    CodeDuplicateKey,
+				Message: fmt.Sprintf("duplicate key %s overrides line %d (%s)", k, last[k]+1, o.duplicates),
+			})
+			last[k] = i
+		}
+	}
+
+	return out, nil
+}
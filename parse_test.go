@@ -0,0 +1,125 @@
+package envy
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DuplicatePolicy_String(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		p   DuplicatePolicy
+		exp string
+	}{
+		{p: LastWins, exp: "last-wins"},
+		{p: FirstWins, exp: "first-wins"},
+		{p: DuplicateError, exp: "error"},
+		{p: 7, exp: "DuplicatePolicy(7)"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.exp, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			r.Equal(tc.exp, tc.p.String())
+		})
+	}
+}
+
+func Test_FromFile_OnDuplicate(t *testing.T) {
+	t.Parallel()
+
+	const in = "PORT=1\nHOST=a\nPORT=2\n#if HOST=a\nPORT=3\n#else\nHOST=b\n#endif\n"
+
+	tcs := []struct {
+		name  string
+		opts  []ParseOption
+		exp   []string
+		line  int
+		diags []string
+		err   string
+	}{
+		{
+			name: "default is last wins",
+			exp:  []string{"HOST=a", "PORT=3"},
+			line: 5,
+		},
+		{
+			name:  "last wins",
+			opts:  []ParseOption{OnDuplicate(LastWins)},
+			exp:   []string{"HOST=a", "PORT=3"},
+			line:  5,
+			diags: []string{".env:3: duplicate key PORT overrides line 1 (last-wins)", ".env:5: duplicate key PORT overrides line 3 (last-wins)"},
+		},
+		{
+			name:  "first wins",
+			opts:  []ParseOption{OnDuplicate(FirstWins)},
+			exp:   []string{"HOST=a", "PORT=1"},
+			line:  1,
+			diags: []string{".env:3: duplicate key PORT ignored; first-wins keeps line 1", ".env:5: duplicate key PORT ignored; first-wins keeps line 1"},
+		},
+		{
+			name: "error",
+			opts: []ParseOption{OnDuplicate(DuplicateError)},
+			err:  ".env: line 3: duplicate key PORT (first set on line 1)",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+
+			var diags []string
+			opts := append(tc.opts, WithDiagnostics(func(d Diagnostic) {
+				diags = append(diags, d.String())
+			}))
+
+			if tc.diags == nil {
+				opts = tc.opts
+			}
+
+			env, err := FromFile(fstest.MapFS{".env": &fstest.MapFile{Data: []byte(in)}}, ".env", opts...)
+			if tc.err != "" {
+				r.EqualError(err, tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, env.Environ())
+			r.Equal(tc.diags, diags)
+
+			m, _ := env.Meta("PORT")
+			r.Equal(tc.line, m.Line)
+		})
+	}
+}
+
+func Test_FromFile_SkipMalformed(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	const in = "A=1\nB=\"open\nC=2\nnot an entry\nD='x' y\nE=5\n"
+	cab := fstest.MapFS{".env": &fstest.MapFile{Data: []byte(in)}}
+
+	_, err := FromFile(cab, ".env")
+	r.ErrorIs(err, ErrMalformed)
+
+	var diags []string
+	env, err := FromFile(cab, ".env", SkipMalformed(true), WithDiagnostics(func(d Diagnostic) {
+		r.Equal(CodeMalformed, d.Code)
+		diags = append(diags, d.String())
+	}))
+	r.NoError(err)
+	r.Equal([]string{"A=1", "C=2", "E=5"}, env.Environ())
+	r.Equal([]string{
+		".env:2: unterminated quoted value",
+		`.env:4: not a KEY=VALUE entry: "not an entry"`,
+		`.env:5: unexpected "y" after quoted value`,
+	}, diags)
+}
@@ -0,0 +1,339 @@
+package envy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"sort"
+	"strings"
+)
+
+// ErrPolicy is wrapped by the errors returned when a Policy rejects a
+// change or an Export.
+var ErrPolicy = errors.New("rejected by policy")
+
+// policyError marks err as ErrPolicy without changing its message.
+type policyError struct {
+	err error
+}
+
+func (e policyError) Error() string {
+	return e.err.Error()
+}
+
+func (e policyError) Unwrap() []error {
+	return []error{ErrPolicy, e.err}
+}
+
+// The operations a Policy is asked about.
+const (
+	// PolicyChange is any change to an Env, such as Setenv, Unsetenv, or
+	// Apply.
+	PolicyChange = "change"
+	// PolicyExport is Export handing the Env to a process.
+	PolicyExport = "export"
+)
+
+// PolicyInput describes what a Policy is asked to allow. It encodes to
+// JSON, to be passed as the input of an external engine such as OPA.
+type PolicyInput struct {
+	// Op is PolicyChange or PolicyExport.
+	Op string `json:"op"`
+	// Diff holds the changes to be made; it is empty for an export.
+	Diff Diff `json:"diff,omitempty"`
+	// Env is the contents of the Env after the change, or the contents
+	// being exported. Values are as stored, so encrypted values are not
+	// decrypted.
+	Env map[string]string `json:"env"`
+}
+
+// Policy decides whether changes to an Env, and exports of it, are
+// allowed. Evaluate returns an error to reject the operation. It is
+// called with the Env locked, so it must not use the Env it guards.
+type Policy interface {
+	Evaluate(in PolicyInput) error
+}
+
+// PolicyFunc adapts a func to a Policy.
+type PolicyFunc func(in PolicyInput) error
+
+// Evaluate calls fn(in).
+func (fn PolicyFunc) Evaluate(in PolicyInput) error {
+	return fn(in)
+}
+
+// SetPolicy makes every later change to the Env, through Setenv, Unsetenv,
+// Apply, Temp, or any other method, and every export, ask p first; if p
+// rejects it, nothing changes and the method returns an error wrapping
+// ErrPolicy and p's error. Exports are Export, which Supervise uses to
+// start children, and every other method handing the contents out: WriteTo,
+// Reader, ToFile, ToJSON, MarshalJSON, ToDockerEnvFile, ToKubernetesEnv,
+// ToKubernetesConfigMap, Seal, and Sign, as well as Environ, which returns
+// an empty slice instead. Changes that would have no effect are not
+// checked, nor are Wipe and Destroy, which must always be able to remove
+// secrets. A nil p removes the policy. The policy belongs to this Env only;
+// Envs derived from it, such as by Merge, have none. It returns an error
+// for a nil Env.
+func (e *Env) SetPolicy(p Policy) error {
+	if e.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.policy = p
+	return nil
+}
+
+// Export returns the variables of the Env as sorted "key=value" strings,
+// as Environ does, once the Policy set with SetPolicy allows them to be
+// handed to a process. It returns an error for a nil Env, the errors of
+// pending resolvers that fail, and an error wrapping ErrPolicy if the
+// policy rejects the export.
+func (e *Env) Export() ([]string, error) {
+	if e.IsNil() {
+		return nil, fmt.Errorf("nil env")
+	}
+
+	ents, err := e.exportEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]string, len(ents))
+	for i, ent := range ents {
+		out[i] = ent.key + "=" + ent.value
+	}
+	return out, nil
+}
+
+// exportEntries returns the entries of the Env sorted by key, as entries
+// does, once every pending resolver has run and the Policy allows them to
+// be exported. Every method that hands the Env's contents out, such as
+// WriteTo, ToJSON, Seal, and Sign, reads them through it.
+func (e *Env) exportEntries() ([]entry, error) {
+	if err := e.ResolveAll(); err != nil {
+		return nil, err
+	}
+	return e.allowedEntries()
+}
+
+// allowedEntries is exportEntries without running the resolvers.
+func (e *Env) allowedEntries() ([]entry, error) {
+	if e.IsNil() {
+		return nil, nil
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.policy != nil {
+		if err := e.policy.Evaluate(PolicyInput{Op: PolicyExport, Env: maps.Clone(e.envs)}); err != nil {
+			return nil, policyError{err}
+		}
+	}
+
+	ents := make([]entry, 0, len(e.envs))
+	for k, v := range e.envs {
+		ents = append(ents, entry{key: k, value: v})
+	}
+
+	sort.Slice(ents, func(i, j int) bool {
+		return ents[i].key < ents[j].key
+	})
+	return ents, nil
+}
+
+// checkPolicy asks the policy whether setting set and then unsetting unset
+// is allowed. The lock must be held.
+func (e *Env) checkPolicy(set map[string]string, unset []string) error {
+	if e.policy == nil {
+		return nil
+	}
+
+	next := maps.Clone(e.envs)
+	maps.Copy(next, set)
+	for _, k := range unset {
+		delete(next, k)
+	}
+
+	d := diffMaps(e.envs, next)
+	if len(d) == 0 {
+		return nil
+	}
+
+	if err := e.policy.Evaluate(PolicyInput{Op: PolicyChange, Diff: d, Env: next}); err != nil {
+		return policyError{err}
+	}
+	return nil
+}
+
+// Rules is a Policy read from a simple rule file by ParseRules.
+type Rules struct {
+	rules []rule
+}
+
+// rule is a line of a rule file.
+type rule struct {
+	line int
+	text string
+
+	deny  bool
+	key   string
+	value string
+	// hasValue distinguishes "deny KEY=" from "deny KEY".
+	hasValue bool
+
+	// when holds the KEY=VALUE conditions, all of which must hold.
+	when [][2]string
+}
+
+// ParseRules reads a Policy from r, one rule per line, for guardrails
+// such as keeping DEBUG off in production:
+//
+//	# comments and blank lines are ignored
+//	deny DEBUG=true when APP_ENV=production
+//	deny AWS_* when APP_ENV=dev
+//	require DATABASE_URL when APP_ENV=production and REGION=eu-*
+//
+// "deny KEY" rejects an Env holding KEY, and "deny KEY=VALUE" one holding
+// KEY set to VALUE. "require KEY" rejects an Env without KEY or with KEY
+// empty. A rule with "when" only applies if every "KEY=VALUE" condition,
+// joined by "and", holds. A change is checked against the Env as it would
+// be after it, by every rule whose key or condition keys it touches, so
+// setting DEBUG=true and then APP_ENV=production is rejected as surely as
+// the other way round; Export checks every rule. Keys and values of
+// denials and values of conditions may be path.Match patterns. Every rule
+// is checked, and the error lists all the rules broken.
+func ParseRules(r io.Reader) (*Rules, error) {
+	if r == nil {
+		return nil, fmt.Errorf("nil reader")
+	}
+
+	rs := &Rules{}
+
+	buf := bufio.NewScanner(r)
+	n := 0
+	for buf.Scan() {
+		n++
+		text := strings.TrimSpace(buf.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		ru, err := parseRule(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		ru.line = n
+		rs.rules = append(rs.rules, ru)
+	}
+
+	if err := buf.Err(); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// parseRule parses the text of a rule.
+func parseRule(text string) (rule, error) {
+	ru := rule{text: text}
+
+	verb, rest, _ := strings.Cut(text, " ")
+	switch verb {
+	case "deny":
+		ru.deny = true
+	case "require":
+	default:
+		return ru, fmt.Errorf("unknown rule %q; expected deny or require", verb)
+	}
+
+	target, cond, hasCond := strings.Cut(strings.TrimSpace(rest), " when ")
+	target = strings.TrimSpace(target)
+	ru.key, ru.value, ru.hasValue = strings.Cut(target, "=")
+
+	switch {
+	case ru.key == "" || strings.ContainsAny(ru.key, " \t"):
+		return ru, fmt.Errorf("%s: expected a key, found %q", verb, target)
+	case !ru.deny && ru.hasValue:
+		return ru, fmt.Errorf("require takes a key, not %q", target)
+	}
+
+	if !hasCond {
+		return ru, nil
+	}
+
+	for _, c := range strings.Split(cond, " and ") {
+		k, v, ok := strings.Cut(strings.TrimSpace(c), "=")
+		if !ok || k == "" {
+			return ru, fmt.Errorf("when: expected KEY=VALUE, found %q", strings.TrimSpace(c))
+		}
+		ru.when = append(ru.when, [2]string{k, v})
+	}
+	return ru, nil
+}
+
+// Evaluate returns an error listing the rules in rs that in breaks, or
+// nil if it breaks none.
+func (rs *Rules) Evaluate(in PolicyInput) error {
+	if rs == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, ru := range rs.rules {
+		if why, ok := ru.broken(in); ok {
+			errs = append(errs, fmt.Errorf("line %d: %s: %s", ru.line, ru.text, why))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// touched reports whether d changes a key the rule is about, its own or
+// one of its conditions'.
+func (ru rule) touched(d Diff) bool {
+	for _, c := range d {
+		if matchAny([]string{ru.key}, c.Key) {
+			return true
+		}
+
+		for _, w := range ru.when {
+			if w[0] == c.Key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// broken reports whether in breaks the rule, and why.
+func (ru rule) broken(in PolicyInput) (string, bool) {
+	if in.Op != PolicyExport && !ru.touched(in.Diff) {
+		return "", false
+	}
+
+	for _, c := range ru.when {
+		if !matchAny([]string{c[1]}, in.Env[c[0]]) {
+			return "", false
+		}
+	}
+
+	if !ru.deny {
+		return ru.key + " is required", in.Env[ru.key] == ""
+	}
+
+	for _, k := range sortedKeys(in.Env) {
+		if !matchAny([]string{ru.key}, k) {
+			continue
+		}
+
+		if !ru.hasValue || matchAny([]string{ru.value}, in.Env[k]) {
+			return k + " is denied", true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,370 @@
+package envy
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testRules = `# guardrails
+deny DEBUG=true when APP_ENV=production
+deny AWS_* when APP_ENV=dev
+
+require DATABASE_URL when APP_ENV=prod*
+deny WSLENV when APP_ENV=sealed
+`
+
+func Test_ParseRules(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		in   string
+		err  string
+	}{
+		{name: "rules", in: testRules},
+		{name: "empty", in: ""},
+		{name: "unknown verb", in: "allow A\n", err: `line 1: unknown rule "allow"; expected deny or require`},
+		{name: "no key", in: "\ndeny\n", err: `line 2: deny: expected a key, found ""`},
+		{name: "require value", in: "require A=1\n", err: `line 1: require takes a key, not "A=1"`},
+		{name: "bad condition", in: "deny A when B\n", err: `line 1: when: expected KEY=VALUE, found "B"`},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			_, err := ParseRules(strings.NewReader(tc.in))
+			if tc.err != "" {
+				r.Error(err)
+				r.Equal(tc.err, err.Error())
+				return
+			}
+			r.NoError(err)
+		})
+	}
+
+	r := require.New(t)
+	_, err := ParseRules(nil)
+	r.Error(err)
+}
+
+func Test_Env_SetPolicy(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  map[string]string
+		fn   func(e *Env) error
+		err  string
+		exp  map[string]string
+	}{
+		{
+			name: "allowed",
+			env:  map[string]string{"APP_ENV": "production"},
+			fn:   func(e *Env) error { return e.Setenv("DEBUG", "false") },
+			exp:  map[string]string{"APP_ENV": "production", "DEBUG": "false"},
+		},
+		{
+			name: "denied value",
+			env:  map[string]string{"APP_ENV": "production"},
+			fn:   func(e *Env) error { return e.Setenv("DEBUG", "true") },
+			err:  "line 2: deny DEBUG=true when APP_ENV=production: DEBUG is denied",
+			exp:  map[string]string{"APP_ENV": "production"},
+		},
+		{
+			name: "condition not met",
+			env:  map[string]string{"APP_ENV": "staging"},
+			fn:   func(e *Env) error { return e.Setenv("DEBUG", "true") },
+			exp:  map[string]string{"APP_ENV": "staging", "DEBUG": "true"},
+		},
+		{
+			name: "condition set by the same change",
+			env:  map[string]string{},
+			fn: func(e *Env) error {
+				return e.Apply(Diff{{Key: "APP_ENV", Kind: Added, New: "dev"}, {Key: "AWS_REGION", Kind: Added, New: "x"}})
+			},
+			err: "line 3: deny AWS_* when APP_ENV=dev: AWS_REGION is denied",
+			exp: map[string]string{},
+		},
+		{
+			name: "condition set after the value",
+			env:  map[string]string{"DEBUG": "true"},
+			fn:   func(e *Env) error { return e.Setenv("APP_ENV", "production") },
+			err:  "line 2: deny DEBUG=true when APP_ENV=production: DEBUG is denied",
+			exp:  map[string]string{"DEBUG": "true"},
+		},
+		{
+			name: "condition set after the pattern",
+			env:  map[string]string{"AWS_REGION": "x"},
+			fn:   func(e *Env) error { return e.Setenv("APP_ENV", "dev") },
+			err:  "AWS_REGION is denied",
+			exp:  map[string]string{"AWS_REGION": "x"},
+		},
+		{
+			name: "condition set without the required key",
+			env:  map[string]string{},
+			fn:   func(e *Env) error { return e.Setenv("APP_ENV", "prod") },
+			err:  "DATABASE_URL is required",
+			exp:  map[string]string{},
+		},
+		{
+			name: "rule not touched",
+			env:  map[string]string{"APP_ENV": "production", "DEBUG": "true"},
+			fn:   func(e *Env) error { return e.Setenv("PORT", "80") },
+			exp:  map[string]string{"APP_ENV": "production", "DEBUG": "true", "PORT": "80"},
+		},
+		{
+			name: "required unset",
+			env:  map[string]string{"APP_ENV": "prod", "DATABASE_URL": "pg://"},
+			fn:   func(e *Env) error { return e.Unsetenv("DATABASE_URL") },
+			err:  "line 5: require DATABASE_URL when APP_ENV=prod*: DATABASE_URL is required",
+			exp:  map[string]string{"APP_ENV": "prod", "DATABASE_URL": "pg://"},
+		},
+		{
+			name: "required emptied",
+			env:  map[string]string{"APP_ENV": "production", "DATABASE_URL": "pg://"},
+			fn:   func(e *Env) error { return e.Setenv("DATABASE_URL", "") },
+			err:  "DATABASE_URL is required",
+			exp:  map[string]string{"APP_ENV": "production", "DATABASE_URL": "pg://"},
+		},
+		{
+			name: "required not touched",
+			env:  map[string]string{"APP_ENV": "prod"},
+			fn:   func(e *Env) error { return e.Setenv("PORT", "80") },
+			exp:  map[string]string{"APP_ENV": "prod", "PORT": "80"},
+		},
+		{
+			name: "no-op not checked",
+			env:  map[string]string{"APP_ENV": "production", "DEBUG": "true"},
+			fn:   func(e *Env) error { return e.Setenv("DEBUG", "true") },
+			exp:  map[string]string{"APP_ENV": "production", "DEBUG": "true"},
+		},
+		{
+			name: "read from",
+			env:  map[string]string{"APP_ENV": "production"},
+			fn: func(e *Env) error {
+				_, err := e.ReadFrom(strings.NewReader("DEBUG=true\nPORT=80\n"))
+				return err
+			},
+			err: "DEBUG is denied",
+			exp: map[string]string{"APP_ENV": "production"},
+		},
+		{
+			name: "temp",
+			env:  map[string]string{"APP_ENV": "production"},
+			fn: func(e *Env) error {
+				return e.Temp(map[string]string{"DEBUG": "true"}, func(*Env) error {
+					return fmt.Errorf("fn must not be called")
+				})
+			},
+			err: "DEBUG is denied",
+			exp: map[string]string{"APP_ENV": "production"},
+		},
+		{
+			name: "temp restore not checked",
+			env:  map[string]string{"APP_ENV": "prod"},
+			fn: func(e *Env) error {
+				return e.Temp(map[string]string{"DATABASE_URL": "pg://"}, func(*Env) error {
+					return nil
+				})
+			},
+			exp: map[string]string{"APP_ENV": "prod"},
+		},
+		{
+			name: "share wsl",
+			env:  map[string]string{"APP_ENV": "sealed"},
+			fn:   func(e *Env) error { return e.ShareWSL("HOME") },
+			err:  "WSLENV is denied",
+			exp:  map[string]string{"APP_ENV": "sealed"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			rules, err := ParseRules(strings.NewReader(testRules))
+			r.NoError(err)
+
+			env := FromMap(tc.env)
+			r.NoError(env.SetPolicy(rules))
+
+			err = tc.fn(env)
+			if tc.err != "" {
+				r.ErrorIs(err, ErrPolicy)
+				r.Contains(err.Error(), tc.err)
+			} else {
+				r.NoError(err)
+			}
+			r.Equal(tc.exp, env.jsonMap())
+		})
+	}
+
+	r := require.New(t)
+	var env *Env
+	r.Error(env.SetPolicy(nil))
+}
+
+func Test_Env_Export(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  map[string]string
+		exp  []string
+		err  string
+	}{
+		{
+			name: "allowed",
+			env:  map[string]string{"APP_ENV": "production", "DATABASE_URL": "pg://", "DEBUG": "false"},
+			exp:  []string{"APP_ENV=production", "DATABASE_URL=pg://", "DEBUG=false"},
+		},
+		{
+			name: "all broken rules",
+			env:  map[string]string{"APP_ENV": "production", "DEBUG": "true"},
+			err:  "line 2: deny DEBUG=true when APP_ENV=production: DEBUG is denied\nline 5: require DATABASE_URL when APP_ENV=prod*: DATABASE_URL is required",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			rules, err := ParseRules(strings.NewReader(testRules))
+			r.NoError(err)
+
+			env := FromMap(tc.env)
+			r.NoError(env.SetPolicy(rules))
+
+			out, err := env.Export()
+			if tc.err != "" {
+				r.ErrorIs(err, ErrPolicy)
+				r.Equal(tc.err, err.Error())
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, out)
+		})
+	}
+}
+
+func Test_Env_Export_methods(t *testing.T) {
+	t.Parallel()
+
+	rules, err := ParseRules(strings.NewReader(testRules))
+	require.NoError(t, err)
+
+	env := FromMap(map[string]string{"APP_ENV": "production", "DATABASE_URL": "pg://", "DEBUG": "true"})
+	require.NoError(t, env.SetPolicy(rules))
+
+	pub, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tcs := []struct {
+		name string
+		fn   func() error
+	}{
+		{name: "write to", fn: func() error {
+			_, err := env.WriteTo(io.Discard)
+			return err
+		}},
+		{name: "reader", fn: func() error {
+			_, err := io.ReadAll(env.Reader())
+			return err
+		}},
+		{name: "to file", fn: func() error {
+			return env.ToFile(filepath.Join(t.TempDir(), ".env"))
+		}},
+		{name: "to json", fn: func() error {
+			return env.ToJSON(io.Discard)
+		}},
+		{name: "marshal json", fn: func() error {
+			_, err := json.Marshal(env)
+			return err
+		}},
+		{name: "docker", fn: func() error {
+			return env.ToDockerEnvFile(io.Discard)
+		}},
+		{name: "seal", fn: func() error {
+			_, err := env.Seal(pub.PublicKey())
+			return err
+		}},
+		{name: "sign", fn: func() error {
+			_, err := env.Sign(priv)
+			return err
+		}},
+	}
+
+	// not parallel, as env is changed below
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+
+			err := tc.fn()
+			r.ErrorIs(err, ErrPolicy)
+			r.Contains(err.Error(), "DEBUG is denied")
+		})
+	}
+
+	r := require.New(t)
+	r.Empty(env.Environ())
+	r.Empty(env.Environ(NaturalOrder(true)))
+
+	// the contents are still there, only not handed out
+	r.Equal("true", env.Getenv("DEBUG"))
+	r.NoError(env.Unsetenv("DEBUG"))
+	r.Len(env.Environ(), 2)
+}
+
+func Test_PolicyFunc(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	errNo := errors.New("no")
+
+	var got []PolicyInput
+	env := FromMap(map[string]string{"A": "1"})
+	r.NoError(env.SetPolicy(PolicyFunc(func(in PolicyInput) error {
+		got = append(got, in)
+		if in.Env["B"] == "bad" {
+			return errNo
+		}
+		return nil
+	})))
+
+	r.NoError(env.Setenv("B", "ok"))
+	err := env.Setenv("B", "bad")
+	r.ErrorIs(err, ErrPolicy)
+	r.ErrorIs(err, errNo)
+	r.Equal("ok", env.Getenv("B"))
+
+	r.Len(got, 2)
+	r.Equal(PolicyChange, got[0].Op)
+	r.Equal(Diff{{Key: "B", Kind: Added, New: "ok"}}, got[0].Diff)
+	r.Equal(map[string]string{"A": "1", "B": "ok"}, got[0].Env)
+
+	out, err := env.Export()
+	r.NoError(err)
+	r.Equal([]string{"A=1", "B=ok"}, out)
+	r.Equal(PolicyExport, got[2].Op)
+
+	r.NoError(env.SetPolicy(nil))
+	r.NoError(env.Setenv("B", "bad"))
+}
@@ -0,0 +1,208 @@
+package envy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FromPowerShell reads PowerShell environment assignments of the form
+// `$env:KEY = "value"` (or `${env:KEY} = 'value'`) from r. Double-quoted
+// values understand backtick escapes; single-quoted values are literal.
+// Values are not interpolated. Lines that are not environment assignments,
+// such as comments or other commands in a profile, are ignored.
+func FromPowerShell(r io.Reader) (*Env, error) {
+	if r == nil {
+		return nil, fmt.Errorf("nil reader")
+	}
+
+	em := map[string]string{}
+
+	buf := bufio.NewScanner(r)
+	for buf.Scan() {
+		key, value, ok := parsePowerShellLine(buf.Text())
+		if !ok {
+			continue
+		}
+		em[key] = value
+	}
+
+	if err := buf.Err(); err != nil {
+		return nil, err
+	}
+
+	return FromMap(em), nil
+}
+
+// ToPowerShell writes the Env to w as sorted `$env:KEY = 'value'` lines that
+// can be dot-sourced by PowerShell and read back with FromPowerShell.
+func (e *Env) ToPowerShell(w io.Writer) error {
+	if w == nil {
+		return fmt.Errorf("nil writer")
+	}
+
+	for _, ent := range e.entries() {
+		if _, err := fmt.Fprintf(w, "%s = %s\n", powerShellVar(ent.key), powerShellQuote(ent.value)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parsePowerShellLine parses a single `$env:KEY = value` assignment.
+func parsePowerShellLine(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if len(line) < 5 || !strings.EqualFold(line[:5], "$env:") && !strings.HasPrefix(strings.ToLower(line), "${env:") {
+		return "", "", false
+	}
+
+	var key, rest string
+	if line[1] == '{' {
+		i := strings.IndexByte(line, '}')
+		if i < 0 {
+			return "", "", false
+		}
+		key, rest = line[6:i], line[i+1:]
+	} else {
+		rest = line[5:]
+		i := strings.IndexFunc(rest, func(r rune) bool {
+			return !isPowerShellNameRune(r)
+		})
+		if i < 0 {
+			return "", "", false
+		}
+		key, rest = rest[:i], rest[i:]
+	}
+
+	rest = strings.TrimSpace(rest)
+	if key == "" || !strings.HasPrefix(rest, "=") {
+		return "", "", false
+	}
+	rest = strings.TrimSpace(rest[1:])
+
+	value, ok := parsePowerShellValue(rest)
+	if !ok {
+		return "", "", false
+	}
+	return key, value, true
+}
+
+// parsePowerShellValue unquotes a PowerShell string literal. Bare words are
+// returned up to the first whitespace or comment.
+func parsePowerShellValue(s string) (string, bool) {
+	if s == "" {
+		return "", true
+	}
+
+	switch s[0] {
+	case '\'':
+		var bb strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				bb.WriteByte(s[i])
+				continue
+			}
+			// '' is an escaped single quote
+			if i+1 < len(s) && s[i+1] == '\'' {
+				bb.WriteByte('\'')
+				i++
+				continue
+			}
+			return bb.String(), true
+		}
+		return "", false
+	case '"':
+		var bb strings.Builder
+		for i := 1; i < len(s); i++ {
+			c := s[i]
+			switch {
+			case c == '`' && i+1 < len(s):
+				i++
+				bb.WriteString(powerShellUnescape(s[i]))
+			case c == '"' && i+1 < len(s) && s[i+1] == '"':
+				bb.WriteByte('"')
+				i++
+			case c == '"':
+				return bb.String(), true
+			default:
+				bb.WriteByte(c)
+			}
+		}
+		return "", false
+	}
+
+	if i := strings.IndexAny(s, " \t#;"); i >= 0 {
+		s = s[:i]
+	}
+	return s, true
+}
+
+// powerShellUnescape returns the character for the backtick escape `c.
+func powerShellUnescape(c byte) string {
+	switch c {
+	case '0':
+		return "\x00"
+	case 'a':
+		return "\a"
+	case 'b':
+		return "\b"
+	case 'f':
+		return "\f"
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case 'v':
+		return "\v"
+	}
+	return string(c)
+}
+
+// powerShellVar returns the PowerShell expression naming the environment
+// variable key, using the braced form when key has special characters.
+func powerShellVar(key string) string {
+	for _, r := range key {
+		if !isPowerShellNameRune(r) {
+			return "${env:" + key + "}"
+		}
+	}
+	return "$env:" + key
+}
+
+// powerShellQuote quotes s as a PowerShell string literal. Single quotes are
+// used unless s contains control characters that need backtick escapes.
+func powerShellQuote(s string) string {
+	if !strings.ContainsAny(s, "\n\r\t\x00") {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+
+	var bb strings.Builder
+	bb.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\n':
+			bb.WriteString("`n")
+		case '\r':
+			bb.WriteString("`r")
+		case '\t':
+			bb.WriteString("`t")
+		case 0:
+			bb.WriteString("`0")
+		case '`', '"', '$':
+			bb.WriteByte('`')
+			bb.WriteByte(c)
+		default:
+			bb.WriteByte(c)
+		}
+	}
+	bb.WriteByte('"')
+	return bb.String()
+}
+
+func isPowerShellNameRune(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
@@ -0,0 +1,116 @@
+package envy
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromPowerShell(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name  string
+		input io.Reader
+		exp   []string
+		err   bool
+	}{
+		{
+			name:  "empty",
+			input: strings.NewReader(""),
+			exp:   []string{},
+		},
+		{
+			name:  "double quoted",
+			input: strings.NewReader(`$env:KEY = "VALUE"`),
+			exp:   []string{"KEY=VALUE"},
+		},
+		{
+			name:  "single quoted",
+			input: strings.NewReader(`$Env:KEY='it''s $literal'`),
+			exp:   []string{"KEY=it's $literal"},
+		},
+		{
+			name:  "backtick escapes",
+			input: strings.NewReader("$env:KEY = \"a`tb `\"c`\" \"\"d\"\"\""),
+			exp:   []string{"KEY=a\tb \"c\" \"d\""},
+		},
+		{
+			name:  "bare word with comment",
+			input: strings.NewReader("$env:KEY = value # comment"),
+			exp:   []string{"KEY=value"},
+		},
+		{
+			name:  "braced name",
+			input: strings.NewReader(`${env:ProgramFiles(x86)} = 'C:\Program Files (x86)'`),
+			exp:   []string{`ProgramFiles(x86)=C:\Program Files (x86)`},
+		},
+		{
+			name: "profile with other lines",
+			input: strings.NewReader(strings.Join([]string{
+				"# my profile",
+				"Set-Alias ll Get-ChildItem",
+				`$env:PATH = "C:\bin"`,
+				"$notenv = 'x'",
+				`$env:BROKEN = "unterminated`,
+				"",
+			}, "\n")),
+			exp: []string{`PATH=C:\bin`},
+		},
+		{
+			name: "nil reader",
+			err:  true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+
+			env, err := FromPowerShell(tc.input)
+			if tc.err {
+				r.Error(err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, env.Environ())
+		})
+	}
+}
+
+func Test_Env_ToPowerShell(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := FromMap(map[string]string{
+		"B":                 "it's",
+		"A":                 "plain",
+		"C":                 "line1\nline2 $x `y`",
+		"ProgramFiles(x86)": `C:\Program Files (x86)`,
+	})
+
+	bb := &bytes.Buffer{}
+	r.NoError(env.ToPowerShell(bb))
+
+	exp := strings.Join([]string{
+		"$env:A = 'plain'",
+		"$env:B = 'it''s'",
+		"$env:C = \"line1`nline2 `$x ``y``\"",
+		`${env:ProgramFiles(x86)} = 'C:\Program Files (x86)'`,
+		"",
+	}, "\n")
+	r.Equal(exp, bb.String())
+
+	// round trip
+	got, err := FromPowerShell(bb)
+	r.NoError(err)
+	r.Equal(env.Environ(), got.Environ())
+
+	r.Error(env.ToPowerShell(nil))
+}
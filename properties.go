@@ -0,0 +1,230 @@
+package envy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// property is an entry of a Java .properties file.
+type property struct {
+	key   string
+	value string
+	// line is the 1-based line the entry starts on.
+	line int
+	// comment is the text of the comment lines directly above the entry.
+	comment string
+	// doc counts the "#---" document separators above the entry, which
+	// split a file into documents as in Spring Boot.
+	doc int
+}
+
+// FromProperties reads the Java .properties file at path into an Env, in
+// the format described on java.util.Properties. Keys are kept as written,
+// such as "server.port", so Nest(".") recovers the hierarchy, and a key set
+// more than once takes its last value. The line and the comment lines
+// directly above each key are recorded as its Meta. It returns an error
+// for a nil fs.FS or a read failure; the error wraps ErrMalformed if the
+// file cannot be parsed.
+func FromProperties(cab fs.FS, path string) (*Env, error) {
+	if cab == nil {
+		return nil, fmt.Errorf("nil fs.FS")
+	}
+
+	f, err := cab.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	props, err := parseProperties(f)
+	if err != nil {
+		return nil, malformedError{fmt.Errorf("%s: %w", path, err)}
+	}
+
+	em := map[string]string{}
+	meta := map[string]Meta{}
+	for _, p := range props {
+		if !validKey(p.key) {
+			continue
+		}
+
+		em[p.key] = p.value
+		meta[p.key] = Meta{
+			Loader:      "file",
+			File:        path,
+			Line:        p.line,
+			Description: p.comment,
+		}
+	}
+
+	e := FromMap(em)
+	e.meta = meta
+	return e, nil
+}
+
+// parseProperties reads r in the format of java.util.Properties: "#" and
+// "!" start comments, a key ends at the first unescaped "=", ":", or white
+// space, a line ending in an unescaped backslash continues on the next
+// one, and \t, \n, \r, \f, and \uXXXX escapes are decoded. The file is
+// read as UTF-8.
+func parseProperties(r io.Reader) ([]property, error) {
+	if r == nil {
+		return nil, fmt.Errorf("nil reader")
+	}
+
+	var props []property
+	var comments []string
+	doc := 0
+
+	buf := bufio.NewScanner(r)
+	n := 0
+	for buf.Scan() {
+		n++
+		start := n
+		line := strings.TrimLeft(strings.TrimSuffix(buf.Text(), "\r"), " \t\f")
+
+		if line == "" {
+			comments = nil
+			continue
+		}
+
+		if line[0] == '#' || line[0] == '!' {
+			if line[1:] == "---" {
+				doc++
+				comments = nil
+				continue
+			}
+			comments = append(comments, strings.TrimSpace(line[1:]))
+			continue
+		}
+
+		for continues(line) && buf.Scan() {
+			n++
+			next := strings.TrimLeft(strings.TrimSuffix(buf.Text(), "\r"), " \t\f")
+			line = line[:len(line)-1] + next
+		}
+		if continues(line) {
+			line = line[:len(line)-1]
+		}
+
+		k, v := splitProperty(line)
+
+		key, err := unescapeProperty(k)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", start, err)
+		}
+
+		val, err := unescapeProperty(v)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", start, key, err)
+		}
+
+		props = append(props, property{
+			key:     key,
+			value:   val,
+			line:    start,
+			comment: strings.Join(comments, "\n"),
+			doc:     doc,
+		})
+		comments = nil
+	}
+
+	if err := buf.Err(); err != nil {
+		return nil, err
+	}
+	return props, nil
+}
+
+// continues reports whether line ends in an odd number of backslashes,
+// continuing it on the next line.
+func continues(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitProperty splits a logical line into its still escaped key and
+// value.
+func splitProperty(line string) (string, string) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+
+	key, rest := line[:end], line[end:]
+
+	rest = strings.TrimLeft(rest, " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	return key, rest
+}
+
+// unescapeProperty decodes the escapes of a .properties key or value.
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var bb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i == len(s)-1 {
+			bb.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 't':
+			bb.WriteByte('\t')
+		case 'n':
+			bb.WriteByte('\n')
+		case 'r':
+			bb.WriteByte('\r')
+		case 'f':
+			bb.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf(`malformed \u escape`)
+			}
+
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf(`malformed \u escape %q`, s[i-1:i+5])
+			}
+			i += 4
+
+			// characters outside the BMP are escaped as surrogate pairs
+			if utf16.IsSurrogate(rune(r)) && strings.HasPrefix(s[i+1:], `\u`) && i+7 <= len(s) {
+				if lo, err := strconv.ParseUint(s[i+3:i+7], 16, 16); err == nil {
+					if dr := utf16.DecodeRune(rune(r), rune(lo)); dr != unicode.ReplacementChar {
+						bb.WriteRune(dr)
+						i += 6
+						continue
+					}
+				}
+			}
+			bb.WriteRune(rune(r))
+		default:
+			bb.WriteByte(s[i])
+		}
+	}
+	return bb.String(), nil
+}
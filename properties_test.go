@@ -0,0 +1,163 @@
+package envy
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseProperties(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		in   string
+		exp  []property
+		err  bool
+	}{
+		{
+			name: "separators",
+			in:   "a=1\nb:2\nc 3\nd = 4\ne\t:\t5\nf\n",
+			exp: []property{
+				{key: "a", value: "1", line: 1},
+				{key: "b", value: "2", line: 2},
+				{key: "c", value: "3", line: 3},
+				{key: "d", value: "4", line: 4},
+				{key: "e", value: "5", line: 5},
+				{key: "f", value: "", line: 6},
+			},
+		},
+		{
+			name: "comments",
+			in:   "# the port\n! really\nserver.port=8080\n\n# dangling\n\nname=app # not a comment\n",
+			exp: []property{
+				{key: "server.port", value: "8080", line: 3, comment: "the port\nreally"},
+				{key: "name", value: "app # not a comment", line: 7},
+			},
+		},
+		{
+			name: "continuation",
+			in:   "fruits=apple, \\\n    banana, \\\n    pear\nnext=1\n",
+			exp: []property{
+				{key: "fruits", value: "apple, banana, pear", line: 1},
+				{key: "next", value: "1", line: 4},
+			},
+		},
+		{
+			name: "escaped backslash is not a continuation",
+			in:   "path=c:\\\\\nnext=1\n",
+			exp: []property{
+				{key: "path", value: `c:\`, line: 1},
+				{key: "next", value: "1", line: 2},
+			},
+		},
+		{
+			name: "escapes",
+			in:   "my\\ key\\=x=tab\\there\\nnl \\u00e9 \\uD83D\\uDE00 \\q\n",
+			exp: []property{
+				{key: "my key=x", value: "tab\there\nnl \u00e9 \U0001F600 q", line: 1},
+			},
+		},
+		{
+			name: "documents",
+			in:   "a=1\n#---\nspring.config.activate.on-profile=dev\na=2\n!---\nb=3\n",
+			exp: []property{
+				{key: "a", value: "1", line: 1},
+				{key: "spring.config.activate.on-profile", value: "dev", line: 3, doc: 1},
+				{key: "a", value: "2", line: 4, doc: 1},
+				{key: "b", value: "3", line: 6, doc: 2},
+			},
+		},
+		{
+			name: "crlf",
+			in:   "a=1\r\nb=2\r\n",
+			exp: []property{
+				{key: "a", value: "1", line: 1},
+				{key: "b", value: "2", line: 2},
+			},
+		},
+		{name: "bad unicode", in: "a=\\u12\n", err: true},
+		{name: "bad hex", in: "a=\\uzzzz\n", err: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			act, err := parseProperties(strings.NewReader(tc.in))
+			if tc.err {
+				r.Error(err)
+				r.Contains(err.Error(), "line 1")
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, act)
+		})
+	}
+
+	_, err := parseProperties(nil)
+	require.Error(t, err)
+}
+
+func Test_FromProperties(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		in   string
+		exp  map[string]string
+		err  string
+	}{
+		{
+			name: "entries",
+			in:   "# the port\nserver.port=8080\nname: my app\nname = last\ngreeting=caf\\u00e9\n",
+			exp:  map[string]string{"server.port": "8080", "name": "last", "greeting": "café"},
+		},
+		{name: "empty", in: "", exp: map[string]string{}},
+		{name: "bad escape", in: "a=\\u12\n", err: `app.properties: line 1: a: malformed \u escape`},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			cab := fstest.MapFS{"app.properties": {Data: []byte(tc.in)}}
+
+			env, err := FromProperties(cab, "app.properties")
+			if tc.err != "" {
+				r.ErrorIs(err, ErrMalformed)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(len(tc.exp), env.Len())
+			for k, v := range tc.exp {
+				r.Equal(v, env.Getenv(k), k)
+			}
+		})
+	}
+
+	r := require.New(t)
+	cab := fstest.MapFS{"app.properties": {Data: []byte("# the port\nserver.port=8080\n")}}
+
+	env, err := FromProperties(cab, "app.properties")
+	r.NoError(err)
+
+	m, ok := env.Meta("server.port")
+	r.True(ok)
+	r.Equal(2, m.Line)
+	r.Equal("the port", m.Description)
+
+	_, err = FromProperties(cab, "nope.properties")
+	r.ErrorIs(err, fs.ErrNotExist)
+
+	_, err = FromProperties(nil, "app.properties")
+	r.Error(err)
+}
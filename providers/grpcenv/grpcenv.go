@@ -0,0 +1,146 @@
+// Package grpcenv materializes an envy.Env from a configuration service
+// implementing the EnvService defined in envy.proto, and keeps it up to date.
+//
+// The package does not depend on gRPC, and this module ships no code
+// generated from envy.proto. Instead the package uses the small Client
+// interface, which mirrors the service's two RPCs; callers generate a
+// client from envy.proto into a package of their own and wrap it.
+package grpcenv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/markbates/envy"
+)
+
+// Snapshot is the result of the GetEnv RPC.
+type Snapshot struct {
+	Vars     map[string]string
+	Revision uint64
+}
+
+// Update is a single message from the WatchEnv RPC.
+type Update struct {
+	Set      map[string]string
+	Unset    []string
+	Revision uint64
+	// Full means Set is the complete environment.
+	Full bool
+}
+
+// Stream receives updates from a WatchEnv call. Recv returns io.EOF when the
+// server ends the stream.
+type Stream interface {
+	Recv() (Update, error)
+}
+
+// Client calls the EnvService RPCs.
+type Client interface {
+	GetEnv(ctx context.Context, name string) (Snapshot, error)
+	WatchEnv(ctx context.Context, name string, revision uint64) (Stream, error)
+}
+
+// Provider loads the environment called Name from the service.
+type Provider struct {
+	// Client is the service client. It is required.
+	Client Client
+
+	// Name is the environment to request.
+	Name string
+}
+
+// Load fetches the environment once, returning it along with its revision.
+func (p *Provider) Load(ctx context.Context) (*envy.Env, uint64, error) {
+	if p == nil || p.Client == nil {
+		return nil, 0, fmt.Errorf("nil client")
+	}
+
+	snap, err := p.Client.GetEnv(ctx, p.Name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	vars := make(map[string]string, len(snap.Vars))
+	for k, v := range snap.Vars {
+		vars[k] = v
+	}
+
+	env := envy.FromMap(vars)
+	if err := env.Attribute("provider grpc:///" + p.Name); err != nil {
+		return nil, 0, err
+	}
+	return env, snap.Revision, nil
+}
+
+// Source returns an envy.Source that calls Load with ctx.
+func (p *Provider) Source(ctx context.Context) envy.Source {
+	return func() (*envy.Env, error) {
+		env, _, err := p.Load(ctx)
+		return env, err
+	}
+}
+
+// Sync streams changes made after revision and applies each one to env with
+// envy.Env.Apply, so subscribers are notified once per update. It blocks
+// until the stream ends or fails, returning nil when the server closes the
+// stream cleanly. Either way it returns the revision of the last update
+// applied, or revision if there was none, so a caller can resume by
+// calling Sync again with it.
+func (p *Provider) Sync(ctx context.Context, env *envy.Env, revision uint64) (uint64, error) {
+	if p == nil || p.Client == nil {
+		return revision, fmt.Errorf("nil client")
+	}
+
+	if env.IsNil() {
+		return revision, fmt.Errorf("nil env")
+	}
+
+	stream, err := p.Client.WatchEnv(ctx, p.Name, revision)
+	if err != nil {
+		return revision, err
+	}
+
+	for {
+		u, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return revision, nil
+		}
+
+		if err != nil {
+			return revision, err
+		}
+
+		if err := env.Apply(diff(env, u)); err != nil {
+			return revision, err
+		}
+		revision = u.Revision
+	}
+}
+
+// diff converts an update into the changes it makes to env.
+func diff(env *envy.Env, u Update) envy.Diff {
+	if u.Full {
+		return envy.Compare(env, envy.FromMap(copyMap(u.Set)))
+	}
+
+	d := envy.Diff{}
+	for k, v := range u.Set {
+		d = append(d, envy.Change{Key: k, Kind: envy.Modified, New: v})
+	}
+
+	for _, k := range u.Unset {
+		d = append(d, envy.Change{Key: k, Kind: envy.Removed})
+	}
+	return d
+}
+
+func copyMap(m map[string]string) map[string]string {
+	cp := make(map[string]string, len(m))
+	for k, v := range m {
+		cp[k] = v
+	}
+	return cp
+}
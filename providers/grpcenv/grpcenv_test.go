@@ -0,0 +1,146 @@
+package grpcenv
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/markbates/envy"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient serves a fixed snapshot and a fixed list of updates.
+type fakeClient struct {
+	snap    Snapshot
+	updates []Update
+	err     error
+}
+
+func (c *fakeClient) GetEnv(ctx context.Context, name string) (Snapshot, error) {
+	if name != "app" {
+		return Snapshot{}, fmt.Errorf("unknown env %q", name)
+	}
+	return c.snap, nil
+}
+
+func (c *fakeClient) WatchEnv(ctx context.Context, name string, rev uint64) (Stream, error) {
+	return &fakeStream{updates: c.updates, err: c.err}, nil
+}
+
+type fakeStream struct {
+	updates []Update
+	err     error
+}
+
+func (s *fakeStream) Recv() (Update, error) {
+	if len(s.updates) == 0 {
+		if s.err != nil {
+			return Update{}, s.err
+		}
+		return Update{}, io.EOF
+	}
+
+	u := s.updates[0]
+	s.updates = s.updates[1:]
+	return u, nil
+}
+
+func Test_Provider_Load(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{snap: Snapshot{Vars: map[string]string{"PORT": "8080"}, Revision: 3}}
+
+	tcs := []struct {
+		name string
+		p    *Provider
+		exp  []string
+		rev  uint64
+		err  bool
+	}{
+		{
+			name: "nil client",
+			p:    &Provider{Name: "app"},
+			err:  true,
+		},
+		{
+			name: "unknown env",
+			p:    &Provider{Client: client, Name: "nope"},
+			err:  true,
+		},
+		{
+			name: "loaded",
+			p:    &Provider{Client: client, Name: "app"},
+			exp:  []string{"PORT=8080"},
+			rev:  3,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			env, rev, err := tc.p.Load(context.Background())
+			if tc.err {
+				r.Error(err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, env.Environ())
+			r.Equal(tc.rev, rev)
+		})
+	}
+}
+
+func Test_Provider_Sync(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	client := &fakeClient{
+		snap: Snapshot{Vars: map[string]string{"PORT": "8080", "HOST": "a"}, Revision: 1},
+		updates: []Update{
+			{Set: map[string]string{"PORT": "9090"}, Revision: 2},
+			{Unset: []string{"HOST"}, Revision: 3},
+			{Set: map[string]string{"NEW": "1"}, Full: true, Revision: 4},
+		},
+	}
+
+	p := &Provider{Client: client, Name: "app"}
+	env, rev, err := p.Load(context.Background())
+	r.NoError(err)
+
+	var got []envy.Diff
+	_, err = env.Subscribe(func(d envy.Diff) {
+		got = append(got, d)
+	})
+	r.NoError(err)
+
+	rev, err = p.Sync(context.Background(), env, rev)
+	r.NoError(err)
+	r.Equal(uint64(4), rev)
+	r.Equal([]string{"NEW=1"}, env.Environ())
+	r.Len(got, 3)
+	r.Equal(envy.Diff{{Key: "PORT", Kind: envy.Modified, Old: "8080", New: "9090"}}, got[0])
+}
+
+func Test_Provider_Sync_Error(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	p := &Provider{Client: &fakeClient{err: fmt.Errorf("boom")}, Name: "app"}
+	_, err := p.Sync(context.Background(), envy.Zero(), 0)
+	r.Error(err)
+	_, err = p.Sync(context.Background(), nil, 0)
+	r.Error(err)
+
+	// the revision to resume from survives a failed stream
+	p.Client = &fakeClient{
+		updates: []Update{{Set: map[string]string{"PORT": "9090"}, Revision: 7}},
+		err:     fmt.Errorf("boom"),
+	}
+	rev, err := p.Sync(context.Background(), envy.Zero(), 5)
+	r.Error(err)
+	r.Equal(uint64(7), rev)
+}
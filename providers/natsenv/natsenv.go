@@ -0,0 +1,186 @@
+// Package natsenv loads an envy.Env from a NATS JetStream key/value bucket
+// and keeps it up to date by watching the bucket.
+//
+// The package does not depend on the NATS client. Instead it uses the small
+// Bucket interface, which is easily satisfied by wrapping a
+// jetstream.KeyValue from github.com/nats-io/nats.go.
+package natsenv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/markbates/envy"
+)
+
+// Entry is a single update from a bucket watch.
+type Entry struct {
+	Key   string
+	Value []byte
+	// Deleted is true for delete and purge operations.
+	Deleted bool
+}
+
+// Bucket is the subset of a JetStream key/value bucket needed by Provider.
+type Bucket interface {
+	// Keys returns every key in the bucket.
+	Keys(ctx context.Context) ([]string, error)
+	// Get returns the current value of key.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Watch streams updates for all keys made after it is called. The
+	// channel is closed when ctx is done.
+	Watch(ctx context.Context) (<-chan Entry, error)
+}
+
+// Provider reads a bucket into an Env.
+type Provider struct {
+	// Bucket is the key/value bucket. It is required.
+	Bucket Bucket
+
+	// Prefix, if set, limits the provider to bucket keys beginning with
+	// it. The prefix is removed before Key is applied.
+	Prefix string
+
+	// Key converts a bucket key into an environment key. If nil,
+	// DefaultKey is used.
+	Key func(string) string
+}
+
+// DefaultKey converts a bucket key such as "db.host-name" into an
+// environment key such as "DB_HOST_NAME".
+func DefaultKey(k string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_", "/", "_").Replace(k))
+}
+
+// Load reads the bucket once.
+func (p *Provider) Load(ctx context.Context) (*envy.Env, error) {
+	if p == nil || p.Bucket == nil {
+		return nil, fmt.Errorf("nil bucket")
+	}
+
+	keys, err := p.Bucket.Keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	em := map[string]string{}
+	for _, k := range keys {
+		name, ok := p.name(k)
+		if !ok {
+			continue
+		}
+
+		v, err := p.Bucket.Get(ctx, k)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		em[name] = string(v)
+	}
+
+	env := envy.FromMap(em)
+	if err := env.Attribute(p.loader()); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// Source returns an envy.Source that calls Load with ctx.
+func (p *Provider) Source(ctx context.Context) envy.Source {
+	return func() (*envy.Env, error) {
+		return p.Load(ctx)
+	}
+}
+
+// Sync watches the bucket and applies each update to env with Setenv or
+// Unsetenv, so the env's subscribers (see envy.Env.Subscribe) are notified
+// of every change. Once the watch is established, it loads the bucket and
+// applies it to env as one change, so nothing written since env was
+// loaded, or before the watch began, is missed: values that differ are
+// set, and keys that Load took from the bucket and that it no longer
+// holds are unset. Other keys of env are left alone. It blocks until ctx
+// is done or the watch ends, and returns ctx.Err() or nil respectively.
+func (p *Provider) Sync(ctx context.Context, env *envy.Env) error {
+	if p == nil || p.Bucket == nil {
+		return fmt.Errorf("nil bucket")
+	}
+
+	if env.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	updates, err := p.Bucket.Watch(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := p.catchUp(ctx, env); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case u, ok := <-updates:
+			if !ok {
+				return ctx.Err()
+			}
+
+			name, ok := p.name(u.Key)
+			if !ok {
+				continue
+			}
+
+			if u.Deleted {
+				err = env.Unsetenv(name)
+			} else {
+				err = env.Setenv(name, string(u.Value))
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// catchUp loads the bucket and applies it to env, as described on Sync.
+func (p *Provider) catchUp(ctx context.Context, env *envy.Env) error {
+	cur, err := p.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	var d envy.Diff
+	for _, c := range envy.Compare(env, cur) {
+		if c.Kind == envy.Removed && env.Source(c.Key) != p.loader() {
+			continue
+		}
+		d = append(d, c)
+	}
+
+	if len(d) == 0 {
+		return nil
+	}
+	return env.Apply(d)
+}
+
+// loader names the provider as the Source of the values it loads.
+func (p *Provider) loader() string {
+	return "provider nats:///" + p.Prefix
+}
+
+// name maps a bucket key to an environment key, reporting false for keys
+// outside Prefix.
+func (p *Provider) name(k string) (string, bool) {
+	rest, ok := strings.CutPrefix(k, p.Prefix)
+	if !ok || rest == "" {
+		return "", false
+	}
+
+	if p.Key != nil {
+		return p.Key(rest), true
+	}
+	return DefaultKey(rest), true
+}
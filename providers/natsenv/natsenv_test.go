@@ -0,0 +1,213 @@
+package natsenv
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/markbates/envy"
+	"github.com/stretchr/testify/require"
+)
+
+// memBucket is an in-memory Bucket.
+type memBucket struct {
+	mu      sync.Mutex
+	data    map[string]string
+	watches []chan Entry
+}
+
+func (b *memBucket) Keys(ctx context.Context) ([]string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	keys := []string{}
+	for k := range b.data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (b *memBucket) Get(ctx context.Context, key string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	v, ok := b.data[key]
+	if !ok {
+		return nil, fmt.Errorf("key not found")
+	}
+	return []byte(v), nil
+}
+
+func (b *memBucket) Watch(ctx context.Context) (<-chan Entry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Entry, 10)
+	b.watches = append(b.watches, ch)
+	return ch, nil
+}
+
+func (b *memBucket) push(e Entry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, ch := range b.watches {
+		ch <- e
+	}
+}
+
+func (b *memBucket) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, ch := range b.watches {
+		close(ch)
+	}
+	b.watches = nil
+}
+
+func Test_Provider_Load(t *testing.T) {
+	t.Parallel()
+
+	b := &memBucket{data: map[string]string{
+		"app.port":      "8080",
+		"app.db.host":   "localhost",
+		"other.port":    "9090",
+		"app.log-level": "debug",
+	}}
+
+	tcs := []struct {
+		name string
+		p    *Provider
+		exp  []string
+		err  bool
+	}{
+		{
+			name: "nil bucket",
+			p:    &Provider{},
+			err:  true,
+		},
+		{
+			name: "all keys",
+			p:    &Provider{Bucket: b},
+			exp:  []string{"APP_DB_HOST=localhost", "APP_LOG_LEVEL=debug", "APP_PORT=8080", "OTHER_PORT=9090"},
+		},
+		{
+			name: "prefix",
+			p:    &Provider{Bucket: b, Prefix: "app."},
+			exp:  []string{"DB_HOST=localhost", "LOG_LEVEL=debug", "PORT=8080"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			env, err := tc.p.Load(context.Background())
+			if tc.err {
+				r.Error(err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, env.Environ())
+		})
+	}
+}
+
+func Test_Provider_Sync(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	b := &memBucket{data: map[string]string{"app.port": "8080"}}
+	p := &Provider{Bucket: b, Prefix: "app."}
+
+	env, err := p.Load(context.Background())
+	r.NoError(err)
+
+	diffs := make(chan envy.Diff, 10)
+	_, err = env.Subscribe(func(d envy.Diff) {
+		diffs <- d
+	})
+	r.NoError(err)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- p.Sync(context.Background(), env)
+	}()
+
+	// wait for the watch to be registered
+	r.Eventually(func() bool {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return len(b.watches) == 1
+	}, time.Second, time.Millisecond)
+
+	b.push(Entry{Key: "app.port", Value: []byte("9090")})
+	b.push(Entry{Key: "other.key", Value: []byte("ignored")})
+	b.push(Entry{Key: "app.port", Deleted: true})
+
+	r.Equal(envy.Diff{{Key: "PORT", Kind: envy.Modified, Old: "8080", New: "9090"}}, <-diffs)
+	r.Equal(envy.Diff{{Key: "PORT", Kind: envy.Removed, Old: "9090"}}, <-diffs)
+
+	b.close()
+	r.NoError(<-errc)
+	r.Empty(env.Environ())
+}
+
+func Test_Provider_Sync_catchUp(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	b := &memBucket{data: map[string]string{"app.port": "8080", "app.host": "db"}}
+	p := &Provider{Bucket: b, Prefix: "app."}
+
+	env, err := p.Load(context.Background())
+	r.NoError(err)
+	r.NoError(env.Setenv("OTHER", "kept"))
+
+	// written after Load, before Sync watches
+	b.mu.Lock()
+	b.data["app.port"] = "9090"
+	b.data["app.debug"] = "true"
+	delete(b.data, "app.host")
+	b.mu.Unlock()
+
+	diffs := make(chan envy.Diff, 10)
+	_, err = env.Subscribe(func(d envy.Diff) {
+		diffs <- d
+	})
+	r.NoError(err)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- p.Sync(context.Background(), env)
+	}()
+
+	r.Equal(envy.Diff{
+		{Key: "DEBUG", Kind: envy.Added, New: "true"},
+		{Key: "HOST", Kind: envy.Removed, Old: "db"},
+		{Key: "PORT", Kind: envy.Modified, Old: "8080", New: "9090"},
+	}, <-diffs)
+
+	b.close()
+	r.NoError(<-errc)
+	r.Equal([]string{"DEBUG=true", "OTHER=kept", "PORT=9090"}, env.Environ())
+}
+
+func Test_Provider_Sync_Errors(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	r.Error((&Provider{}).Sync(context.Background(), envy.Zero()))
+	r.Error((&Provider{Bucket: &memBucket{}}).Sync(context.Background(), nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.ErrorIs((&Provider{Bucket: &memBucket{}}).Sync(ctx, envy.Zero()), context.Canceled)
+}
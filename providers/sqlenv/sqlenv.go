@@ -0,0 +1,251 @@
+// Package sqlenv loads an envy.Env from key/value rows in a database/sql
+// table and writes changes back to it.
+package sqlenv
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/markbates/envy"
+)
+
+// Placeholder styles for bound parameters.
+const (
+	// Question uses "?" placeholders (MySQL, SQLite).
+	Question = "?"
+	// Dollar uses "$1", "$2", ... placeholders (PostgreSQL).
+	Dollar = "$"
+)
+
+var identRx = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Provider reads and writes environment variables stored as rows in a table.
+// The zero value, given a DB, reads from table "envy" with columns "name" and
+// "value" using "?" placeholders.
+type Provider struct {
+	// DB is the database to use. It is required.
+	DB *sql.DB
+
+	// Table is the table holding the variables. Defaults to "envy".
+	Table string
+
+	// KeyColumn and ValueColumn name the key and value columns. They
+	// default to "name" and "value".
+	KeyColumn   string
+	ValueColumn string
+
+	// Query, if set, replaces the generated SELECT used by Load. It must
+	// return exactly two string columns: key and value. Args are passed
+	// to it as bound parameters.
+	Query string
+	Args  []any
+
+	// Placeholder is the bound parameter style, Question or Dollar.
+	// Defaults to Question.
+	Placeholder string
+
+	// LockRows makes Save read the current rows with SELECT ... FOR
+	// UPDATE, so concurrent Saves wait for each other instead of
+	// writing over each other. Set it for databases that support it,
+	// such as PostgreSQL and MySQL; SQLite, which does not, serializes
+	// writing transactions anyway.
+	LockRows bool
+}
+
+// querier runs queries on a database or in a transaction.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Load reads all rows into a new Env.
+func (p *Provider) Load(ctx context.Context) (*envy.Env, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	q := p.Query
+	if q == "" {
+		q = fmt.Sprintf("SELECT %s, %s FROM %s", p.keyCol(), p.valueCol(), p.table())
+	}
+
+	em, err := read(ctx, p.DB, q, p.Args...)
+	if err != nil {
+		return nil, err
+	}
+
+	env := envy.FromMap(em)
+	if err := env.Attribute("provider sql:///" + p.table()); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// Source returns an envy.Source that calls Load with ctx.
+func (p *Provider) Source(ctx context.Context) envy.Source {
+	return func() (*envy.Env, error) {
+		return p.Load(ctx)
+	}
+}
+
+// Save makes the table match env inside a single transaction: changed keys
+// are updated, new keys inserted, and keys missing from env deleted. It
+// returns an error wrapping envy.ErrPolicy if env's Policy rejects the
+// export, leaving the table as it is. The current rows are read in the same
+// transaction, locked if LockRows is set. Save always uses the generated
+// table/column names, never Query.
+func (p *Provider) Save(ctx context.Context, env *envy.Env) (err error) {
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	if env.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	// an Env its Policy will not export must not empty the table
+	vars, err := env.Export()
+	if err != nil {
+		return err
+	}
+
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	sel := fmt.Sprintf("SELECT %s, %s FROM %s", p.keyCol(), p.valueCol(), p.table())
+	if p.LockRows {
+		sel += " FOR UPDATE"
+	}
+
+	cur, err := read(ctx, tx, sel)
+	if err != nil {
+		return err
+	}
+
+	update := fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s = %s", p.table(), p.valueCol(), p.ph(1), p.keyCol(), p.ph(2))
+	insert := fmt.Sprintf("INSERT INTO %s (%s, %s) VALUES (%s, %s)", p.table(), p.keyCol(), p.valueCol(), p.ph(1), p.ph(2))
+	del := fmt.Sprintf("DELETE FROM %s WHERE %s = %s", p.table(), p.keyCol(), p.ph(1))
+
+	for _, kv := range vars {
+		k, v := splitPair(kv)
+
+		old, ok := cur[k]
+		if !ok {
+			if _, err := tx.ExecContext(ctx, insert, k, v); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if old == v {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, update, v, k); err != nil {
+			return err
+		}
+	}
+
+	for k := range cur {
+		if env.IsSet(k) {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, del, k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// read returns the key and value rows of query q.
+func read(ctx context.Context, db querier, q string, args ...any) (map[string]string, error) {
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	em := map[string]string{}
+	for rows.Next() {
+		var k, v sql.NullString
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, err
+		}
+		em[k.String] = v.String
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return em, nil
+}
+
+func (p *Provider) validate() error {
+	if p == nil || p.DB == nil {
+		return fmt.Errorf("nil db")
+	}
+
+	for _, id := range []string{p.table(), p.keyCol(), p.valueCol()} {
+		if !identRx.MatchString(id) {
+			return fmt.Errorf("invalid identifier %q", id)
+		}
+	}
+
+	switch p.Placeholder {
+	case "", Question, Dollar:
+	default:
+		return fmt.Errorf("invalid placeholder %q", p.Placeholder)
+	}
+
+	return nil
+}
+
+func (p *Provider) table() string {
+	if p.Table == "" {
+		return "envy"
+	}
+	return p.Table
+}
+
+func (p *Provider) keyCol() string {
+	if p.KeyColumn == "" {
+		return "name"
+	}
+	return p.KeyColumn
+}
+
+func (p *Provider) valueCol() string {
+	if p.ValueColumn == "" {
+		return "value"
+	}
+	return p.ValueColumn
+}
+
+// ph returns the n-th (1-based) bound parameter placeholder.
+func (p *Provider) ph(n int) string {
+	if p.Placeholder == Dollar {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// splitPair splits a "KEY=VALUE" string as returned by Export.
+func splitPair(kv string) (string, string) {
+	k, v, _ := strings.Cut(kv, "=")
+	return k, v
+}
@@ -0,0 +1,217 @@
+package sqlenv
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/markbates/envy"
+	"github.com/stretchr/testify/require"
+)
+
+// memDriver is a minimal database/sql driver that understands exactly the
+// statements generated by Provider, backed by a map per DSN. It logs the
+// queries made on each DSN, marking those made in a transaction.
+type memDriver struct {
+	mu      sync.Mutex
+	tables  map[string]map[string]string
+	queries map[string][]string
+}
+
+var mem = &memDriver{tables: map[string]map[string]string{}, queries: map[string][]string{}}
+
+func init() {
+	sql.Register("envymem", mem)
+}
+
+func (d *memDriver) Open(dsn string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.tables[dsn]; !ok {
+		d.tables[dsn] = map[string]string{}
+	}
+	return &memConn{d: d, dsn: dsn}, nil
+}
+
+type memConn struct {
+	d   *memDriver
+	dsn string
+	tx  bool
+}
+
+func (c *memConn) Prepare(q string) (driver.Stmt, error) { return &memStmt{c: c, q: q}, nil }
+func (c *memConn) Close() error                          { return nil }
+func (c *memConn) Begin() (driver.Tx, error)             { c.tx = true; return c, nil }
+func (c *memConn) Commit() error                         { c.tx = false; return nil }
+func (c *memConn) Rollback() error                       { c.tx = false; return nil }
+
+type memStmt struct {
+	c *memConn
+	q string
+}
+
+func (s *memStmt) Close() error  { return nil }
+func (s *memStmt) NumInput() int { return -1 }
+
+func (s *memStmt) Exec(args []driver.Value) (driver.Result, error) {
+	d := s.c.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t := d.tables[s.c.dsn]
+	switch {
+	case strings.HasPrefix(s.q, "INSERT"):
+		t[args[0].(string)] = args[1].(string)
+	case strings.HasPrefix(s.q, "UPDATE"):
+		t[args[1].(string)] = args[0].(string)
+	case strings.HasPrefix(s.q, "DELETE"):
+		delete(t, args[0].(string))
+	default:
+		return nil, fmt.Errorf("unsupported exec: %s", s.q)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *memStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if !strings.HasPrefix(s.q, "SELECT") {
+		return nil, fmt.Errorf("unsupported query: %s", s.q)
+	}
+
+	d := s.c.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	q := s.q
+	if s.c.tx {
+		q = "tx " + q
+	}
+	d.queries[s.c.dsn] = append(d.queries[s.c.dsn], q)
+
+	rows := &memRows{}
+	for k, v := range d.tables[s.c.dsn] {
+		rows.data = append(rows.data, [2]string{k, v})
+	}
+	sort.Slice(rows.data, func(i, j int) bool { return rows.data[i][0] < rows.data[j][0] })
+	return rows, nil
+}
+
+type memRows struct {
+	data [][2]string
+	off  int
+}
+
+func (r *memRows) Columns() []string { return []string{"name", "value"} }
+func (r *memRows) Close() error      { return nil }
+
+func (r *memRows) Next(dest []driver.Value) error {
+	if r.off >= len(r.data) {
+		return io.EOF
+	}
+	dest[0], dest[1] = r.data[r.off][0], r.data[r.off][1]
+	r.off++
+	return nil
+}
+
+func openDB(t *testing.T, rows map[string]string) (*sql.DB, map[string]string) {
+	t.Helper()
+
+	dsn := t.Name()
+	db, err := sql.Open("envymem", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	mem.mu.Lock()
+	mem.tables[dsn] = rows
+	mem.mu.Unlock()
+
+	return db, rows
+}
+
+func Test_Provider_Load(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	db, _ := openDB(t, map[string]string{"KEY1": "VALUE1", "KEY2": "VALUE2"})
+
+	p := &Provider{DB: db}
+	env, err := p.Load(context.Background())
+	r.NoError(err)
+	r.Equal([]string{"KEY1=VALUE1", "KEY2=VALUE2"}, env.Environ())
+
+	merged, err := envy.With(envy.Zero(), p.Source(context.Background()))
+	r.NoError(err)
+	r.Equal(env.Environ(), merged.Environ())
+	r.Equal("provider sql:///envy", merged.Source("KEY1"))
+}
+
+func Test_Provider_Save(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	db, rows := openDB(t, map[string]string{"KEEP": "same", "CHANGE": "old", "DROP": "gone"})
+
+	p := &Provider{DB: db, Placeholder: Dollar, LockRows: true}
+	env := envy.FromMap(map[string]string{"KEEP": "same", "CHANGE": "new", "ADD": "added"})
+	r.NoError(p.Save(context.Background(), env))
+
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	r.Equal(map[string]string{"KEEP": "same", "CHANGE": "new", "ADD": "added"}, rows)
+
+	// the rows are read, and locked, in the transaction that writes them
+	r.Equal([]string{"tx SELECT name, value FROM envy FOR UPDATE"}, mem.queries[t.Name()])
+}
+
+func Test_Provider_Invalid(t *testing.T) {
+	t.Parallel()
+
+	db, _ := openDB(t, map[string]string{})
+
+	tcs := []struct {
+		name string
+		p    *Provider
+	}{
+		{name: "nil provider", p: nil},
+		{name: "nil db", p: &Provider{}},
+		{name: "bad table", p: &Provider{DB: db, Table: "envy; DROP TABLE users"}},
+		{name: "bad column", p: &Provider{DB: db, KeyColumn: "a b"}},
+		{name: "bad placeholder", p: &Provider{DB: db, Placeholder: ":"}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			_, err := tc.p.Load(context.Background())
+			r.Error(err)
+			r.Error(tc.p.Save(context.Background(), envy.Zero()))
+		})
+	}
+}
+
+func Test_Provider_Save_policy(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	db, rows := openDB(t, map[string]string{"KEEP": "same"})
+
+	env := envy.FromMap(map[string]string{"OTHER": "x"})
+	r.NoError(env.SetPolicy(envy.PolicyFunc(func(envy.PolicyInput) error {
+		return fmt.Errorf("no")
+	})))
+
+	p := &Provider{DB: db}
+	r.ErrorIs(p.Save(context.Background(), env), envy.ErrPolicy)
+
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	r.Equal(map[string]string{"KEEP": "same"}, rows)
+}
@@ -0,0 +1,198 @@
+// Package zkenv loads an envy.Env from a ZooKeeper znode tree.
+//
+// The package does not depend on a particular ZooKeeper client. Instead it
+// uses the small Client and WatchClient interfaces, which are easily
+// satisfied by wrapping a connection from a library such as
+// github.com/go-zookeeper/zk.
+package zkenv
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/markbates/envy"
+)
+
+// Client is the subset of a ZooKeeper connection needed to read a tree.
+type Client interface {
+	// Children returns the names of the direct children of path.
+	Children(path string) ([]string, error)
+	// Get returns the data stored at path.
+	Get(path string) ([]byte, error)
+}
+
+// WatchClient is a Client that can also set one-shot watches. The returned
+// channels must be closed, or receive a value, when the watched node or its
+// children change.
+type WatchClient interface {
+	Client
+	ChildrenW(path string) ([]string, <-chan struct{}, error)
+	GetW(path string) ([]byte, <-chan struct{}, error)
+}
+
+// Loader reads every leaf znode under Prefix into an Env. A leaf at
+// Prefix/db/host becomes the key DB_HOST.
+type Loader struct {
+	// Client is the ZooKeeper connection. It is required.
+	Client Client
+
+	// Prefix is the root of the tree to read, for example "/config/app".
+	Prefix string
+
+	// Key converts a path relative to Prefix into an environment key. If
+	// nil, DefaultKey is used.
+	Key func(rel string) string
+}
+
+// DefaultKey converts a relative znode path such as "db/host-name" into an
+// environment key such as "DB_HOST_NAME".
+func DefaultKey(rel string) string {
+	return strings.ToUpper(strings.NewReplacer("/", "_", "-", "_", ".", "_").Replace(rel))
+}
+
+// Load reads the tree once.
+func (l *Loader) Load() (*envy.Env, error) {
+	env, _, err := l.load(nil)
+	return env, err
+}
+
+// Source returns an envy.Source that calls Load.
+func (l *Loader) Source() envy.Source {
+	return l.Load
+}
+
+// Watch loads the tree and calls fn with the result, then waits for any
+// watched znode to change and repeats, until ctx is done. A load error is
+// passed to fn and stops the watch. The Client must implement WatchClient.
+func (l *Loader) Watch(ctx context.Context, fn func(*envy.Env, error)) error {
+	if fn == nil {
+		return fmt.Errorf("nil func")
+	}
+
+	if l == nil {
+		return fmt.Errorf("nil loader")
+	}
+
+	wc, ok := l.Client.(WatchClient)
+	if !ok {
+		return fmt.Errorf("%T does not support watches", l.Client)
+	}
+
+	for {
+		env, watches, err := l.load(wc)
+		fn(env, err)
+		if err != nil {
+			return err
+		}
+
+		if err := wait(ctx, watches); err != nil {
+			return err
+		}
+	}
+}
+
+// load walks the tree, setting watches with wc if it is not nil.
+func (l *Loader) load(wc WatchClient) (*envy.Env, []<-chan struct{}, error) {
+	if l == nil || l.Client == nil {
+		return nil, nil, fmt.Errorf("nil client")
+	}
+
+	key := l.Key
+	if key == nil {
+		key = DefaultKey
+	}
+
+	root := path.Clean("/" + l.Prefix)
+
+	em := map[string]string{}
+	var watches []<-chan struct{}
+
+	var walk func(p string) error
+	walk = func(p string) error {
+		var kids []string
+		var err error
+		if wc != nil {
+			var ch <-chan struct{}
+			kids, ch, err = wc.ChildrenW(p)
+			watches = append(watches, ch)
+		} else {
+			kids, err = l.Client.Children(p)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+
+		if len(kids) > 0 {
+			for _, k := range kids {
+				if err := walk(path.Join(p, k)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		// the root itself is never a key
+		if p == root {
+			return nil
+		}
+
+		var data []byte
+		if wc != nil {
+			var ch <-chan struct{}
+			data, ch, err = wc.GetW(p)
+			watches = append(watches, ch)
+		} else {
+			data, err = l.Client.Get(p)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, root), "/")
+		em[key(rel)] = string(data)
+		return nil
+	}
+
+	if err := walk(root); err != nil {
+		return nil, nil, err
+	}
+
+	env := envy.FromMap(em)
+	if err := env.Attribute("provider zk://" + root); err != nil {
+		return nil, nil, err
+	}
+	return env, watches, nil
+}
+
+// wait blocks until any of watches fires or ctx is done.
+func wait(ctx context.Context, watches []<-chan struct{}) error {
+	fired := make(chan struct{}, 1)
+	done := make(chan struct{})
+	defer close(done)
+
+	for _, ch := range watches {
+		if ch == nil {
+			continue
+		}
+
+		go func(ch <-chan struct{}) {
+			select {
+			case <-ch:
+				select {
+				case fired <- struct{}{}:
+				default:
+				}
+			case <-done:
+			}
+		}(ch)
+	}
+
+	select {
+	case <-fired:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,198 @@
+package zkenv
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/markbates/envy"
+	"github.com/stretchr/testify/require"
+)
+
+// memZK is an in-memory znode tree keyed by full path.
+type memZK struct {
+	mu      sync.Mutex
+	nodes   map[string]string
+	watches []chan struct{}
+}
+
+func newMemZK(nodes map[string]string) *memZK {
+	return &memZK{nodes: nodes}
+}
+
+func (z *memZK) Children(p string) ([]string, error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	seen := map[string]bool{}
+	found := false
+	for n := range z.nodes {
+		if n == p {
+			found = true
+		}
+		rest, ok := strings.CutPrefix(n, strings.TrimSuffix(p, "/")+"/")
+		if !ok {
+			continue
+		}
+		found = true
+		child, _, _ := strings.Cut(rest, "/")
+		seen[child] = true
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no node")
+	}
+
+	kids := []string{}
+	for k := range seen {
+		kids = append(kids, k)
+	}
+	sort.Strings(kids)
+	return kids, nil
+}
+
+func (z *memZK) Get(p string) ([]byte, error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	v, ok := z.nodes[p]
+	if !ok {
+		return nil, fmt.Errorf("no node")
+	}
+	return []byte(v), nil
+}
+
+func (z *memZK) watch() <-chan struct{} {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	ch := make(chan struct{})
+	z.watches = append(z.watches, ch)
+	return ch
+}
+
+func (z *memZK) ChildrenW(p string) ([]string, <-chan struct{}, error) {
+	kids, err := z.Children(p)
+	return kids, z.watch(), err
+}
+
+func (z *memZK) GetW(p string) ([]byte, <-chan struct{}, error) {
+	b, err := z.Get(p)
+	return b, z.watch(), err
+}
+
+func (z *memZK) set(p, v string) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	z.nodes[p] = v
+	for _, ch := range z.watches {
+		close(ch)
+	}
+	z.watches = nil
+}
+
+// clientOnly hides the watch methods of memZK.
+type clientOnly struct {
+	Client
+}
+
+func Test_Loader_Load(t *testing.T) {
+	t.Parallel()
+
+	zk := newMemZK(map[string]string{
+		"/config/app":              "",
+		"/config/app/port":         "8080",
+		"/config/app/db/host-name": "localhost",
+		"/config/other/port":       "9090",
+	})
+
+	tcs := []struct {
+		name   string
+		loader *Loader
+		exp    []string
+		err    bool
+	}{
+		{
+			name:   "nil client",
+			loader: &Loader{Prefix: "/config/app"},
+			err:    true,
+		},
+		{
+			name:   "default keys",
+			loader: &Loader{Client: zk, Prefix: "/config/app"},
+			exp:    []string{"DB_HOST_NAME=localhost", "PORT=8080"},
+		},
+		{
+			name: "custom keys",
+			loader: &Loader{Client: zk, Prefix: "config/app/", Key: func(rel string) string {
+				return "APP_" + DefaultKey(path.Base(rel))
+			}},
+			exp: []string{"APP_HOST_NAME=localhost", "APP_PORT=8080"},
+		},
+		{
+			name:   "missing prefix",
+			loader: &Loader{Client: zk, Prefix: "/nope"},
+			err:    true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			env, err := tc.loader.Load()
+			if tc.err {
+				r.Error(err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, env.Environ())
+		})
+	}
+}
+
+func Test_Loader_Watch(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	zk := newMemZK(map[string]string{
+		"/app/port": "8080",
+	})
+
+	l := &Loader{Client: zk, Prefix: "/app"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	got := make(chan *envy.Env)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- l.Watch(ctx, func(env *envy.Env, err error) {
+			r.NoError(err)
+			got <- env
+		})
+	}()
+
+	r.Equal("8080", (<-got).Getenv("PORT"))
+
+	zk.set("/app/port", "9090")
+	r.Equal("9090", (<-got).Getenv("PORT"))
+
+	cancel()
+	r.ErrorIs(<-errc, context.Canceled)
+}
+
+func Test_Loader_Watch_Unsupported(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	l := &Loader{Client: clientOnly{newMemZK(map[string]string{})}, Prefix: "/app"}
+	r.Error(l.Watch(context.Background(), func(*envy.Env, error) {}))
+}
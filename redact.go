@@ -0,0 +1,83 @@
+package envy
+
+import "strings"
+
+// Redacted replaces sensitive values in redacted output.
+const Redacted = "[REDACTED]"
+
+// sensitiveWords are key fragments that mark a value as sensitive.
+var sensitiveWords = []string{
+	"PASSWORD",
+	"PASSWD",
+	"SECRET",
+	"TOKEN",
+	"CREDENTIAL",
+	"PRIVATE",
+	"API_KEY",
+	"APIKEY",
+	"ACCESS_KEY",
+	"AUTH",
+	"DSN",
+}
+
+// IsSensitive reports whether key looks like it holds a secret, based on
+// common naming conventions such as *_PASSWORD, *_TOKEN, and *_SECRET. It is
+// the default used when a nil redaction func is given, except by
+// Env.Redact, which uses IsSecret; IsSecret falls back on it for
+// Unclassified keys.
+func IsSensitive(key string) bool {
+	k := strings.ToUpper(key)
+	for _, w := range sensitiveWords {
+		if strings.Contains(k, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of d in which the Old and New values of keys for
+// which sensitive returns true are replaced with Redacted. Empty values are
+// left empty. If sensitive is nil, IsSensitive is used; a Diff carries no
+// Meta, so pass the IsSecret of the Envs compared to also redact values
+// marked Secret.
+func (d Diff) Redact(sensitive func(key string) bool) Diff {
+	if sensitive == nil {
+		sensitive = IsSensitive
+	}
+
+	out := make(Diff, len(d))
+	for i, c := range d {
+		if sensitive(c.Key) {
+			c.Old = redact(c.Old)
+			c.New = redact(c.New)
+		}
+		out[i] = c
+	}
+	return out
+}
+
+// Redact returns a new Env in which the values of keys for which sensitive
+// returns true are replaced with Redacted. If sensitive is nil, e.IsSecret
+// is used, so values marked Secret are redacted whatever their names.
+func (e *Env) Redact(sensitive func(key string) bool) *Env {
+	if sensitive == nil {
+		sensitive = e.IsSecret
+	}
+
+	em := map[string]string{}
+	for _, ent := range e.entries() {
+		v := ent.value
+		if sensitive(ent.key) {
+			v = redact(v)
+		}
+		em[ent.key] = v
+	}
+	return FromMap(em)
+}
+
+func redact(s string) string {
+	if s == "" {
+		return s
+	}
+	return Redacted
+}
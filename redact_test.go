@@ -0,0 +1,76 @@
+package envy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_IsSensitive(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		key string
+		exp bool
+	}{
+		{key: "DB_PASSWORD", exp: true},
+		{key: "github_token", exp: true},
+		{key: "AWS_SECRET_ACCESS_KEY", exp: true},
+		{key: "STRIPE_API_KEY", exp: true},
+		{key: "DATABASE_DSN", exp: true},
+		{key: "PORT", exp: false},
+		{key: "HOME", exp: false},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.key, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			r.Equal(tc.exp, IsSensitive(tc.key))
+		})
+	}
+}
+
+func Test_Diff_Redact(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	d := Diff{
+		{Key: "DB_PASSWORD", Kind: Modified, Old: "a", New: "b"},
+		{Key: "PORT", Kind: Modified, Old: "1", New: "2"},
+		{Key: "TOKEN", Kind: Added, New: "t"},
+	}
+
+	r.Equal(Diff{
+		{Key: "DB_PASSWORD", Kind: Modified, Old: Redacted, New: Redacted},
+		{Key: "PORT", Kind: Modified, Old: "1", New: "2"},
+		{Key: "TOKEN", Kind: Added, New: Redacted},
+	}, d.Redact(nil))
+
+	// the original is untouched
+	r.Equal("a", d[0].Old)
+
+	r.Equal(Diff{
+		{Key: "DB_PASSWORD", Kind: Modified, Old: "a", New: "b"},
+		{Key: "PORT", Kind: Modified, Old: Redacted, New: Redacted},
+		{Key: "TOKEN", Kind: Added, New: "t"},
+	}, d.Redact(func(k string) bool { return strings.HasPrefix(k, "P") }))
+}
+
+func Test_Env_Redact(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := FromMap(map[string]string{"DB_PASSWORD": "hunter2", "PORT": "8080", "API_TOKEN": ""})
+	r.Equal([]string{"API_TOKEN=", "DB_PASSWORD=" + Redacted, "PORT=8080"}, env.Redact(nil).Environ())
+
+	// Meta overrides the names
+	r.NoError(env.SetMeta("PORT", Meta{Sensitivity: Secret}))
+	r.NoError(env.SetMeta("DB_PASSWORD", Meta{Sensitivity: Public}))
+	r.Equal([]string{"API_TOKEN=", "DB_PASSWORD=hunter2", "PORT=" + Redacted}, env.Redact(nil).Environ())
+
+	var nilEnv *Env
+	r.Empty(nilEnv.Redact(nil).Environ())
+}
@@ -0,0 +1,78 @@
+package envy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RefPrefix marks a value that refers to a key of another Env in the
+// registry, as "@ref:NAME/KEY", for composing configuration across the
+// environments of a multi-tenant runtime. In an Env that allows it with
+// FollowRefs, Getenv, Lookupenv, Decrypted, and the typed getters follow
+// the reference when the key is read, using the Env registered as NAME at
+// that time, so the referenced value is resolved and decrypted by that
+// Env, which must allow references itself for a chain to continue.
+// Elsewhere, and always in Environ, Raw, and the writers, the reference is
+// kept as written. NAME extends to the last "/" of the value.
+const RefPrefix = "@ref:"
+
+// ErrRef is wrapped by the errors Decrypted returns for references that
+// cannot be followed.
+var ErrRef = errors.New("cannot resolve reference")
+
+// FollowRefs turns following RefPrefix references on or off for reads of
+// the Env; it is off by default, so a value that came from a file cannot
+// read other Envs of the registry unless the Env's owner allows it. It
+// returns an error for a nil Env, and for turning it on in an Env holding
+// untrusted input: one read by FromUntrusted or with Sandboxed, or merged
+// from one, as such input could otherwise read another tenant's secrets.
+func (e *Env) FollowRefs(on bool) error {
+	if e.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if on && e.untrusted {
+		return fmt.Errorf("cannot follow references in an env holding untrusted input")
+	}
+
+	e.followRefs = on
+	return nil
+}
+
+// maxRefHops bounds how many references a read follows, which also stops
+// reference cycles.
+const maxRefHops = 8
+
+// deref returns the value referred to by val, the value of key, which has
+// been reached by following hops references.
+func deref(key, val string, hops int) (string, error) {
+	if hops >= maxRefHops {
+		return "", fmt.Errorf("%w: %s: more than %d references deep", ErrRef, key, maxRefHops)
+	}
+
+	ref := strings.TrimPrefix(val, RefPrefix)
+	i := strings.LastIndexByte(ref, '/')
+	if i <= 0 || i == len(ref)-1 {
+		return "", fmt.Errorf("%w: %s: malformed reference %q", ErrRef, key, val)
+	}
+	name, other := ref[:i], ref[i+1:]
+
+	target, ok := Named(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s: no env named %q", ErrRef, key, name)
+	}
+
+	v, set, err := target.lookupHops(other, hops+1)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", key, err)
+	}
+
+	if !set {
+		return "", fmt.Errorf("%w: %s: %s is not set in %q", ErrRef, key, other, name)
+	}
+	return v, nil
+}
@@ -0,0 +1,138 @@
+package envy
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_ref(t *testing.T) {
+	t.Parallel()
+
+	key := bytes.Repeat([]byte{7}, 32)
+	enc, err := EncryptValue(key, "s3cret")
+	require.NoError(t, err)
+
+	shared := FromMap(map[string]string{
+		"DB_URL":   "postgres://shared",
+		"POOL":     "20",
+		"PASSWORD": enc,
+		"NEXT":     "@ref:ref-test/tenants/a/DB_URL",
+		"SELF":     "@ref:ref-test-shared/SELF",
+	})
+	require.NoError(t, shared.SetKeyProvider(StaticKey(key)))
+	require.NoError(t, shared.FollowRefs(true))
+
+	tenant := FromMap(map[string]string{"DB_URL": "postgres://tenant-a"})
+
+	require.NoError(t, Register("ref-test-shared", shared))
+	require.NoError(t, Register("ref-test/tenants/a", tenant))
+	t.Cleanup(func() {
+		Unregister("ref-test-shared")
+		Unregister("ref-test/tenants/a")
+	})
+
+	env := FromMap(map[string]string{
+		"DB_URL":   "@ref:ref-test-shared/DB_URL",
+		"POOL":     "@ref:ref-test-shared/POOL",
+		"PASSWORD": "@ref:ref-test-shared/PASSWORD",
+		"CHAINED":  "@ref:ref-test-shared/NEXT",
+		"MISSING":  "@ref:ref-test-shared/NOPE",
+		"NO_ENV":   "@ref:ref-test-nope/DB_URL",
+		"BAD":      "@ref:no-slash",
+		"CYCLE":    "@ref:ref-test-shared/SELF",
+	})
+	require.NoError(t, env.FollowRefs(true))
+
+	tcs := []struct {
+		name string
+		key  string
+		exp  string
+		err  string
+	}{
+		{name: "plain", key: "DB_URL", exp: "postgres://shared"},
+		{name: "decrypted by target", key: "PASSWORD", exp: "s3cret"},
+		{name: "chained, name with slashes", key: "CHAINED", exp: "postgres://tenant-a"},
+		{name: "key not set", key: "MISSING", err: `MISSING: NOPE is not set in "ref-test-shared"`},
+		{name: "env not registered", key: "NO_ENV", err: `NO_ENV: no env named "ref-test-nope"`},
+		{name: "malformed", key: "BAD", err: `BAD: malformed reference "@ref:no-slash"`},
+		{name: "cycle", key: "CYCLE", err: "more than 8 references deep"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			act, err := env.Decrypted(tc.key)
+			if tc.err != "" {
+				r.ErrorIs(err, ErrRef)
+				r.Contains(err.Error(), tc.err)
+				r.Equal("", env.Getenv(tc.key))
+
+				_, ok := env.Lookupenv(tc.key)
+				r.True(ok)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, act)
+			r.Equal(tc.exp, env.Getenv(tc.key))
+		})
+	}
+
+	r := require.New(t)
+
+	n, err := Get[int](env, "POOL")
+	r.NoError(err)
+	r.Equal(20, n)
+
+	// the reference is kept as written
+	r.Contains(env.Environ(), "DB_URL=@ref:ref-test-shared/DB_URL")
+
+	// references are followed at read time
+	r.NoError(shared.Setenv("POOL", "30"))
+	r.Equal("30", env.Getenv("POOL"))
+
+	// and only where allowed
+	off := FromMap(map[string]string{"POOL": "@ref:ref-test-shared/POOL"})
+	r.Equal("@ref:ref-test-shared/POOL", off.Getenv("POOL"))
+}
+
+func Test_Env_ref_untrusted(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	r.NoError(Register("ref-test-admin", FromMap(map[string]string{"DB_PASSWORD": "s3cret"})))
+	t.Cleanup(func() {
+		Unregister("ref-test-admin")
+	})
+
+	in, err := FromUntrusted(strings.NewReader("X=@ref:ref-test-admin/DB_PASSWORD\n"), "tenant.env", Limits{})
+	r.NoError(err)
+
+	// not followed by default, and cannot be turned on
+	r.Equal("@ref:ref-test-admin/DB_PASSWORD", in.Getenv("X"))
+	r.Error(in.FollowRefs(true))
+	r.Equal("@ref:ref-test-admin/DB_PASSWORD", in.Getenv("X"))
+
+	// nor in an Env the untrusted input is merged into, or a child of it
+	trusted := Zero()
+	r.NoError(trusted.FollowRefs(true))
+
+	merged, err := trusted.Merge(in)
+	r.NoError(err)
+	r.Error(merged.FollowRefs(true))
+	r.Equal("@ref:ref-test-admin/DB_PASSWORD", merged.Getenv("X"))
+
+	child, cleanup := merged.Child()
+	defer cleanup()
+	r.Error(child.FollowRefs(true))
+
+	// a trusted Env still follows references once allowed
+	own := FromMap(map[string]string{"X": "@ref:ref-test-admin/DB_PASSWORD"})
+	r.NoError(own.FollowRefs(true))
+	r.Equal("s3cret", own.Getenv("X"))
+}
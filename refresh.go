@@ -0,0 +1,69 @@
+package envy
+
+import (
+	"fmt"
+	"os"
+)
+
+// Refresh re-reads the process environment for an Env created by New and
+// applies whatever changed in the process since New or the previous
+// Refresh, notifying subscribers once. Only keys that changed in the
+// process are touched, so values set locally with Setenv survive unless
+// the process changed the same key. It returns the changes applied, and an
+// error for a nil Env or one not created by New.
+func (e *Env) Refresh() (Diff, error) {
+	if e.IsNil() {
+		return nil, fmt.Errorf("nil env")
+	}
+
+	current := FromSlice(os.Environ()).envs
+
+	d, err := e.mutate(func() (map[string]string, []string, error) {
+		if e.process == nil {
+			return nil, nil, fmt.Errorf("env was not created by New")
+		}
+
+		set := map[string]string{}
+		var unset []string
+		for _, c := range diffMaps(e.process, current) {
+			switch c.Kind {
+			case Added, Modified:
+				set[c.Key] = c.New
+			case Removed:
+				unset = append(unset, c.Key)
+			}
+		}
+		return set, unset, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// only now that the change is made is the process caught up with, so
+	// a change the Policy rejects is tried again by the next Refresh
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.process != nil {
+		e.process = current
+	}
+
+	// the changed values came from the process, too
+
+	for _, c := range d {
+		if c.Kind != Removed {
+			e.attribute(c.Key, processLoader)
+		}
+	}
+	return d, nil
+}
+
+// Drift returns the changes needed to turn the Env into the current process
+// environment: Added keys exist only in the process, Removed keys only in
+// the Env, and Modified keys differ, with Old from the Env and New from the
+// process. Long-running services can use it to detect that something else
+// changed the real environment behind their back. A nil Env is treated as
+// empty.
+func (e *Env) Drift() Diff {
+	return Compare(e, FromSlice(os.Environ()))
+}
@@ -0,0 +1,123 @@
+package envy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test_Env_Refresh changes the process environment, so it must not run in
+// parallel with other tests that read it.
+func Test_Env_Refresh(t *testing.T) {
+	r := require.New(t)
+
+	t.Setenv("ENVY_REFRESH_KEEP", "keep")
+	t.Setenv("ENVY_REFRESH_MOD", "old")
+	t.Setenv("ENVY_REFRESH_GONE", "gone")
+	t.Setenv("ENVY_REFRESH_LOCAL", "process")
+
+	env := New()
+	r.NoError(env.Setenv("ENVY_REFRESH_LOCAL", "local"))
+	r.NoError(env.Setenv("ENVY_REFRESH_KEEP", "override"))
+
+	var got []Diff
+	_, err := env.Subscribe(func(d Diff) {
+		got = append(got, d)
+	})
+	r.NoError(err)
+
+	t.Setenv("ENVY_REFRESH_MOD", "new")
+	t.Setenv("ENVY_REFRESH_NEW", "added")
+	t.Setenv("ENVY_REFRESH_GONE", "")
+
+	d, err := env.Refresh()
+	r.NoError(err)
+
+	exp := Diff{
+		{Key: "ENVY_REFRESH_GONE", Kind: Modified, Old: "gone", New: ""},
+		{Key: "ENVY_REFRESH_MOD", Kind: Modified, Old: "old", New: "new"},
+		{Key: "ENVY_REFRESH_NEW", Kind: Added, New: "added"},
+	}
+	r.Equal(exp, d)
+	r.Equal([]Diff{exp}, got)
+
+	// local overrides of keys the process did not change survive
+	r.Equal("override", env.Getenv("ENVY_REFRESH_KEEP"))
+	r.Equal("local", env.Getenv("ENVY_REFRESH_LOCAL"))
+
+	// nothing changed since the last refresh
+	d, err = env.Refresh()
+	r.NoError(err)
+	r.Empty(d)
+	r.Len(got, 1)
+}
+
+func Test_Env_Refresh_Policy(t *testing.T) {
+	r := require.New(t)
+
+	t.Setenv("ENVY_REFRESH_DEBUG", "false")
+
+	env := New()
+	rules, err := ParseRules(strings.NewReader("deny ENVY_REFRESH_DEBUG=true\n"))
+	r.NoError(err)
+	r.NoError(env.SetPolicy(rules))
+
+	t.Setenv("ENVY_REFRESH_DEBUG", "true")
+
+	_, err = env.Refresh()
+	r.ErrorIs(err, ErrPolicy)
+	r.Equal("false", env.Getenv("ENVY_REFRESH_DEBUG"))
+
+	// the rejected change is not forgotten
+	r.NoError(env.SetPolicy(nil))
+
+	d, err := env.Refresh()
+	r.NoError(err)
+	r.Equal(Diff{{Key: "ENVY_REFRESH_DEBUG", Kind: Modified, Old: "false", New: "true"}}, d)
+}
+
+func Test_Env_Refresh_Errors(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  *Env
+	}{
+		{name: "nil env"},
+		{name: "not from New", env: Zero()},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			_, err := tc.env.Refresh()
+			r.Error(err)
+		})
+	}
+}
+
+// Test_Env_Drift changes the process environment, so it must not run in
+// parallel with other tests that read it.
+func Test_Env_Drift(t *testing.T) {
+	r := require.New(t)
+
+	t.Setenv("ENVY_DRIFT_SAME", "same")
+	t.Setenv("ENVY_DRIFT_MOD", "old")
+
+	env := New()
+	r.Empty(env.Drift())
+
+	r.NoError(env.Setenv("ENVY_DRIFT_LOCAL", "local"))
+	t.Setenv("ENVY_DRIFT_MOD", "new")
+	t.Setenv("ENVY_DRIFT_NEW", "added")
+
+	exp := Diff{
+		{Key: "ENVY_DRIFT_LOCAL", Kind: Removed, Old: "local"},
+		{Key: "ENVY_DRIFT_MOD", Kind: Modified, Old: "old", New: "new"},
+		{Key: "ENVY_DRIFT_NEW", Kind: Added, New: "added"},
+	}
+	r.Equal(exp, env.Drift())
+}
@@ -0,0 +1,64 @@
+package envy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]*Env{}
+)
+
+// Register makes env available by name via Named. Registering a name that is
+// already in use replaces the previous Env. It returns an error for a blank
+// name or a nil Env.
+func Register(name string, env *Env) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("blank env name")
+	}
+
+	if env.IsNil() {
+		return fmt.Errorf("nil env %q", name)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registry[name] = env
+	return nil
+}
+
+// Unregister removes the Env registered as name. Removing a missing name is a
+// no-op.
+func Unregister(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	delete(registry, name)
+}
+
+// Named returns the Env registered as name and whether it was found.
+func Named(name string) (*Env, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	env, ok := registry[name]
+	return env, ok
+}
+
+// Names returns the sorted names of all registered environments.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for n := range registry {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,100 @@
+package envy
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Register(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		key  string
+		env  *Env
+		err  bool
+	}{
+		{
+			name: "valid",
+			key:  "registry-valid",
+			env:  FromMap(map[string]string{"KEY": "VALUE"}),
+		},
+		{
+			name: "blank name",
+			key:  "  ",
+			env:  Zero(),
+			err:  true,
+		},
+		{
+			name: "nil env",
+			key:  "registry-nil",
+			env:  nil,
+			err:  true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			err := Register(tc.key, tc.env)
+			if tc.err {
+				r.Error(err)
+				_, ok := Named(tc.key)
+				r.False(ok)
+				return
+			}
+
+			r.NoError(err)
+			t.Cleanup(func() { Unregister(tc.key) })
+
+			got, ok := Named(tc.key)
+			r.True(ok)
+			r.Same(tc.env, got)
+			r.Contains(Names(), tc.key)
+		})
+	}
+}
+
+func Test_Register_Replace(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	a := Zero()
+	b := Zero()
+
+	r.NoError(Register("registry-replace", a))
+	r.NoError(Register("registry-replace", b))
+	t.Cleanup(func() { Unregister("registry-replace") })
+
+	got, ok := Named("registry-replace")
+	r.True(ok)
+	r.Same(b, got)
+
+	Unregister("registry-replace")
+	_, ok = Named("registry-replace")
+	r.False(ok)
+}
+
+func Test_Register_Concurrent(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("registry-concurrent-%d", i)
+			r.NoError(Register(name, Zero()))
+			_, ok := Named(name)
+			r.True(ok)
+			Unregister(name)
+		}(i)
+	}
+	wg.Wait()
+}
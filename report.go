@@ -0,0 +1,204 @@
+package envy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity ranks a Problem.
+type Severity int
+
+const (
+	// Info is worth knowing but needs no action.
+	Info Severity = iota + 1
+	// Warning should be looked at but does not stop the Env from being
+	// used.
+	Warning
+	// Error makes the Env unfit for use.
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return "unknown"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Severity) MarshalText() ([]byte, error) {
+	switch s {
+	case Info, Warning, Error:
+		return []byte(s.String()), nil
+	}
+	return nil, fmt.Errorf("invalid severity %d", int(s))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *Severity) UnmarshalText(b []byte) error {
+	for _, v := range []Severity{Info, Warning, Error} {
+		if string(b) == v.String() {
+			*s = v
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid severity %q", b)
+}
+
+// Machine-readable Problem codes.
+const (
+	// CodeMissing marks a required key that is not set.
+	CodeMissing = "missing"
+	// CodeEmpty marks a required key that is set but empty.
+	CodeEmpty = "empty"
+	// CodeDuplicateKey marks a key set more than once in a file.
+	CodeDuplicateKey = "duplicate-key"
+	// CodeSecret marks a value that looks like a credential.
+	CodeSecret = "plaintext-secret"
+	// CodeMalformed marks an entry that could not be parsed and was
+	// ignored.
+	CodeMalformed = "malformed"
+)
+
+// Problem is a single finding of a validation, parse, or requirement
+// check.
+type Problem struct {
+	Severity Severity `json:"severity"`
+	// Code identifies the kind of problem, such as CodeMissing, so
+	// tooling can gate on it without parsing Message.
+	Code    string `json:"code"`
+	Key     string `json:"key,omitempty"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	var bb strings.Builder
+	if p.File != "" {
+		bb.WriteString(p.File)
+		if p.Line > 0 {
+			fmt.Fprintf(&bb, ":%d", p.Line)
+		}
+		bb.WriteString(": ")
+	}
+
+	fmt.Fprintf(&bb, "%s: %s [%s]", p.Severity, p.Message, p.Code)
+	return bb.String()
+}
+
+// Report aggregates every problem found by the checks that produce one, so
+// that tooling can render all of them at once or gate on their severity
+// instead of stopping at the first.
+type Report struct {
+	Problems []Problem `json:"problems"`
+}
+
+// Add appends problems to the report.
+func (r *Report) Add(problems ...Problem) {
+	r.Problems = append(r.Problems, problems...)
+}
+
+// Merge appends the problems of other to the report.
+func (r *Report) Merge(other Report) {
+	r.Add(other.Problems...)
+}
+
+// OK reports whether the report has no Error problems.
+func (r Report) OK() bool {
+	return r.Max() < Error
+}
+
+// Max returns the highest severity in the report, or 0 if it is empty.
+func (r Report) Max() Severity {
+	var max Severity
+	for _, p := range r.Problems {
+		if p.Severity > max {
+			max = p.Severity
+		}
+	}
+	return max
+}
+
+// Filter returns the problems of at least severity min.
+func (r Report) Filter(min Severity) []Problem {
+	var out []Problem
+	for _, p := range r.Problems {
+		if p.Severity >= min {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Err returns nil if the report is OK, and otherwise an error joining one
+// error per Error problem.
+func (r Report) Err() error {
+	var errs []error
+	for _, p := range r.Filter(Error) {
+		errs = append(errs, errors.New(p.String()))
+	}
+	return errors.Join(errs...)
+}
+
+// Require checks that every key is set to a non-empty value, reporting a
+// CodeMissing or CodeEmpty Error for each that is not, in key order.
+func (e *Env) Require(keys ...string) Report {
+	keys = append([]string(nil), keys...)
+	sort.Strings(keys)
+
+	var r Report
+	for _, k := range keys {
+		switch {
+		case !e.IsSet(k):
+			r.Add(Problem{Severity: Error, Code: CodeMissing, Key: k, Message: k + " is required but not set"})
+		case e.Getenv(k) == "":
+			r.Add(Problem{Severity: Error, Code: CodeEmpty, Key: k, Message: k + " is required but empty"})
+		}
+	}
+	return r
+}
+
+// Problem converts the diagnostic into a Warning.
+func (d Diagnostic) Problem() Problem {
+	return Problem{
+		Severity: Warning,
+		Code:     d.Code,
+		Key:      d.Key,
+		File:     d.File,
+		Line:     d.Line,
+		Message:  d.Message,
+	}
+}
+
+// ReportTo makes parsing add every Diagnostic to r as a Warning.
+func ReportTo(r *Report) ParseOption {
+	return WithDiagnostics(func(d Diagnostic) {
+		r.Add(d.Problem())
+	})
+}
+
+// SecretProblems converts the findings of ScanSecrets into Warnings with
+// CodeSecret, attributed to where each value came from when it is known.
+func SecretProblems(env *Env, findings []SecretFinding) Report {
+	var r Report
+	for _, f := range findings {
+		m, _ := env.Meta(f.Key)
+		r.Add(Problem{
+			Severity: Warning,
+			Code:     CodeSecret,
+			Key:      f.Key,
+			File:     m.File,
+			Line:     m.Line,
+			Message:  f.Key + " looks like a secret: " + f.Reason,
+		})
+	}
+	return r
+}
@@ -0,0 +1,129 @@
+package envy
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Severity_Text(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		s   Severity
+		exp string
+		err bool
+	}{
+		{s: Info, exp: "info"},
+		{s: Warning, exp: "warning"},
+		{s: Error, exp: "error"},
+		{s: 0, exp: "unknown", err: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.exp, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			r.Equal(tc.exp, tc.s.String())
+
+			b, err := tc.s.MarshalText()
+			if tc.err {
+				r.Error(err)
+				r.Error(new(Severity).UnmarshalText([]byte(tc.exp)))
+				return
+			}
+
+			r.NoError(err)
+
+			var got Severity
+			r.NoError(got.UnmarshalText(b))
+			r.Equal(tc.s, got)
+		})
+	}
+}
+
+func Test_Env_Require(t *testing.T) {
+	t.Parallel()
+
+	env := FromMap(map[string]string{"PORT": "8080", "HOST": ""})
+
+	tcs := []struct {
+		name string
+		env  *Env
+		keys []string
+		exp  []Problem
+	}{
+		{
+			name: "all set",
+			env:  env,
+			keys: []string{"PORT"},
+		},
+		{
+			name: "missing and empty",
+			env:  env,
+			keys: []string{"TOKEN", "PORT", "HOST"},
+			exp: []Problem{
+				{Severity: Error, Code: CodeEmpty, Key: "HOST", Message: "HOST is required but empty"},
+				{Severity: Error, Code: CodeMissing, Key: "TOKEN", Message: "TOKEN is required but not set"},
+			},
+		},
+		{
+			name: "nil env",
+			keys: []string{"A"},
+			exp: []Problem{
+				{Severity: Error, Code: CodeMissing, Key: "A", Message: "A is required but not set"},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			rep := tc.env.Require(tc.keys...)
+			r.Equal(tc.exp, rep.Problems)
+			r.Equal(len(tc.exp) == 0, rep.OK())
+			r.Equal(len(tc.exp) == 0, rep.Err() == nil)
+		})
+	}
+}
+
+func Test_Report(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	var rep Report
+	r.True(rep.OK())
+	r.Zero(rep.Max())
+	r.NoError(rep.Err())
+
+	cab := fstest.MapFS{".env": &fstest.MapFile{Data: []byte("A=1\nTOKEN=ghp_aaaaaaaaaaaaaaaaaaaaaaaa\nA=2\n")}}
+	env, err := FromFile(cab, ".env", ReportTo(&rep))
+	r.NoError(err)
+
+	rep.Merge(SecretProblems(env, ScanSecrets(env)))
+	rep.Add(Problem{Severity: Info, Code: "note", Message: "just so you know"})
+
+	r.True(rep.OK())
+	r.Equal(Warning, rep.Max())
+	r.Len(rep.Filter(Warning), 2)
+	r.Len(rep.Filter(Info), 3)
+
+	r.Equal([]string{
+		".env:3: warning: duplicate key A overrides line 1 (last-wins) [duplicate-key]",
+		".env:2: warning: TOKEN looks like a secret: GitHub personal access token [plaintext-secret]",
+		"info: just so you know [note]",
+	}, []string{rep.Problems[0].String(), rep.Problems[1].String(), rep.Problems[2].String()})
+
+	rep.Merge(env.Require("MISSING", "OTHER"))
+	r.False(rep.OK())
+	r.EqualError(rep.Err(), "error: MISSING is required but not set [missing]\nerror: OTHER is required but not set [missing]")
+
+	b, err := json.Marshal(rep.Problems[0])
+	r.NoError(err)
+	r.JSONEq(`{"severity":"warning","code":"duplicate-key","key":"A","file":".env","line":3,"message":"duplicate key A overrides line 1 (last-wins)"}`, string(b))
+}
@@ -0,0 +1,179 @@
+package envy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Resolver looks up the value of key in one place, such as a secret
+// manager, and reports whether it has one. See AddResolver.
+type Resolver func(key string) (string, bool, error)
+
+// EnvResolver returns a Resolver that looks keys up in env, such as an Env
+// loaded from a file.
+func EnvResolver(env *Env) Resolver {
+	return func(key string) (string, bool, error) {
+		val, ok := env.Lookupenv(key)
+		return val, ok, nil
+	}
+}
+
+// ValueResolver returns a Resolver that always finds value, for the end
+// of a fallback chain.
+func ValueResolver(value string) Resolver {
+	return func(string) (string, bool, error) {
+		return value, true, nil
+	}
+}
+
+// resolution is the fallback chain of one key.
+type resolution struct {
+	mu    sync.Mutex
+	done  bool
+	chain []ranked
+}
+
+// ranked is a Resolver and its priority.
+type ranked struct {
+	priority int
+	resolve  Resolver
+}
+
+// AddResolver adds r to the fallback chain of key. The chain runs on the
+// first Getenv or Decrypted of key, trying resolvers from the highest
+// priority down, in the order they were added for equal priorities, until
+// one finds a value, which is then set in the Env. The Env's own value, if
+// key is set, ranks at priority 0 ahead of resolvers added with priority 0,
+// so use positive priorities for sources that override it, like a secret
+// manager, and negative ones for defaults. Setting the value found is not
+// reported to subscribers, since reading a key must not look like a change
+// to it: a Supervised child is not restarted by it. If a resolver fails the
+// read returns the error, through Decrypted, and the chain runs again on
+// the next read. Export, Environ, and the other methods handing the Env
+// out run every pending chain first, as ResolveAll does; other reads, such
+// as IsSet, see the Env's current contents. Adding a resolver makes the
+// chain of key run again on its next read. Resolvers are
+// called without the Env's lock held, but must not read key from the same
+// Env. It returns an error for a nil Env or Resolver, or an invalid key.
+func (e *Env) AddResolver(key string, priority int, r Resolver) error {
+	if e.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	if r == nil {
+		return fmt.Errorf("nil resolver")
+	}
+
+	if !validKey(key) {
+		return fmt.Errorf("invalid key %q", key)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.resolvers == nil {
+		e.resolvers = map[string]*resolution{}
+	}
+
+	// replace the resolution rather than changing it, as it may be
+	// running without the Env's lock
+	var chain []ranked
+	if old, ok := e.resolvers[key]; ok {
+		chain = append(chain, old.chain...)
+	}
+	chain = append(chain, ranked{priority: priority, resolve: r})
+
+	sort.SliceStable(chain, func(i, j int) bool {
+		return chain[i].priority > chain[j].priority
+	})
+
+	e.resolvers[key] = &resolution{chain: chain}
+	return nil
+}
+
+// ResolveAll runs the pending fallback chains of every key, so that reads
+// such as Environ see the resolved values. It returns the errors of the
+// chains that failed.
+func (e *Env) ResolveAll() error {
+	if e.IsNil() {
+		return nil
+	}
+
+	e.mu.RLock()
+	keys := make([]string, 0, len(e.resolvers))
+	for k := range e.resolvers {
+		keys = append(keys, k)
+	}
+	e.mu.RUnlock()
+
+	sort.Strings(keys)
+
+	var errs []error
+	for _, k := range keys {
+		if err := e.resolve(k); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolve runs the fallback chain of key if it has not run yet. Resolvers
+// are called without the Env's lock held.
+func (e *Env) resolve(key string) error {
+	e.mu.RLock()
+	res := e.resolvers[key]
+	e.mu.RUnlock()
+
+	if res == nil {
+		return nil
+	}
+
+	res.mu.Lock()
+	defer res.mu.Unlock()
+
+	return res.run(e, key)
+}
+
+// run runs the chain of key in e, unless it already ran. res.mu must be
+// held.
+func (res *resolution) run(e *Env, key string) error {
+	if res.done {
+		return nil
+	}
+
+	e.mu.RLock()
+	cur, set := e.envs[key]
+	e.mu.RUnlock()
+
+	val, found := cur, set
+	for _, rk := range res.chain {
+		if set && rk.priority <= 0 {
+			break
+		}
+
+		v, ok, err := rk.resolve(key)
+		if err != nil {
+			return fmt.Errorf("resolve %s: %w", key, err)
+		}
+
+		if ok {
+			val, found = v, true
+			break
+		}
+	}
+
+	if found && (!set || val != cur) {
+		// subscribers are not notified, as described on AddResolver
+		_, _, err := e.change(func() (map[string]string, []string, error) {
+			return map[string]string{key: val}, nil, nil
+		}, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	res.done = true
+	return nil
+}
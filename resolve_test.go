@@ -0,0 +1,181 @@
+package envy
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_AddResolver(t *testing.T) {
+	t.Parallel()
+
+	missing := func(string) (string, bool, error) { return "", false, nil }
+	failing := func(string) (string, bool, error) { return "", false, errors.New("vault sealed") }
+	file := FromMap(map[string]string{"DB_PASSWORD": "from-file"})
+
+	type res struct {
+		priority int
+		r        Resolver
+	}
+
+	tcs := []struct {
+		name  string
+		env   map[string]string
+		chain []res
+		exp   string
+		set   bool
+		err   bool
+	}{
+		{
+			name:  "highest priority wins",
+			chain: []res{{0, ValueResolver("default")}, {100, ValueResolver("secret")}, {50, EnvResolver(file)}},
+			exp:   "secret",
+			set:   true,
+		},
+		{
+			name:  "falls back",
+			chain: []res{{100, missing}, {50, EnvResolver(file)}, {-1, ValueResolver("default")}},
+			exp:   "from-file",
+			set:   true,
+		},
+		{
+			name:  "equal priorities in order",
+			chain: []res{{1, ValueResolver("first")}, {1, ValueResolver("second")}},
+			exp:   "first",
+			set:   true,
+		},
+		{
+			name:  "own value beats defaults",
+			env:   map[string]string{"DB_PASSWORD": "own"},
+			chain: []res{{0, ValueResolver("zero")}, {-1, ValueResolver("default")}},
+			exp:   "own",
+			set:   true,
+		},
+		{
+			name:  "override beats own value",
+			env:   map[string]string{"DB_PASSWORD": "own"},
+			chain: []res{{1, ValueResolver("override")}},
+			exp:   "override",
+			set:   true,
+		},
+		{
+			name:  "nothing found",
+			chain: []res{{1, missing}},
+		},
+		{
+			name:  "error",
+			chain: []res{{100, failing}, {-1, ValueResolver("default")}},
+			err:   true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			env := FromMap(tc.env)
+			for _, c := range tc.chain {
+				r.NoError(env.AddResolver("DB_PASSWORD", c.priority, c.r))
+			}
+
+			act, err := env.Decrypted("DB_PASSWORD")
+			if tc.err {
+				r.Error(err)
+				r.Contains(err.Error(), "vault sealed")
+				r.Equal("", env.Getenv("DB_PASSWORD"))
+				r.False(env.IsSet("DB_PASSWORD"))
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, act)
+			r.Equal(tc.exp, env.Getenv("DB_PASSWORD"))
+			r.Equal(tc.set, env.IsSet("DB_PASSWORD"))
+		})
+	}
+}
+
+func Test_Env_AddResolver_Lazy(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	var calls atomic.Int32
+	lookup := func(key string) (string, bool, error) {
+		calls.Add(1)
+		return "resolved-" + key, true, nil
+	}
+
+	env := Zero()
+	r.NoError(env.AddResolver("A", 1, lookup))
+	r.NoError(env.AddResolver("B", 1, lookup))
+	r.Zero(calls.Load())
+	r.False(env.IsSet("A"))
+
+	// resolving on read is not a change subscribers hear about
+	var diffs atomic.Int32
+	_, err := env.Subscribe(func(Diff) {
+		diffs.Add(1)
+	})
+	r.NoError(err)
+
+	got := make([]string, 10)
+	var wg sync.WaitGroup
+	for i := range got {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got[i] = env.Getenv("A")
+		}()
+	}
+	wg.Wait()
+
+	for _, v := range got {
+		r.Equal("resolved-A", v)
+	}
+
+	r.Equal(int32(1), calls.Load())
+	r.Zero(diffs.Load())
+
+	// Environ runs the pending chains
+	r.Equal([]string{"A=resolved-A", "B=resolved-B"}, env.Environ())
+	r.Equal(int32(2), calls.Load())
+	r.NoError(env.ResolveAll())
+	r.Equal(int32(2), calls.Load())
+	r.Zero(diffs.Load())
+
+	r.NoError(env.AddResolver("A", 2, ValueResolver("again")))
+	r.Equal("again", env.Getenv("A"))
+}
+
+func Test_Env_AddResolver_Errors(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	var e *Env
+	r.Error(e.AddResolver("A", 0, ValueResolver("x")))
+	r.NoError(e.ResolveAll())
+
+	env := Zero()
+	r.Error(env.AddResolver("A", 0, nil))
+	r.Error(env.AddResolver("", 0, ValueResolver("x")))
+
+	r.NoError(env.AddResolver("A", 0, func(string) (string, bool, error) {
+		return "", false, errors.New("boom")
+	}))
+	r.NoError(env.AddResolver("B", 0, ValueResolver("b")))
+
+	err := env.ResolveAll()
+	r.Error(err)
+	r.Contains(err.Error(), "resolve A: boom")
+	r.Equal("b", env.Getenv("B"))
+
+	// exporting fails rather than hand out an unresolved Env, while
+	// Environ, like Getenv, carries on
+	_, err = env.Export()
+	r.ErrorContains(err, "resolve A: boom")
+	r.Equal([]string{"B=b"}, env.Environ())
+}
@@ -0,0 +1,41 @@
+package envy
+
+import "path"
+
+// MinimalKeys are always passed through by Restricted, unless denied. They
+// are the variables most programs need to start: the search path, the
+// user's home directory, and the locale.
+var MinimalKeys = []string{"PATH", "HOME", "LANG"}
+
+// Restricted returns a new Env holding only MinimalKeys and the keys
+// matched by allow, minus any key matched by denyPatterns, for building
+// least-privilege environments for child processes. Entries in both lists
+// may be exact keys or path.Match patterns such as "LC_*"; a malformed
+// pattern only matches a key equal to it. Deny always wins over allow. A nil
+// Env yields an empty one.
+func (e *Env) Restricted(allow []string, denyPatterns []string) *Env {
+	allowed := append(append([]string{}, MinimalKeys...), allow...)
+
+	em := map[string]string{}
+	for _, ent := range e.entries() {
+		if matchAny(allowed, ent.key) && !matchAny(denyPatterns, ent.key) {
+			em[ent.key] = ent.value
+		}
+	}
+	return FromMap(em)
+}
+
+// matchAny reports whether key matches any of patterns.
+func matchAny(patterns []string, key string) bool {
+	for _, p := range patterns {
+		ok, err := path.Match(p, key)
+		if err != nil {
+			ok = p == key
+		}
+
+		if ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package envy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_Restricted(t *testing.T) {
+	t.Parallel()
+
+	full := FromMap(map[string]string{
+		"PATH":        "/bin",
+		"HOME":        "/home/me",
+		"LANG":        "en_US.UTF-8",
+		"LC_ALL":      "C",
+		"LC_TIME":     "C",
+		"AWS_SECRET":  "s3cr3t",
+		"DB_PASSWORD": "hunter2",
+		"PORT":        "8080",
+	})
+
+	tcs := []struct {
+		name  string
+		env   *Env
+		allow []string
+		deny  []string
+		exp   []string
+	}{
+		{
+			name: "nil env",
+			exp:  []string{},
+		},
+		{
+			name: "minimal",
+			env:  full,
+			exp:  []string{"HOME=/home/me", "LANG=en_US.UTF-8", "PATH=/bin"},
+		},
+		{
+			name:  "allowlist with patterns",
+			env:   full,
+			allow: []string{"PORT", "LC_*"},
+			exp:   []string{"HOME=/home/me", "LANG=en_US.UTF-8", "LC_ALL=C", "LC_TIME=C", "PATH=/bin", "PORT=8080"},
+		},
+		{
+			name:  "deny wins",
+			env:   full,
+			allow: []string{"*"},
+			deny:  []string{"*SECRET*", "*PASSWORD", "HOME"},
+			exp:   []string{"LANG=en_US.UTF-8", "LC_ALL=C", "LC_TIME=C", "PATH=/bin", "PORT=8080"},
+		},
+		{
+			name:  "malformed pattern matches literally",
+			env:   FromMap(map[string]string{"A[": "1", "B": "2"}),
+			allow: []string{"A["},
+			exp:   []string{"A[=1"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			r.Equal(tc.exp, tc.env.Restricted(tc.allow, tc.deny).Environ())
+		})
+	}
+}
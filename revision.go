@@ -0,0 +1,48 @@
+package envy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Revision returns the number of changes made to the Env since it was
+// created. It increases by one for every Setenv, Unsetenv, Apply, or other
+// mutation that changes at least one value, and is 0 for a nil Env.
+func (e *Env) Revision() uint64 {
+	if e.IsNil() {
+		return 0
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.rev
+}
+
+// Fingerprint returns a hex-encoded SHA-256 digest of the Env's contents.
+// Two environments with the same keys and values have the same fingerprint,
+// regardless of how they were built.
+func (e *Env) Fingerprint() string {
+	h := sha256.New()
+	for _, ent := range e.entries() {
+		// NUL cannot appear in keys or values passed to exec, so
+		// it unambiguously separates them
+		h.Write([]byte(ent.key))
+		h.Write([]byte{0})
+		h.Write([]byte(ent.value))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Len returns the number of variables in the Env.
+func (e *Env) Len() int {
+	if e.IsNil() {
+		return 0
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return len(e.envs)
+}
@@ -0,0 +1,60 @@
+package envy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_Revision(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	var nilEnv *Env
+	r.Equal(uint64(0), nilEnv.Revision())
+
+	env := Zero()
+	r.Equal(uint64(0), env.Revision())
+
+	r.NoError(env.Setenv("KEY", "VALUE"))
+	r.Equal(uint64(1), env.Revision())
+
+	// no change, no new revision
+	r.NoError(env.Setenv("KEY", "VALUE"))
+	r.NoError(env.Unsetenv("MISSING"))
+	r.Equal(uint64(1), env.Revision())
+
+	r.NoError(env.Apply(Diff{{Key: "A", Kind: Added, New: "1"}, {Key: "B", Kind: Added, New: "2"}}))
+	r.Equal(uint64(2), env.Revision())
+}
+
+func Test_Env_Fingerprint(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	a := FromMap(map[string]string{"KEY1": "VALUE1", "KEY2": "VALUE2"})
+	b := FromSlice([]string{"KEY2=VALUE2", "KEY1=VALUE1"})
+	r.Equal(a.Fingerprint(), b.Fingerprint())
+	r.Len(a.Fingerprint(), 64)
+
+	r.NoError(b.Setenv("KEY2", "OTHER"))
+	r.NotEqual(a.Fingerprint(), b.Fingerprint())
+
+	// key/value boundaries matter
+	r.NotEqual(
+		FromMap(map[string]string{"AB": "C"}).Fingerprint(),
+		FromMap(map[string]string{"A": "BC"}).Fingerprint(),
+	)
+
+	var nilEnv *Env
+	r.Equal(Zero().Fingerprint(), nilEnv.Fingerprint())
+}
+
+func Test_Env_Len(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	var nilEnv *Env
+	r.Equal(0, nilEnv.Len())
+	r.Equal(2, FromMap(map[string]string{"A": "1", "B": "2"}).Len())
+}
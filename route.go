@@ -0,0 +1,135 @@
+package envy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RouteOption configures Route.
+type RouteOption func(*routeOptions)
+
+type routeOptions struct {
+	trim     bool
+	unrouted string
+}
+
+// TrimPrefix makes Route remove the matched prefix from keys, so a
+// component reads HOST rather than PG_HOST. Keys equal to their prefix
+// are dropped.
+func TrimPrefix(on bool) RouteOption {
+	return func(o *routeOptions) {
+		o.trim = on
+	}
+}
+
+// Unrouted makes Route put the keys no prefix matches in the namespace
+// name, rather than leaving them out.
+func Unrouted(name string) RouteOption {
+	return func(o *routeOptions) {
+		o.unrouted = name
+	}
+}
+
+// Route splits the Env into one Env per namespace by key prefix, so that
+// component constructors receive only the configuration they own:
+//
+//	envs, err := env.Route(map[string]string{
+//		"db":    "PG_",
+//		"cache": "REDIS_",
+//	})
+//
+// prefixes maps each namespace to its prefix. A key goes to the namespace
+// with the longest matching prefix, so "PG_REPLICA_" can be routed apart
+// from "PG_". Every namespace is in the result, empty if no key matched.
+// Metadata and the KeyProvider are carried over. It returns an error for an
+// empty namespace or prefix, a prefix shared by two namespaces, or an
+// Unrouted namespace that is also given a prefix.
+func (e *Env) Route(prefixes map[string]string, opts ...RouteOption) (map[string]*Env, error) {
+	var o routeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	byPrefix := map[string]string{}
+	for name, p := range prefixes {
+		if name == "" {
+			return nil, fmt.Errorf("empty namespace for prefix %q", p)
+		}
+
+		if p == "" {
+			return nil, fmt.Errorf("%s: empty prefix", name)
+		}
+
+		if other, ok := byPrefix[p]; ok {
+			a, b := min(name, other), max(name, other)
+			return nil, fmt.Errorf("%s and %s have the same prefix %q", a, b, p)
+		}
+		byPrefix[p] = name
+	}
+
+	if _, ok := prefixes[o.unrouted]; ok && o.unrouted != "" {
+		return nil, fmt.Errorf("%s: unrouted namespace has a prefix", o.unrouted)
+	}
+
+	// longest first, so the most specific prefix wins
+	sorted := make([]string, 0, len(byPrefix))
+	for p := range byPrefix {
+		sorted = append(sorted, p)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	ems := map[string]map[string]string{}
+	metas := map[string]map[string]Meta{}
+	for name := range prefixes {
+		ems[name] = map[string]string{}
+		metas[name] = map[string]Meta{}
+	}
+	if o.unrouted != "" {
+		ems[o.unrouted] = map[string]string{}
+		metas[o.unrouted] = map[string]Meta{}
+	}
+
+	var keys KeyProvider
+	if !e.IsNil() {
+		e.mu.RLock()
+		keys = e.keys
+		e.mu.RUnlock()
+	}
+
+	for _, ent := range e.entries() {
+		name, key := o.unrouted, ent.key
+		for _, p := range sorted {
+			if strings.HasPrefix(ent.key, p) {
+				name = byPrefix[p]
+				if o.trim {
+					key = strings.TrimPrefix(ent.key, p)
+				}
+				break
+			}
+		}
+
+		if name == "" || !validKey(key) {
+			continue
+		}
+
+		ems[name][key] = ent.value
+		if m, ok := e.Meta(ent.key); ok {
+			metas[name][key] = m
+		}
+	}
+
+	out := make(map[string]*Env, len(ems))
+	for name, em := range ems {
+		child := FromMap(em)
+		child.meta = metas[name]
+		child.keys = keys
+		out[name] = child
+	}
+	return out, nil
+}
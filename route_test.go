@@ -0,0 +1,97 @@
+package envy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_Route(t *testing.T) {
+	t.Parallel()
+
+	env := FromMap(map[string]string{
+		"PG_HOST":         "db",
+		"PG_PORT":         "5432",
+		"PG_REPLICA_HOST": "replica",
+		"PG_":             "bare",
+		"REDIS_URL":       "redis://cache",
+		"PORT":            "8080",
+	})
+
+	tcs := []struct {
+		name     string
+		prefixes map[string]string
+		opts     []RouteOption
+		exp      map[string][]string
+		err      string
+	}{
+		{
+			name:     "keys kept",
+			prefixes: map[string]string{"db": "PG_", "cache": "REDIS_"},
+			exp: map[string][]string{
+				"db":    {"PG_=bare", "PG_HOST=db", "PG_PORT=5432", "PG_REPLICA_HOST=replica"},
+				"cache": {"REDIS_URL=redis://cache"},
+			},
+		},
+		{
+			name:     "longest prefix wins",
+			prefixes: map[string]string{"db": "PG_", "replica": "PG_REPLICA_"},
+			opts:     []RouteOption{TrimPrefix(true)},
+			exp: map[string][]string{
+				"db":      {"HOST=db", "PORT=5432"},
+				"replica": {"HOST=replica"},
+			},
+		},
+		{
+			name:     "unrouted",
+			prefixes: map[string]string{"cache": "REDIS_", "queue": "SQS_"},
+			opts:     []RouteOption{Unrouted("app")},
+			exp: map[string][]string{
+				"cache": {"REDIS_URL=redis://cache"},
+				"queue": {},
+				"app":   {"PG_=bare", "PG_HOST=db", "PG_PORT=5432", "PG_REPLICA_HOST=replica", "PORT=8080"},
+			},
+		},
+		{name: "empty prefix", prefixes: map[string]string{"db": ""}, err: "db: empty prefix"},
+		{name: "empty namespace", prefixes: map[string]string{"": "PG_"}, err: `empty namespace for prefix "PG_"`},
+		{name: "shared prefix", prefixes: map[string]string{"db": "PG_", "pg": "PG_"}, err: `db and pg have the same prefix "PG_"`},
+		{name: "unrouted with prefix", prefixes: map[string]string{"db": "PG_"}, opts: []RouteOption{Unrouted("db")}, err: "db: unrouted namespace has a prefix"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			envs, err := env.Route(tc.prefixes, tc.opts...)
+			if tc.err != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Len(envs, len(tc.exp))
+			for name, exp := range tc.exp {
+				r.Equal(exp, envs[name].Environ(), name)
+			}
+		})
+	}
+}
+
+func Test_Env_Route_meta(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := fromLines([]string{"PG_HOST=db"})
+	env.meta = fileMeta(".env", []string{"PG_HOST=db"})
+
+	envs, err := env.Route(map[string]string{"db": "PG_"}, TrimPrefix(true))
+	r.NoError(err)
+	r.Equal(".env line 1", envs["db"].Source("HOST"))
+
+	var nilEnv *Env
+	envs, err = nilEnv.Route(map[string]string{"db": "PG_"})
+	r.NoError(err)
+	r.Equal(0, envs["db"].Len())
+}
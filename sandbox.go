@@ -0,0 +1,178 @@
+package envy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrRejected is returned when a Sandboxed parse refuses its input.
+var ErrRejected = errors.New("rejected untrusted env file")
+
+// Limits bounds the input accepted by a Sandboxed parse. A zero field uses
+// the value from DefaultLimits.
+type Limits struct {
+	// MaxBytes is the largest accepted input.
+	MaxBytes int
+	// MaxLines is the most lines accepted, counting blanks and comments.
+	MaxLines int
+	// MaxLineBytes is the longest accepted line.
+	MaxLineBytes int
+	// MaxEntries is the most KEY=VALUE entries accepted.
+	MaxEntries int
+}
+
+// DefaultLimits are the Limits used for zero fields.
+var DefaultLimits = Limits{
+	MaxBytes:     1 << 20,
+	MaxLines:     10000,
+	MaxLineBytes: 32 << 10,
+	MaxEntries:   1000,
+}
+
+// Sandboxed parses the file as untrusted input, for platforms that accept
+// env files from their users. Only blank lines, comments, and KEY=VALUE
+// entries are allowed: #if directives, which read the process environment,
+// are rejected, GOOS-suffixed keys are kept as written, and values are
+// never expanded. Keys must be made of ASCII letters, digits, '_', '.', and
+// '-', and not start with a digit. Input beyond the limits, invalid UTF-8,
+// and control or bidirectional formatting characters other than tab are
+// rejected with an error wrapping ErrRejected, without reading further;
+// so are values that decode to such characters, such as "x\ry" or a
+// quoted value spanning lines.
+func Sandboxed(l Limits) ParseOption {
+	l = l.withDefaults()
+	return func(o *parseOptions) {
+		o.sandbox = &l
+	}
+}
+
+// FromUntrusted reads an env file from r with the Sandboxed rules and
+// limits. The name is used in errors and as the File of each entry's Meta.
+func FromUntrusted(r io.Reader, name string, l Limits) (*Env, error) {
+	if r == nil {
+		return nil, fmt.Errorf("nil reader")
+	}
+	return parseFile(name, r, []ParseOption{Sandboxed(l)})
+}
+
+// withDefaults fills in the zero fields of l from DefaultLimits.
+func (l Limits) withDefaults() Limits {
+	def := func(v *int, d int) {
+		if *v <= 0 {
+			*v = d
+		}
+	}
+
+	def(&l.MaxBytes, DefaultLimits.MaxBytes)
+	def(&l.MaxLines, DefaultLimits.MaxLines)
+	def(&l.MaxLineBytes, DefaultLimits.MaxLineBytes)
+	def(&l.MaxEntries, DefaultLimits.MaxEntries)
+	return l
+}
+
+// readSandboxed reads the lines of r within the limits of l, checking each
+// one as it goes. Comment lines are blanked so they cannot be mistaken for
+// entries.
+func readSandboxed(r io.Reader, l Limits) ([]string, error) {
+	lr := &io.LimitedReader{R: r, N: int64(l.MaxBytes) + 1}
+
+	buf := bufio.NewScanner(lr)
+	buf.Buffer(make([]byte, 0, 4096), l.MaxLineBytes+1)
+
+	var lines []string
+	entries := 0
+	for buf.Scan() {
+		if lr.N <= 0 {
+			return nil, fmt.Errorf("%w: larger than %d bytes", ErrRejected, l.MaxBytes)
+		}
+
+		n := len(lines) + 1
+		if n > l.MaxLines {
+			return nil, fmt.Errorf("%w: more than %d lines", ErrRejected, l.MaxLines)
+		}
+
+		line := buf.Text()
+		if len(line) > l.MaxLineBytes {
+			return nil, fmt.Errorf("%w: line %d: longer than %d bytes", ErrRejected, n, l.MaxLineBytes)
+		}
+
+		if err := checkUntrusted(line); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrRejected, n, err)
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "#"):
+			if d, ok := directive(trimmed); ok {
+				return nil, fmt.Errorf("%w: line %d: %s directives are not allowed", ErrRejected, n, d)
+			}
+			line = ""
+		default:
+			k, _, ok := strings.Cut(trimmed, "=")
+			if !ok {
+				return nil, fmt.Errorf("%w: line %d: not a KEY=VALUE entry", ErrRejected, n)
+			}
+
+			if !untrustedKey(k) {
+				return nil, fmt.Errorf("%w: line %d: invalid key %q", ErrRejected, n, k)
+			}
+
+			if entries++; entries > l.MaxEntries {
+				return nil, fmt.Errorf("%w: more than %d entries", ErrRejected, l.MaxEntries)
+			}
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err := buf.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("%w: line %d: longer than %d bytes", ErrRejected, len(lines)+1, l.MaxLineBytes)
+		}
+		return nil, err
+	}
+
+	if lr.N <= 0 {
+		return nil, fmt.Errorf("%w: larger than %d bytes", ErrRejected, l.MaxBytes)
+	}
+	return lines, nil
+}
+
+// checkUntrusted returns an error for invalid UTF-8 and for control and
+// bidirectional formatting characters, other than tab, in line.
+func checkUntrusted(line string) error {
+	for i, r := range line {
+		switch {
+		case r == utf8.RuneError && !strings.HasPrefix(line[i:], string(utf8.RuneError)):
+			return fmt.Errorf("invalid UTF-8 at byte %d", i)
+		case r == '\t':
+		case unicode.IsControl(r), unicode.Is(unicode.Bidi_Control, r):
+			return fmt.Errorf("control character %U", r)
+		}
+	}
+	return nil
+}
+
+// untrustedKey reports whether k is a key a Sandboxed parse accepts.
+func untrustedKey(k string) bool {
+	if k == "" || ('0' <= k[0] && k[0] <= '9') {
+		return false
+	}
+
+	for i := 0; i < len(k); i++ {
+		c := k[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '_', c == '.', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
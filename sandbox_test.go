@@ -0,0 +1,92 @@
+package envy
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromUntrusted(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name   string
+		in     string
+		limits Limits
+		exp    []string
+		err    string
+	}{
+		{
+			name: "plain entries",
+			in:   "# comment\nA=1\n\nB=$A ${HOME}\nC=\"\tx\"\nD.windows=y\n",
+			exp:  []string{"A=1", "B=$A ${HOME}", "C=\tx", "D.windows=y"},
+		},
+		{
+			name: "commented entry",
+			in:   "#A=1\nB=2\n",
+			exp:  []string{"B=2"},
+		},
+		{name: "directive", in: "#if HOME\nA=1\n#endif\n", err: "line 1: #if directives"},
+		{name: "prose comment", in: "#if unset, A is 1\nA=1\n", exp: []string{"A=1"}},
+		{name: "not an entry", in: "A=1\nexport\n", err: "line 2: not a KEY=VALUE entry"},
+		{name: "bad key", in: "A B=1\n", err: `invalid key "A B"`},
+		{name: "digit key", in: "1A=1\n", err: `invalid key "1A"`},
+		{name: "escape", in: "A=\x1b[31mred\n", err: "line 1: control character U+001B"},
+		{name: "nul", in: "A=x\x00y\n", err: "control character U+0000"},
+		{name: "bidi", in: "A=\u202eevil\n", err: "control character U+202E"},
+		{name: "escaped return", in: "A=1\nB=\"x\\ry\"\n", err: "line 2: B: control character U+000D"},
+		{name: "escaped newline", in: "A=\"x\\ny\"\n", err: "line 1: A: control character U+000A"},
+		{name: "multi-line", in: "A=\"x\ny=z\"\n", err: "line 1: A: control character U+000A"},
+		{name: "invalid utf8", in: "A=\xff\n", err: "invalid UTF-8 at byte 2"},
+		{name: "replacement char", in: "A=\ufffd\n", exp: []string{"A=\ufffd"}},
+		{name: "too big", in: "A=1\nB=2\n", limits: Limits{MaxBytes: 5}, err: "larger than 5 bytes"},
+		{name: "too many lines", in: "\n\n\n", limits: Limits{MaxLines: 2}, err: "more than 2 lines"},
+		{name: "line too long", in: "A=" + strings.Repeat("x", 20) + "\n", limits: Limits{MaxLineBytes: 10}, err: "line 1: longer than 10 bytes"},
+		{name: "too many entries", in: "A=1\nB=2\nC=3\n", limits: Limits{MaxEntries: 2}, err: "more than 2 entries"},
+		{name: "exactly at limits", in: "A=1\nB=2", limits: Limits{MaxBytes: 7, MaxLines: 2, MaxEntries: 2}, exp: []string{"A=1", "B=2"}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			env, err := FromUntrusted(strings.NewReader(tc.in), "upload.env", tc.limits)
+			if tc.err != "" {
+				r.ErrorIs(err, ErrRejected)
+				r.Contains(err.Error(), "upload.env")
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, env.Environ())
+		})
+	}
+}
+
+func Test_FromFile_Sandboxed(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	cab := fstest.MapFS{
+		".env": {Data: []byte("# the host\nHOST=example.com\nHOST=other\n")},
+	}
+
+	var diags []Diagnostic
+	env, err := FromFile(cab, ".env", Sandboxed(Limits{}), WithDiagnostics(func(d Diagnostic) {
+		diags = append(diags, d)
+	}))
+	r.NoError(err)
+	r.Equal("other", env.Getenv("HOST"))
+	r.Len(diags, 1)
+	r.Equal(".env line 3", env.Source("HOST"))
+
+	_, err = FromFile(cab, ".env", Sandboxed(Limits{}), OnDuplicate(DuplicateError))
+	r.Error(err)
+
+	_, err = FromUntrusted(nil, "x", Limits{})
+	r.Error(err)
+}
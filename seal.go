@@ -0,0 +1,132 @@
+package envy
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// sealVersion is the first byte of a sealed Env.
+const sealVersion = 1
+
+// sealInfo binds derived keys to this format.
+const sealInfo = "envy seal v1"
+
+// ErrOpen is returned by Open when a sealed Env cannot be decrypted, for
+// example because it was tampered with or sealed for a different key.
+var ErrOpen = errors.New("cannot open sealed env")
+
+// Seal encrypts the Env for the holder of the X25519 private key matching
+// pub, producing an authenticated blob that can be handed to another
+// process through a pipe, file, or queue and read back with Open. Each call
+// uses a fresh ephemeral key, so sealing the same Env twice gives different
+// output. The blob is the version byte, the 32-byte ephemeral public key,
+// and the AES-256-GCM nonce and ciphertext, keyed by HKDF-SHA256 over the
+// X25519 shared secret. Metadata is not included.
+func (e *Env) Seal(pub *ecdh.PublicKey) ([]byte, error) {
+	if e.IsNil() {
+		return nil, fmt.Errorf("nil env")
+	}
+
+	if pub == nil || pub.Curve() != ecdh.X25519() {
+		return nil, fmt.Errorf("seal requires an X25519 public key")
+	}
+
+	em, err := e.exportMap()
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := json.Marshal(em)
+	if err != nil {
+		return nil, err
+	}
+
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := sealAEAD(eph, pub, eph.PublicKey(), pub)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append([]byte{sealVersion}, eph.PublicKey().Bytes()...)
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := append(append([]byte{}, header...), nonce...)
+	return aead.Seal(out, nonce, plain, header), nil
+}
+
+// Open decrypts an Env produced by Seal with the X25519 private key whose
+// public key it was sealed for. It returns an error wrapping ErrOpen if the
+// blob is malformed, was modified, or was sealed for another key.
+func Open(sealed []byte, priv *ecdh.PrivateKey) (*Env, error) {
+	if priv == nil || priv.Curve() != ecdh.X25519() {
+		return nil, fmt.Errorf("open requires an X25519 private key")
+	}
+
+	const keySize = 32
+	if len(sealed) < 1+keySize || sealed[0] != sealVersion {
+		return nil, fmt.Errorf("%w: unknown format", ErrOpen)
+	}
+
+	header := sealed[:1+keySize]
+	eph, err := ecdh.X25519().NewPublicKey(header[1:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpen, err)
+	}
+
+	aead, err := sealAEAD(priv, eph, eph, priv.PublicKey())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpen, err)
+	}
+
+	rest := sealed[len(header):]
+	if len(rest) < aead.NonceSize() {
+		return nil, fmt.Errorf("%w: truncated", ErrOpen)
+	}
+
+	plain, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpen, err)
+	}
+
+	em := map[string]string{}
+	if err := json.Unmarshal(plain, &em); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpen, err)
+	}
+	return FromMap(em), nil
+}
+
+// sealAEAD derives the cipher shared by priv and pub, binding the key to
+// both the ephemeral and the recipient public keys.
+func sealAEAD(priv *ecdh.PrivateKey, pub, ephemeral, recipient *ecdh.PublicKey) (cipher.AEAD, error) {
+	secret, err := priv.ECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := append(ephemeral.Bytes(), recipient.Bytes()...)
+	key, err := hkdf.Key(sha256.New, secret, salt, sealInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
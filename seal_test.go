@@ -0,0 +1,84 @@
+package envy
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_Seal(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	r.NoError(err)
+
+	other, err := ecdh.X25519().GenerateKey(rand.Reader)
+	r.NoError(err)
+
+	env := FromMap(map[string]string{"TOKEN": "s3cr3t", "MULTI": "a\nb", "EMPTY": ""})
+
+	sealed, err := env.Seal(priv.PublicKey())
+	r.NoError(err)
+	r.NotContains(string(sealed), "s3cr3t")
+
+	again, err := env.Seal(priv.PublicKey())
+	r.NoError(err)
+	r.NotEqual(sealed, again)
+
+	opened, err := Open(sealed, priv)
+	r.NoError(err)
+	r.Equal(env.Environ(), opened.Environ())
+
+	tampered := append([]byte{}, sealed...)
+	tampered[len(tampered)-1] ^= 1
+
+	tcs := []struct {
+		name   string
+		sealed []byte
+		priv   *ecdh.PrivateKey
+	}{
+		{name: "wrong key", sealed: sealed, priv: other},
+		{name: "tampered", sealed: tampered, priv: priv},
+		{name: "truncated", sealed: sealed[:40], priv: priv},
+		{name: "empty", priv: priv},
+		{name: "unknown version", sealed: append([]byte{9}, sealed[1:]...), priv: priv},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			_, err := Open(tc.sealed, tc.priv)
+			r.ErrorIs(err, ErrOpen)
+		})
+	}
+}
+
+func Test_Env_Seal_Errors(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	p256, err := ecdh.P256().GenerateKey(rand.Reader)
+	r.NoError(err)
+
+	x, err := ecdh.X25519().GenerateKey(rand.Reader)
+	r.NoError(err)
+
+	var nilEnv *Env
+	_, err = nilEnv.Seal(x.PublicKey())
+	r.Error(err)
+
+	_, err = Zero().Seal(nil)
+	r.Error(err)
+
+	_, err = Zero().Seal(p256.PublicKey())
+	r.Error(err)
+
+	_, err = Open([]byte{1}, p256)
+	r.Error(err)
+	r.NotErrorIs(err, ErrOpen)
+}
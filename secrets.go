@@ -0,0 +1,140 @@
+package envy
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+)
+
+// SecretFinding describes a value that looks like a credential.
+type SecretFinding struct {
+	// Key is the variable holding the value.
+	Key string
+	// Reason explains why the value was flagged.
+	Reason string
+}
+
+func (f SecretFinding) String() string {
+	return f.Key + ": " + f.Reason
+}
+
+// secretPrefixes are well-known prefixes of issued credentials.
+var secretPrefixes = []struct {
+	prefix string
+	name   string
+}{
+	{"AKIA", "AWS access key"},
+	{"ASIA", "AWS temporary access key"},
+	{"ghp_", "GitHub personal access token"},
+	{"gho_", "GitHub OAuth token"},
+	{"ghu_", "GitHub user token"},
+	{"ghs_", "GitHub server token"},
+	{"ghr_", "GitHub refresh token"},
+	{"github_pat_", "GitHub fine-grained token"},
+	{"glpat-", "GitLab personal access token"},
+	{"xoxb-", "Slack bot token"},
+	{"xoxp-", "Slack user token"},
+	{"xoxa-", "Slack app token"},
+	{"xoxs-", "Slack session token"},
+	{"sk_live_", "Stripe secret key"},
+	{"rk_live_", "Stripe restricted key"},
+	{"AIza", "Google API key"},
+	{"-----BEGIN", "PEM private key"},
+}
+
+const (
+	// minSecretLen is the shortest value considered for entropy checks.
+	minSecretLen = 20
+	// secretEntropy is the bits-per-character threshold for general values.
+	secretEntropy = 4.0
+	// hexEntropy is the threshold for values made only of hex digits, whose
+	// alphabet caps entropy at 4 bits per character.
+	hexEntropy = 3.0
+)
+
+// ScanSecrets reports values in env that look like credentials: values
+// starting with a known token prefix (AKIA, ghp_, xoxb-, ...), URLs with an
+// embedded password, and long values with high Shannon entropy. Values
+// encrypted with EncryptValue are not secrets in the clear, so they are
+// never reported. It is meant for pre-commit and CI checks of files that
+// should not contain secrets, so it errs on the side of flagging. Findings
+// are sorted by key; a nil Env yields none.
+func ScanSecrets(env *Env) []SecretFinding {
+	var findings []SecretFinding
+	for _, ent := range env.entries() {
+		if reason := secretReason(ent.value); reason != "" {
+			findings = append(findings, SecretFinding{
+				Key:    ent.key,
+				Reason: reason,
+			})
+		}
+	}
+	return findings
+}
+
+// secretReason returns why v looks like a secret, or "" if it does not.
+func secretReason(v string) string {
+	v = strings.TrimSpace(v)
+	// encrypted values are what a file should hold instead of secrets
+	if v == "" || strings.HasPrefix(v, EncryptedPrefix) {
+		return ""
+	}
+
+	for _, p := range secretPrefixes {
+		if strings.HasPrefix(v, p.prefix) && len(v) > len(p.prefix)+8 {
+			return p.name
+		}
+	}
+
+	if u, err := url.Parse(v); err == nil && u.Scheme != "" && u.Host != "" {
+		if p, ok := u.User.Password(); ok && p != "" {
+			return "password in URL"
+		}
+		return ""
+	}
+
+	// sentences and paths are long but not secret
+	if len(v) < minSecretLen || strings.ContainsAny(v, " \t") || strings.HasPrefix(v, "/") || strings.HasPrefix(v, "./") {
+		return ""
+	}
+
+	threshold := secretEntropy
+	if isHex(v) {
+		threshold = hexEntropy
+	}
+
+	if h := entropy(v); h >= threshold {
+		return fmt.Sprintf("high entropy (%.1f bits/char)", h)
+	}
+	return ""
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// isHex reports whether s consists only of hex digits.
+func isHex(s string) bool {
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9', r >= 'a' && r <= 'f', r >= 'A' && r <= 'F':
+		default:
+			return false
+		}
+	}
+	return true
+}
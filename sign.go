@@ -0,0 +1,116 @@
+package envy
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// signContext separates bundle signatures from other uses of the key.
+const signContext = "envy bundle v1\x00"
+
+// ErrSignature is returned when a bundle's signature does not verify.
+var ErrSignature = errors.New("invalid env bundle signature")
+
+// bundle is the serialized form of a signed Env.
+type bundle struct {
+	Vars      map[string]string `json:"vars"`
+	Signature []byte            `json:"signature"`
+}
+
+// Sign serializes the Env as a JSON bundle signed with the ed25519 key priv,
+// so that deployment systems can prove a service boots with the
+// configuration their pipeline produced. The signature covers every key and
+// value; read the bundle back with Verify. Metadata is not included.
+func (e *Env) Sign(priv ed25519.PrivateKey) ([]byte, error) {
+	if e.IsNil() {
+		return nil, fmt.Errorf("nil env")
+	}
+
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid ed25519 private key")
+	}
+
+	ents, err := e.exportEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	b := bundle{
+		Vars:      make(map[string]string, len(ents)),
+		Signature: ed25519.Sign(priv, signedMessage(ents)),
+	}
+	for _, ent := range ents {
+		b.Vars[ent.key] = ent.value
+	}
+
+	return json.MarshalIndent(b, "", "  ")
+}
+
+// Verify parses a bundle produced by Sign and returns its Env if the
+// signature verifies against pub. It returns an error wrapping ErrSignature
+// if the bundle was signed by another key or altered after signing.
+func Verify(signed []byte, pub ed25519.PublicKey) (*Env, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid ed25519 public key")
+	}
+
+	var b bundle
+	dec := json.NewDecoder(bytes.NewReader(signed))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&b); err != nil {
+		return nil, fmt.Errorf("parsing env bundle: %w", err)
+	}
+
+	n := len(b.Vars)
+	env := FromMap(b.Vars)
+	if env.Len() != n {
+		// invalid keys would be dropped silently rather than verified
+		return nil, ErrSignature
+	}
+
+	if !ed25519.Verify(pub, signedMessage(env.entries()), b.Signature) {
+		return nil, ErrSignature
+	}
+	return env, nil
+}
+
+// SignedFileSource returns a Source that reads the bundle at path from cab
+// and verifies it with pub, so Load fails rather than booting with
+// configuration that was not signed.
+func SignedFileSource(cab fs.FS, path string, pub ed25519.PublicKey) Source {
+	return func() (*Env, error) {
+		if cab == nil {
+			return nil, fmt.Errorf("nil fs.FS")
+		}
+
+		b, err := fs.ReadFile(cab, path)
+		if err != nil {
+			return nil, err
+		}
+
+		env, err := Verify(b, pub)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return env, nil
+	}
+}
+
+// signedMessage returns the bytes a bundle signature covers. Keys and
+// values are length-prefixed, since a bundle, unlike a process environment,
+// may carry NUL bytes that would otherwise let entries run together.
+func signedMessage(ents []entry) []byte {
+	b := []byte(signContext)
+	for _, ent := range ents {
+		b = binary.BigEndian.AppendUint64(b, uint64(len(ent.key)))
+		b = append(b, ent.key...)
+		b = binary.BigEndian.AppendUint64(b, uint64(len(ent.value)))
+		b = append(b, ent.value...)
+	}
+	return b
+}
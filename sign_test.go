@@ -0,0 +1,113 @@
+package envy
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_Sign(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	r.NoError(err)
+
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	r.NoError(err)
+
+	env := FromMap(map[string]string{"PORT": "8080", "MULTI": "a\nb"})
+
+	signed, err := env.Sign(priv)
+	r.NoError(err)
+
+	got, err := Verify(signed, pub)
+	r.NoError(err)
+	r.Equal(env.Environ(), got.Environ())
+
+	alter := func(fn func(b *bundle)) []byte {
+		var b bundle
+		r.NoError(json.Unmarshal(signed, &b))
+		fn(&b)
+
+		out, err := json.Marshal(b)
+		r.NoError(err)
+		return out
+	}
+
+	tcs := []struct {
+		name   string
+		signed []byte
+		pub    ed25519.PublicKey
+		sigErr bool
+	}{
+		{name: "wrong key", signed: signed, pub: otherPub, sigErr: true},
+		{name: "changed value", signed: alter(func(b *bundle) { b.Vars["PORT"] = "9090" }), pub: pub, sigErr: true},
+		{name: "added key", signed: alter(func(b *bundle) { b.Vars["DEBUG"] = "true" }), pub: pub, sigErr: true},
+		{name: "invalid key", signed: alter(func(b *bundle) { b.Vars[""] = "x" }), pub: pub, sigErr: true},
+		{name: "missing signature", signed: alter(func(b *bundle) { b.Signature = nil }), pub: pub, sigErr: true},
+		{name: "unknown field", signed: []byte(`{"vars":{},"signature":"","extra":1}`), pub: pub},
+		{name: "not json", signed: []byte("PORT=8080"), pub: pub},
+		{name: "bad public key", signed: signed, pub: pub[:5]},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			_, err := Verify(tc.signed, tc.pub)
+			r.Error(err)
+			r.Equal(tc.sigErr, errors.Is(err, ErrSignature))
+		})
+	}
+}
+
+func Test_Env_Sign_Errors(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	r.NoError(err)
+
+	var nilEnv *Env
+	_, err = nilEnv.Sign(priv)
+	r.Error(err)
+
+	_, err = Zero().Sign(priv[:10])
+	r.Error(err)
+}
+
+func Test_SignedFileSource(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	r.NoError(err)
+
+	signed, err := FromMap(map[string]string{"A": "1"}).Sign(priv)
+	r.NoError(err)
+
+	cab := fstest.MapFS{
+		"env.json": &fstest.MapFile{Data: signed},
+		"bad.json": &fstest.MapFile{Data: []byte(`{"vars":{"A":"2"},"signature":"AAAA"}`)},
+	}
+
+	env, err := SignedFileSource(cab, "env.json", pub)()
+	r.NoError(err)
+	r.Equal([]string{"A=1"}, env.Environ())
+
+	_, err = SignedFileSource(cab, "bad.json", pub)()
+	r.ErrorIs(err, ErrSignature)
+
+	_, err = SignedFileSource(cab, "missing.json", pub)()
+	r.Error(err)
+
+	_, err = SignedFileSource(nil, "env.json", pub)()
+	r.Error(err)
+}
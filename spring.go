@@ -0,0 +1,151 @@
+package envy
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+// Spring Boot properties that choose which profiles apply.
+const (
+	// SpringProfilesActive lists the profiles FromSpring stacks when it is
+	// given none.
+	SpringProfilesActive = "spring.profiles.active"
+	// SpringActivateOnProfile limits a document of a multi-document
+	// properties file to the profiles it lists.
+	SpringActivateOnProfile = "spring.config.activate.on-profile"
+)
+
+// FromSpring loads a Spring Boot configuration from cab the way Spring
+// does, so JVM services can move to Go without changing their config
+// artifacts. It reads application.properties, then
+// application-{profile}.properties for each profile in order, each one
+// overriding the ones before. Without profiles, those listed in
+// spring.profiles.active in application.properties are used.
+//
+// A file can hold several documents separated by "#---" lines; a document
+// setting spring.config.activate.on-profile applies only if one of the
+// profiles it lists is active. Missing files are skipped, but at least one
+// must exist. Keys are kept as written, such as "server.port", so Nest(".")
+// recovers the hierarchy. To read from a jar, pass a *zip.Reader narrowed
+// to BOOT-INF/classes with fs.Sub.
+func FromSpring(cab fs.FS, profiles ...string) (*Env, error) {
+	if cab == nil {
+		return nil, fmt.Errorf("nil fs.FS")
+	}
+
+	for _, p := range profiles {
+		if !validProfile(p) {
+			return nil, fmt.Errorf("invalid profile %q", p)
+		}
+	}
+
+	base, found, err := springFile(cab, "application.properties", profiles)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(profiles) == 0 {
+		profiles = splitProfiles(base.Getenv(SpringProfilesActive))
+		for _, p := range profiles {
+			if !validProfile(p) {
+				return nil, fmt.Errorf("application.properties: invalid profile %q in %s", p, SpringProfilesActive)
+			}
+		}
+
+		// documents activated by the profiles just read apply as well
+		if base, _, err = springFile(cab, "application.properties", profiles); err != nil {
+			return nil, err
+		}
+	}
+
+	env := base
+	for _, p := range profiles {
+		layer, ok, err := springFile(cab, "application-"+p+".properties", profiles)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		found = true
+		if env, err = env.Merge(layer); err != nil {
+			return nil, err
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no application properties: %w", fs.ErrNotExist)
+	}
+	return env, nil
+}
+
+// springFile reads the documents of the properties file at path that apply
+// to the active profiles, and reports whether the file exists.
+func springFile(cab fs.FS, path string, active []string) (*Env, bool, error) {
+	f, err := cab.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Zero(), false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	props, err := parseProperties(f)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// documents limited to profiles that are not active are skipped
+	skip := map[int]bool{}
+	for _, p := range props {
+		if p.key != SpringActivateOnProfile {
+			continue
+		}
+
+		skip[p.doc] = !slices.ContainsFunc(splitProfiles(p.value), func(want string) bool {
+			return slices.Contains(active, want)
+		})
+	}
+
+	em := map[string]string{}
+	meta := map[string]Meta{}
+	for _, p := range props {
+		if skip[p.doc] || p.key == SpringActivateOnProfile || !validKey(p.key) {
+			continue
+		}
+
+		em[p.key] = p.value
+		meta[p.key] = Meta{
+			Loader:      "file",
+			File:        path,
+			Line:        p.line,
+			Description: p.comment,
+		}
+	}
+
+	e := FromMap(em)
+	e.meta = meta
+	return e, true, nil
+}
+
+// splitProfiles splits a comma-separated list of profiles.
+func splitProfiles(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// validProfile reports whether p can name a profile file.
+func validProfile(p string) bool {
+	return p != "" && !strings.ContainsAny(p, `/\`) && p != "." && p != ".."
+}
@@ -0,0 +1,139 @@
+package envy
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromSpring(t *testing.T) {
+	t.Parallel()
+
+	cab := fstest.MapFS{
+		"application.properties": {Data: []byte(`# the port
+server.port=8080
+spring.datasource.url=jdbc:postgresql://localhost/app
+spring.profiles.active=dev
+#---
+spring.config.activate.on-profile=prod,staging
+server.port=443
+`)},
+		"application-dev.properties":  {Data: []byte("logging.level.root=DEBUG\n")},
+		"application-prod.properties": {Data: []byte("spring.datasource.url=jdbc:postgresql://db/app\nlogging.level.root=WARN\n")},
+		"application-bad.properties":  {Data: []byte("a=\\uzz\n")},
+	}
+
+	tcs := []struct {
+		name     string
+		profiles []string
+		exp      map[string]string
+		err      string
+	}{
+		{
+			name: "active from file",
+			exp: map[string]string{
+				"server.port":            "8080",
+				"spring.datasource.url":  "jdbc:postgresql://localhost/app",
+				"spring.profiles.active": "dev",
+				"logging.level.root":     "DEBUG",
+			},
+		},
+		{
+			name:     "stacked profiles",
+			profiles: []string{"dev", "prod"},
+			exp: map[string]string{
+				"server.port":            "443",
+				"spring.datasource.url":  "jdbc:postgresql://db/app",
+				"spring.profiles.active": "dev",
+				"logging.level.root":     "WARN",
+			},
+		},
+		{
+			name:     "order matters",
+			profiles: []string{"prod", "dev"},
+			exp: map[string]string{
+				"server.port":            "443",
+				"spring.datasource.url":  "jdbc:postgresql://db/app",
+				"spring.profiles.active": "dev",
+				"logging.level.root":     "DEBUG",
+			},
+		},
+		{
+			name:     "activated document only",
+			profiles: []string{"staging"},
+			exp: map[string]string{
+				"server.port":            "443",
+				"spring.datasource.url":  "jdbc:postgresql://localhost/app",
+				"spring.profiles.active": "dev",
+			},
+		},
+		{name: "invalid profile", profiles: []string{"../etc"}, err: "invalid profile"},
+		{name: "malformed", profiles: []string{"bad"}, err: "application-bad.properties: line 1"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			env, err := FromSpring(cab, tc.profiles...)
+			if tc.err != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(len(tc.exp), env.Len())
+			for k, v := range tc.exp {
+				r.Equal(v, env.Getenv(k), k)
+			}
+		})
+	}
+
+	r := require.New(t)
+
+	env, err := FromSpring(cab, "prod")
+	r.NoError(err)
+	r.Equal("application-prod.properties line 1", env.Source("spring.datasource.url"))
+	r.Equal("application.properties line 7", env.Source("server.port"))
+
+	env, err = FromSpring(cab)
+	r.NoError(err)
+	m, ok := env.Meta("server.port")
+	r.True(ok)
+	r.Equal("the port", m.Description)
+
+	_, err = FromSpring(fstest.MapFS{})
+	r.ErrorIs(err, fs.ErrNotExist)
+
+	_, err = FromSpring(nil)
+	r.Error(err)
+}
+
+func Test_FromSpring_jar(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	bb := &bytes.Buffer{}
+	zw := zip.NewWriter(bb)
+	w, err := zw.Create("BOOT-INF/classes/application.properties")
+	r.NoError(err)
+	_, err = w.Write([]byte("server.port=9090\n"))
+	r.NoError(err)
+	r.NoError(zw.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(bb.Bytes()), int64(bb.Len()))
+	r.NoError(err)
+
+	classes, err := fs.Sub(zr, "BOOT-INF/classes")
+	r.NoError(err)
+
+	env, err := FromSpring(classes)
+	r.NoError(err)
+	r.Equal("9090", env.Getenv("server.port"))
+}
@@ -0,0 +1,127 @@
+package envy
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrStale is returned by a StaleGuard's reads when its Env has not been
+// refreshed successfully within the allowed window.
+var ErrStale = errors.New("env is stale")
+
+// StaleGuard protects an Env that is refreshed in the background, for
+// example by a provider's Watch or Sync loop, from silently serving stale
+// values, such as secrets that have since been rotated. The refresh loop
+// reports each attempt with Observe; reads through the guard fail with
+// ErrStale once no attempt has succeeded for longer than the maximum
+// staleness, or call a callback instead if one is given.
+type StaleGuard struct {
+	env     *Env
+	max     time.Duration
+	onStale func(age time.Duration)
+	now     func() time.Time
+
+	mu sync.Mutex
+	// last is the time of the last successful refresh.
+	last time.Time
+	// warned is set once onStale has been called for the current lapse.
+	warned bool
+}
+
+// NewStaleGuard returns a guard for env that allows at most max between
+// successful refreshes, counting from now. If onStale is not nil, reads do
+// not fail; instead onStale is called with the data's age the first time a
+// read finds it stale, and again after each later refresh lapses.
+func NewStaleGuard(env *Env, max time.Duration, onStale func(age time.Duration)) (*StaleGuard, error) {
+	if env == nil {
+		return nil, fmt.Errorf("nil env")
+	}
+
+	if max <= 0 {
+		return nil, fmt.Errorf("max staleness must be positive, got %s", max)
+	}
+
+	return &StaleGuard{
+		env:     env,
+		max:     max,
+		onStale: onStale,
+		now:     time.Now,
+		last:    time.Now(),
+	}, nil
+}
+
+// Observe records the outcome of a refresh attempt. A nil err marks the
+// data as fresh; failures leave the age unchanged.
+func (g *StaleGuard) Observe(err error) {
+	if err != nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.last = g.now()
+	g.warned = false
+}
+
+// Refresh calls fn, such as a provider's Sync, records its outcome with
+// Observe, and returns its error.
+func (g *StaleGuard) Refresh(fn func() error) error {
+	err := fn()
+	g.Observe(err)
+	return err
+}
+
+// Age returns the time since the last successful refresh.
+func (g *StaleGuard) Age() time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.now().Sub(g.last)
+}
+
+// Check returns an error wrapping ErrStale if the data is older than the
+// maximum staleness and no onStale callback was given. With a callback, it
+// calls it instead and returns nil.
+func (g *StaleGuard) Check() error {
+	g.mu.Lock()
+	age := g.now().Sub(g.last)
+	if age <= g.max {
+		g.mu.Unlock()
+		return nil
+	}
+
+	if g.onStale == nil {
+		g.mu.Unlock()
+		return fmt.Errorf("%w: last refreshed %s ago, allowed %s", ErrStale, age.Round(time.Millisecond), g.max)
+	}
+
+	warn := !g.warned
+	g.warned = true
+	g.mu.Unlock()
+
+	if warn {
+		g.onStale(age)
+	}
+	return nil
+}
+
+// Lookup returns the value of key after checking that the data is fresh.
+func (g *StaleGuard) Lookup(key string) (string, bool, error) {
+	if err := g.Check(); err != nil {
+		return "", false, err
+	}
+
+	val, ok := g.env.Lookupenv(key)
+	return val, ok, nil
+}
+
+// Env returns the guarded Env after checking that the data is fresh.
+func (g *StaleGuard) Env() (*Env, error) {
+	if err := g.Check(); err != nil {
+		return nil, err
+	}
+	return g.env, nil
+}
@@ -0,0 +1,114 @@
+package envy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// clock is a manually advanced time source.
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func newTestGuard(t *testing.T, onStale func(time.Duration)) (*StaleGuard, *clock) {
+	t.Helper()
+
+	g, err := NewStaleGuard(FromMap(map[string]string{"TOKEN": "t"}), time.Minute, onStale)
+	require.NoError(t, err)
+
+	c := &clock{t: time.Unix(1000, 0)}
+	g.now = c.now
+	g.last = c.t
+	return g, c
+}
+
+func Test_StaleGuard_Fail(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	g, c := newTestGuard(t, nil)
+
+	v, ok, err := g.Lookup("TOKEN")
+	r.NoError(err)
+	r.True(ok)
+	r.Equal("t", v)
+
+	_, ok, err = g.Lookup("MISSING")
+	r.NoError(err)
+	r.False(ok)
+
+	c.t = c.t.Add(2 * time.Minute)
+	r.Equal(2*time.Minute, g.Age())
+
+	// a failed refresh does not help
+	r.Error(g.Refresh(func() error { return fmt.Errorf("boom") }))
+
+	_, _, err = g.Lookup("TOKEN")
+	r.ErrorIs(err, ErrStale)
+	r.EqualError(err, "env is stale: last refreshed 2m0s ago, allowed 1m0s")
+
+	_, err = g.Env()
+	r.ErrorIs(err, ErrStale)
+
+	r.NoError(g.Refresh(func() error { return nil }))
+	r.Zero(g.Age())
+
+	env, err := g.Env()
+	r.NoError(err)
+	r.Equal("t", env.Getenv("TOKEN"))
+}
+
+func Test_StaleGuard_Callback(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	var ages []time.Duration
+	g, c := newTestGuard(t, func(age time.Duration) {
+		ages = append(ages, age)
+	})
+
+	r.NoError(g.Check())
+	r.Empty(ages)
+
+	c.t = c.t.Add(90 * time.Second)
+	r.NoError(g.Check())
+	r.NoError(g.Check())
+	r.Equal([]time.Duration{90 * time.Second}, ages)
+
+	g.Observe(nil)
+	c.t = c.t.Add(2 * time.Minute)
+	_, _, err := g.Lookup("TOKEN")
+	r.NoError(err)
+	r.Equal([]time.Duration{90 * time.Second, 2 * time.Minute}, ages)
+}
+
+func Test_NewStaleGuard(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  *Env
+		max  time.Duration
+	}{
+		{name: "nil env", max: time.Second},
+		{name: "zero max", env: Zero()},
+		{name: "negative max", env: Zero(), max: -time.Second},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			_, err := NewStaleGuard(tc.env, tc.max, nil)
+			r.Error(err)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package envy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxArgStrlen is the largest single "KEY=VALUE" string, including its
+// terminating NUL, that Linux lets execve pass to a new process
+// (MAX_ARG_STRLEN); a longer one makes exec fail with E2BIG.
+const MaxArgStrlen = 128 << 10
+
+// statsLargest is how many entries Stats lists in Largest.
+const statsLargest = 10
+
+// statsBuckets are the upper bounds of the value size buckets of Stats.
+var statsBuckets = []int{0, 64, 256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, MaxArgStrlen}
+
+// Stats describes the size of an Env, to find what makes an environment
+// too big for exec before it fails, or for the kernel limits on a whole
+// environment, commonly a quarter of the stack limit on Linux and 32767
+// characters on Windows.
+type Stats struct {
+	// Entries counts the variables.
+	Entries int `json:"entries"`
+	// KeyBytes and ValueBytes total the lengths of the keys and values.
+	KeyBytes   int `json:"key_bytes"`
+	ValueBytes int `json:"value_bytes"`
+	// Bytes is the size of the environment block passed to exec, a
+	// "KEY=VALUE" string and a NUL per variable.
+	Bytes int `json:"bytes"`
+	// Largest lists the biggest variables, largest first, at most ten.
+	Largest []EntrySize `json:"largest"`
+	// Histogram counts the values by size.
+	Histogram []SizeBucket `json:"histogram"`
+	// Oversized lists the keys whose entry is longer than MaxArgStrlen,
+	// which Linux refuses to exec with.
+	Oversized []string `json:"oversized,omitempty"`
+}
+
+// EntrySize is the size of one variable in Stats.
+type EntrySize struct {
+	Key string `json:"key"`
+	// Bytes is the size of its "KEY=VALUE" string and NUL.
+	Bytes int `json:"bytes"`
+}
+
+// SizeBucket counts the values of Stats whose length is more than the
+// previous bucket's Max and at most Max. The last bucket has no Max, -1,
+// and counts the values longer than MaxArgStrlen.
+type SizeBucket struct {
+	Max   int `json:"max"`
+	Count int `json:"count"`
+}
+
+func (b SizeBucket) String() string {
+	if b.Max < 0 {
+		return fmt.Sprintf("> %d: %d", MaxArgStrlen, b.Count)
+	}
+	return fmt.Sprintf("<= %d: %d", b.Max, b.Count)
+}
+
+func (s Stats) String() string {
+	var bb strings.Builder
+	fmt.Fprintf(&bb, "%d entries, %d bytes (%d in keys, %d in values)", s.Entries, s.Bytes, s.KeyBytes, s.ValueBytes)
+
+	if len(s.Largest) > 0 {
+		bb.WriteString("; largest:")
+		for i, l := range s.Largest {
+			if i > 0 {
+				bb.WriteByte(',')
+			}
+			fmt.Fprintf(&bb, " %s (%d)", l.Key, l.Bytes)
+		}
+	}
+
+	if len(s.Oversized) > 0 {
+		fmt.Fprintf(&bb, "; over %d bytes: %s", MaxArgStrlen, strings.Join(s.Oversized, ", "))
+	}
+	return bb.String()
+}
+
+// Stats returns the size of the Env: how many variables it holds, how
+// many bytes they take, which are largest, and how their values are
+// distributed by size. A nil Env has no entries.
+func (e *Env) Stats() Stats {
+	s := Stats{
+		Largest:   []EntrySize{},
+		Histogram: make([]SizeBucket, len(statsBuckets)+1),
+	}
+
+	for i, max := range statsBuckets {
+		s.Histogram[i].Max = max
+	}
+	s.Histogram[len(statsBuckets)].Max = -1
+
+	sizes := []EntrySize{}
+	for _, ent := range e.entries() {
+		size := len(ent.key) + len(ent.value) + 2
+
+		s.Entries++
+		s.KeyBytes += len(ent.key)
+		s.ValueBytes += len(ent.value)
+		s.Bytes += size
+		sizes = append(sizes, EntrySize{Key: ent.key, Bytes: size})
+
+		if size > MaxArgStrlen {
+			s.Oversized = append(s.Oversized, ent.key)
+		}
+
+		i := sort.SearchInts(statsBuckets, len(ent.value))
+		s.Histogram[i].Count++
+	}
+
+	// entries come sorted by key, so equal sizes stay in key order
+	sort.SliceStable(sizes, func(i, j int) bool {
+		return sizes[i].Bytes > sizes[j].Bytes
+	})
+
+	if len(sizes) > statsLargest {
+		sizes = sizes[:statsLargest]
+	}
+	s.Largest = append(s.Largest, sizes...)
+	return s
+}
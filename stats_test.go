@@ -0,0 +1,98 @@
+package envy
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_Stats(t *testing.T) {
+	t.Parallel()
+
+	big := strings.Repeat("x", MaxArgStrlen)
+
+	many := map[string]string{}
+	for i := range 12 {
+		many[fmt.Sprintf("K%02d", i)] = strings.Repeat("v", i)
+	}
+
+	tcs := []struct {
+		name      string
+		env       *Env
+		entries   int
+		bytes     int
+		largest   []EntrySize
+		counts    []int
+		oversized []string
+	}{
+		{
+			name:    "nil env",
+			env:     nil,
+			largest: []EntrySize{},
+			counts:  []int{0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:    "sizes",
+			env:     FromMap(map[string]string{"A": "", "BB": "12345", "C": strings.Repeat("y", 300)}),
+			entries: 3,
+			bytes:   3 + 9 + 303,
+			largest: []EntrySize{{Key: "C", Bytes: 303}, {Key: "BB", Bytes: 9}, {Key: "A", Bytes: 3}},
+			counts:  []int{1, 1, 0, 1, 0, 0, 0, 0, 0},
+		},
+		{
+			name:      "oversized",
+			env:       FromMap(map[string]string{"BIG": big, "SMALL": "1"}),
+			entries:   2,
+			bytes:     len(big) + 5 + 8,
+			largest:   []EntrySize{{Key: "BIG", Bytes: len(big) + 5}, {Key: "SMALL", Bytes: 8}},
+			counts:    []int{0, 1, 0, 0, 0, 0, 0, 1, 0},
+			oversized: []string{"BIG"},
+		},
+		{
+			name:    "largest capped",
+			env:     FromMap(many),
+			entries: 12,
+			bytes:   12*5 + 66,
+			largest: []EntrySize{
+				{Key: "K11", Bytes: 16}, {Key: "K10", Bytes: 15}, {Key: "K09", Bytes: 14}, {Key: "K08", Bytes: 13},
+				{Key: "K07", Bytes: 12}, {Key: "K06", Bytes: 11}, {Key: "K05", Bytes: 10}, {Key: "K04", Bytes: 9},
+				{Key: "K03", Bytes: 8}, {Key: "K02", Bytes: 7},
+			},
+			counts: []int{1, 11, 0, 0, 0, 0, 0, 0, 0},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			s := tc.env.Stats()
+			r.Equal(tc.entries, s.Entries)
+			r.Equal(tc.bytes, s.Bytes)
+			r.Equal(s.Bytes, s.KeyBytes+s.ValueBytes+2*s.Entries)
+			r.Equal(tc.largest, s.Largest)
+			r.Equal(tc.oversized, s.Oversized)
+
+			counts := make([]int, len(s.Histogram))
+			for i, b := range s.Histogram {
+				counts[i] = b.Count
+			}
+			r.Equal(tc.counts, counts)
+			r.Equal(-1, s.Histogram[len(s.Histogram)-1].Max)
+		})
+	}
+}
+
+func Test_Stats_String(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	s := FromMap(map[string]string{"A": "1", "BB": "22"}).Stats()
+	r.Equal("2 entries, 10 bytes (3 in keys, 3 in values); largest: BB (6), A (4)", s.String())
+
+	r.Equal("<= 64: 2", s.Histogram[1].String())
+	r.Equal("> 131072: 0", s.Histogram[len(s.Histogram)-1].String())
+}
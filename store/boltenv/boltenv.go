@@ -0,0 +1,135 @@
+// Package boltenv persists an envy.Env in a local bbolt database so that
+// changes made at runtime survive restarts.
+package boltenv
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/markbates/envy"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bucket is the bbolt bucket holding the variables.
+var Bucket = []byte("envy")
+
+// Store is an Env backed by a bbolt file. Every change made to the Env, by
+// any means, is written through to the file before the mutating call returns.
+type Store struct {
+	db     *bolt.DB
+	env    *envy.Env
+	cancel func()
+
+	mu sync.Mutex
+	// saved holds the contents of the database.
+	saved *envy.Env
+	err   error
+}
+
+// Open opens (creating if necessary) the database at path and loads its
+// contents into a new Env.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	em := map[string]string{}
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(Bucket)
+		if err != nil {
+			return err
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			em[string(k)] = string(v)
+			return nil
+		})
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	s := &Store{
+		db:    db,
+		env:   envy.FromMap(em),
+		saved: envy.FromMap(maps.Clone(em)),
+	}
+
+	s.cancel, err = s.env.Subscribe(s.persist)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Env returns the persistent Env.
+func (s *Store) Env() *envy.Env {
+	return s.env
+}
+
+// Err returns the first error encountered while persisting a change, if any.
+// Because changes are persisted from a subscription, the mutating call
+// itself cannot report the failure; callers that need to know should check
+// Err after writing.
+func (s *Store) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+// Close stops persisting changes and closes the database. The Env remains
+// usable in memory.
+func (s *Store) Close() error {
+	s.cancel()
+	return s.db.Close()
+}
+
+// persist writes the changes between the database and the Env as it is
+// now. The Diff it is notified with is not used: concurrent changes are
+// notified in no particular order, so writing each Diff as it arrives
+// could leave an older value in the database than in the Env.
+func (s *Store) persist(envy.Diff) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := envy.Compare(s.saved, s.env)
+	if len(d) == 0 {
+		return
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(Bucket)
+		if b == nil {
+			return fmt.Errorf("missing bucket %q", Bucket)
+		}
+
+		for _, c := range d {
+			var err error
+			if c.Kind == envy.Removed {
+				err = b.Delete([]byte(c.Key))
+			} else {
+				err = b.Put([]byte(c.Key), []byte(c.New))
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err == nil {
+		err = s.saved.Apply(d)
+	}
+
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+}
@@ -0,0 +1,78 @@
+package boltenv
+
+import (
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Store(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "env.db")
+
+	s, err := Open(path)
+	r.NoError(err)
+	r.Empty(s.Env().Environ())
+
+	env := s.Env()
+	r.NoError(env.Setenv("KEY1", "VALUE1"))
+	r.NoError(env.Setenv("KEY2", "VALUE2"))
+	r.NoError(env.Unsetenv("KEY2"))
+	r.NoError(env.ApplyJSONMergePatch([]byte(`{"KEY3": "VALUE3"}`)))
+	r.NoError(s.Err())
+	r.NoError(s.Close())
+
+	// changes after Close are not persisted
+	r.NoError(env.Setenv("KEY4", "VALUE4"))
+
+	s, err = Open(path)
+	r.NoError(err)
+	defer s.Close()
+
+	r.Equal([]string{"KEY1=VALUE1", "KEY3=VALUE3"}, s.Env().Environ())
+}
+
+func Test_Store_concurrent(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "env.db")
+
+	s, err := Open(path)
+	r.NoError(err)
+
+	env := s.Env()
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = env.Setenv("KEY", strconv.Itoa(i))
+		}()
+	}
+	wg.Wait()
+
+	r.NoError(s.Err())
+	want := env.Environ()
+	r.NoError(s.Close())
+
+	s, err = Open(path)
+	r.NoError(err)
+	defer s.Close()
+
+	r.Equal(want, s.Env().Environ())
+}
+
+func Test_Open_Error(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	_, err := Open(filepath.Join(t.TempDir(), "missing", "env.db"))
+	r.Error(err)
+}
@@ -0,0 +1,71 @@
+package envy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrOverwrite is returned by a Strict source when a later source would
+// overwrite a key set by an earlier one.
+var ErrOverwrite = errors.New("would overwrite existing key")
+
+// Strict returns a Source that layers sources in order, like Load, but
+// fails instead of letting a later source silently overwrite a key set by
+// an earlier one. The error wraps ErrOverwrite and names every conflicting
+// key along with where both values came from, when known, so precedence
+// has to be declared explicitly, for example by removing the key from one
+// of the sources. Values that are overwritten with an identical value still
+// conflict.
+func Strict(sources ...Source) Source {
+	return func() (*Env, error) {
+		env := Zero()
+		for _, src := range sources {
+			next, err := src()
+			if err != nil {
+				return nil, err
+			}
+
+			if err := conflicts(env, next); err != nil {
+				return nil, err
+			}
+
+			if env, err = env.Merge(next); err != nil {
+				return nil, err
+			}
+		}
+		return env, nil
+	}
+}
+
+// conflicts returns an error describing the keys of next already set in env.
+func conflicts(env, next *Env) error {
+	var dupes []string
+	for _, ent := range next.entries() {
+		if !env.IsSet(ent.key) {
+			continue
+		}
+
+		dupes = append(dupes, describeOverwrite(ent.key, env.Source(ent.key), next.Source(ent.key)))
+	}
+
+	if len(dupes) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrOverwrite, strings.Join(dupes, ", "))
+}
+
+// describeOverwrite names key and, when known, its old and new sources.
+func describeOverwrite(key, from, to string) string {
+	if from == "" {
+		from = "unknown"
+	}
+	if to == "" {
+		to = "unknown"
+	}
+
+	if from == "unknown" && to == "unknown" {
+		return key
+	}
+	return fmt.Sprintf("%s (%s, then %s)", key, from, to)
+}
@@ -0,0 +1,75 @@
+package envy
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Strict(t *testing.T) {
+	t.Parallel()
+
+	cab := fstest.MapFS{
+		".env":       &fstest.MapFile{Data: []byte("PORT=3000\nHOST=localhost\n")},
+		".env.local": &fstest.MapFile{Data: []byte("DEBUG=true\nPORT=8080\n")},
+		".env.extra": &fstest.MapFile{Data: []byte("EXTRA=1\n")},
+	}
+
+	mapSource := func(m map[string]string) Source {
+		return func() (*Env, error) {
+			return FromMap(m), nil
+		}
+	}
+
+	tcs := []struct {
+		name    string
+		sources []Source
+		exp     []string
+		err     string
+	}{
+		{
+			name: "no sources",
+			exp:  []string{},
+		},
+		{
+			name:    "disjoint",
+			sources: []Source{FileSource(cab, ".env"), FileSource(cab, ".env.extra")},
+			exp:     []string{"EXTRA=1", "HOST=localhost", "PORT=3000"},
+		},
+		{
+			name:    "overwrite with sources",
+			sources: []Source{FileSource(cab, ".env"), FileSource(cab, ".env.local")},
+			err:     "would overwrite existing key: PORT (.env line 1, then .env.local line 2)",
+		},
+		{
+			name:    "overwrite without sources",
+			sources: []Source{mapSource(map[string]string{"A": "1", "B": "1"}), mapSource(map[string]string{"B": "1", "A": "2"})},
+			err:     "would overwrite existing key: A, B",
+		},
+		{
+			name: "source error",
+			sources: []Source{func() (*Env, error) {
+				return nil, fmt.Errorf("boom")
+			}},
+			err: "boom",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			env, err := Strict(tc.sources...)()
+			if tc.err != "" {
+				r.EqualError(err, tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, env.Environ())
+		})
+	}
+}
@@ -0,0 +1,190 @@
+//go:build !envy_minimal
+
+package envy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Command describes the child process run by Supervise.
+type Command struct {
+	// Path is the program to run. It is resolved with exec.LookPath.
+	Path string
+	// Args are the arguments, not including the program name.
+	Args []string
+	// Dir is the working directory. It defaults to the current one.
+	Dir string
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Signal, if set, is sent to the child when the environment changes
+	// instead of restarting it, for programs that reload their own
+	// configuration, e.g. on SIGHUP.
+	Signal os.Signal
+
+	// Debounce is how long to wait for further changes before acting on
+	// one, so a burst of updates causes a single restart. Defaults to
+	// 100ms.
+	Debounce time.Duration
+
+	// StopTimeout is how long a child has to exit after being
+	// interrupted before it is killed. Defaults to 5s.
+	StopTimeout time.Duration
+}
+
+// Supervise runs cmd with env's variables as its entire environment and
+// restarts it, with the new variables, whenever env changes, in the style of
+// direnv or overmind. If cmd.Signal is set the child is signaled instead. A
+// child is stopped by interrupting it and, if it has not exited after
+// cmd.StopTimeout, killing it.
+//
+// Supervise returns when the child exits on its own, with the result of
+// exec.Cmd.Wait, or when ctx is done, after stopping the child, with
+// ctx.Err(). Each child gets the variables from Export, so if env's Policy
+// rejects the export the child is not started and Supervise returns the
+// error.
+func Supervise(ctx context.Context, env *Env, cmd Command) error {
+	if env.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	if cmd.Path == "" {
+		return fmt.Errorf("missing command path")
+	}
+
+	if cmd.Debounce <= 0 {
+		cmd.Debounce = 100 * time.Millisecond
+	}
+
+	if cmd.StopTimeout <= 0 {
+		cmd.StopTimeout = 5 * time.Second
+	}
+
+	changes := make(chan struct{}, 1)
+	stop, err := env.Subscribe(func(Diff) {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	c, done, err := cmd.start(env)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := cmd.stop(c, done); err != nil {
+				return errors.Join(ctx.Err(), err)
+			}
+			return ctx.Err()
+		case err := <-done:
+			return err
+		case <-changes:
+		}
+
+		// wait for the burst of changes to settle
+		settle := time.NewTimer(cmd.Debounce)
+	debounce:
+		for {
+			select {
+			case <-ctx.Done():
+				settle.Stop()
+				if err := cmd.stop(c, done); err != nil {
+					return errors.Join(ctx.Err(), err)
+				}
+				return ctx.Err()
+			case err := <-done:
+				settle.Stop()
+				return err
+			case <-changes:
+				settle.Reset(cmd.Debounce)
+			case <-settle.C:
+				break debounce
+			}
+		}
+
+		if cmd.Signal != nil {
+			if err := c.Process.Signal(cmd.Signal); err != nil {
+				return errors.Join(err, cmd.stop(c, done))
+			}
+			continue
+		}
+
+		if err := cmd.stop(c, done); err != nil {
+			return err
+		}
+
+		if c, done, err = cmd.start(env); err != nil {
+			return err
+		}
+	}
+}
+
+// start launches the child with the current contents of env. The returned
+// channel receives the result of Wait.
+func (cmd Command) start(env *Env) (*exec.Cmd, <-chan error, error) {
+	vars, err := env.Export()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c := exec.Command(cmd.Path, cmd.Args...)
+	c.Env = vars
+	c.Dir = cmd.Dir
+	c.Stdin = cmd.Stdin
+	c.Stdout = cmd.Stdout
+	c.Stderr = cmd.Stderr
+
+	if err := c.Start(); err != nil {
+		return nil, nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Wait()
+	}()
+	return c, done, nil
+}
+
+// stop interrupts the child, kills it if it does not exit within
+// StopTimeout, and waits for it.
+func (cmd Command) stop(c *exec.Cmd, done <-chan error) error {
+	// interrupting is not supported everywhere, e.g. on Windows
+	if err := c.Process.Signal(os.Interrupt); err != nil {
+		return kill(c, done)
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(cmd.StopTimeout):
+		return kill(c, done)
+	}
+}
+
+// kill kills the child and waits for it. A child that has already exited
+// is not an error; one that cannot be killed is, and is not waited for,
+// since it may never exit.
+func kill(c *exec.Cmd, done <-chan error) error {
+	if err := c.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("kill %s: %w", c.Path, err)
+	}
+
+	<-done
+	return nil
+}
@@ -0,0 +1,190 @@
+//go:build !envy_minimal
+
+package envy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu sync.Mutex
+	bb bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.bb.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.bb.String()
+}
+
+func skipWithoutSh(t *testing.T) {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh and unix signals")
+	}
+}
+
+func Test_Supervise_Restart(t *testing.T) {
+	t.Parallel()
+	skipWithoutSh(t)
+	r := require.New(t)
+
+	env := FromMap(map[string]string{"GREETING": "hello"})
+	out := &syncBuffer{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Supervise(ctx, env, Command{
+			Path:     "sh",
+			Args:     []string{"-c", `echo "$GREETING"; exec sleep 10`},
+			Stdout:   out,
+			Debounce: 10 * time.Millisecond,
+		})
+	}()
+
+	r.Eventually(func() bool { return out.String() == "hello\n" }, 5*time.Second, 10*time.Millisecond)
+
+	r.NoError(env.Setenv("GREETING", "hi"))
+	r.NoError(env.Setenv("GREETING", "howdy"))
+
+	r.Eventually(func() bool { return strings.HasSuffix(out.String(), "\nhowdy\n") }, 5*time.Second, 10*time.Millisecond)
+	r.NotContains(out.String(), "hi\n")
+
+	cancel()
+	r.ErrorIs(<-errc, context.Canceled)
+}
+
+func Test_Supervise_Signal(t *testing.T) {
+	t.Parallel()
+	skipWithoutSh(t)
+	r := require.New(t)
+
+	env := FromMap(map[string]string{})
+	out := &syncBuffer{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Supervise(ctx, env, Command{
+			Path:     "sh",
+			Args:     []string{"-c", `trap 'echo reload' HUP; echo started; while true; do sleep 0.01; done`},
+			Stdout:   out,
+			Signal:   syscall.SIGHUP,
+			Debounce: 10 * time.Millisecond,
+		})
+	}()
+
+	r.Eventually(func() bool { return out.String() == "started\n" }, 5*time.Second, 10*time.Millisecond)
+
+	r.NoError(env.Setenv("A", "1"))
+	r.Eventually(func() bool { return out.String() == "started\nreload\n" }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	r.ErrorIs(<-errc, context.Canceled)
+}
+
+func Test_Supervise_Exit(t *testing.T) {
+	t.Parallel()
+	skipWithoutSh(t)
+
+	tcs := []struct {
+		name string
+		cmd  Command
+		code int
+	}{
+		{name: "success", cmd: Command{Path: "sh", Args: []string{"-c", "exit 0"}}},
+		{name: "failure", cmd: Command{Path: "sh", Args: []string{"-c", "exit 4"}}, code: 4},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			err := Supervise(context.Background(), Zero(), tc.cmd)
+			if tc.code == 0 {
+				r.NoError(err)
+				return
+			}
+
+			var exit *exec.ExitError
+			r.True(errors.As(err, &exit))
+			r.Equal(tc.code, exit.ExitCode())
+		})
+	}
+}
+
+func Test_Supervise_Errors(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  *Env
+		cmd  Command
+		err  string
+	}{
+		{name: "nil env", cmd: Command{Path: "true"}, err: "nil env"},
+		{name: "missing path", env: Zero(), err: "missing command path"},
+		{name: "not found", env: Zero(), cmd: Command{Path: "envy-no-such-command"}, err: "not found"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			err := Supervise(context.Background(), tc.env, tc.cmd)
+			r.Error(err)
+			r.True(strings.Contains(err.Error(), tc.err), err.Error())
+		})
+	}
+}
+
+func Test_Supervise_Policy(t *testing.T) {
+	t.Parallel()
+	skipWithoutSh(t)
+	r := require.New(t)
+
+	env := FromMap(map[string]string{"DEBUG": "true"})
+	r.NoError(env.SetPolicy(PolicyFunc(func(in PolicyInput) error {
+		if in.Op == PolicyExport && in.Env["DEBUG"] == "true" {
+			return errors.New("no debug children")
+		}
+		return nil
+	})))
+
+	out := &syncBuffer{}
+	err := Supervise(context.Background(), env, Command{
+		Path:   "sh",
+		Args:   []string{"-c", "echo started"},
+		Stdout: out,
+	})
+	r.ErrorIs(err, ErrPolicy)
+	r.Empty(out.String())
+}
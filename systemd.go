@@ -0,0 +1,237 @@
+package envy
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Systemd makes FromFile and FromReader read files the way systemd reads
+// an EnvironmentFile=, so a file can be checked and loaded exactly as a
+// unit would see it:
+//
+//   - lines starting with # or ; are comments, and a backslash at the end
+//     of a comment continues it on the next line
+//   - there is no "export" prefix, interpolation, or #if directive, and %
+//     specifiers are not expanded, so "$" and "%" are kept as written
+//   - an unquoted value is trimmed, a backslash escapes the character after
+//     it, and a backslash at the end of a line joins the next one
+//   - single-quoted text is literal and double-quoted text decodes \", \\,
+//     \`, and \$, keeping any other backslash; both may span lines, and
+//     quoted text and unquoted text after it are joined
+//   - assignments with names that are not valid shell names, values with
+//     control characters other than tab and newline, and lines without "="
+//     are skipped, reported with CodeMalformed to WithDiagnostics
+//
+// Keys set more than once take their last value, unless OnDuplicate says
+// otherwise. FromReader ignores its separator in this mode, reading r as
+// a whole file. A file that is not valid UTF-8 fails to load, as it does
+// in systemd.
+func Systemd(on bool) ParseOption {
+	return func(o *parseOptions) {
+		o.systemd = on
+	}
+}
+
+// systemdState is a state of the systemdLines parser, which follows the
+// state machine of systemd's parse_env_file_internal.
+type systemdState int
+
+const (
+	sdPreKey systemdState = iota
+	sdKey
+	sdPreValue
+	sdValue
+	sdValueEscape
+	sdSingleQuote
+	sdDoubleQuote
+	sdDoubleQuoteEscape
+	sdComment
+	sdCommentEscape
+)
+
+// systemdLines parses src, the contents of file, with the rules described
+// on Systemd. Like dotenvLines, it returns one plain KEY=VALUE line per
+// entry at the index of the line the entry starts on, comments as "#"
+// lines, and blank lines elsewhere, so line numbers are preserved.
+func systemdLines(file, src string, o parseOptions) ([]string, error) {
+	if !utf8.ValidString(src) {
+		return nil, fmt.Errorf("invalid UTF-8")
+	}
+
+	out := make([]string, strings.Count(src, "\n")+1)
+
+	var key, val, comment strings.Builder
+	state := sdPreKey
+	line, start := 0, 0
+	// trim marks where trailing white space of an unquoted value begins,
+	// or -1
+	trim := -1
+
+	skip := func(msg string) {
+		o.diagnostic(Diagnostic{
+			File:    file,
+			Line:    start + 1,
+			Key:     strings.TrimSpace(key.String()),
+			Code:    CodeMalformed,
+			Message: msg,
+		})
+	}
+
+	push := func() {
+		k := strings.TrimSpace(key.String())
+		v := val.String()
+		if trim >= 0 {
+			v = v[:trim]
+		}
+
+		key.Reset()
+		val.Reset()
+		trim = -1
+
+		switch {
+		case !systemdName(k):
+			skip(fmt.Sprintf("invalid environment variable name %q", k))
+		case strings.ContainsFunc(v, func(r rune) bool {
+			return r < ' ' && r != '\t' && r != '\n' || r == 0x7f
+		}):
+			skip(fmt.Sprintf("%s: value contains control characters", k))
+		default:
+			out[start] = k + "=" + v
+		}
+	}
+
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		newline := c == '\n' || c == '\r'
+
+		switch state {
+		case sdPreKey:
+			switch {
+			case c == '#' || c == ';':
+				state = sdComment
+				start = line
+			case !strings.ContainsRune(" \t\n\r", rune(c)):
+				state = sdKey
+				start = line
+				key.WriteByte(c)
+			}
+		case sdKey:
+			switch {
+			case newline:
+				skip(fmt.Sprintf("missing = in %q", strings.TrimSpace(key.String())))
+				key.Reset()
+				state = sdPreKey
+			case c == '=':
+				state = sdPreValue
+			default:
+				key.WriteByte(c)
+			}
+		case sdPreValue:
+			switch {
+			case newline:
+				push()
+				state = sdPreKey
+			case c == '\'':
+				state = sdSingleQuote
+			case c == '"':
+				state = sdDoubleQuote
+			case c == '\\':
+				state = sdValueEscape
+			case c == ' ' || c == '\t':
+			default:
+				state = sdValue
+				val.WriteByte(c)
+			}
+		case sdValue:
+			switch {
+			case newline:
+				push()
+				state = sdPreKey
+			case c == '\\':
+				state = sdValueEscape
+				trim = -1
+			default:
+				if c != ' ' && c != '\t' {
+					trim = -1
+				} else if trim < 0 {
+					trim = val.Len()
+				}
+				val.WriteByte(c)
+			}
+		case sdValueEscape:
+			state = sdValue
+			if !newline {
+				val.WriteByte(c)
+			}
+		case sdSingleQuote:
+			if c == '\'' {
+				state = sdPreValue
+				break
+			}
+			val.WriteByte(c)
+		case sdDoubleQuote:
+			switch c {
+			case '"':
+				state = sdPreValue
+			case '\\':
+				state = sdDoubleQuoteEscape
+			default:
+				val.WriteByte(c)
+			}
+		case sdDoubleQuoteEscape:
+			state = sdDoubleQuote
+			switch {
+			case strings.IndexByte("\"\\`$", c) >= 0:
+				val.WriteByte(c)
+			case c == '\n':
+			default:
+				val.WriteByte('\\')
+				val.WriteByte(c)
+			}
+		case sdComment:
+			switch {
+			case c == '\\':
+				state = sdCommentEscape
+			case newline:
+				out[start] = "# " + strings.TrimSpace(comment.String())
+				comment.Reset()
+				state = sdPreKey
+			default:
+				comment.WriteByte(c)
+			}
+		case sdCommentEscape:
+			state = sdComment
+		}
+
+		if c == '\n' {
+			line++
+		}
+	}
+
+	switch state {
+	case sdKey:
+		skip(fmt.Sprintf("missing = in %q", strings.TrimSpace(key.String())))
+	case sdPreValue, sdValue, sdValueEscape, sdSingleQuote, sdDoubleQuote, sdDoubleQuoteEscape:
+		push()
+	case sdComment, sdCommentEscape:
+		out[start] = "# " + strings.TrimSpace(comment.String())
+	}
+	return out, nil
+}
+
+// systemdName reports whether k is a name systemd accepts for an
+// environment variable: letters, digits, and '_', not starting with a
+// digit.
+func systemdName(k string) bool {
+	if k == "" || k[0] >= '0' && k[0] <= '9' {
+		return false
+	}
+
+	for i := 0; i < len(k); i++ {
+		if c := k[i]; c != '_' && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
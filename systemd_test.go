@@ -0,0 +1,138 @@
+package envy
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Systemd(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name  string
+		in    string
+		exp   map[string]string
+		diags []string
+		err   string
+	}{
+		{
+			name: "unquoted",
+			in:   "A=1\n  B = two words  \nC=a#b\nD=50%\nE=$HOME\nF=\n",
+			exp:  map[string]string{"A": "1", "B": "two words", "C": "a#b", "D": "50%", "E": "$HOME", "F": ""},
+		},
+		{
+			name: "comments",
+			in:   "# one\n; two\n#three \\\nstill=comment\nA=1\n",
+			exp:  map[string]string{"A": "1"},
+		},
+		{
+			name: "escapes",
+			in:   "A=a\\ b\\\\c\\\"\nB=one\\\ntwo\nC=x\\ \n",
+			exp:  map[string]string{"A": "a b\\c\"", "B": "onetwo", "C": "x "},
+		},
+		{
+			name: "single quotes",
+			in:   "A='a \\n $b'\nB='multi\nline'\n",
+			exp:  map[string]string{"A": "a \\n $b", "B": "multi\nline"},
+		},
+		{
+			name: "double quotes",
+			in:   "A=\"a\\\"b\\$c\\`d\\\\e\\nf\"\nB=\"x\\\ny\"\nC=\"multi\nline\"\n",
+			exp:  map[string]string{"A": "a\"b$c`d\\e\\nf", "B": "xy", "C": "multi\nline"},
+		},
+		{
+			name: "joined",
+			in:   "A=\"a b\" c 'd'\nB='x'y \n",
+			exp:  map[string]string{"A": "a bc 'd'", "B": "xy"},
+		},
+		{
+			name: "quote inside unquoted value",
+			in:   "A=it's\n",
+			exp:  map[string]string{"A": "it's"},
+		},
+		{
+			name: "unterminated quote",
+			in:   "A=\"open\nB=2",
+			exp:  map[string]string{"A": "open\nB=2"},
+		},
+		{
+			name: "crlf",
+			in:   "A=1\r\nB=2\r\n",
+			exp:  map[string]string{"A": "1", "B": "2"},
+		},
+		{
+			name:  "last wins",
+			in:    "A=1\nA=2\n",
+			exp:   map[string]string{"A": "2"},
+			diags: []string{"2: duplicate-key"},
+		},
+		{
+			name:  "skipped",
+			in:    "export A=1\n1B=2\nC.D=3\nnoequals\nE=\"\x01\"\nF=ok\nlast",
+			exp:   map[string]string{"F": "ok"},
+			diags: []string{"1: malformed", "2: malformed", "3: malformed", "4: malformed", "5: malformed", "7: malformed"},
+		},
+		{
+			name: "invalid UTF-8",
+			in:   "A=\xff\n",
+			err:  "env: invalid UTF-8",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			var diags []string
+			diagnose := WithDiagnostics(func(d Diagnostic) {
+				diags = append(diags, fmt.Sprintf("%d: %s", d.Line, d.Code))
+			})
+
+			cab := fstest.MapFS{"env": {Data: []byte(tc.in)}}
+			env, err := FromFile(cab, "env", Systemd(true), diagnose)
+			if tc.err != "" {
+				r.ErrorIs(err, ErrMalformed)
+				r.Contains(err.Error(), tc.err)
+
+				_, err = FromReader(strings.NewReader(tc.in), '\n', Systemd(true))
+				r.ErrorIs(err, ErrMalformed)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, env.jsonMap())
+			r.Equal(tc.diags, diags)
+
+			env, err = FromReader(strings.NewReader(tc.in), '\n', Systemd(true))
+			r.NoError(err)
+			r.Equal(tc.exp, env.jsonMap())
+		})
+	}
+}
+
+func Test_Systemd_meta(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	cab := fstest.MapFS{"env": {Data: []byte("A='multi\nline'\n; the port\nPORT=80\n")}}
+
+	env, err := FromFile(cab, "env", Systemd(true))
+	r.NoError(err)
+
+	m, ok := env.Meta("PORT")
+	r.True(ok)
+	r.Equal(4, m.Line)
+	r.Equal("the port", m.Description)
+
+	_, err = FromFile(cab, "env", Systemd(true), OnDuplicate(DuplicateError))
+	r.NoError(err)
+
+	cab = fstest.MapFS{"env": {Data: []byte("A=1\nA=2\n")}}
+	_, err = FromFile(cab, "env", Systemd(true), OnDuplicate(DuplicateError))
+	r.ErrorIs(err, ErrMalformed)
+}
@@ -0,0 +1,67 @@
+package envy
+
+import "fmt"
+
+// Temp applies overrides to the Env, calls fn with the Env, and then restores
+// every overridden key to its previous value (or unsets it if it was not
+// previously set). Restoration happens even if fn panics. Changes fn makes to
+// keys not in overrides are kept. Both the overrides and the restore are
+// changes like any other: subscribers are notified, the Revision advances,
+// and history is recorded. The Policy is asked about the overrides but not
+// the restore, which puts back values the Env already held and so cannot
+// be refused. It returns an error for a nil Env, the error of a rejected
+// override, in which case fn is not called, or the error returned by fn.
+func (e *Env) Temp(overrides map[string]string, fn func(*Env) error) (err error) {
+	if e.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	if fn == nil {
+		return fmt.Errorf("nil func")
+	}
+
+	set := map[string]string{}
+	var unset []string
+	meta := map[string]Meta{}
+
+	_, err = e.mutate(func() (map[string]string, []string, error) {
+		for k := range overrides {
+			if v, ok := e.envs[k]; ok {
+				set[k] = v
+			} else {
+				unset = append(unset, k)
+			}
+
+			if m, ok := e.meta[k]; ok {
+				meta[k] = m
+			}
+		}
+		return overrides, nil, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_, rerr := e.restore(func() (map[string]string, []string, error) {
+			return set, unset, nil
+		})
+		if err == nil {
+			err = rerr
+		}
+
+		// the restored values are the ones the metadata describes
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		for k, m := range meta {
+			if v, ok := e.envs[k]; ok && v == set[k] {
+				if e.meta == nil {
+					e.meta = map[string]Meta{}
+				}
+				e.meta[k] = m
+			}
+		}
+	}()
+
+	return fn(e)
+}
@@ -0,0 +1,129 @@
+package envy
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_Temp(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name      string
+		env       *Env
+		overrides map[string]string
+		fn        func(*Env) error
+		exp       []string
+		err       bool
+	}{
+		{
+			name:      "nil env",
+			env:       nil,
+			overrides: map[string]string{"KEY": "VALUE"},
+			fn:        func(*Env) error { return nil },
+			err:       true,
+		},
+		{
+			name: "nil func",
+			env:  Zero(),
+			err:  true,
+		},
+		{
+			name:      "overrides visible and restored",
+			env:       FromMap(map[string]string{"KEY1": "VALUE1"}),
+			overrides: map[string]string{"KEY1": "TEMP1", "KEY2": "TEMP2"},
+			fn: func(e *Env) error {
+				if e.Getenv("KEY1") != "TEMP1" || e.Getenv("KEY2") != "TEMP2" {
+					return fmt.Errorf("overrides not applied")
+				}
+				return nil
+			},
+			exp: []string{"KEY1=VALUE1"},
+		},
+		{
+			name:      "other changes are kept",
+			env:       FromMap(map[string]string{"KEY1": "VALUE1"}),
+			overrides: map[string]string{"KEY1": "TEMP1"},
+			fn: func(e *Env) error {
+				return e.Setenv("KEY3", "VALUE3")
+			},
+			exp: []string{"KEY1=VALUE1", "KEY3=VALUE3"},
+		},
+		{
+			name:      "fn error is returned and env restored",
+			env:       FromMap(map[string]string{"KEY1": "VALUE1"}),
+			overrides: map[string]string{"KEY1": "TEMP1"},
+			fn: func(e *Env) error {
+				return fmt.Errorf("boom")
+			},
+			exp: []string{"KEY1=VALUE1"},
+			err: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			err := tc.env.Temp(tc.overrides, tc.fn)
+			if tc.err {
+				r.Error(err)
+			} else {
+				r.NoError(err)
+			}
+
+			if tc.exp != nil {
+				r.Equal(tc.exp, tc.env.Environ())
+			}
+		})
+	}
+}
+
+func Test_Env_Temp_Panic(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := FromMap(map[string]string{"KEY1": "VALUE1"})
+
+	r.Panics(func() {
+		_ = env.Temp(map[string]string{"KEY1": "TEMP1", "KEY2": "TEMP2"}, func(*Env) error {
+			panic("boom")
+		})
+	})
+
+	r.Equal([]string{"KEY1=VALUE1"}, env.Environ())
+}
+
+func Test_Env_Temp_changes(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env, err := FromFile(fstest.MapFS{".env": &fstest.MapFile{Data: []byte("KEY1=VALUE1\n")}}, ".env")
+	r.NoError(err)
+	r.NoError(env.KeepHistory(10))
+
+	var diffs []Diff
+	_, err = env.Subscribe(func(d Diff) {
+		diffs = append(diffs, d)
+	})
+	r.NoError(err)
+
+	rev := env.Revision()
+	r.NoError(env.Temp(map[string]string{"KEY1": "TEMP1", "KEY2": "TEMP2"}, func(*Env) error {
+		return nil
+	}))
+
+	r.Equal(rev+2, env.Revision())
+	r.Len(env.History(), 2)
+	r.Equal([]Diff{
+		{{Key: "KEY1", Kind: Modified, Old: "VALUE1", New: "TEMP1"}, {Key: "KEY2", Kind: Added, New: "TEMP2"}},
+		{{Key: "KEY1", Kind: Modified, Old: "TEMP1", New: "VALUE1"}, {Key: "KEY2", Kind: Removed, Old: "TEMP2"}},
+	}, diffs)
+
+	// the restored value keeps its origin
+	r.Equal(".env line 1", env.Source("KEY1"))
+}
@@ -0,0 +1,237 @@
+package envy
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Prompter answers the placeholder name of a template, given the default
+// written in the template, which is empty if there is none.
+type Prompter func(name, def string) (string, error)
+
+// Answers returns a Prompter that answers from m, for non-interactive
+// setup. A name missing from m gets its default, or an error if it has
+// none.
+func Answers(m map[string]string) Prompter {
+	return func(name, def string) (string, error) {
+		if v, ok := m[name]; ok {
+			return v, nil
+		}
+
+		if def != "" {
+			return def, nil
+		}
+		return "", fmt.Errorf("no answer for %s", name)
+	}
+}
+
+// Interactive returns a Prompter that writes "NAME [default]: " to out and
+// reads the answer, a line, from in. An empty line takes the default.
+func Interactive(in io.Reader, out io.Writer) Prompter {
+	br := bufio.NewReader(in)
+
+	return func(name, def string) (string, error) {
+		if def != "" {
+			fmt.Fprintf(out, "%s [%s]: ", name, def)
+		} else {
+			fmt.Fprintf(out, "%s: ", name)
+		}
+
+		line, err := br.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			return def, nil
+		}
+		return line, nil
+	}
+}
+
+// RenderTemplate writes the env file template read from r to w with each
+// {{prompt "NAME"}} or {{prompt "NAME" "default"}} placeholder replaced by
+// the answer of p. Every name is asked once, however often it appears. The
+// template is a text/template, so comments and blank lines are kept and
+// other actions are available. Answers read back as given: an entry holding
+// one that would not, such as "s3cr #et", is written double quoted and
+// escaped. It returns an error if p fails or an answer contains a newline,
+// which would add lines to the file.
+func RenderTemplate(w io.Writer, r io.Reader, p Prompter) error {
+	if w == nil {
+		return fmt.Errorf("nil writer")
+	}
+
+	b, err := renderTemplate("template", r, p)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+// FromTemplate renders the template at path in cab, as RenderTemplate
+// does, and parses the result as FromFile would, for guided project setup.
+func FromTemplate(cab fs.FS, path string, p Prompter, opts ...ParseOption) (*Env, error) {
+	if cab == nil {
+		return nil, fmt.Errorf("nil fs.FS")
+	}
+
+	f, err := cab.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := renderTemplate(path, f, p)
+	if err != nil {
+		return nil, err
+	}
+	return parseFile(path, bytes.NewReader(b), opts)
+}
+
+// renderTemplate implements RenderTemplate for the template named name.
+func renderTemplate(name string, r io.Reader, p Prompter) ([]byte, error) {
+	if r == nil {
+		return nil, fmt.Errorf("nil reader")
+	}
+
+	if p == nil {
+		return nil, fmt.Errorf("nil prompter")
+	}
+
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Answers are rendered as markers first and spliced in once the
+	// file around them can be parsed, so they can be quoted as needed.
+	mark := "ENVY_ANSWER_"
+	for bytes.Contains(src, []byte(mark)) {
+		mark += "_"
+	}
+
+	index := map[string]int{}
+	var answers []string
+	prompt := func(key string, def ...string) (string, error) {
+		if i, ok := index[key]; ok {
+			return mark + strconv.Itoa(i) + "_", nil
+		}
+
+		if key == "" || len(def) > 1 {
+			return "", fmt.Errorf("prompt takes a name and an optional default")
+		}
+
+		v, err := p(key, strings.Join(def, ""))
+		if err != nil {
+			return "", err
+		}
+
+		if strings.ContainsAny(v, "\r\n") {
+			return "", fmt.Errorf("%s: answer contains a newline", key)
+		}
+
+		index[key] = len(answers)
+		answers = append(answers, v)
+		return mark + strconv.Itoa(index[key]) + "_", nil
+	}
+
+	t, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"prompt": prompt}).
+		Parse(string(src))
+	if err != nil {
+		return nil, err
+	}
+
+	bb := &bytes.Buffer{}
+	if err := t.Execute(bb, nil); err != nil {
+		return nil, err
+	}
+
+	var raw, escaped []string
+	for i, v := range answers {
+		m := mark + strconv.Itoa(i) + "_"
+		raw = append(raw, m, v)
+		escaped = append(escaped, m, strings.ReplaceAll(v, "$", "$$"))
+	}
+	return spliceAnswers(bb.String(), mark, strings.NewReplacer(raw...), strings.NewReplacer(escaped...)), nil
+}
+
+// spliceAnswers replaces the answer markers in the rendered text with the
+// answers, using raw, and returns the file. An entry whose answers would
+// not read back as given, such as "s3cr #et", "pa$word", or `"x`, with or
+// without interpolation, is rewritten as KEY="value", quoted and escaped
+// as WriteTo does; the lines of a quoted value it spanned are left blank
+// to keep line numbers. escaped holds the answers with "$" doubled, as
+// dotenvLines writes literal dollar signs. Text that does not parse is
+// spliced as it is, for the parser to report.
+func spliceAnswers(text, mark string, raw, escaped *strings.Replacer) []byte {
+	lines := strings.Split(text, "\n")
+
+	decoded, err := dotenvLines(lines, true, nil)
+	if err != nil {
+		return []byte(raw.Replace(text))
+	}
+
+	plain, err := dotenvLines(lines, false, nil)
+	if err != nil {
+		return []byte(raw.Replace(text))
+	}
+
+	done := make([]bool, len(lines))
+	for i := 0; i < len(lines); i++ {
+		k, v, ok := lineEntry(decoded[i])
+		if !ok {
+			continue
+		}
+
+		end := entryEnd(lines, i)
+		if !strings.Contains(strings.Join(lines[i:end+1], "\n"), mark) {
+			i = end
+			continue
+		}
+
+		if end == i {
+			line := raw.Replace(lines[i])
+			got, err := dotenvLines([]string{line}, true, nil)
+			if err == nil {
+				gk, gv, ok := lineEntry(got[0])
+				if ok && gk == raw.Replace(k) && gv == escaped.Replace(v) {
+					lines[i], done[i] = line, true
+					continue
+				}
+			}
+		}
+
+		_, pv, _ := lineEntry(plain[i])
+		lines[i] = raw.Replace(k) + "=" + dotenvValue(raw.Replace(pv))
+		for j := i + 1; j <= end; j++ {
+			lines[j] = ""
+		}
+		for j := i; j <= end; j++ {
+			done[j] = true
+		}
+		i = end
+	}
+
+	for i, line := range lines {
+		if !done[i] {
+			lines[i] = raw.Replace(line)
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
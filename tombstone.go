@@ -0,0 +1,127 @@
+package envy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tombstone removes keys from the Env and marks them as deleted, so that
+// merging the Env over another, as an upper layer of a stack, hides the
+// keys in the result rather than leaving the lower layer's values in
+// place. In env files read by FromFile the same is written "unset KEY".
+// Setting a key clears its tombstone. It returns an error for a nil Env or
+// an invalid key.
+func (e *Env) Tombstone(keys ...string) error {
+	if e.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	for _, k := range keys {
+		if !validKey(k) {
+			return fmt.Errorf("invalid key %q", k)
+		}
+	}
+
+	_, err := e.mutate(func() (map[string]string, []string, error) {
+		if e.tombstones == nil {
+			e.tombstones = map[string]bool{}
+		}
+
+		for _, k := range keys {
+			e.tombstones[k] = true
+		}
+		return nil, keys, nil
+	})
+	return err
+}
+
+// Tombstones returns the sorted keys marked deleted with Tombstone.
+func (e *Env) Tombstones() []string {
+	if e.IsNil() {
+		return nil
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	keys := make([]string, 0, len(e.tombstones))
+	for k := range e.tombstones {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// mergeTombstones returns the tombstones of lower merged with upper: those
+// of lower that upper does not set, and all of upper's. Both locks must be
+// held.
+func mergeTombstones(lower, upper *Env) map[string]bool {
+	if len(lower.tombstones) == 0 && len(upper.tombstones) == 0 {
+		return nil
+	}
+
+	ts := map[string]bool{}
+	for k := range lower.tombstones {
+		if _, ok := upper.envs[k]; !ok {
+			ts[k] = true
+		}
+	}
+
+	for k := range upper.tombstones {
+		ts[k] = true
+	}
+	return ts
+}
+
+// unsetKeys returns the keys of an "unset KEY..." line, which may end in
+// a comment, and whether s is one.
+func unsetKeys(s string) ([]string, bool) {
+	rest, ok := strings.CutPrefix(s, "unset")
+	if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '\t') {
+		return nil, false
+	}
+
+	var keys []string
+	for _, f := range strings.Fields(rest) {
+		if strings.HasPrefix(f, "#") {
+			break
+		}
+
+		if !validKey(f) {
+			return nil, false
+		}
+		keys = append(keys, f)
+	}
+	return keys, len(keys) > 0
+}
+
+// applyUnset tombstones the keys of the "unset" lines among lines, plain
+// lines as produced by dotenvLines, unless a later line sets them again.
+// The Env must not be shared yet.
+func (e *Env) applyUnset(lines []string) {
+	unset := map[string]bool{}
+	for _, line := range lines {
+		if keys, ok := unsetKeys(strings.TrimSpace(line)); ok {
+			for _, k := range keys {
+				unset[k] = true
+			}
+			continue
+		}
+
+		if k, _, ok := lineEntry(line); ok {
+			delete(unset, k)
+		}
+	}
+
+	for k := range unset {
+		if e.tombstones == nil {
+			e.tombstones = map[string]bool{}
+		}
+
+		e.tombstones[k] = true
+		delete(e.envs, k)
+		delete(e.meta, k)
+	}
+}
@@ -0,0 +1,95 @@
+package envy
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_Tombstone(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	base := FromMap(map[string]string{"A": "1", "B": "2", "C": "3"})
+
+	upper := FromMap(map[string]string{"D": "4"})
+	r.NoError(upper.Tombstone("B", "C"))
+	r.Equal([]string{"B", "C"}, upper.Tombstones())
+
+	merged, d, err := base.MergeReport(upper)
+	r.NoError(err)
+	r.Equal([]string{"A=1", "D=4"}, merged.Environ())
+	r.Equal(Diff{
+		{Key: "B", Kind: Removed, Old: "2"},
+		{Key: "C", Kind: Removed, Old: "3"},
+		{Key: "D", Kind: Added, New: "4"},
+	}, d)
+
+	// tombstones carry up the stack, until a higher layer sets the key
+	top := FromMap(map[string]string{"C": "top"})
+	stacked, err := merged.Merge(top)
+	r.NoError(err)
+	r.Equal([]string{"B"}, stacked.Tombstones())
+
+	final, err := base.Merge(stacked)
+	r.NoError(err)
+	r.Equal([]string{"A=1", "C=top", "D=4"}, final.Environ())
+
+	// setting a key clears its tombstone
+	r.NoError(upper.Setenv("B", "back"))
+	r.Equal([]string{"C"}, upper.Tombstones())
+
+	merged, err = base.Merge(upper)
+	r.NoError(err)
+	r.Equal([]string{"A=1", "B=back", "D=4"}, merged.Environ())
+
+	// tombstoning removes the key from the Env itself
+	r.NoError(base.Tombstone("A"))
+	r.False(base.IsSet("A"))
+
+	r.Error(base.Tombstone("A=B"))
+
+	var nilEnv *Env
+	r.Error(nilEnv.Tombstone("A"))
+	r.Nil(nilEnv.Tombstones())
+}
+
+func Test_FromFile_unset(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name       string
+		in         string
+		exp        []string
+		tombstones []string
+	}{
+		{name: "unset", in: "A=1\nunset B C # not wanted here\n", exp: []string{"A=1"}, tombstones: []string{"B", "C"}},
+		{name: "unset then set", in: "unset A\nA=1\n", exp: []string{"A=1"}, tombstones: []string{}},
+		{name: "set then unset", in: "A=1\nB=2\nunset A\n", exp: []string{"B=2"}, tombstones: []string{"A"}},
+		{name: "in inactive block", in: "#if ENVY_TOMBSTONE_TEST_NOPE\nunset A\n#endif\n", exp: []string{}, tombstones: []string{}},
+		{name: "key named unset", in: "unset=1\nunset = 2\n", exp: []string{"unset=2"}, tombstones: []string{}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			env, err := FromFile(fstest.MapFS{".env": {Data: []byte(tc.in)}}, ".env")
+			r.NoError(err)
+			r.Equal(tc.exp, env.Environ())
+			r.Equal(tc.tombstones, env.Tombstones())
+		})
+	}
+
+	r := require.New(t)
+
+	base := FromMap(map[string]string{"DEBUG": "1", "PORT": "80"})
+	local, err := FromFile(fstest.MapFS{".env.local": {Data: []byte("unset DEBUG\n")}}, ".env.local")
+	r.NoError(err)
+
+	merged, err := base.Merge(local)
+	r.NoError(err)
+	r.Equal([]string{"PORT=80"}, merged.Environ())
+}
@@ -0,0 +1,693 @@
+package envy
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// TOMLOption configures FromTOML.
+type TOMLOption func(*tomlOptions)
+
+type tomlOptions struct {
+	sep string
+}
+
+// TOMLSeparator sets the separator FromTOML puts between the parts of a
+// nested key. The default is "_".
+func TOMLSeparator(sep string) TOMLOption {
+	return func(o *tomlOptions) {
+		o.sep = sep
+	}
+}
+
+// FromTOML reads the TOML file at path and flattens its tables into an
+// Env, in the same way as FromYAML: each part of a key is upper cased,
+// with '-' and spaces turned into '_', and the parts of dotted keys and
+// table names are joined with the separator, so "host" in a [db] table
+// sets DB_HOST. Strings are decoded, integers are written in decimal, and
+// floats, booleans, and dates are kept as written, without underscores. An
+// array of scalars is set as a comma-separated list, the form Get reads;
+// other arrays, including arrays of tables, are flattened with each item's
+// index as a part of its keys. The line and the comment lines directly
+// above each key are recorded as its Meta. It returns an error for a nil
+// fs.FS, a read or syntax failure, keys that flatten to the same name, or
+// a list item containing a comma.
+func FromTOML(cab fs.FS, path string, opts ...TOMLOption) (*Env, error) {
+	if cab == nil {
+		return nil, fmt.Errorf("nil fs.FS")
+	}
+
+	o := tomlOptions{sep: "_"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	f, err := cab.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utf8.Valid(b) {
+		return nil, malformedError{fmt.Errorf("%s: invalid UTF-8", path)}
+	}
+
+	p := &tomlParser{
+		src:    strings.TrimPrefix(string(b), "\ufeff"),
+		line:   1,
+		sep:    o.sep,
+		path:   path,
+		em:     map[string]string{},
+		meta:   map[string]Meta{},
+		arrays: map[string]int{},
+	}
+
+	if err := p.parse(); err != nil {
+		return nil, malformedError{fmt.Errorf("%s: line %d: %w", path, p.line, err)}
+	}
+
+	e := FromMap(p.em)
+	e.meta = p.meta
+	return e, nil
+}
+
+// tomlValue is a parsed TOML value: a scalar, an array, or an inline
+// table.
+type tomlValue struct {
+	scalar string
+	items  []tomlValue
+	pairs  []tomlPair
+	array  bool
+	table  bool
+}
+
+// tomlPair is a key/value pair of an inline table.
+type tomlPair struct {
+	key  []string
+	val  tomlValue
+	line int
+}
+
+// tomlParser reads a TOML document, adding its entries as it goes.
+type tomlParser struct {
+	src  string
+	pos  int
+	line int
+
+	sep  string
+	path string
+	em   map[string]string
+	meta map[string]Meta
+
+	// table is the path of the current table, with the indexes of
+	// arrays of tables.
+	table []string
+	// arrays counts the tables of each array of tables, by raw path.
+	arrays map[string]int
+	// comment holds the comment lines directly above the current line.
+	comment []string
+}
+
+var (
+	tomlInt   = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$`)
+	tomlRadix = regexp.MustCompile(`^0(x[0-9A-Fa-f](_?[0-9A-Fa-f])*|o[0-7](_?[0-7])*|b[01](_?[01])*)$`)
+	tomlFloat = regexp.MustCompile(`^[+-]?((0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][+-]?[0-9](_?[0-9])*)?|inf|nan)$`)
+	tomlDate  = regexp.MustCompile(`^([0-9]{4}-[0-9]{2}-[0-9]{2}([Tt ][0-9]{2}:[0-9]{2}(:[0-9]{2}(\.[0-9]+)?)?([Zz]|[+-][0-9]{2}:[0-9]{2})?)?|[0-9]{2}:[0-9]{2}(:[0-9]{2}(\.[0-9]+)?)?)$`)
+)
+
+// parse reads the document.
+func (p *tomlParser) parse() error {
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil
+		}
+
+		switch c := p.peek(); {
+		case c == '\n' || strings.HasPrefix(p.rest(), "\r\n"):
+			// a blank line separates comments from what follows
+			p.comment = nil
+			if err := p.endLine(); err != nil {
+				return err
+			}
+		case c == '#':
+			p.comment = append(p.comment, strings.TrimSpace(p.readComment()))
+			if err := p.endLine(); err != nil {
+				return err
+			}
+		case c == '[':
+			if err := p.header(); err != nil {
+				return err
+			}
+		default:
+			line := p.line
+			key, err := p.key()
+			if err != nil {
+				return err
+			}
+
+			if err := p.expect('='); err != nil {
+				return err
+			}
+
+			v, err := p.value()
+			if err != nil {
+				return err
+			}
+
+			path := append(append([]string{}, p.table...), key...)
+			if err := p.add(path, v, line, strings.Join(p.comment, "\n")); err != nil {
+				return err
+			}
+
+			p.comment = nil
+			if err := p.endLine(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// header reads a [table] or [[array]] header.
+func (p *tomlParser) header() error {
+	p.pos++
+	array := p.peek() == '['
+	if array {
+		p.pos++
+	}
+
+	key, err := p.key()
+	if err != nil {
+		return err
+	}
+
+	if err := p.expect(']'); err != nil {
+		return err
+	}
+	if array && p.peek() != ']' {
+		return fmt.Errorf("expected ]]")
+	}
+	if array {
+		p.pos++
+	}
+
+	// a table nested in an array of tables belongs to its last item
+	var table, raw []string
+	for i, part := range key {
+		table = append(table, part)
+		raw = append(raw, part)
+		id := strings.Join(raw, "\x00")
+
+		if array && i == len(key)-1 {
+			table = append(table, strconv.Itoa(p.arrays[id]))
+			p.arrays[id]++
+			break
+		}
+
+		if n, ok := p.arrays[id]; ok {
+			table = append(table, strconv.Itoa(n-1))
+		}
+	}
+
+	p.table = table
+	p.comment = nil
+	return p.endLine()
+}
+
+// add adds the entries of v as the key path.
+func (p *tomlParser) add(path []string, v tomlValue, line int, comment string) error {
+	switch {
+	case v.table:
+		for _, pair := range v.pairs {
+			if err := p.add(append(append([]string{}, path...), pair.key...), pair.val, pair.line, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	case v.array:
+		scalars := true
+		for _, item := range v.items {
+			scalars = scalars && !item.array && !item.table
+		}
+
+		if !scalars {
+			for i, item := range v.items {
+				if err := p.add(append(append([]string{}, path...), strconv.Itoa(i)), item, line, comment); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		items := make([]string, len(v.items))
+		for i, item := range v.items {
+			if strings.Contains(item.scalar, ",") {
+				return fmt.Errorf("%s: %q contains a comma", p.flat(path), item.scalar)
+			}
+			items[i] = item.scalar
+		}
+		return p.set(path, strings.Join(items, ","), line, comment)
+	}
+	return p.set(path, v.scalar, line, comment)
+}
+
+// set adds the entry for the key path.
+func (p *tomlParser) set(path []string, val string, line int, comment string) error {
+	key := p.flat(path)
+	if _, ok := p.em[key]; ok {
+		return fmt.Errorf("%s is set more than once (first on line %d)", key, p.meta[key].Line)
+	}
+
+	if !validKey(key) {
+		return fmt.Errorf("invalid key %q", key)
+	}
+
+	p.em[key] = val
+	p.meta[key] = Meta{
+		Loader:      "file",
+		File:        p.path,
+		Line:        line,
+		Description: comment,
+	}
+	return nil
+}
+
+// flat returns the env key of path.
+func (p *tomlParser) flat(path []string) string {
+	key := ""
+	for _, part := range path {
+		key = flatKey(key, part, p.sep)
+	}
+	return key
+}
+
+// key reads a bare, quoted, or dotted key.
+func (p *tomlParser) key() ([]string, error) {
+	var parts []string
+	for {
+		p.skipSpace()
+
+		var part string
+		var err error
+		switch p.peek() {
+		case '"':
+			part, err = p.basic()
+		case '\'':
+			part, err = p.literal()
+		default:
+			start := p.pos
+			for !p.eof() && tomlBare(p.peek()) {
+				p.pos++
+			}
+
+			if start == p.pos {
+				return nil, fmt.Errorf("expected a key, found %q", p.next())
+			}
+			part = p.src[start:p.pos]
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+
+		p.skipSpace()
+		if p.peek() != '.' {
+			return parts, nil
+		}
+		p.pos++
+	}
+}
+
+// value reads a value.
+func (p *tomlParser) value() (tomlValue, error) {
+	var s string
+	var err error
+
+	switch rest := p.rest(); {
+	case strings.HasPrefix(rest, `"""`):
+		s, err = p.multiline(`"""`, true)
+	case strings.HasPrefix(rest, "'''"):
+		s, err = p.multiline("'''", false)
+	case strings.HasPrefix(rest, `"`):
+		s, err = p.basic()
+	case strings.HasPrefix(rest, "'"):
+		s, err = p.literal()
+	case strings.HasPrefix(rest, "["):
+		return p.array()
+	case strings.HasPrefix(rest, "{"):
+		return p.inline()
+	default:
+		s, err = p.bare()
+	}
+	return tomlValue{scalar: s}, err
+}
+
+// array reads an array, which may span lines and hold comments.
+func (p *tomlParser) array() (tomlValue, error) {
+	p.pos++
+	v := tomlValue{array: true}
+	for {
+		p.skipBlank()
+		if p.peek() == ']' {
+			p.pos++
+			return v, nil
+		}
+
+		item, err := p.value()
+		if err != nil {
+			return v, err
+		}
+		v.items = append(v.items, item)
+
+		p.skipBlank()
+		switch p.next() {
+		case ',':
+		case ']':
+			return v, nil
+		default:
+			p.pos--
+			return v, fmt.Errorf("expected , or ] in array, found %q", p.next())
+		}
+	}
+}
+
+// inline reads an inline table.
+func (p *tomlParser) inline() (tomlValue, error) {
+	p.pos++
+	v := tomlValue{table: true}
+
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.pos++
+		return v, nil
+	}
+
+	for {
+		line := p.line
+		key, err := p.key()
+		if err != nil {
+			return v, err
+		}
+
+		if err := p.expect('='); err != nil {
+			return v, err
+		}
+
+		val, err := p.value()
+		if err != nil {
+			return v, err
+		}
+		v.pairs = append(v.pairs, tomlPair{key: key, val: val, line: line})
+
+		p.skipSpace()
+		switch p.next() {
+		case ',':
+		case '}':
+			return v, nil
+		default:
+			p.pos--
+			return v, fmt.Errorf("expected , or } in inline table, found %q", p.next())
+		}
+	}
+}
+
+// basic reads a single-line basic string.
+func (p *tomlParser) basic() (string, error) {
+	p.pos++
+	var bb strings.Builder
+	for {
+		if p.eof() {
+			return "", fmt.Errorf("unterminated string")
+		}
+
+		switch c := p.next(); c {
+		case '"':
+			return bb.String(), nil
+		case '\n':
+			return "", fmt.Errorf("newline in string")
+		case '\\':
+			if err := p.escape(&bb); err != nil {
+				return "", err
+			}
+		default:
+			bb.WriteByte(c)
+		}
+	}
+}
+
+// literal reads a single-line literal string.
+func (p *tomlParser) literal() (string, error) {
+	p.pos++
+	end := strings.IndexAny(p.rest(), "'\n")
+	if end < 0 || p.rest()[end] == '\n' {
+		return "", fmt.Errorf("unterminated string")
+	}
+
+	s := p.rest()[:end]
+	p.pos += end + 1
+	return s, nil
+}
+
+// multiline reads a multi-line string delimited by q, decoding escapes if
+// escapes is true. A newline right after the opening delimiter is dropped.
+func (p *tomlParser) multiline(q string, escapes bool) (string, error) {
+	p.pos += len(q)
+	if strings.HasPrefix(p.rest(), "\r\n") {
+		p.pos += 2
+		p.line++
+	} else if p.peek() == '\n' {
+		p.pos++
+		p.line++
+	}
+
+	var bb strings.Builder
+	for {
+		if p.eof() {
+			return "", fmt.Errorf("unterminated string")
+		}
+
+		if strings.HasPrefix(p.rest(), q) {
+			// up to two quotes may end the string before the delimiter
+			n := len(q)
+			for n < len(q)+2 && strings.HasPrefix(p.rest()[n:], q[:1]) {
+				n++
+			}
+			bb.WriteString(p.rest()[len(q):n])
+			p.pos += n
+			return bb.String(), nil
+		}
+
+		c := p.next()
+		switch {
+		case c == '\n':
+			p.line++
+			bb.WriteByte(c)
+		case c == '\\' && escapes:
+			// a backslash ending a line trims the white space after it
+			if trimmed := strings.TrimLeft(p.rest(), " \t\r"); strings.HasPrefix(trimmed, "\n") {
+				for !p.eof() && strings.IndexByte(" \t\r\n", p.peek()) >= 0 {
+					if p.next() == '\n' {
+						p.line++
+					}
+				}
+				continue
+			}
+
+			if err := p.escape(&bb); err != nil {
+				return "", err
+			}
+		default:
+			bb.WriteByte(c)
+		}
+	}
+}
+
+// escape decodes the escape after a backslash.
+func (p *tomlParser) escape(bb *strings.Builder) error {
+	if p.eof() {
+		return fmt.Errorf("unterminated string")
+	}
+
+	switch c := p.next(); c {
+	case 'b':
+		bb.WriteByte('\b')
+	case 't':
+		bb.WriteByte('\t')
+	case 'n':
+		bb.WriteByte('\n')
+	case 'f':
+		bb.WriteByte('\f')
+	case 'r':
+		bb.WriteByte('\r')
+	case 'e':
+		bb.WriteByte('\x1b')
+	case '"', '\\':
+		bb.WriteByte(c)
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+
+		if len(p.rest()) < n {
+			return fmt.Errorf(`malformed \%c escape`, c)
+		}
+
+		r, err := strconv.ParseUint(p.rest()[:n], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return fmt.Errorf(`malformed \%c escape %q`, c, p.rest()[:n])
+		}
+		p.pos += n
+		bb.WriteRune(rune(r))
+	default:
+		return fmt.Errorf(`invalid escape \%c`, c)
+	}
+	return nil
+}
+
+// bare reads an unquoted value: a boolean, number, or date.
+func (p *tomlParser) bare() (string, error) {
+	start := p.pos
+	for !p.eof() && strings.IndexByte(" \t\r\n,]}#", p.peek()) < 0 {
+		p.pos++
+	}
+
+	// a date and time may be separated by a space
+	if tok := p.src[start:p.pos]; len(tok) == 10 && tomlDate.MatchString(tok) &&
+		len(p.rest()) > 1 && p.peek() == ' ' && p.rest()[1] >= '0' && p.rest()[1] <= '9' {
+		p.pos++
+		for !p.eof() && strings.IndexByte(" \t\r\n,]}#", p.peek()) < 0 {
+			p.pos++
+		}
+	}
+
+	tok := p.src[start:p.pos]
+	switch {
+	case tok == "":
+		return "", fmt.Errorf("expected a value, found %q", p.next())
+	case tok == "true", tok == "false", tomlDate.MatchString(tok):
+		return tok, nil
+	case tomlInt.MatchString(tok):
+		return strings.TrimPrefix(strings.ReplaceAll(tok, "_", ""), "+"), nil
+	case tomlRadix.MatchString(tok):
+		n, err := strconv.ParseInt(tok, 0, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid integer %q: %w", tok, err)
+		}
+		return strconv.FormatInt(n, 10), nil
+	case tomlFloat.MatchString(tok):
+		return strings.TrimPrefix(strings.ReplaceAll(tok, "_", ""), "+"), nil
+	}
+	return "", fmt.Errorf("invalid value %q", tok)
+}
+
+// expect skips white space and reads c.
+func (p *tomlParser) expect(c byte) error {
+	p.skipSpace()
+	if p.eof() || p.peek() != c {
+		return fmt.Errorf("expected %q, found %q", c, p.next())
+	}
+	p.pos++
+	p.skipSpace()
+	return nil
+}
+
+// endLine reads the rest of a line, which may only hold a comment.
+func (p *tomlParser) endLine() error {
+	p.skipSpace()
+	if p.peek() == '#' {
+		p.readComment()
+	}
+
+	switch {
+	case p.eof():
+	case strings.HasPrefix(p.rest(), "\r\n"):
+		p.pos += 2
+		p.line++
+	case p.peek() == '\n':
+		p.pos++
+		p.line++
+	default:
+		return fmt.Errorf("unexpected %q at end of line", p.next())
+	}
+	return nil
+}
+
+// readComment reads a comment up to the end of the line and returns its
+// text after the "#".
+func (p *tomlParser) readComment() string {
+	end := strings.IndexByte(p.rest(), '\n')
+	if end < 0 {
+		end = len(p.rest())
+	}
+
+	c := strings.TrimSuffix(p.rest()[1:end], "\r")
+	p.pos += end
+	return c
+}
+
+// skipSpace skips spaces and tabs.
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlank skips white space, newlines, and comments, as allowed inside
+// arrays.
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r':
+			p.pos++
+		case '\n':
+			p.pos++
+			p.line++
+		case '#':
+			p.readComment()
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// next returns the current byte and moves past it.
+func (p *tomlParser) next() byte {
+	c := p.peek()
+	p.pos++
+	return c
+}
+
+func (p *tomlParser) rest() string {
+	if p.eof() {
+		return ""
+	}
+	return p.src[p.pos:]
+}
+
+// tomlBare reports whether c may appear in a bare key.
+func tomlBare(c byte) bool {
+	return c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,166 @@
+package envy
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromTOML(t *testing.T) {
+	t.Parallel()
+
+	const doc = `# the app
+name = "my-app"
+port = 8_080
+mode = 0o755
+ratio = +1_000.5
+debug = true
+born = 1979-05-27 07:32:00Z
+path = 'C:\Users\app'
+empty = ""
+cache.url = "redis://cache"
+"quoted key" = "\u00e9\tx"
+hosts = [
+  "a.example.com", # first
+  "b.example.com",
+]
+point = { x = 1, y = 2 }
+text = """
+line one
+line two \
+  continued"""
+
+[db]
+# primary database
+host = "localhost"
+max-conns = 10
+
+[db.replica]
+host = 'replica'
+
+[[servers]]
+name = "web"
+port = 80
+
+[[servers]]
+name = "api"
+
+[servers.tls]
+cert = "api.pem"
+`
+
+	tcs := []struct {
+		name string
+		in   string
+		opts []TOMLOption
+		exp  map[string]string
+		err  string
+	}{
+		{
+			name: "document",
+			in:   doc,
+			exp: map[string]string{
+				"NAME":               "my-app",
+				"PORT":               "8080",
+				"MODE":               "493",
+				"RATIO":              "1000.5",
+				"DEBUG":              "true",
+				"BORN":               "1979-05-27 07:32:00Z",
+				"PATH":               `C:\Users\app`,
+				"EMPTY":              "",
+				"CACHE_URL":          "redis://cache",
+				"QUOTED_KEY":         "é\tx",
+				"HOSTS":              "a.example.com,b.example.com",
+				"POINT_X":            "1",
+				"POINT_Y":            "2",
+				"TEXT":               "line one\nline two continued",
+				"DB_HOST":            "localhost",
+				"DB_MAX_CONNS":       "10",
+				"DB_REPLICA_HOST":    "replica",
+				"SERVERS_0_NAME":     "web",
+				"SERVERS_0_PORT":     "80",
+				"SERVERS_1_NAME":     "api",
+				"SERVERS_1_TLS_CERT": "api.pem",
+			},
+		},
+		{
+			name: "separator",
+			in:   "[db]\nhost = \"x\"\ncache.url = \"y\"\n",
+			opts: []TOMLOption{TOMLSeparator("__")},
+			exp:  map[string]string{"DB__HOST": "x", "DB__CACHE__URL": "y"},
+		},
+		{
+			name: "nested arrays",
+			in:   "matrix = [[1, 2], [3]]\nusers = [{ name = \"a\" }, { name = \"b\" }]\n",
+			exp: map[string]string{
+				"MATRIX_0":     "1,2",
+				"MATRIX_1":     "3",
+				"USERS_0_NAME": "a",
+				"USERS_1_NAME": "b",
+			},
+		},
+		{
+			name: "literal multiline",
+			in:   "re = '''\n\\d+ ''quoted'''''\n",
+			exp:  map[string]string{"RE": "\\d+ ''quoted''"},
+		},
+		{name: "empty", in: "", exp: map[string]string{}},
+		{name: "comments only", in: "# nothing\r\n", exp: map[string]string{}},
+		{name: "collision", in: "[db]\nhost = \"a\"\n\n[DB]\nhost = \"b\"\n", err: "app.toml: line 5: DB_HOST is set more than once (first on line 2)"},
+		{name: "comma in list", in: "hosts = [\"a,b\", \"c\"]\n", err: `line 1: HOSTS: "a,b" contains a comma`},
+		{name: "unterminated", in: "a = \"b\n", err: "line 1: newline in string"},
+		{name: "bad value", in: "a = yes\n", err: `line 1: invalid value "yes"`},
+		{name: "leading zero", in: "a = 0755\n", err: `line 1: invalid value "0755"`},
+		{name: "missing equals", in: "a \"b\"\n", err: "line 1: expected '='"},
+		{name: "trailing garbage", in: "a = 1 2\n", err: "line 1: unexpected '2' at end of line"},
+		{name: "bad escape", in: "a = \"\\q\"\n", err: `line 1: invalid escape \q`},
+		{name: "unclosed array", in: "a = [1,\n", err: "line 2: expected a value"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			cab := fstest.MapFS{"app.toml": {Data: []byte(tc.in)}}
+
+			env, err := FromTOML(cab, "app.toml", tc.opts...)
+			if tc.err != "" {
+				r.ErrorIs(err, ErrMalformed)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(len(tc.exp), env.Len())
+			for k, v := range tc.exp {
+				r.Equal(v, env.Getenv(k), k)
+			}
+		})
+	}
+}
+
+func Test_FromTOML_meta(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	cab := fstest.MapFS{"app.toml": {Data: []byte("[db]\n# primary database\nhost = \"localhost\"\n")}}
+
+	env, err := FromTOML(cab, "app.toml")
+	r.NoError(err)
+
+	m, ok := env.Meta("DB_HOST")
+	r.True(ok)
+	r.Equal("app.toml", m.File)
+	r.Equal(3, m.Line)
+	r.Equal("primary database", m.Description)
+	r.Equal("app.toml line 3", env.Source("DB_HOST"))
+
+	_, err = FromTOML(cab, "nope.toml")
+	r.ErrorIs(err, fs.ErrNotExist)
+
+	_, err = FromTOML(nil, "app.toml")
+	r.Error(err)
+}
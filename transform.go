@@ -0,0 +1,97 @@
+package envy
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Transformer rewrites the value of key while a file is loaded.
+type Transformer func(key, value string) (string, error)
+
+// transform is a pipeline of Transformers for the keys matching pattern.
+type transform struct {
+	pattern string
+	fns     []Transformer
+}
+
+// Transform makes FromFile pass the value of every key matching pattern
+// through fns, in order, after the file is parsed. Patterns are exact keys
+// or path.Match patterns such as "*_B64"; a malformed pattern only matches a
+// key equal to it. Transforms given in separate options run in the order
+// given, so a key matched by several patterns goes through each pipeline.
+// An error fails the load, naming the key and its line.
+func Transform(pattern string, fns ...Transformer) ParseOption {
+	return func(o *parseOptions) {
+		o.transforms = append(o.transforms, transform{pattern: pattern, fns: fns})
+	}
+}
+
+// TrimSpace removes leading and trailing white space from values.
+func TrimSpace(key, value string) (string, error) {
+	return strings.TrimSpace(value), nil
+}
+
+// DecodeBase64 decodes values in standard base64 encoding, with or without
+// padding, for keys such as TLS_CERT_B64 that hold binary or multiline data.
+func DecodeBase64(key, value string) (string, error) {
+	value = strings.TrimRight(value, "=")
+	b, err := base64.RawStdEncoding.DecodeString(value)
+	if err != nil {
+		return "", fmt.Errorf("decode base64: %w", err)
+	}
+	return string(b), nil
+}
+
+// ExpandHome replaces a leading "~" in a value that is "~" or starts with
+// "~/" with the current user's home directory. Other values, including
+// "~user/...", are unchanged.
+func ExpandHome(key, value string) (string, error) {
+	rest, ok := strings.CutPrefix(value, "~")
+	if !ok || (rest != "" && rest[0] != '/' && rest[0] != filepath.Separator) {
+		return value, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return home + rest, nil
+}
+
+// transform applies the Transform pipelines to the Env loaded from file.
+// The Env must not be shared yet.
+func (e *Env) transform(file string, ts []transform) error {
+	if len(ts) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(e.envs))
+	for k := range e.envs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, t := range ts {
+		for _, k := range keys {
+			if !matchAny([]string{t.pattern}, k) {
+				continue
+			}
+
+			for _, fn := range t.fns {
+				v, err := fn(k, e.envs[k])
+				if err != nil {
+					if m, ok := e.meta[k]; ok && m.Line > 0 {
+						return fmt.Errorf("%s: line %d: %s: %w", file, m.Line, k, err)
+					}
+					return fmt.Errorf("%s: %s: %w", file, k, err)
+				}
+				e.envs[k] = v
+			}
+		}
+	}
+	return nil
+}
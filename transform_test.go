@@ -0,0 +1,87 @@
+package envy
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromFile_Transform(t *testing.T) {
+	t.Parallel()
+
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	cert := base64.StdEncoding.EncodeToString([]byte("-----BEGIN-----\nabc\n"))
+
+	tcs := []struct {
+		name string
+		in   string
+		opts []ParseOption
+		exp  map[string]string
+		err  string
+	}{
+		{
+			name: "base64 keys",
+			in:   "CERT_B64=" + cert + "\nPLAIN=" + cert + "\nRAW_B64=aGk\n",
+			opts: []ParseOption{Transform("*_B64", DecodeBase64)},
+			exp:  map[string]string{"CERT_B64": "-----BEGIN-----\nabc\n", "PLAIN": cert, "RAW_B64": "hi"},
+		},
+		{
+			name: "home",
+			in:   "CACHE=~/cache\nHOME_ONLY=~\nOTHER=~bob/x\nMID=a~/b\n",
+			opts: []ParseOption{Transform("*", ExpandHome)},
+			exp:  map[string]string{"CACHE": home + "/cache", "HOME_ONLY": home, "OTHER": "~bob/x", "MID": "a~/b"},
+		},
+		{
+			name: "pipeline in order",
+			in:   "A= YQ== \nB= b \n",
+			opts: []ParseOption{Transform("A", TrimSpace, DecodeBase64), Transform("*", TrimSpace)},
+			exp:  map[string]string{"A": "a", "B": "b"},
+		},
+		{
+			name: "error names line",
+			in:   "# comment\nBAD_B64=not base64!\n",
+			opts: []ParseOption{Transform("*_B64", DecodeBase64)},
+			err:  ".env: line 2: BAD_B64: decode base64",
+		},
+		{
+			name: "custom",
+			in:   "A=1\n",
+			opts: []ParseOption{Transform("A", func(key, value string) (string, error) {
+				return "", errors.New("nope")
+			})},
+			err: ".env: line 1: A: nope",
+		},
+		{
+			name: "sandboxed",
+			in:   "A= x \n",
+			opts: []ParseOption{Sandboxed(Limits{}), Transform("A", TrimSpace)},
+			exp:  map[string]string{"A": "x"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			cab := fstest.MapFS{".env": {Data: []byte(tc.in)}}
+			env, err := FromFile(cab, ".env", tc.opts...)
+			if tc.err != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			for k, v := range tc.exp {
+				r.Equal(v, env.Getenv(k), k)
+			}
+		})
+	}
+}
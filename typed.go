@@ -0,0 +1,247 @@
+package envy
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotSet is returned by the typed getters, such as GetInt, for a key
+// that is not set.
+var ErrNotSet = errors.New("not set")
+
+// GetInt returns the value of key parsed as a base 10 int. Surrounding
+// white space is ignored. It returns an error wrapping ErrNotSet for a
+// missing key, or naming the key if the value cannot be read or parsed.
+func (e *Env) GetInt(key string) (int, error) {
+	return getParsed(e, key, strconv.Atoi)
+}
+
+// GetIntOr is GetInt, returning def if key is missing or invalid.
+func (e *Env) GetIntOr(key string, def int) int {
+	return or(e.GetInt(key))(def)
+}
+
+// GetInt64 returns the value of key parsed as a base 10 int64, as for
+// GetInt.
+func (e *Env) GetInt64(key string) (int64, error) {
+	return getParsed(e, key, func(s string) (int64, error) {
+		return strconv.ParseInt(s, 10, 64)
+	})
+}
+
+// GetInt64Or is GetInt64, returning def if key is missing or invalid.
+func (e *Env) GetInt64Or(key string, def int64) int64 {
+	return or(e.GetInt64(key))(def)
+}
+
+// GetBool returns the value of key parsed by strconv.ParseBool, so 1, t,
+// true, 0, f, and false in any case are accepted, as for GetInt.
+func (e *Env) GetBool(key string) (bool, error) {
+	return getParsed(e, key, strconv.ParseBool)
+}
+
+// GetBoolOr is GetBool, returning def if key is missing or invalid.
+func (e *Env) GetBoolOr(key string, def bool) bool {
+	return or(e.GetBool(key))(def)
+}
+
+// GetFloat64 returns the value of key parsed as a float64, as for GetInt.
+func (e *Env) GetFloat64(key string) (float64, error) {
+	return getParsed(e, key, func(s string) (float64, error) {
+		return strconv.ParseFloat(s, 64)
+	})
+}
+
+// GetFloat64Or is GetFloat64, returning def if key is missing or invalid.
+func (e *Env) GetFloat64Or(key string, def float64) float64 {
+	return or(e.GetFloat64(key))(def)
+}
+
+// GetDuration returns the value of key parsed by time.ParseDuration, such
+// as "1m30s", as for GetInt.
+func (e *Env) GetDuration(key string) (time.Duration, error) {
+	return getParsed(e, key, time.ParseDuration)
+}
+
+// GetDurationOr is GetDuration, returning def if key is missing or invalid.
+func (e *Env) GetDurationOr(key string, def time.Duration) time.Duration {
+	return or(e.GetDuration(key))(def)
+}
+
+// getParsed looks up key, resolving and decrypting it as Decrypted does,
+// and parses its trimmed value with parse.
+func getParsed[T any](e *Env, key string, parse func(string) (T, error)) (T, error) {
+	var zero T
+
+	val, ok, err := e.lookup(key)
+	if err != nil {
+		return zero, err
+	}
+
+	if !ok {
+		return zero, fmt.Errorf("%s: %w", key, ErrNotSet)
+	}
+
+	v, err := parse(strings.TrimSpace(val))
+	if err != nil {
+		return zero, fmt.Errorf("%s: %w", key, err)
+	}
+	return v, nil
+}
+
+// or returns a func that returns v, or def if err is not nil.
+func or[T any](v T, err error) func(def T) T {
+	return func(def T) T {
+		if err != nil {
+			return def
+		}
+		return v
+	}
+}
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[reflect.Type]func(string) (any, error){
+		reflect.TypeFor[time.Duration](): decoder(time.ParseDuration),
+		reflect.TypeFor[time.Time](): decoder(func(s string) (time.Time, error) {
+			return time.Parse(time.RFC3339, s)
+		}),
+		reflect.TypeFor[*url.URL](): decoder(url.Parse),
+		reflect.TypeFor[[]byte](): decoder(func(s string) ([]byte, error) {
+			return []byte(s), nil
+		}),
+	}
+)
+
+// RegisterDecoder makes Get decode values of type T with fn, replacing the
+// built-in decoding of T, if any. A nil fn removes the decoder.
+func RegisterDecoder[T any](fn func(string) (T, error)) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+
+	t := reflect.TypeFor[T]()
+	if fn == nil {
+		delete(decoders, t)
+		return
+	}
+	decoders[t] = decoder(fn)
+}
+
+// Get returns the value of key decoded as a T, as GetInt does for ints.
+// Types with a decoder added by RegisterDecoder use it. Otherwise
+// time.Duration is parsed by time.ParseDuration, time.Time as RFC 3339,
+// and *url.URL by url.Parse; types implementing encoding.TextUnmarshaler
+// decode themselves; and strings, bools, ints, uints, and floats, named
+// or not, are parsed with strconv. Slices of any of these are read from a
+// comma-separated list whose items are trimmed of white space; an empty
+// value is an empty slice.
+func Get[T any](e *Env, key string) (T, error) {
+	t := reflect.TypeFor[T]()
+
+	dec, err := decoderFor(t, true)
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("%s: %w", key, err)
+	}
+
+	return getParsed(e, key, func(s string) (T, error) {
+		v, err := dec(s)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+
+		// a registered decoder for an interface type may return nil
+		out, _ := v.(T)
+		return out, nil
+	})
+}
+
+// decoder adapts fn to the form stored in decoders.
+func decoder[T any](fn func(string) (T, error)) func(string) (any, error) {
+	return func(s string) (any, error) {
+		return fn(s)
+	}
+}
+
+// decoderFor returns the decoder Get uses for t, following the order
+// documented there. Slices are only decoded if slices is true, so that
+// their items cannot be lists themselves.
+func decoderFor(t reflect.Type, slices bool) (func(string) (any, error), error) {
+	decodersMu.RLock()
+	dec, ok := decoders[t]
+	decodersMu.RUnlock()
+	if ok {
+		return dec, nil
+	}
+
+	if reflect.PointerTo(t).Implements(reflect.TypeFor[encoding.TextUnmarshaler]()) {
+		return func(s string) (any, error) {
+			v := reflect.New(t)
+			if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+				return nil, err
+			}
+			return v.Elem().Interface(), nil
+		}, nil
+	}
+
+	if t.Kind() == reflect.Slice && slices {
+		item, err := decoderFor(t.Elem(), false)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(s string) (any, error) {
+			out := reflect.MakeSlice(t, 0, 0)
+			if s == "" {
+				return out.Interface(), nil
+			}
+
+			for i, p := range strings.Split(s, ",") {
+				v, err := item(strings.TrimSpace(p))
+				if err != nil {
+					return nil, fmt.Errorf("item %d: %w", i, err)
+				}
+				out = reflect.Append(out, reflect.ValueOf(v))
+			}
+			return out.Interface(), nil
+		}, nil
+	}
+
+	var parse func(string) (any, error)
+	switch t.Kind() {
+	case reflect.String:
+		parse = func(s string) (any, error) { return s, nil }
+	case reflect.Bool:
+		parse = decoder(strconv.ParseBool)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parse = decoder(func(s string) (int64, error) {
+			return strconv.ParseInt(s, 10, t.Bits())
+		})
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parse = decoder(func(s string) (uint64, error) {
+			return strconv.ParseUint(s, 10, t.Bits())
+		})
+	case reflect.Float32, reflect.Float64:
+		parse = decoder(func(s string) (float64, error) {
+			return strconv.ParseFloat(s, t.Bits())
+		})
+	default:
+		return nil, fmt.Errorf("no decoder for %s", t)
+	}
+
+	return func(s string) (any, error) {
+		v, err := parse(s)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.ValueOf(v).Convert(t).Interface(), nil
+	}, nil
+}
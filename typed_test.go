@@ -0,0 +1,233 @@
+package envy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_typed(t *testing.T) {
+	t.Parallel()
+
+	env := FromMap(map[string]string{
+		"PORT":    " 8080 ",
+		"BIG":     "9223372036854775807",
+		"DEBUG":   "TRUE",
+		"RATIO":   "0.75",
+		"TIMEOUT": "1m30s",
+		"BAD":     "nope",
+		"EMPTY":   "",
+	})
+
+	tcs := []struct {
+		name  string
+		get   func(key string) (any, error)
+		or    func(key string) any
+		key   string
+		exp   any
+		def   any
+		unset bool
+		err   bool
+	}{
+		{name: "int", key: "PORT", exp: 8080, def: 1,
+			get: func(k string) (any, error) { return env.GetInt(k) },
+			or:  func(k string) any { return env.GetIntOr(k, 1) }},
+		{name: "int64", key: "BIG", exp: int64(9223372036854775807), def: int64(1),
+			get: func(k string) (any, error) { return env.GetInt64(k) },
+			or:  func(k string) any { return env.GetInt64Or(k, 1) }},
+		{name: "bool", key: "DEBUG", exp: true, def: false,
+			get: func(k string) (any, error) { return env.GetBool(k) },
+			or:  func(k string) any { return env.GetBoolOr(k, false) }},
+		{name: "float64", key: "RATIO", exp: 0.75, def: 0.5,
+			get: func(k string) (any, error) { return env.GetFloat64(k) },
+			or:  func(k string) any { return env.GetFloat64Or(k, 0.5) }},
+		{name: "duration", key: "TIMEOUT", exp: 90 * time.Second, def: time.Second,
+			get: func(k string) (any, error) { return env.GetDuration(k) },
+			or:  func(k string) any { return env.GetDurationOr(k, time.Second) }},
+		{name: "int invalid", key: "BAD", exp: 0, def: 1, err: true,
+			get: func(k string) (any, error) { return env.GetInt(k) },
+			or:  func(k string) any { return env.GetIntOr(k, 1) }},
+		{name: "bool empty", key: "EMPTY", exp: false, def: true, err: true,
+			get: func(k string) (any, error) { return env.GetBool(k) },
+			or:  func(k string) any { return env.GetBoolOr(k, true) }},
+		{name: "duration missing", key: "MISSING", exp: time.Duration(0), def: time.Second, unset: true,
+			get: func(k string) (any, error) { return env.GetDuration(k) },
+			or:  func(k string) any { return env.GetDurationOr(k, time.Second) }},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			act, err := tc.get(tc.key)
+			r.Equal(tc.exp, act)
+
+			switch {
+			case tc.unset:
+				r.ErrorIs(err, ErrNotSet)
+				r.Contains(err.Error(), tc.key)
+				r.Equal(tc.def, tc.or(tc.key))
+			case tc.err:
+				r.Error(err)
+				r.NotErrorIs(err, ErrNotSet)
+				r.Contains(err.Error(), tc.key)
+				r.Equal(tc.def, tc.or(tc.key))
+			default:
+				r.NoError(err)
+				r.Equal(tc.exp, tc.or(tc.key))
+			}
+		})
+	}
+}
+
+func Test_Env_typed_encrypted(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	key := bytes.Repeat([]byte{5}, 32)
+	enc, err := EncryptValue(key, "42")
+	r.NoError(err)
+
+	env := FromMap(map[string]string{"WORKERS": enc})
+
+	_, err = env.GetInt("WORKERS")
+	r.ErrorIs(err, ErrDecrypt)
+	r.Equal(3, env.GetIntOr("WORKERS", 3))
+
+	r.NoError(env.SetKeyProvider(StaticKey(key)))
+
+	n, err := env.GetInt("WORKERS")
+	r.NoError(err)
+	r.Equal(42, n)
+
+	var nilEnv *Env
+	_, err = nilEnv.GetInt("WORKERS")
+	r.ErrorIs(err, ErrNotSet)
+}
+
+type level int
+
+type upper string
+
+func (u *upper) UnmarshalText(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("empty")
+	}
+	*u = upper(strings.ToUpper(string(b)))
+	return nil
+}
+
+type point struct{ x, y int }
+
+func Test_Get(t *testing.T) {
+	t.Parallel()
+
+	RegisterDecoder(func(s string) (point, error) {
+		var p point
+		_, err := fmt.Sscanf(s, "%d,%d", &p.x, &p.y)
+		return p, err
+	})
+
+	env := FromMap(map[string]string{
+		"NAME":    "  app ",
+		"PORT":    "8080",
+		"SMALL":   "300",
+		"UINT":    "42",
+		"RATIO":   "0.5",
+		"DEBUG":   "t",
+		"TIMEOUT": "250ms",
+		"START":   "2024-05-01T10:00:00Z",
+		"URL":     "https://example.com/api?x=1",
+		"HOSTS":   "a.example.com, b.example.com,c.example.com",
+		"PORTS":   "80,443",
+		"NONE":    "",
+		"BAD":     "1,x",
+		"LEVEL":   "3",
+		"MODE":    "prod",
+		"ORIGIN":  "3,4",
+		"BYTES":   "1,2",
+	})
+
+	u, err := url.Parse("https://example.com/api?x=1")
+	require.NoError(t, err)
+
+	tcs := []struct {
+		name string
+		get  func() (any, error)
+		exp  any
+		err  string
+	}{
+		{name: "string", get: func() (any, error) { return Get[string](env, "NAME") }, exp: "app"},
+		{name: "int", get: func() (any, error) { return Get[int](env, "PORT") }, exp: 8080},
+		{name: "int8 overflow", get: func() (any, error) { return Get[int8](env, "SMALL") }, err: "SMALL: strconv.ParseInt"},
+		{name: "uint16", get: func() (any, error) { return Get[uint16](env, "UINT") }, exp: uint16(42)},
+		{name: "float32", get: func() (any, error) { return Get[float32](env, "RATIO") }, exp: float32(0.5)},
+		{name: "bool", get: func() (any, error) { return Get[bool](env, "DEBUG") }, exp: true},
+		{name: "duration", get: func() (any, error) { return Get[time.Duration](env, "TIMEOUT") }, exp: 250 * time.Millisecond},
+		{name: "time", get: func() (any, error) { return Get[time.Time](env, "START") }, exp: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{name: "url", get: func() (any, error) { return Get[*url.URL](env, "URL") }, exp: u},
+		{name: "strings", get: func() (any, error) { return Get[[]string](env, "HOSTS") }, exp: []string{"a.example.com", "b.example.com", "c.example.com"}},
+		{name: "ints", get: func() (any, error) { return Get[[]int](env, "PORTS") }, exp: []int{80, 443}},
+		{name: "empty slice", get: func() (any, error) { return Get[[]int](env, "NONE") }, exp: []int{}},
+		{name: "bad item", get: func() (any, error) { return Get[[]int](env, "BAD") }, err: "BAD: item 1"},
+		{name: "named int", get: func() (any, error) { return Get[level](env, "LEVEL") }, exp: level(3)},
+		{name: "text unmarshaler", get: func() (any, error) { return Get[upper](env, "MODE") }, exp: upper("PROD")},
+		{name: "text unmarshaler error", get: func() (any, error) { return Get[upper](env, "NONE") }, err: "NONE: empty"},
+		{name: "registered", get: func() (any, error) { return Get[point](env, "ORIGIN") }, exp: point{3, 4}},
+		{name: "bytes", get: func() (any, error) { return Get[[]byte](env, "BYTES") }, exp: []byte("1,2")},
+		{name: "nested slice", get: func() (any, error) { return Get[[][]int](env, "PORTS") }, err: "PORTS: no decoder for []int"},
+		{name: "unsupported", get: func() (any, error) { return Get[map[string]int](env, "PORTS") }, err: "no decoder for map[string]int"},
+		{name: "missing", get: func() (any, error) { return Get[int](env, "MISSING") }, err: "MISSING: not set"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			act, err := tc.get()
+			if tc.err != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, act)
+		})
+	}
+}
+
+func Test_RegisterDecoder(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	type csv []string
+
+	env := FromMap(map[string]string{"LIST": "a;b"})
+
+	v, err := Get[csv](env, "LIST")
+	r.NoError(err)
+	r.Equal(csv{"a;b"}, v)
+
+	RegisterDecoder(func(s string) (csv, error) {
+		return strings.Split(s, ";"), nil
+	})
+
+	v, err = Get[csv](env, "LIST")
+	r.NoError(err)
+	r.Equal(csv{"a", "b"}, v)
+
+	RegisterDecoder[csv](nil)
+
+	v, err = Get[csv](env, "LIST")
+	r.NoError(err)
+	r.Equal(csv{"a;b"}, v)
+}
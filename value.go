@@ -0,0 +1,62 @@
+package envy
+
+// Value is a variable read from an Env. It keeps the text as stored, so
+// templates such as "$HOME/bin" survive for later re-expansion, and can
+// expand it against the Env on demand.
+type Value struct {
+	// Key is the variable's name.
+	Key string
+
+	raw string
+	set bool
+	env *Env
+}
+
+// Value returns the variable named by key. For a missing key or a nil Env
+// the Value is empty and IsSet reports false.
+func (e *Env) Value(key string) Value {
+	v := Value{Key: key, env: e}
+	if e.IsNil() {
+		return v
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	v.raw, v.set = e.envs[key]
+	if m, ok := e.meta[key]; ok && m.Raw != "" {
+		v.raw = m.Raw
+	}
+	return v
+}
+
+// Raw returns the value of key exactly as stored, without expanding
+// references such as $HOME; for a value FromFile expanded with Interpolate
+// it is the text as written, its Meta.Raw. It returns "" for a missing key
+// or a nil Env.
+func (e *Env) Raw(key string) string {
+	return e.Value(key).Raw()
+}
+
+// Raw returns the stored text, or the text as written if it was expanded
+// on load.
+func (v Value) Raw() string {
+	return v.raw
+}
+
+// Expanded returns the stored text with ${var} and $var references
+// replaced using the current contents of the Env the Value was read from,
+// as by Expandenv with opts.
+func (v Value) Expanded(opts ...ExpandOption) string {
+	return v.env.Expandenv(v.raw, opts...)
+}
+
+// IsSet reports whether the variable was present when it was read.
+func (v Value) IsSet() bool {
+	return v.set
+}
+
+// String returns the expanded value.
+func (v Value) String() string {
+	return v.Expanded()
+}
@@ -0,0 +1,72 @@
+package envy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_Value(t *testing.T) {
+	t.Parallel()
+
+	env := FromMap(map[string]string{
+		"HOME":  "/home/me",
+		"BIN":   "$HOME/bin",
+		"WIN":   "%HOME%\\bin",
+		"PLAIN": "value",
+		"EMPTY": "",
+	})
+
+	tcs := []struct {
+		name     string
+		env      *Env
+		key      string
+		raw      string
+		expanded string
+		set      bool
+		opts     []ExpandOption
+	}{
+		{name: "nil env", key: "BIN"},
+		{name: "missing", env: env, key: "NOPE"},
+		{name: "empty", env: env, key: "EMPTY", set: true},
+		{name: "plain", env: env, key: "PLAIN", raw: "value", expanded: "value", set: true},
+		{name: "template", env: env, key: "BIN", raw: "$HOME/bin", expanded: "/home/me/bin", set: true},
+		{
+			name:     "percent template",
+			env:      env,
+			key:      "WIN",
+			raw:      "%HOME%\\bin",
+			expanded: "/home/me\\bin",
+			set:      true,
+			opts:     []ExpandOption{ExpandPercent(true)},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			v := tc.env.Value(tc.key)
+			r.Equal(tc.key, v.Key)
+			r.Equal(tc.raw, v.Raw())
+			r.Equal(tc.raw, tc.env.Raw(tc.key))
+			r.Equal(tc.expanded, v.Expanded(tc.opts...))
+			r.Equal(tc.set, v.IsSet())
+		})
+	}
+}
+
+func Test_Value_String(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := FromMap(map[string]string{"HOME": "/home/me", "BIN": "$HOME/bin"})
+	v := env.Value("BIN")
+	r.Equal("/home/me/bin", v.String())
+
+	// expansion uses the Env as it is now
+	r.NoError(env.Setenv("HOME", "/root"))
+	r.Equal("/root/bin", v.String())
+	r.Equal("$HOME/bin", v.Raw())
+}
@@ -0,0 +1,47 @@
+package envy
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// FromValues creates an Env from form-encoded values, such as a parsed
+// webhook payload or the query of a signed URL. A key given more than once
+// is an error rather than silently picking one of its values, so a
+// parameter appended to a URL cannot override an earlier one. Keys with no
+// values are skipped. It returns an error for keys that cannot be stored in
+// an Env.
+func FromValues(v url.Values) (*Env, error) {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	em := map[string]string{}
+	for _, k := range keys {
+		vals := v[k]
+		switch {
+		case len(vals) == 0:
+			continue
+		case len(vals) > 1:
+			return nil, fmt.Errorf("%s: %d values given", k, len(vals))
+		case !validKey(k):
+			return nil, fmt.Errorf("invalid key %q", k)
+		}
+		em[k] = vals[0]
+	}
+	return FromMap(em), nil
+}
+
+// URLValues returns the Env as form values with one value per key, ready
+// to be encoded into a query string or request body with Encode. A nil Env
+// gives empty values.
+func (e *Env) URLValues() url.Values {
+	v := url.Values{}
+	for _, ent := range e.entries() {
+		v.Set(ent.key, ent.value)
+	}
+	return v
+}
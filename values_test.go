@@ -0,0 +1,70 @@
+package envy
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromValues(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		in   url.Values
+		exp  []string
+		err  bool
+	}{
+		{
+			name: "single values",
+			in:   url.Values{"A": {"1"}, "B": {"x y&z"}, "EMPTY": {""}},
+			exp:  []string{"A=1", "B=x y&z", "EMPTY="},
+		},
+		{
+			name: "no values",
+			in:   url.Values{"A": {"1"}, "B": {}},
+			exp:  []string{"A=1"},
+		},
+		{name: "nil", exp: []string{}},
+		{name: "repeated key", in: url.Values{"A": {"1", "2"}}, err: true},
+		{name: "empty key", in: url.Values{"": {"1"}}, err: true},
+		{name: "key with =", in: url.Values{"A=B": {"1"}}, err: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			env, err := FromValues(tc.in)
+			if tc.err {
+				r.Error(err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, env.Environ())
+		})
+	}
+}
+
+func Test_Env_URLValues(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := FromMap(map[string]string{"A": "1", "B": "x y&z=w"})
+
+	v := env.URLValues()
+	r.Equal("A=1&B=x+y%26z%3Dw", v.Encode())
+
+	q, err := url.ParseQuery(v.Encode())
+	r.NoError(err)
+
+	back, err := FromValues(q)
+	r.NoError(err)
+	r.Equal(env.Environ(), back.Environ())
+
+	var e *Env
+	r.Empty(e.URLValues())
+}
@@ -0,0 +1,167 @@
+// Package webhook posts a redacted JSON description of every change made to
+// an envy.Env to one or more HTTP endpoints, so configuration changes in
+// long-running services can feed audit and chat-ops systems.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/markbates/envy"
+)
+
+// SignatureHeader carries the hex-encoded HMAC-SHA256 of the request body,
+// prefixed with "sha256=", when a Target has a Secret.
+const SignatureHeader = "X-Envy-Signature"
+
+// Payload is the JSON body posted to each target.
+type Payload struct {
+	Time    time.Time `json:"time"`
+	Changes envy.Diff `json:"changes"`
+}
+
+// Target is an endpoint that receives change notifications.
+type Target struct {
+	// URL receives a POST for every change. It is required.
+	URL string
+
+	// Secret, if set, is used to sign each request body; see
+	// SignatureHeader.
+	Secret string
+
+	// Header holds extra headers to send, such as Authorization.
+	Header http.Header
+
+	// Client sends the requests. If nil, a client with a 10 second
+	// timeout is used.
+	Client *http.Client
+
+	// Sensitive decides which keys have their values redacted. If nil,
+	// envy.IsSensitive is used.
+	Sensitive func(key string) bool
+}
+
+var defaultClient = &http.Client{Timeout: 10 * time.Second}
+
+// Send posts d, redacted, to the target. A non-2xx response is an error.
+func (t *Target) Send(ctx context.Context, d envy.Diff) error {
+	if t == nil || t.URL == "" {
+		return fmt.Errorf("missing webhook url")
+	}
+
+	body, err := json.Marshal(Payload{
+		Time:    time.Now().UTC(),
+		Changes: d.Redact(t.Sensitive),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	for k, vs := range t.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if t.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(t.Secret))
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	c := t.Client
+	if c == nil {
+		c = defaultClient
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook %s: %s", t.URL, res.Status)
+	}
+	return nil
+}
+
+// Watch subscribes to env and sends every change to each target. Changes are
+// delivered in order by a background goroutine so that slow endpoints do not
+// block writers; if more than 64 changes are waiting, further changes are
+// dropped and reported to onError. Delivery errors are also passed to
+// onError, which may be nil. Watching envy.Default() follows it across
+// envy.Load and envy.Reload, and each reload is sent as a change. The
+// returned func stops the watch and waits for in-flight deliveries to
+// finish.
+func Watch(env *envy.Env, onError func(error), targets ...*Target) (func(), error) {
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no webhook targets")
+	}
+
+	for _, t := range targets {
+		if t == nil || t.URL == "" {
+			return nil, fmt.Errorf("missing webhook url")
+		}
+	}
+
+	if onError == nil {
+		onError = func(error) {}
+	}
+
+	queue := make(chan envy.Diff, 64)
+
+	cancel, err := env.Subscribe(func(d envy.Diff) {
+		select {
+		case queue <- d:
+		default:
+			onError(fmt.Errorf("webhook queue full; dropped change to %v", d.Keys()))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			case d := <-queue:
+				for _, t := range targets {
+					if err := t.Send(context.Background(), d); err != nil {
+						onError(err)
+					}
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			close(done)
+			wg.Wait()
+		})
+	}, nil
+}
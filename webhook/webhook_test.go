@@ -0,0 +1,186 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/markbates/envy"
+	"github.com/stretchr/testify/require"
+)
+
+type received struct {
+	payload Payload
+	sig     string
+	auth    string
+}
+
+func newServer(t *testing.T, status int) (*httptest.Server, chan received) {
+	t.Helper()
+
+	ch := make(chan received, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+
+		var p Payload
+		_ = json.Unmarshal(b, &p)
+
+		mac := hmac.New(sha256.New, []byte("shh"))
+		mac.Write(b)
+
+		rc := received{payload: p, auth: r.Header.Get("Authorization")}
+		if r.Header.Get(SignatureHeader) == "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			rc.sig = "valid"
+		}
+
+		ch <- rc
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, ch
+}
+
+func Test_Target_Send(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name   string
+		status int
+		target func(url string) *Target
+		err    bool
+	}{
+		{
+			name:   "ok",
+			status: http.StatusNoContent,
+			target: func(url string) *Target {
+				return &Target{URL: url, Secret: "shh", Header: http.Header{"Authorization": {"Bearer x"}}}
+			},
+		},
+		{
+			name:   "server error",
+			status: http.StatusInternalServerError,
+			target: func(url string) *Target { return &Target{URL: url} },
+			err:    true,
+		},
+		{
+			name:   "missing url",
+			status: http.StatusOK,
+			target: func(string) *Target { return &Target{} },
+			err:    true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			srv, ch := newServer(t, tc.status)
+
+			d := envy.Diff{{Key: "DB_PASSWORD", Kind: envy.Modified, Old: "a", New: "b"}}
+			err := tc.target(srv.URL).Send(context.Background(), d)
+			if tc.err {
+				r.Error(err)
+				return
+			}
+
+			r.NoError(err)
+			got := <-ch
+			r.Equal("valid", got.sig)
+			r.Equal("Bearer x", got.auth)
+			r.Equal(envy.Diff{{Key: "DB_PASSWORD", Kind: envy.Modified, Old: envy.Redacted, New: envy.Redacted}}, got.payload.Changes)
+		})
+	}
+}
+
+func Test_Watch(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	srv, ch := newServer(t, http.StatusOK)
+
+	env := envy.FromMap(map[string]string{"PORT": "8080"})
+	stop, err := Watch(env, func(err error) { r.NoError(err) }, &Target{URL: srv.URL})
+	r.NoError(err)
+	defer stop()
+
+	r.NoError(env.Setenv("PORT", "9090"))
+	r.NoError(env.Unsetenv("PORT"))
+
+	for _, exp := range []envy.Diff{
+		{{Key: "PORT", Kind: envy.Modified, Old: "8080", New: "9090"}},
+		{{Key: "PORT", Kind: envy.Removed, Old: "9090"}},
+	} {
+		select {
+		case got := <-ch:
+			r.Equal(exp, got.payload.Changes)
+		case <-time.After(time.Second):
+			r.Fail("timed out waiting for webhook")
+		}
+	}
+
+	stop()
+	r.NoError(env.Setenv("PORT", "1"))
+	select {
+	case <-ch:
+		r.Fail("unexpected webhook after stop")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func Test_Watch_Errors(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	_, err := Watch(envy.Zero(), nil)
+	r.Error(err)
+
+	_, err = Watch(envy.Zero(), nil, &Target{})
+	r.Error(err)
+
+	_, err = Watch(nil, nil, &Target{URL: "http://example.com"})
+	r.Error(err)
+}
+
+func Test_Watch_Reload(t *testing.T) {
+	// Load and Reload replace the package-level Default, so not parallel
+	r := require.New(t)
+	t.Cleanup(func() {
+		r.NoError(envy.Load())
+	})
+
+	srv, ch := newServer(t, http.StatusOK)
+
+	port := "8080"
+	r.NoError(envy.Load(func() (*envy.Env, error) {
+		return envy.FromMap(map[string]string{"PORT": port}), nil
+	}))
+
+	stop, err := Watch(envy.Default(), func(err error) { r.NoError(err) }, &Target{URL: srv.URL})
+	r.NoError(err)
+	defer stop()
+
+	port = "9090"
+	r.NoError(envy.Reload())
+	r.NoError(envy.Default().Setenv("PORT", "1"))
+
+	for _, exp := range []envy.Diff{
+		{{Key: "PORT", Kind: envy.Modified, Old: "8080", New: "9090"}},
+		{{Key: "PORT", Kind: envy.Modified, Old: "9090", New: "1"}},
+	} {
+		select {
+		case got := <-ch:
+			r.Equal(exp, got.payload.Changes)
+		case <-time.After(time.Second):
+			r.Fail("timed out waiting for webhook")
+		}
+	}
+}
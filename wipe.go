@@ -0,0 +1,92 @@
+package envy
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Wipe removes keys from the Env and drops every other reference the Env
+// holds to their values: the change history kept by KeepHistory and the
+// snapshot of the process environment taken by New are discarded, the
+// layers kept for Explain forget them, and subscribers are notified with
+// Old set to Redacted rather than the secret. Go strings are immutable, so
+// the bytes themselves cannot be overwritten in place; Wipe makes them
+// unreachable from the Env so the garbage collector can reclaim them, but
+// copies held elsewhere are unaffected. Keys the Env does not hold are
+// still dropped from those copies. It returns an error for a nil Env.
+func (e *Env) Wipe(keys ...string) error {
+	if e.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	e.mu.Lock()
+
+	// a layer may hold the key even if an upper one hid it from the Env
+	if len(e.layers) > 0 {
+		e.layers = dropLayerKeys(e.layers, keys)
+	}
+
+	d := Diff{}
+	for _, k := range keys {
+		// the snapshot New took of the process may hold it even if the
+		// Env no longer does
+		delete(e.process, k)
+
+		if _, ok := e.envs[k]; !ok {
+			continue
+		}
+
+		delete(e.envs, k)
+		delete(e.meta, k)
+		delete(e.modified, k)
+		delete(e.resolvers, k)
+		d = append(d, Change{Key: k, Kind: Removed, Old: Redacted})
+	}
+
+	if len(d) == 0 {
+		e.mu.Unlock()
+		return nil
+	}
+
+	sort.Slice(d, func(i, j int) bool {
+		return d[i].Key < d[j].Key
+	})
+
+	// the history holds old values, so it cannot be kept
+	e.history = nil
+	e.rev++
+
+	subs := e.subscribers()
+	e.mu.Unlock()
+
+	for _, s := range subs {
+		s.fn(d)
+	}
+	return nil
+}
+
+// Destroy drops every value, the history, all subscribers, and the
+// KeyProvider, and marks the Env unusable: afterwards it behaves like a nil
+// Env, reading as empty and returning an error from every mutation. See Wipe
+// for the limits of removing strings from memory. Destroying a nil or
+// already destroyed Env is a no-op.
+func (e *Env) Destroy() {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	clear(e.envs)
+	e.envs = nil
+	e.history = nil
+	e.process = nil
+	e.meta = nil
+	e.subs = nil
+	e.keys = nil
+	e.modified = nil
+	e.resolvers = nil
+	e.tombstones = nil
+	e.layers = nil
+}
@@ -0,0 +1,132 @@
+package envy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Env_Wipe(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name string
+		env  *Env
+		keys []string
+		exp  []string
+		diff []Diff
+		err  bool
+	}{
+		{
+			name: "nil env",
+			err:  true,
+		},
+		{
+			name: "missing keys",
+			env:  FromMap(map[string]string{"A": "1"}),
+			keys: []string{"B"},
+			exp:  []string{"A=1"},
+		},
+		{
+			name: "wipes and redacts",
+			env:  FromMap(map[string]string{"A": "1", "TOKEN": "t", "SECRET": "s"}),
+			keys: []string{"TOKEN", "SECRET", "NOPE"},
+			exp:  []string{"A=1"},
+			diff: []Diff{{
+				{Key: "SECRET", Kind: Removed, Old: Redacted},
+				{Key: "TOKEN", Kind: Removed, Old: Redacted},
+			}},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+
+			var got []Diff
+			if tc.env != nil {
+				_, err := tc.env.Subscribe(func(d Diff) {
+					got = append(got, d)
+				})
+				r.NoError(err)
+			}
+
+			err := tc.env.Wipe(tc.keys...)
+			if tc.err {
+				r.Error(err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, tc.env.Environ())
+			r.Equal(tc.diff, got)
+		})
+	}
+}
+
+func Test_Env_Wipe_History(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := FromMap(map[string]string{})
+	r.NoError(env.KeepHistory(10))
+	r.NoError(env.Setenv("TOKEN", "t"))
+	r.NoError(env.Setenv("TOKEN", "t2"))
+
+	r.NoError(env.Wipe("TOKEN"))
+
+	_, err := env.Snapshot(env.Revision() - 1)
+	r.Error(err)
+
+	_, err = env.Snapshot(env.Revision())
+	r.NoError(err)
+}
+
+func Test_Env_Destroy(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	env := FromMap(map[string]string{"TOKEN": "t"})
+	env.Destroy()
+
+	r.True(env.IsNil())
+	r.Empty(env.Getenv("TOKEN"))
+	r.Error(env.Setenv("A", "1"))
+	r.Error(env.Wipe("TOKEN"))
+
+	_, err := env.Subscribe(func(Diff) {})
+	r.Error(err)
+
+	// idempotent and nil-safe
+	env.Destroy()
+	(*Env)(nil).Destroy()
+}
+
+// Test_Env_Wipe_Process changes the process environment, so it must not
+// run in parallel with other tests that read it.
+func Test_Env_Wipe_Process(t *testing.T) {
+	r := require.New(t)
+
+	t.Setenv("ENVY_WIPE_SECRET", "s3cret")
+
+	env := New()
+	r.NoError(env.Wipe("ENVY_WIPE_SECRET"))
+	r.False(env.IsSet("ENVY_WIPE_SECRET"))
+
+	env.mu.RLock()
+	_, ok := env.process["ENVY_WIPE_SECRET"]
+	env.mu.RUnlock()
+	r.False(ok)
+
+	// also when the key was already unset
+	other := New()
+	r.NoError(other.Unsetenv("ENVY_WIPE_SECRET"))
+	r.NoError(other.Wipe("ENVY_WIPE_SECRET"))
+
+	other.mu.RLock()
+	_, ok = other.process["ENVY_WIPE_SECRET"]
+	other.mu.RUnlock()
+	r.False(ok)
+}
@@ -0,0 +1,160 @@
+package envy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WSLENVKey is the variable that lists which environment variables are
+// shared between Windows and WSL processes.
+const WSLENVKey = "WSLENV"
+
+// WSLFlag is a WSLENV flag controlling how a shared variable is translated.
+type WSLFlag byte
+
+const (
+	// WSLPath translates the value as a single path.
+	WSLPath WSLFlag = 'p'
+	// WSLPathList translates the value as a list of paths.
+	WSLPathList WSLFlag = 'l'
+	// WSLToUnix only shares the value when starting WSL from Windows.
+	WSLToUnix WSLFlag = 'u'
+	// WSLToWindows only shares the value when starting Windows from WSL.
+	WSLToWindows WSLFlag = 'w'
+)
+
+// WSLENVEntry is a single NAME/flags element of WSLENV.
+type WSLENVEntry struct {
+	Name  string
+	Flags []WSLFlag
+}
+
+func (w WSLENVEntry) String() string {
+	if len(w.Flags) == 0 {
+		return w.Name
+	}
+
+	var bb strings.Builder
+	bb.WriteString(w.Name)
+	bb.WriteByte('/')
+	for _, f := range w.Flags {
+		bb.WriteByte(byte(f))
+	}
+	return bb.String()
+}
+
+// ParseWSLENV splits a WSLENV value into its entries. Empty elements are
+// skipped.
+func ParseWSLENV(s string) []WSLENVEntry {
+	var ents []WSLENVEntry
+	for _, part := range strings.Split(s, ":") {
+		name, flags, _ := strings.Cut(part, "/")
+		if name == "" {
+			continue
+		}
+
+		ent := WSLENVEntry{Name: name}
+		for i := 0; i < len(flags); i++ {
+			ent.Flags = append(ent.Flags, WSLFlag(flags[i]))
+		}
+		ents = append(ents, ent)
+	}
+	return ents
+}
+
+// ShareWSL adds key to the Env's WSLENV so it is passed across the
+// Windows/WSL boundary with the given flags. If key is already listed its
+// flags are replaced, keeping its position. With no flags, they are inferred
+// from the current value of key using WSLFlagsFor.
+func (e *Env) ShareWSL(key string, flags ...WSLFlag) error {
+	if e.IsNil() {
+		return fmt.Errorf("nil env")
+	}
+
+	if key == "" || strings.ContainsAny(key, ":/") {
+		return fmt.Errorf("invalid WSLENV name %q", key)
+	}
+
+	for _, f := range flags {
+		switch f {
+		case WSLPath, WSLPathList, WSLToUnix, WSLToWindows:
+		default:
+			return fmt.Errorf("invalid WSLENV flag %q", f)
+		}
+	}
+
+	_, err := e.mutate(func() (map[string]string, []string, error) {
+		if len(flags) == 0 {
+			flags = WSLFlagsFor(e.envs[key])
+		}
+
+		ents := ParseWSLENV(e.envs[WSLENVKey])
+		found := false
+		for i, ent := range ents {
+			if ent.Name == key {
+				ents[i].Flags = flags
+				found = true
+			}
+		}
+
+		if !found {
+			ents = append(ents, WSLENVEntry{Name: key, Flags: flags})
+		}
+
+		parts := make([]string, len(ents))
+		for i, ent := range ents {
+			parts[i] = ent.String()
+		}
+		return map[string]string{WSLENVKey: strings.Join(parts, ":")}, nil, nil
+	})
+	return err
+}
+
+// WSLFlagsFor guesses the path-translation flag for value: WSLPath for a
+// single absolute Windows or Unix path, WSLPathList for a list of them, and
+// no flags otherwise.
+func WSLFlagsFor(value string) []WSLFlag {
+	if value == "" {
+		return nil
+	}
+
+	if isWindowsAbs(value) || strings.HasPrefix(value, "/") && !strings.Contains(value, ":") {
+		return []WSLFlag{WSLPath}
+	}
+
+	for _, sep := range []string{";", ":"} {
+		parts := strings.Split(strings.Trim(value, sep), sep)
+		if len(parts) < 2 {
+			continue
+		}
+
+		ok := true
+		for _, p := range parts {
+			if sep == ";" && !isWindowsAbs(p) || sep == ":" && !strings.HasPrefix(p, "/") {
+				ok = false
+				break
+			}
+		}
+
+		if ok {
+			return []WSLFlag{WSLPathList}
+		}
+	}
+
+	return nil
+}
+
+// isWindowsAbs reports whether p looks like an absolute Windows path, either
+// drive-qualified (C:\) or UNC (\\server).
+func isWindowsAbs(p string) bool {
+	if strings.HasPrefix(p, `\\`) {
+		return true
+	}
+
+	if len(p) < 3 || p[1] != ':' || p[2] != '\\' && p[2] != '/' {
+		return false
+	}
+
+	c := p[0] | 0x20
+	return c >= 'a' && c <= 'z' && !strings.Contains(p[2:], ";")
+}
@@ -0,0 +1,127 @@
+package envy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseWSLENV(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	got := ParseWSLENV("GOPATH/l:USERPROFILE/pu::DISPLAY")
+	r.Equal([]WSLENVEntry{
+		{Name: "GOPATH", Flags: []WSLFlag{WSLPathList}},
+		{Name: "USERPROFILE", Flags: []WSLFlag{WSLPath, WSLToUnix}},
+		{Name: "DISPLAY"},
+	}, got)
+
+	r.Equal("USERPROFILE/pu", got[1].String())
+	r.Equal("DISPLAY", got[2].String())
+	r.Empty(ParseWSLENV(""))
+}
+
+func Test_WSLFlagsFor(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name  string
+		value string
+		exp   []WSLFlag
+	}{
+		{name: "empty", value: "", exp: nil},
+		{name: "plain value", value: "debug", exp: nil},
+		{name: "windows path", value: `C:\Users\me`, exp: []WSLFlag{WSLPath}},
+		{name: "unc path", value: `\\server\share`, exp: []WSLFlag{WSLPath}},
+		{name: "unix path", value: "/home/me", exp: []WSLFlag{WSLPath}},
+		{name: "windows path list", value: `C:\bin;D:\tools`, exp: []WSLFlag{WSLPathList}},
+		{name: "unix path list", value: "/usr/bin:/bin", exp: []WSLFlag{WSLPathList}},
+		{name: "url", value: "http://example.com", exp: nil},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			r.Equal(tc.exp, WSLFlagsFor(tc.value))
+		})
+	}
+}
+
+func Test_Env_ShareWSL(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name  string
+		env   *Env
+		key   string
+		flags []WSLFlag
+		exp   string
+		err   bool
+	}{
+		{
+			name: "nil env",
+			env:  nil,
+			key:  "KEY",
+			err:  true,
+		},
+		{
+			name: "invalid name",
+			env:  Zero(),
+			key:  "A:B",
+			err:  true,
+		},
+		{
+			name:  "invalid flag",
+			env:   Zero(),
+			key:   "KEY",
+			flags: []WSLFlag{'x'},
+			err:   true,
+		},
+		{
+			name:  "new WSLENV",
+			env:   Zero(),
+			key:   "KEY",
+			flags: []WSLFlag{WSLPath},
+			exp:   "KEY/p",
+		},
+		{
+			name:  "append",
+			env:   FromMap(map[string]string{"WSLENV": "A/l"}),
+			key:   "KEY",
+			flags: []WSLFlag{WSLPath, WSLToWindows},
+			exp:   "A/l:KEY/pw",
+		},
+		{
+			name:  "replace in place",
+			env:   FromMap(map[string]string{"WSLENV": "A/l:KEY/p:B"}),
+			key:   "KEY",
+			flags: []WSLFlag{WSLPathList},
+			exp:   "A/l:KEY/l:B",
+		},
+		{
+			name: "inferred flags",
+			env:  FromMap(map[string]string{"KEY": `C:\bin;D:\tools`}),
+			key:  "KEY",
+			exp:  "KEY/l",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := require.New(t)
+			err := tc.env.ShareWSL(tc.key, tc.flags...)
+			if tc.err {
+				r.Error(err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(tc.exp, tc.env.Getenv(WSLENVKey))
+		})
+	}
+}
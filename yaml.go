@@ -0,0 +1,264 @@
+//go:build !envy_minimal
+
+package envy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLOption configures FromYAML.
+type YAMLOption func(*yamlOptions)
+
+type yamlOptions struct {
+	sep string
+}
+
+// YAMLSeparator sets the separator FromYAML puts between the parts of a
+// nested key. The default is "_".
+func YAMLSeparator(sep string) YAMLOption {
+	return func(o *yamlOptions) {
+		o.sep = sep
+	}
+}
+
+// FromYAML reads the first document of the YAML file at path, which must
+// be a mapping, flat or nested, and flattens it into an Env, so config kept
+// in YAML can be handled like the environment. Each part of a key is upper
+// cased, with '-' and spaces turned into '_', and nested keys are joined
+// with the separator, as are the dot-separated parts of a key, so both
+// "db: {host: x}" and "db.host: x" set DB_HOST. Scalars are kept as
+// written, so 0755 stays 0755, and nulls are skipped. A sequence of scalars
+// is set as a comma-separated list, the form Get reads, and other sequences
+// are flattened with each item's index as a part of its keys. Anchors,
+// aliases, and "<<" merge keys are followed. The line and head comment of
+// each key are recorded as its Meta. It returns an error for a nil fs.FS, a
+// read or syntax failure, keys that flatten to the same name, or a list
+// item containing a comma.
+func FromYAML(cab fs.FS, path string, opts ...YAMLOption) (*Env, error) {
+	if cab == nil {
+		return nil, fmt.Errorf("nil fs.FS")
+	}
+
+	o := yamlOptions{sep: "_"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	f, err := cab.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var doc yaml.Node
+	if err := yaml.NewDecoder(f).Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, malformedError{fmt.Errorf("%s: %w", path, err)}
+	}
+
+	fl := &yamlFlattener{
+		sep:    o.sep,
+		path:   path,
+		em:     map[string]string{},
+		meta:   map[string]Meta{},
+		merged: map[string]bool{},
+	}
+
+	if len(doc.Content) > 0 {
+		root := doc.Content[0]
+		if root.Kind != yaml.MappingNode {
+			return nil, malformedError{fmt.Errorf("%s: line %d: expected a mapping", path, root.Line)}
+		}
+
+		if err := fl.mapping("", root); err != nil {
+			return nil, malformedError{fmt.Errorf("%s: %w", path, err)}
+		}
+	}
+
+	e := FromMap(fl.em)
+	e.meta = fl.meta
+	return e, nil
+}
+
+// yamlFlattener collects the entries of a YAML document.
+type yamlFlattener struct {
+	sep  string
+	path string
+	em   map[string]string
+	meta map[string]Meta
+
+	// merged holds the keys set through "<<" merge keys, which the
+	// mapping's own keys override.
+	merged map[string]bool
+}
+
+// mapping adds the entries of the mapping n under prefix.
+func (fl *yamlFlattener) mapping(prefix string, n *yaml.Node) error {
+	n = yamlAlias(n)
+
+	// merge keys go first so the mapping's own keys override them
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], yamlAlias(n.Content[i+1])
+		if k.Tag != "!!merge" {
+			continue
+		}
+
+		sources := []*yaml.Node{v}
+		if v.Kind == yaml.SequenceNode {
+			sources = v.Content
+		}
+
+		// earlier sources take precedence over later ones
+		for j := len(sources) - 1; j >= 0; j-- {
+			src := yamlAlias(sources[j])
+			if src.Kind != yaml.MappingNode {
+				return fmt.Errorf("line %d: cannot merge a %s", src.Line, yamlKind(src))
+			}
+
+			sub := &yamlFlattener{
+				sep:    fl.sep,
+				path:   fl.path,
+				em:     map[string]string{},
+				meta:   map[string]Meta{},
+				merged: map[string]bool{},
+			}
+			if err := sub.mapping(prefix, src); err != nil {
+				return err
+			}
+
+			for key, val := range sub.em {
+				if _, ok := fl.em[key]; ok && !fl.merged[key] {
+					return fmt.Errorf("line %d: %s is set more than once (first on line %d)", k.Line, key, fl.meta[key].Line)
+				}
+
+				fl.em[key] = val
+				fl.meta[key] = sub.meta[key]
+				fl.merged[key] = true
+			}
+		}
+	}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		if k.Tag == "!!merge" {
+			continue
+		}
+
+		if err := fl.value(fl.join(prefix, k.Value), k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// value adds the entries of v, the value of the key node k, as key.
+func (fl *yamlFlattener) value(key string, k, v *yaml.Node) error {
+	v = yamlAlias(v)
+
+	switch v.Kind {
+	case yaml.MappingNode:
+		return fl.mapping(key, v)
+	case yaml.SequenceNode:
+		if !yamlScalars(v) {
+			for i, item := range v.Content {
+				if err := fl.value(fl.join(key, strconv.Itoa(i)), item, item); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		items := make([]string, 0, len(v.Content))
+		for _, item := range v.Content {
+			item = yamlAlias(item)
+			if strings.Contains(item.Value, ",") {
+				return fmt.Errorf("line %d: %s: %q contains a comma", item.Line, key, item.Value)
+			}
+
+			if item.Tag != "!!null" {
+				items = append(items, item.Value)
+			}
+		}
+		return fl.set(key, strings.Join(items, ","), k)
+	}
+
+	if v.Tag == "!!null" {
+		return nil
+	}
+	return fl.set(key, v.Value, k)
+}
+
+// set adds key, returning an error if another part of the document set
+// it, unless that part was merged in with "<<".
+func (fl *yamlFlattener) set(key, val string, k *yaml.Node) error {
+	if _, ok := fl.em[key]; ok && !fl.merged[key] {
+		return fmt.Errorf("line %d: %s is set more than once (first on line %d)", k.Line, key, fl.meta[key].Line)
+	}
+
+	if !validKey(key) {
+		return fmt.Errorf("line %d: invalid key %q", k.Line, key)
+	}
+
+	delete(fl.merged, key)
+	fl.em[key] = val
+	fl.meta[key] = Meta{
+		Loader:      "file",
+		File:        fl.path,
+		Line:        k.Line,
+		Description: yamlComment(k.HeadComment),
+	}
+	return nil
+}
+
+// join appends the key p to the key prefix.
+func (fl *yamlFlattener) join(prefix, p string) string {
+	return flatKey(prefix, p, fl.sep)
+}
+
+// yamlAlias returns the node an alias refers to, or n.
+func yamlAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	return n
+}
+
+// yamlScalars reports whether every item of the sequence n is a scalar.
+func yamlScalars(n *yaml.Node) bool {
+	for _, item := range n.Content {
+		if yamlAlias(item).Kind != yaml.ScalarNode {
+			return false
+		}
+	}
+	return true
+}
+
+// yamlKind names the kind of n for errors.
+func yamlKind(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.SequenceNode:
+		return "sequence"
+	case yaml.MappingNode:
+		return "mapping"
+	}
+	return "scalar"
+}
+
+// yamlComment returns the text of a head comment without the "#" markers.
+func yamlComment(c string) string {
+	if c == "" {
+		return ""
+	}
+
+	lines := strings.Split(c, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l), "#"))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,156 @@
+//go:build !envy_minimal
+
+package envy
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromYAML(t *testing.T) {
+	t.Parallel()
+
+	const nested = `# the app
+name: my-app
+port: 8080
+mode: 0755
+debug: true
+empty: ""
+none: ~
+db:
+  # primary database
+  host: localhost
+  max-conns: 10
+  replica:
+    host: replica
+cache.url: redis://cache
+hosts: [a.example.com, b.example.com]
+servers:
+  - name: web
+    port: 80
+  - name: api
+text: |
+  line one
+  line two
+`
+
+	const merges = `defaults: &defaults
+  host: localhost
+  port: 5432
+extra: &extra
+  port: 6543
+  ssl: require
+dev:
+  <<: *defaults
+  name: dev
+prod:
+  <<: [*extra, *defaults]
+  host: prod.example.com
+`
+
+	tcs := []struct {
+		name string
+		in   string
+		opts []YAMLOption
+		exp  map[string]string
+		err  string
+	}{
+		{
+			name: "nested",
+			in:   nested,
+			exp: map[string]string{
+				"NAME":            "my-app",
+				"PORT":            "8080",
+				"MODE":            "0755",
+				"DEBUG":           "true",
+				"EMPTY":           "",
+				"DB_HOST":         "localhost",
+				"DB_MAX_CONNS":    "10",
+				"DB_REPLICA_HOST": "replica",
+				"CACHE_URL":       "redis://cache",
+				"HOSTS":           "a.example.com,b.example.com",
+				"SERVERS_0_NAME":  "web",
+				"SERVERS_0_PORT":  "80",
+				"SERVERS_1_NAME":  "api",
+				"TEXT":            "line one\nline two\n",
+			},
+		},
+		{
+			name: "separator",
+			in:   "db:\n  host: x\ncache.url: y\n",
+			opts: []YAMLOption{YAMLSeparator("__")},
+			exp:  map[string]string{"DB__HOST": "x", "CACHE__URL": "y"},
+		},
+		{
+			name: "merge keys",
+			in:   merges,
+			exp: map[string]string{
+				"DEFAULTS_HOST": "localhost",
+				"DEFAULTS_PORT": "5432",
+				"EXTRA_PORT":    "6543",
+				"EXTRA_SSL":     "require",
+				"DEV_HOST":      "localhost",
+				"DEV_PORT":      "5432",
+				"DEV_NAME":      "dev",
+				"PROD_HOST":     "prod.example.com",
+				"PROD_PORT":     "6543",
+				"PROD_SSL":      "require",
+			},
+		},
+		{name: "empty", in: "", exp: map[string]string{}},
+		{name: "comments only", in: "# nothing\n", exp: map[string]string{}},
+		{name: "not a mapping", in: "- a\n- b\n", err: "app.yaml: line 1: expected a mapping"},
+		{name: "collision", in: "db:\n  host: a\ndb.host: b\n", err: "app.yaml: line 3: DB_HOST is set more than once (first on line 2)"},
+		{name: "comma in list", in: "hosts: [\"a,b\", c]\n", err: `line 1: HOSTS: "a,b" contains a comma`},
+		{name: "syntax", in: "a: [b\n", err: "app.yaml: yaml:"},
+		{name: "merge scalar", in: "a:\n  <<: b\n", err: "line 2: cannot merge a scalar"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			cab := fstest.MapFS{"app.yaml": {Data: []byte(tc.in)}}
+
+			env, err := FromYAML(cab, "app.yaml", tc.opts...)
+			if tc.err != "" {
+				r.ErrorIs(err, ErrMalformed)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(len(tc.exp), env.Len())
+			for k, v := range tc.exp {
+				r.Equal(v, env.Getenv(k), k)
+			}
+		})
+	}
+}
+
+func Test_FromYAML_meta(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	cab := fstest.MapFS{"app.yaml": {Data: []byte("db:\n  # primary database\n  host: localhost\n")}}
+
+	env, err := FromYAML(cab, "app.yaml")
+	r.NoError(err)
+
+	m, ok := env.Meta("DB_HOST")
+	r.True(ok)
+	r.Equal("app.yaml", m.File)
+	r.Equal(3, m.Line)
+	r.Equal("primary database", m.Description)
+	r.Equal("app.yaml line 3", env.Source("DB_HOST"))
+
+	_, err = FromYAML(cab, "nope.yaml")
+	r.ErrorIs(err, fs.ErrNotExist)
+
+	_, err = FromYAML(nil, "app.yaml")
+	r.Error(err)
+}